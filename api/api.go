@@ -3,35 +3,98 @@ package api
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/pkg/errors"
 	"github.com/streamdal/snitch-protos/build/go/protos"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/grpcweb"
 	"github.com/streamdal/cli/util"
 )
 
 const (
 	AuthTokenMetadata = "auth-token"
+
+	// KeepaliveTimeout is how long a keepalive ping waits for its ack before
+	// the connection is considered broken
+	KeepaliveTimeout = 20 * time.Second
 )
 
+// IAPI is the interface used by cmd for talking to a streamdal server. It is
+// also implemented by alternative data sources such as the demo generator.
+type IAPI interface {
+	Test(ctx context.Context) error
+	GetAllLiveAudiences(ctx context.Context) ([]*protos.Audience, error)
+	Tail(ctx context.Context, audience *protos.Audience) (chan *protos.TailResponse, error)
+}
+
+// IWatcher is implemented by data sources that can report control-plane
+// changes (ex: clients registering, pipelines being updated)
+type IWatcher interface {
+	WatchAll(ctx context.Context) (chan *protos.GetAllResponse, error)
+}
+
+// IRates is implemented by data sources that can report the throughput of
+// every audience (including audiences that are not being tailed)
+type IRates interface {
+	WatchAudienceRates(ctx context.Context) (chan *protos.GetAudienceRatesResponse, error)
+}
+
+// IConnState is implemented by data sources that are connected to a server
+type IConnState interface {
+	ConnState() connectivity.State
+}
+
 type Options struct {
 	Address        string
 	AuthToken      string
 	ConnectTimeout time.Duration
 	DisableTLS     bool
+
+	// KeepaliveInterval is how often the connection is pinged while streams
+	// are open so that a broken connection leaves READY (0 = disabled); pings
+	// are HTTP/2 frames, so it does not apply to gRPC-web
+	KeepaliveInterval time.Duration
+
+	// Transport is one of the Transport* constants (default: TransportGRPC)
+	Transport string
+
+	// Dialer is used for connecting to the server (or gateway) instead of a
+	// direct TCP connection (ex: to go through an SSH tunnel); optional
+	Dialer func(ctx context.Context, addr string) (net.Conn, error)
+
+	// Requests and responses are traced if the logger is at debug level
+	Logger *log.Logger
+
+	// TracePayloads includes Tail payloads in traces; only their size is
+	// traced otherwise
+	TracePayloads bool
+}
+
+// stater is implemented by *grpc.ClientConn and *grpcweb.Conn
+type stater interface {
+	GetState() connectivity.State
 }
 
 type API struct {
-	conn    *grpc.ClientConn
+	conn    stater
 	client  protos.ExternalClient
 	options *Options
 	log     *log.Logger
+
+	// failedStreams is the number of streams (ex: Tail, GetAllStream) whose
+	// last receive failed; see ConnState()
+	failedStreams atomic.Int32
 }
 
 func New(opts *Options) (*API, error) {
@@ -40,29 +103,71 @@ func New(opts *Options) (*API, error) {
 	}
 
 	// Attempt to connect
-	connectCtx, _ := context.WithTimeout(context.Background(), opts.ConnectTimeout)
+	connectCtx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
+	defer cancel()
+
+	a := &API{
+		options: opts,
+		log:     opts.Logger.WithPrefix("api"),
+	}
+
+	// gRPC-web is plain HTTP/1.1; there is no connection to establish
+	if opts.Transport == TransportGRPCWeb {
+		conn, err := grpcweb.New(&grpcweb.Options{
+			URL:            serverURL(opts, "http", "https"),
+			ConnectTimeout: opts.ConnectTimeout,
+			Dialer:         opts.Dialer,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create gRPC-web client")
+		}
+
+		a.conn, a.client = conn, protos.NewExternalClient(a.trace(conn))
+
+		return a, nil
+	}
 
 	conn, err := connect(opts, connectCtx)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to connect to gRPC server")
 	}
 
-	return &API{
-		conn:    conn,
-		client:  protos.NewExternalClient(conn),
-		options: opts,
-		log:     log.With("pkg", "api"),
-	}, nil
+	a.conn, a.client = conn, protos.NewExternalClient(a.trace(conn))
+
+	return a, nil
 }
 
 func connect(opts *Options, connectCtx context.Context) (*grpc.ClientConn, error) {
 	dialOptions := make([]grpc.DialOption, 0)
+	target := opts.Address
 
-	if opts.DisableTLS {
+	// With the WebSocket transport, TLS (if any) is handled by the WebSocket;
+	// Unix domain sockets are local and never use TLS
+	if opts.DisableTLS || opts.Transport == TransportWebSocket || isUnixSocket(opts.Address) {
 		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
-	conn, err := grpc.DialContext(connectCtx, opts.Address, dialOptions...)
+	if opts.Transport == TransportWebSocket {
+		dialOptions = append(dialOptions, grpc.WithContextDialer(dialWebSocket(opts)))
+
+		// The address may be a URL; do not let gRPC try to resolve it
+		target = "passthrough:///" + opts.Address
+	} else if opts.Dialer != nil {
+		dialOptions = append(dialOptions, grpc.WithContextDialer(opts.Dialer))
+
+		// The address is resolved on the other end of the dialer (ex: the
+		// server is only resolvable from the SSH server)
+		target = "passthrough:///" + opts.Address
+	}
+
+	if opts.KeepaliveInterval > 0 {
+		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    opts.KeepaliveInterval,
+			Timeout: KeepaliveTimeout,
+		}))
+	}
+
+	conn, err := grpc.DialContext(connectCtx, target, dialOptions...)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to dial gRPC server")
 	}
@@ -82,6 +187,36 @@ func (a *API) Test(ctx context.Context) error {
 	return nil
 }
 
+// ConnState returns the state of the gRPC connection; it leaves READY as soon
+// as the transport breaks (ex: the server went away, or a keepalive ping was
+// not answered). A connection that is READY is reported as TRANSIENT_FAILURE
+// while a stream fails to receive (ex: the server dropped the tail stream).
+func (a *API) ConnState() connectivity.State {
+	state := a.conn.GetState()
+
+	if state == connectivity.Ready && a.failedStreams.Load() > 0 {
+		return connectivity.TransientFailure
+	}
+
+	return state
+}
+
+// setStreamFailed records whether the last receive of a stream failed;
+// failed is the state of the stream, owned by its goroutine
+func (a *API) setStreamFailed(failed *bool, value bool) {
+	if *failed == value {
+		return
+	}
+
+	*failed = value
+
+	if value {
+		a.failedStreams.Add(1)
+	} else {
+		a.failedStreams.Add(-1)
+	}
+}
+
 // GetAllLiveAudiences returns all live audiences -- clients that are actively
 // connected to the streamdal server and have announced one or more audiences)
 func (a *API) GetAllLiveAudiences(ctx context.Context) ([]*protos.Audience, error) {
@@ -133,22 +268,29 @@ func (a *API) Tail(ctx context.Context, audience *protos.Audience) (chan *protos
 
 	tailRespCh := make(chan *protos.TailResponse, 1)
 
-	go func() {
+	crash.Go(func() {
 		defer a.log.Debug("api.Tail() goroutine exiting")
 
+		var failed bool
+		defer a.setStreamFailed(&failed, false)
+
 		for {
 			resp, err := grpcCall.Recv()
 			if err != nil {
-				if strings.Contains(err.Error(), "context canceled") {
+				// A failed stream keeps returning its error once canceled
+				if ctx.Err() != nil || strings.Contains(err.Error(), "context canceled") {
 					a.log.Debug("detected context cancellation in api.Tail() during Recv()")
 					return
 				}
 
 				a.log.Errorf("unable to receive tail response: %s", err)
+				a.setStreamFailed(&failed, true)
 				time.Sleep(time.Second)
 				continue
 			}
 
+			a.setStreamFailed(&failed, false)
+
 			select {
 			case tailRespCh <- resp:
 				// Successfully sent msg to tail receiver
@@ -157,11 +299,161 @@ func (a *API) Tail(ctx context.Context, audience *protos.Audience) (chan *protos
 				return
 			}
 		}
-	}()
+	})
 
 	return tailRespCh, nil
 }
 
+// WatchAll streams the state of the server (live clients, audiences and
+// pipelines); a response is sent every time the state changes. The returned
+// channel is closed when ctx is canceled.
+func (a *API) WatchAll(ctx context.Context) (chan *protos.GetAllResponse, error) {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs(AuthTokenMetadata, a.options.AuthToken))
+
+	stream, err := a.client.GetAllStream(ctx, &protos.GetAllRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to complete get all stream request")
+	}
+
+	respCh := make(chan *protos.GetAllResponse, 1)
+
+	crash.Go(func() {
+		defer close(respCh)
+		defer a.log.Debug("api.WatchAll() goroutine exiting")
+
+		var failed bool
+		defer a.setStreamFailed(&failed, false)
+
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				a.log.Errorf("unable to receive get all stream response: %s", err)
+				a.setStreamFailed(&failed, true)
+				time.Sleep(time.Second)
+
+				// A broken stream keeps returning the same error; start a new one
+				if newStream, err := a.client.GetAllStream(ctx, &protos.GetAllRequest{}); err == nil {
+					stream = newStream
+				}
+
+				continue
+			}
+
+			a.setStreamFailed(&failed, false)
+
+			select {
+			case respCh <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return respCh, nil
+}
+
+// WatchAudienceRates streams the rates (msgs/sec and bytes/sec) of all
+// audiences, keyed by util.AudienceToStr(); the server sends a response
+// periodically. The returned channel is closed when ctx is canceled.
+func (a *API) WatchAudienceRates(ctx context.Context) (chan *protos.GetAudienceRatesResponse, error) {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs(AuthTokenMetadata, a.options.AuthToken))
+
+	stream, err := a.client.GetAudienceRates(ctx, &protos.GetAudienceRatesRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to complete get audience rates request")
+	}
+
+	respCh := make(chan *protos.GetAudienceRatesResponse, 1)
+
+	crash.Go(func() {
+		defer close(respCh)
+		defer a.log.Debug("api.WatchAudienceRates() goroutine exiting")
+
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				a.log.Errorf("unable to receive audience rates: %s", err)
+				time.Sleep(time.Second)
+
+				// A broken stream keeps returning the same error; start a new one
+				if newStream, err := a.client.GetAudienceRates(ctx, &protos.GetAudienceRatesRequest{}); err == nil {
+					stream = newStream
+				}
+
+				continue
+			}
+
+			select {
+			case respCh <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return respCh, nil
+}
+
+// GetPipelines returns all pipelines defined on the server
+func (a *API) GetPipelines(ctx context.Context) ([]*protos.Pipeline, error) {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs(AuthTokenMetadata, a.options.AuthToken))
+
+	resp, err := a.client.GetPipelines(ctx, &protos.GetPipelinesRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to complete get pipelines request")
+	}
+
+	return resp.Pipelines, nil
+}
+
+// CreatePipeline creates a new pipeline and returns its ID
+func (a *API) CreatePipeline(ctx context.Context, pipeline *protos.Pipeline) (string, error) {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs(AuthTokenMetadata, a.options.AuthToken))
+
+	resp, err := a.client.CreatePipeline(ctx, &protos.CreatePipelineRequest{Pipeline: pipeline})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to complete create pipeline request")
+	}
+
+	return resp.PipelineId, nil
+}
+
+// UpdatePipeline updates an existing pipeline (pipeline.Id must be set)
+func (a *API) UpdatePipeline(ctx context.Context, pipeline *protos.Pipeline) error {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs(AuthTokenMetadata, a.options.AuthToken))
+
+	resp, err := a.client.UpdatePipeline(ctx, &protos.UpdatePipelineRequest{Pipeline: pipeline})
+	if err != nil {
+		return errors.Wrap(err, "unable to complete update pipeline request")
+	}
+
+	if err := validateStandardResponse(resp); err != nil {
+		return errors.Wrap(err, "unable to update pipeline")
+	}
+
+	return nil
+}
+
+func validateStandardResponse(resp *protos.StandardResponse) error {
+	if resp == nil {
+		return errors.New("response cannot be nil")
+	}
+
+	if resp.Code != protos.ResponseCode_RESPONSE_CODE_OK {
+		return errors.Errorf("server responded with '%s': %s", resp.Code, resp.Message)
+	}
+
+	return nil
+}
+
 func validateGetAllResp(resp *protos.GetAllResponse) error {
 	if resp == nil {
 		return errors.New("get all response cannot be nil")
@@ -185,9 +477,27 @@ func validateOptions(opts *Options) error {
 		return errors.New("auth token cannot be empty")
 	}
 
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
 	if opts.ConnectTimeout < time.Second {
 		return errors.New("connect timeout must be at least 1 second")
 	}
 
+	switch opts.Transport {
+	case "", TransportGRPC, TransportGRPCWeb, TransportWebSocket:
+	default:
+		return errors.Errorf("unknown transport '%s'", opts.Transport)
+	}
+
+	if isUnixSocket(opts.Address) && opts.Transport != "" && opts.Transport != TransportGRPC {
+		return errors.Errorf("transport '%s' cannot be used with a Unix domain socket", opts.Transport)
+	}
+
+	if isUnixSocket(opts.Address) && opts.Dialer != nil {
+		return errors.New("a Unix domain socket cannot be dialed through a dialer")
+	}
+
 	return nil
 }
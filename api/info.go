@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/streamdal/cli/crash"
+)
+
+const (
+	// ServerVersionMetadata is the response header (or trailer) servers
+	// report their version in; servers that predate it do not send it
+	ServerVersionMetadata = "server-version"
+
+	// FeatureProbeTimeout is how long a streaming RPC is given to fail with
+	// Unimplemented; streams that are still open by then are supported
+	FeatureProbeTimeout = 2 * time.Second
+)
+
+// Feature is an RPC of the external API that the CLI uses (or may use)
+type Feature struct {
+	Name        string `json:"name"`
+	Method      string `json:"method"`
+	Description string `json:"description"`
+	Supported   bool   `json:"supported"`
+}
+
+// ServerInfo describes the server the CLI is connected to
+type ServerInfo struct {
+	Version  string     `json:"version,omitempty"` // empty if not reported by the server
+	Features []*Feature `json:"features"`
+}
+
+// feature probes an RPC with a request that has no side effects (it is
+// either read-only or rejected by validation); an Unimplemented error means
+// that the server does not support it
+type feature struct {
+	Feature
+	probe func(ctx context.Context, client protos.ExternalClient) error
+}
+
+// features are listed in the order they are displayed
+var features = []*feature{
+	{
+		Feature: Feature{Name: "tail", Method: "Tail", Description: "Tail payloads of a component"},
+		probe: func(ctx context.Context, client protos.ExternalClient) error {
+			return probeStream(client.Tail(ctx, &protos.TailRequest{}))
+		},
+	},
+	{
+		Feature: Feature{Name: "live-updates", Method: "GetAllStream", Description: "Notifications of clients, audiences and pipelines changing"},
+		probe: func(ctx context.Context, client protos.ExternalClient) error {
+			return probeStream(client.GetAllStream(ctx, &protos.GetAllRequest{}))
+		},
+	},
+	{
+		Feature: Feature{Name: "audience-rates", Method: "GetAudienceRates", Description: "Throughput of every audience"},
+		probe: func(ctx context.Context, client protos.ExternalClient) error {
+			return probeStream(client.GetAudienceRates(ctx, &protos.GetAudienceRatesRequest{}))
+		},
+	},
+	{
+		Feature: Feature{Name: "metrics", Method: "GetMetrics", Description: "Server metrics"},
+		probe: func(ctx context.Context, client protos.ExternalClient) error {
+			return probeStream(client.GetMetrics(ctx, &protos.GetMetricsRequest{}))
+		},
+	},
+	{
+		Feature: Feature{Name: "pipelines", Method: "GetPipelines", Description: "Pipeline export and apply"},
+		probe: func(ctx context.Context, client protos.ExternalClient) error {
+			_, err := client.GetPipelines(ctx, &protos.GetPipelinesRequest{})
+			return err
+		},
+	},
+	{
+		Feature: Feature{Name: "notifications", Method: "GetNotifications", Description: "Notification configs"},
+		probe: func(ctx context.Context, client protos.ExternalClient) error {
+			_, err := client.GetNotifications(ctx, &protos.GetNotificationsRequest{})
+			return err
+		},
+	},
+	{
+		Feature: Feature{Name: "schemas", Method: "GetSchema", Description: "Inferred schemas of audiences"},
+		probe: func(ctx context.Context, client protos.ExternalClient) error {
+			_, err := client.GetSchema(ctx, &protos.GetSchemaRequest{})
+			return err
+		},
+	},
+}
+
+// ServerInfo returns the version of the server (if it reports it) and which
+// features it supports. An error is only returned if the server cannot be
+// talked to; features are probed concurrently, each for at most
+// FeatureProbeTimeout.
+func (a *API) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs(AuthTokenMetadata, a.options.AuthToken))
+
+	var header, trailer metadata.MD
+
+	if _, err := a.client.Test(ctx, &protos.TestRequest{}, grpc.Header(&header), grpc.Trailer(&trailer)); err != nil {
+		return nil, errors.Wrap(err, "unable to complete test request")
+	}
+
+	info := &ServerInfo{Features: make([]*Feature, len(features))}
+
+	for _, md := range []metadata.MD{header, trailer} {
+		if v := md.Get(ServerVersionMetadata); len(v) > 0 && info.Version == "" {
+			info.Version = v[0]
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	for i, f := range features {
+		wg.Add(1)
+
+		i, f := i, f
+
+		crash.Go(func() {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, FeatureProbeTimeout)
+			defer cancel()
+
+			err := f.probe(probeCtx, a.client)
+
+			a.log.Debugf("probed %s: %v", f.Method, err)
+
+			supported := f.Feature
+			supported.Supported = status.Code(err) != codes.Unimplemented
+			info.Features[i] = &supported
+		})
+	}
+
+	wg.Wait()
+
+	return info, nil
+}
+
+// probeStream waits for the first message of a server stream; servers
+// report errors (ex: Unimplemented) on the first receive. A stream that
+// sends nothing before the deadline returns DeadlineExceeded.
+func probeStream(stream grpc.ClientStream, err error) error {
+	if err != nil {
+		return err
+	}
+
+	// The content is not needed; every field is skipped as unknown
+	return stream.RecvMsg(&emptypb.Empty{})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/streamdal/cli/util"
+)
+
+// TraceMaxLength is the number of characters of a request or response that
+// are logged when tracing; payloads can be large
+const TraceMaxLength = 1024
+
+// TraceRedacted replaces the value of secretFields in traced messages
+const TraceRedacted = "<redacted>"
+
+// secretFields are the credentials of notification configs (ex: returned by
+// GetAll and GetNotifications); they are never traced
+var secretFields = map[protoreflect.Name]bool{
+	"bot_token":             true, // NotificationSlack
+	"password":              true, // NotificationEmailSMTP
+	"ses_access_key_id":     true, // NotificationEmailSES
+	"ses_secret_access_key": true, // NotificationEmailSES
+	"token":                 true, // NotificationPagerDuty
+}
+
+// payloadFields are the payloads of Tail responses; only their size is
+// traced unless Options.TracePayloads is set
+var payloadFields = map[protoreflect.Name]bool{
+	"original_data": true,
+	"new_data":      true,
+}
+
+// trace returns conn with every request and response logged if the logger is
+// at debug level; conn is returned as is otherwise so that tracing costs
+// nothing unless enabled
+func (a *API) trace(conn grpc.ClientConnInterface) grpc.ClientConnInterface {
+	if a.log.GetLevel() > log.DebugLevel {
+		return conn
+	}
+
+	return &tracingConn{ClientConnInterface: conn, log: a.log, payloads: a.options.TracePayloads}
+}
+
+// tracingConn logs the requests and responses of unary calls and the
+// messages of streams (ex: Tail)
+type tracingConn struct {
+	grpc.ClientConnInterface
+	log      *log.Logger
+	payloads bool
+}
+
+func (t *tracingConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	started := time.Now()
+
+	err := t.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+	if err != nil {
+		t.log.Debug("grpc call", "method", method, "duration", time.Since(started), "request", traceMessage(args, t.payloads), "err", err)
+		return err
+	}
+
+	t.log.Debug("grpc call", "method", method, "duration", time.Since(started), "request", traceMessage(args, t.payloads), "response", traceMessage(reply, t.payloads))
+
+	return nil
+}
+
+func (t *tracingConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := t.ClientConnInterface.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		t.log.Debug("grpc stream", "method", method, "err", err)
+		return nil, err
+	}
+
+	t.log.Debug("grpc stream", "method", method)
+
+	return &tracingStream{ClientStream: stream, method: method, log: t.log, payloads: t.payloads}, nil
+}
+
+type tracingStream struct {
+	grpc.ClientStream
+	method   string
+	log      *log.Logger
+	payloads bool
+}
+
+func (s *tracingStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+
+	s.log.Debug("grpc send", "method", s.method, "request", traceMessage(m, s.payloads), "err", err)
+
+	return err
+}
+
+func (s *tracingStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	switch {
+	case err == io.EOF:
+		s.log.Debug("grpc stream closed", "method", s.method)
+	case err != nil:
+		s.log.Debug("grpc recv", "method", s.method, "err", err)
+	default:
+		s.log.Debug("grpc recv", "method", s.method, "response", traceMessage(m, s.payloads))
+	}
+
+	return err
+}
+
+// traceMessage returns a message as compact JSON, truncated to
+// TraceMaxLength. Credentials are redacted and the rest goes through
+// util.MaskSecrets; payloads are replaced by their size unless payloads is set.
+func traceMessage(m interface{}, payloads bool) string {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return "<not a protobuf message>"
+	}
+
+	// Redact a copy; m is the caller's message
+	msg = proto.Clone(msg)
+	sizes := redactMessage(msg.ProtoReflect(), payloads)
+
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return "<unable to marshal: " + err.Error() + ">"
+	}
+
+	traced := util.MaskSecrets(string(data))
+
+	if len(traced) > TraceMaxLength {
+		traced = traced[:TraceMaxLength] + "..."
+	}
+
+	for _, size := range sizes {
+		traced += " " + size
+	}
+
+	return traced
+}
+
+// redactMessage sets secretFields to TraceRedacted and clears payloadFields
+// (recursively), or masks them if payloads is set; it returns the sizes of the
+// cleared payloads (ex: original_data=123B)
+func redactMessage(m protoreflect.Message, payloads bool) []string {
+	var (
+		sizes  []string
+		fields []protoreflect.FieldDescriptor
+	)
+
+	// Fields are changed after Range as it only allows clearing the current one
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fields = append(fields, fd)
+		return true
+	})
+
+	for _, fd := range fields {
+		v := m.Get(fd)
+
+		switch {
+		case secretFields[fd.Name()] && fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap():
+			m.Set(fd, protoreflect.ValueOfString(TraceRedacted))
+		case payloadFields[fd.Name()] && fd.Kind() == protoreflect.BytesKind && !fd.IsList() && !fd.IsMap():
+			if payloads {
+				// Masked before being base64 encoded by protojson
+				m.Set(fd, protoreflect.ValueOfBytes([]byte(util.MaskSecrets(string(v.Bytes())))))
+				continue
+			}
+
+			sizes = append(sizes, fmt.Sprintf("%s=%dB", fd.Name(), len(v.Bytes())))
+			m.Clear(fd)
+		case fd.IsList() && fd.Message() != nil:
+			for i := 0; i < v.List().Len(); i++ {
+				sizes = append(sizes, redactMessage(v.List().Get(i).Message(), payloads)...)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				sizes = append(sizes, redactMessage(mv.Message(), payloads)...)
+				return true
+			})
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+			sizes = append(sizes, redactMessage(v.Message(), payloads)...)
+		}
+	}
+
+	return sizes
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"nhooyr.io/websocket"
+)
+
+// Transports used for talking to the server
+const (
+	TransportGRPC      = "grpc"
+	TransportGRPCWeb   = "grpc-web"
+	TransportWebSocket = "ws"
+)
+
+// Addresses with these prefixes are Unix domain sockets (ex:
+// unix:///var/run/streamdal.sock); they are dialed by gRPC as-is
+var unixSocketPrefixes = []string{"unix:", "unix-abstract:"}
+
+// MaxWebSocketMessageSize is the largest WebSocket message accepted from the
+// gateway
+const MaxWebSocketMessageSize = 64 * 1024 * 1024
+
+// serverURL returns the URL of the server for transports that go through a
+// gateway; the address may already be a URL (ex: https://gateway/streamdal),
+// otherwise the secure scheme is used unless TLS is disabled.
+func serverURL(opts *Options, scheme, secureScheme string) string {
+	if strings.Contains(opts.Address, "://") {
+		return opts.Address
+	}
+
+	if opts.DisableTLS {
+		return scheme + "://" + opts.Address
+	}
+
+	return secureScheme + "://" + opts.Address
+}
+
+// dialWebSocket tunnels the gRPC (HTTP/2) connection through a WebSocket
+// gateway that forwards the raw byte stream to the server (ex: websockify)
+func dialWebSocket(opts *Options) func(ctx context.Context, _ string) (net.Conn, error) {
+	return func(ctx context.Context, _ string) (net.Conn, error) {
+		u := serverURL(opts, "ws", "wss")
+
+		var dialOpts *websocket.DialOptions
+
+		if opts.Dialer != nil {
+			dialOpts = &websocket.DialOptions{
+				HTTPClient: &http.Client{
+					Transport: &http.Transport{
+						DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+							return opts.Dialer(ctx, addr)
+						},
+					},
+				},
+			}
+		}
+
+		ws, _, err := websocket.Dial(ctx, u, dialOpts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to connect to WebSocket gateway '%s'", u)
+		}
+
+		ws.SetReadLimit(MaxWebSocketMessageSize)
+
+		// The dial context is canceled once gRPC is connected; the connection
+		// has to outlive it
+		return websocket.NetConn(context.Background(), ws, websocket.MessageBinary), nil
+	}
+}
+
+// isUnixSocket returns true if address is a Unix domain socket
+func isUnixSocket(address string) bool {
+	for _, prefix := range unixSocketPrefixes {
+		if strings.HasPrefix(address, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,149 @@
+// Package archive streams captured payloads to durable storage. Only S3 is
+// supported at this time; credentials are resolved the same way as the AWS CLI
+// (env vars, shared config/credentials files, SSO, instance roles, etc.)
+package archive
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/crash"
+)
+
+type Options struct {
+	// URL is the archive location (ex: s3://bucket/prefix)
+	URL string
+
+	Logger *log.Logger
+}
+
+type Archive struct {
+	bucket   string
+	prefix   string
+	uploader *manager.Uploader
+	log      *log.Logger
+}
+
+func New(ctx context.Context, opts *Options) (*Archive, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate archive options")
+	}
+
+	bucket, prefix, err := ParseURL(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load AWS config")
+	}
+
+	return &Archive{
+		bucket:   bucket,
+		prefix:   prefix,
+		uploader: manager.NewUploader(s3.NewFromConfig(cfg)),
+		log:      opts.Logger.WithPrefix("archive"),
+	}, nil
+}
+
+// ParseURL splits an s3://bucket/prefix URL into the bucket and key prefix
+func ParseURL(archiveURL string) (string, string, error) {
+	u, err := url.Parse(archiveURL)
+	if err != nil {
+		return "", "", errors.Wrap(err, "unable to parse archive URL")
+	}
+
+	if u.Scheme != "s3" {
+		return "", "", errors.Errorf("unsupported archive URL scheme '%s' (only s3:// is supported)", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return "", "", errors.New("archive URL must include a bucket (ex: s3://bucket/prefix)")
+	}
+
+	return u.Host, strings.Trim(u.Path, "/"), nil
+}
+
+// Create starts streaming an object with the given name (below the prefix);
+// data written to the returned writer is uploaded in parts as it arrives so
+// long captures don't have to be kept in memory or on disk. The upload is
+// completed (or aborted if ctx is canceled) by Close().
+func (a *Archive) Create(ctx context.Context, name string) io.WriteCloser {
+	pr, pw := io.Pipe()
+
+	w := &writer{
+		pw:   pw,
+		key:  path.Join(a.prefix, name),
+		done: make(chan error, 1),
+	}
+
+	crash.Go(func() {
+		_, err := a.uploader.Upload(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(a.bucket),
+			Key:    aws.String(w.key),
+			Body:   pr,
+		})
+
+		// Unblock writers if the upload failed
+		_ = pr.CloseWithError(err)
+
+		w.done <- err
+	})
+
+	a.log.Debugf("archiving to s3://%s/%s", a.bucket, w.key)
+
+	return w
+}
+
+type writer struct {
+	pw   *io.PipeWriter
+	key  string
+	done chan error
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	n, err := w.pw.Write(p)
+	if err != nil {
+		return n, errors.Wrapf(err, "unable to upload '%s'", w.key)
+	}
+
+	return n, nil
+}
+
+// Close finishes the upload and waits for it to complete
+func (w *writer) Close() error {
+	_ = w.pw.Close()
+
+	if err := <-w.done; err != nil {
+		return errors.Wrapf(err, "unable to upload '%s'", w.key)
+	}
+
+	return nil
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.URL == "" {
+		return errors.New(".URL cannot be empty")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+// Package audit records actions taken in the CLI (ex: component selected,
+// sample rate changed, pipeline applied) to an append-only local file for
+// post-incident accountability.
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+)
+
+// Actions that are recorded
+const (
+	ActionConnected           = "connected"
+	ActionQuit                = "quit"
+	ActionComponentsSelected  = "components_selected"
+	ActionFilterSet           = "filter_set"
+	ActionFieldFilterSet      = "field_filter_set"
+	ActionSearchSet           = "search_set"
+	ActionSampleRateSet       = "sample_rate_set"
+	ActionViewOptionsSet      = "view_options_set"
+	ActionBreakSet            = "break_set"
+	ActionMaxLinesSet         = "max_lines_set"
+	ActionTimeWindowSet       = "time_window_set"
+	ActionComponentFiltersSet = "component_filters_set"
+	ActionTraceFilterSet      = "trace_filter_set"
+	ActionTailPaused          = "tail_paused"
+	ActionTailResumed         = "tail_resumed"
+	ActionTailCleared         = "tail_cleared"
+	ActionExported            = "exported"
+	ActionShared              = "shared"
+	ActionPipelineApplied     = "pipeline_applied"
+)
+
+type Options struct {
+	// Path of the audit file; it is created if it doesn't exist and entries
+	// are always appended
+	Path string
+
+	// Server is included in every entry (ex: "localhost:8082", "demo")
+	Server string
+
+	Logger *log.Logger
+}
+
+// Entry is a single line (JSON) in the audit file
+type Entry struct {
+	Time    time.Time         `json:"time"`
+	User    string            `json:"user"`
+	Server  string            `json:"server"`
+	Action  string            `json:"action"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+type Audit struct {
+	options *Options
+	file    *os.File
+	user    string
+	mu      sync.Mutex
+	log     *log.Logger
+}
+
+func New(opts *Options) (*Audit, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate audit options")
+	}
+
+	// O_APPEND: entries are never overwritten, even if several CLIs share
+	// the same file
+	f, err := os.OpenFile(opts.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open audit file")
+	}
+
+	username := "unknown"
+
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	return &Audit{
+		options: opts,
+		file:    f,
+		user:    username,
+		log:     opts.Logger.WithPrefix("audit"),
+	}, nil
+}
+
+// Record appends an entry for action; details are optional key/value pairs
+// (ex: "rate" => "10"). Failing to write an entry is logged but does not
+// interrupt the CLI.
+func (a *Audit) Record(action string, details map[string]string) {
+	entry, err := json.Marshal(&Entry{
+		Time:    time.Now().UTC(),
+		User:    a.user,
+		Server:  a.options.Server,
+		Action:  action,
+		Details: details,
+	})
+	if err != nil {
+		a.log.Errorf("unable to marshal audit entry: %s", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// A single write per entry so that entries are never interleaved
+	if _, err := a.file.Write(append(entry, '\n')); err != nil {
+		a.log.Errorf("unable to write audit entry: %s", err)
+	}
+}
+
+func (a *Audit) Close() error {
+	return a.file.Close()
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.Path == "" {
+		return errors.New(".Path cannot be empty")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
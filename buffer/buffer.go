@@ -0,0 +1,206 @@
+// Package buffer contains a bounded, in-memory store for the records that are
+// displayed in the tail view. Storing structured records (instead of relying
+// on the text inside the tview.TextView) allows the view to be re-rendered
+// when view settings change.
+package buffer
+
+import (
+	"sync"
+
+	"github.com/streamdal/cli/types"
+)
+
+const (
+	// recordOverhead is a rough estimate of the memory used by a record in
+	// addition to its payload (struct fields, slice headers, etc.)
+	recordOverhead = 128
+)
+
+type Buffer struct {
+	records    []*types.TailRecord
+	maxRecords int
+	size       int64
+	maxBytes   int64
+	mtx        *sync.RWMutex
+}
+
+// New creates a new buffer that will hold at most maxRecords
+func New(maxRecords int) *Buffer {
+	if maxRecords < 1 {
+		maxRecords = 1
+	}
+
+	return &Buffer{
+		records:    make([]*types.TailRecord, 0),
+		maxRecords: maxRecords,
+		mtx:        &sync.RWMutex{},
+	}
+}
+
+// Add appends a record to the buffer; if the buffer is full, the oldest record
+// is evicted. If a memory cap is set, the oldest records are evicted until the
+// buffer fits within it; the number of records evicted because of the memory
+// cap is returned.
+func (b *Buffer) Add(record *types.TailRecord) int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.records = append(b.records, record)
+	b.size += recordSize(record)
+
+	b.evict(b.maxRecords)
+
+	var evicted int
+
+	// Always keep the newest record, even if it alone exceeds the cap
+	for b.maxBytes > 0 && b.size > b.maxBytes && len(b.records) > 1 {
+		b.evict(len(b.records) - 1)
+		evicted++
+	}
+
+	return evicted
+}
+
+// SetMaxBytes sets the (approximate) memory cap for the buffer; 0 disables it.
+// If the buffer is larger, the oldest records are evicted (the newest record
+// is always kept).
+func (b *Buffer) SetMaxBytes(maxBytes int64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.maxBytes = maxBytes
+
+	for b.maxBytes > 0 && b.size > b.maxBytes && len(b.records) > 1 {
+		b.evict(len(b.records) - 1)
+	}
+}
+
+// MaxBytes returns the memory cap of the buffer; 0 if there is none
+func (b *Buffer) MaxBytes() int64 {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	return b.maxBytes
+}
+
+// Size returns the approximate memory used by the records in the buffer
+func (b *Buffer) Size() int64 {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	return b.size
+}
+
+// evict removes the oldest records until at most keep records remain. Must be
+// called with the lock held.
+func (b *Buffer) evict(keep int) {
+	if len(b.records) <= keep {
+		return
+	}
+
+	for _, r := range b.records[:len(b.records)-keep] {
+		b.size -= recordSize(r)
+	}
+
+	b.records = b.records[len(b.records)-keep:]
+}
+
+// recordSize estimates how much memory a record uses
+func recordSize(r *types.TailRecord) int64 {
+	size := recordOverhead + len(r.Data) + len(r.TraceID) + len(r.Banner) + len(r.Note)
+
+	if r.Preview != nil {
+		size += len(r.Preview.Step) + len(r.Preview.Message) + len(r.Preview.Output) + len(r.Preview.Error)
+	}
+
+	return int64(size)
+}
+
+// SetMaxRecords changes the capacity of the buffer; if the buffer holds more
+// than maxRecords, the oldest records are evicted.
+func (b *Buffer) SetMaxRecords(maxRecords int) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if maxRecords < 1 {
+		maxRecords = 1
+	}
+
+	b.maxRecords = maxRecords
+
+	b.evict(b.maxRecords)
+}
+
+// MaxRecords returns the capacity of the buffer
+func (b *Buffer) MaxRecords() int {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	return b.maxRecords
+}
+
+// AverageSize returns the average payload size (in bytes) of the records in
+// the buffer
+func (b *Buffer) AverageSize() int {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	var total, count int
+
+	for _, r := range b.records {
+		if r.Banner != "" {
+			continue
+		}
+
+		total += len(r.Data)
+		count++
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return total / count
+}
+
+// Records returns a copy of all records currently in the buffer (oldest first)
+func (b *Buffer) Records() []*types.TailRecord {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	records := make([]*types.TailRecord, len(b.records))
+	copy(records, b.records)
+
+	return records
+}
+
+// Get returns the record with the given line number
+func (b *Buffer) Get(lineNum int) (*types.TailRecord, bool) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	for _, r := range b.records {
+		if r.LineNum == lineNum && r.Banner == "" {
+			return r, true
+		}
+	}
+
+	return nil, false
+}
+
+// Len returns the number of records in the buffer
+func (b *Buffer) Len() int {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	return len(b.records)
+}
+
+// Clear removes all records from the buffer
+func (b *Buffer) Clear() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.records = make([]*types.TailRecord, 0)
+	b.size = 0
+}
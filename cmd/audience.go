@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/util"
+)
+
+// audienceJSON is the representation of an audience in "audience list -o json"
+type audienceJSON struct {
+	Audience      string `json:"audience"`
+	ServiceName   string `json:"service_name"`
+	OperationType string `json:"operation_type"`
+	OperationName string `json:"operation_name"`
+	ComponentName string `json:"component_name"`
+}
+
+// runAudienceList handles "audience list"
+func (c *Cmd) runAudienceList() error {
+	source, err := c.newHeadlessSource()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.shutdownCtx, c.options.Config.ConnectTimeout)
+	defer cancel()
+
+	audiences, err := source.GetAllLiveAudiences(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to fetch live audiences")
+	}
+
+	switch c.options.Config.Audience.List.Output {
+	case "json":
+		return printAudiencesJSON(audiences)
+	default:
+		return printAudiencesTable(audiences)
+	}
+}
+
+func printAudiencesTable(audiences []*protos.Audience) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "SERVICE\tOPERATION TYPE\tOPERATION NAME\tCOMPONENT")
+
+	for _, aud := range audiences {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			aud.ServiceName,
+			util.ProtosOperationTypeToStr(aud.OperationType),
+			aud.OperationName,
+			aud.ComponentName,
+		)
+	}
+
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, "unable to write audiences")
+	}
+
+	return nil
+}
+
+func printAudiencesJSON(audiences []*protos.Audience) error {
+	out := make([]*audienceJSON, 0)
+
+	for _, aud := range audiences {
+		out = append(out, &audienceJSON{
+			Audience:      util.FormatAudience(aud),
+			ServiceName:   aud.ServiceName,
+			OperationType: util.ProtosOperationTypeToStr(aud.OperationType),
+			OperationName: aud.OperationName,
+			ComponentName: aud.ComponentName,
+		})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(out); err != nil {
+		return errors.Wrap(err, "unable to encode audiences")
+	}
+
+	return nil
+}
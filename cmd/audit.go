@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/streamdal/cli/audit"
+)
+
+// newAudit opens the audit file if --audit-log is set
+func newAudit(opts *Options) (*audit.Audit, error) {
+	if opts.Config.AuditLog == "" {
+		return nil, nil
+	}
+
+	return audit.New(&audit.Options{
+		Path:   opts.Config.AuditLog,
+		Server: serverName(opts),
+		Logger: opts.Logger,
+	})
+}
+
+// audit records an action in the audit file (if enabled) and in the recent
+// actions that are included in crash reports
+func (c *Cmd) audit(action string, details map[string]string) {
+	c.options.Crash.Record(action, details)
+
+	if c.auditLog == nil {
+		return
+	}
+
+	c.auditLog.Record(action, details)
+}
+
+// serverName returns the server that data is read from ("demo" and "local"
+// for the demo and local sources)
+func serverName(opts *Options) string {
+	switch {
+	case opts.Config.LocalSource():
+		return "local"
+	case opts.Config.Demo:
+		return "demo"
+	default:
+		return opts.Config.Server
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+
+	"github.com/streamdal/cli/config"
+)
+
+// runAuthLogin handles "auth login"; the token is verified against the server
+// before it is stored in the OS keychain.
+func (c *Cmd) runAuthLogin() error {
+	token, err := readToken()
+	if err != nil {
+		return err
+	}
+
+	if token == "" {
+		return errors.New("auth token cannot be empty")
+	}
+
+	c.options.Config.Auth = token
+
+	if _, err := c.newHeadlessAPI(); err != nil {
+		return errors.Wrapf(err, "unable to verify auth token for '%s'", c.options.Config.Server)
+	}
+
+	if err := config.SetKeychainToken(c.options.Config.Server, token); err != nil {
+		return err
+	}
+
+	fmt.Printf("Stored auth token for '%s' in the OS keychain\n", c.options.Config.Server)
+
+	return nil
+}
+
+// runAuthLogout handles "auth logout"
+func (c *Cmd) runAuthLogout() error {
+	deleted, err := config.DeleteKeychainToken(c.options.Config.Server)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		fmt.Printf("No auth token stored for '%s'\n", c.options.Config.Server)
+		return nil
+	}
+
+	fmt.Printf("Removed auth token for '%s' from the OS keychain\n", c.options.Config.Server)
+
+	return nil
+}
+
+// readToken prompts for the token (without echoing it) if stdin is a
+// terminal; otherwise the first line of stdin is used (ex: when piped from a
+// secrets manager).
+func readToken() (string, error) {
+	fd := int(os.Stdin.Fd())
+
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Auth token: ")
+
+		token, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+
+		if err != nil {
+			return "", errors.Wrap(err, "unable to read auth token")
+		}
+
+		return strings.TrimSpace(string(token)), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.Wrap(err, "unable to read auth token from stdin")
+	}
+
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/streamdal/cli/util"
+)
+
+// StatsInterval is how often the bandwidth displayed in the status bar is
+// refreshed while tailing
+const StatsInterval = time.Second
+
+// AccessibleStatsInterval replaces StatsInterval in screen reader friendly
+// mode so that the status bar is not read out every second
+const AccessibleStatsInterval = 10 * time.Second
+
+// bandwidth tracks the messages and bytes received over the tail stream(s),
+// including messages that are filtered out or not displayed (paused,
+// sampled); a high rate is a hint to turn the sample rate down.
+type bandwidth struct {
+	messages    int64
+	total       int64
+	messageRate *util.Throughput
+	rate        *util.Throughput
+}
+
+func newBandwidth() *bandwidth {
+	return &bandwidth{
+		messageRate: util.NewThroughput(ThroughputWindow),
+		rate:        util.NewThroughput(ThroughputWindow),
+	}
+}
+
+// add records a message of n bytes received at the given time
+func (b *bandwidth) add(now time.Time, n int) {
+	b.messages++
+	b.total += int64(n)
+	b.messageRate.Add(now)
+	b.rate.AddN(now, n)
+}
+
+func (b *bandwidth) reset() {
+	b.messages = 0
+	b.total = 0
+	b.messageRate.Reset()
+	b.rate.Reset()
+}
+
+// String is the cumulative and per-second messages and bandwidth (ex:
+// "↓ 12,345 msgs (1.2k/s) · 1.5 MiB (12.0 KiB/s)")
+func (b *bandwidth) String(now time.Time) string {
+	return fmt.Sprintf("%s %s msgs (%s/s)%s %s (%s/s)",
+		util.Glyph("↓", "in:", "received"), util.FormatCount(b.messages), util.FormatRate(b.messageRate.Rate(now)), util.Glyph(" ·", ",", ","),
+		util.FormatSize(b.total), util.FormatSize(int64(b.rate.Rate(now))))
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/streamdal/cli/util"
+)
+
+// bench holds the state of a running benchmark (--bench)
+type bench struct {
+	started   time.Time
+	doneCh    chan struct{}
+	displayed uint64
+}
+
+// startBench starts the benchmark timer; once it fires, tail() will receive
+// on benchDoneCh() and quit.
+func (c *Cmd) startBench() {
+	c.bench = &bench{
+		started: time.Now(),
+		doneCh:  make(chan struct{}),
+	}
+
+	time.AfterFunc(c.options.Config.BenchDuration, func() {
+		close(c.bench.doneCh)
+	})
+}
+
+// benchDoneCh returns a channel that is closed when the benchmark is done. If
+// no benchmark is running, a nil channel (which blocks forever) is returned.
+func (c *Cmd) benchDoneCh() <-chan struct{} {
+	if c.bench == nil {
+		return nil
+	}
+
+	return c.bench.doneCh
+}
+
+func (c *Cmd) printBenchResults() {
+	elapsed := time.Since(c.bench.started).Seconds()
+
+	var generated uint64
+
+	if c.demo != nil {
+		generated = c.demo.Generated()
+	}
+
+	fmt.Printf("Benchmark results (%s @ %d msgs/s, %d byte %s payloads):\n",
+		c.options.Config.BenchDuration,
+		c.options.Config.DemoRate,
+		c.options.Config.DemoPayloadSize,
+		c.options.Config.DemoShape,
+	)
+	fmt.Printf("  Generated: %s msgs (%s msgs/s)\n", util.FormatCount(int64(generated)), util.FormatRate(float64(generated)/elapsed))
+	fmt.Printf("  Displayed: %s msgs (%s msgs/s)\n", util.FormatCount(int64(c.bench.displayed)), util.FormatRate(float64(c.bench.displayed)/elapsed))
+}
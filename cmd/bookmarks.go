@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+)
+
+// toggleBookmark bookmarks the selected line (or the most recent line if
+// nothing is selected); if the line is already bookmarked, the bookmark is
+// removed. Bookmarks are stored on the buffered records so they are retained
+// when the view is re-rendered.
+func (c *Cmd) toggleBookmark(textView *tview.TextView, action *types.Action) {
+	var record *types.TailRecord
+
+	if c.selectedLine != 0 {
+		record, _ = c.buffer.Get(c.selectedLine)
+	} else {
+		record = c.lastRecord(action)
+	}
+
+	if record == nil {
+		return
+	}
+
+	record.Bookmarked = !record.Bookmarked
+
+	// Notes can only be attached to bookmarked lines
+	if !record.Bookmarked {
+		record.Note = ""
+	}
+
+	if record.Bookmarked {
+		// Send telemetry
+		_ = c.options.Telemetry.Inc(types.CounterFeatureBookmarkTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+	}
+
+	c.renderTail(textView, action)
+}
+
+// lastRecord returns the most recent visible (non-banner) record
+func (c *Cmd) lastRecord(action *types.Action) *types.TailRecord {
+	records := c.buffer.Records()
+
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Banner == "" && recordVisible(records[i], action) {
+			return records[i]
+		}
+	}
+
+	return nil
+}
+
+// bookmarks returns all visible bookmarked records (oldest first)
+func (c *Cmd) bookmarks(action *types.Action) []*types.TailRecord {
+	bookmarks := make([]*types.TailRecord, 0)
+
+	for _, record := range c.buffer.Records() {
+		if record.Bookmarked && recordVisible(record, action) {
+			bookmarks = append(bookmarks, record)
+		}
+	}
+
+	return bookmarks
+}
+
+// jumpBookmark selects the previous/next bookmark relative to the selected
+// line; if nothing is selected, the search starts from the most recent line.
+func (c *Cmd) jumpBookmark(textView *tview.TextView, action *types.Action, args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	bookmarks := c.bookmarks(action)
+	if len(bookmarks) == 0 {
+		return
+	}
+
+	current := c.selectedLine
+
+	if current == 0 {
+		current = action.TailLineNum + 1
+	}
+
+	var target int
+
+	if args[0] == "prev" {
+		for i := len(bookmarks) - 1; i >= 0; i-- {
+			if bookmarks[i].LineNum < current {
+				target = bookmarks[i].LineNum
+				break
+			}
+		}
+	} else {
+		for _, record := range bookmarks {
+			if record.LineNum > current {
+				target = record.LineNum
+				break
+			}
+		}
+	}
+
+	if target == 0 {
+		return
+	}
+
+	c.jumpToLine(textView, target)
+}
+
+// jumpToLine selects the given line and scrolls the tail view to it
+func (c *Cmd) jumpToLine(textView *tview.TextView, lineNum int) {
+	c.selectedLine = lineNum
+	selected := strconv.Itoa(lineNum)
+
+	c.options.Console.Redraw(func() {
+		textView.Highlight(selected).ScrollToHighlight()
+	})
+}
+
+// Bookmarks can only be listed from tail so we always go back to tail()
+func (c *Cmd) actionBookmarks(action *types.Action) (*types.Action, error) {
+	// Disable input capture while in bookmarks
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	// Channel used for reading resp from bookmarks dialog
+	answerCh := make(chan int)
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayBookmarks(c.bookmarks(action), answerCh)
+	})
+
+	if lineNum := <-answerCh; lineNum != 0 {
+		c.jumpToLine(c.textview, lineNum)
+	}
+
+	action.Step = types.StepTail
+
+	return action, nil
+}
+
+// Notes can only be attached from tail so we always go back to tail(). A line
+// that is not bookmarked yet is bookmarked when a note is attached to it.
+func (c *Cmd) actionNote(action *types.Action) (*types.Action, error) {
+	action.Step = types.StepTail
+
+	var record *types.TailRecord
+
+	if c.selectedLine != 0 {
+		record, _ = c.buffer.Get(c.selectedLine)
+	} else {
+		record = c.lastRecord(action)
+	}
+
+	if record == nil {
+		return action, nil
+	}
+
+	// Disable input capture while in note
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	// Channel used for reading resp from note dialog
+	answerCh := make(chan string)
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayNote(record.LineNum, record.Note, answerCh)
+	})
+
+	note := strings.TrimSpace(<-answerCh)
+
+	if note == record.Note {
+		return action, nil
+	}
+
+	record.Note = note
+
+	if note != "" {
+		record.Bookmarked = true
+	}
+
+	c.renderTail(c.textview, action)
+
+	return action, nil
+}
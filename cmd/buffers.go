@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"github.com/streamdal/cli/buffer"
+	"github.com/streamdal/cli/types"
+)
+
+// componentBuffer is what is retained of a component (or group of components)
+// while another one is tailed
+type componentBuffer struct {
+	buffer       *buffer.Buffer
+	lineNum      int
+	selectedLine int
+}
+
+// switchBuffer puts away the buffer of the components that were tailed and
+// restores the buffer of tailComponents (or starts an empty one if they were
+// not tailed recently). Only the buffers of recently tailed components are
+// kept (see MaxRecentComponents).
+func (c *Cmd) switchBuffer(action *types.Action, tailComponents []*types.TailComponent) {
+	key := recentKey(tailComponents)
+
+	// Selecting the same component again carries on where it is
+	if key == c.bufferKey {
+		return
+	}
+
+	if c.bufferKey != "" {
+		c.buffers[c.bufferKey] = &componentBuffer{
+			buffer:       c.buffer,
+			lineNum:      action.TailLineNum,
+			selectedLine: c.selectedLine,
+		}
+	}
+
+	c.bufferKey = key
+
+	if restored, ok := c.buffers[key]; ok {
+		delete(c.buffers, key)
+
+		// Max lines may have been changed while the component was not tailed
+		restored.buffer.SetMaxRecords(c.buffer.MaxRecords())
+
+		c.buffer = restored.buffer
+		action.TailLineNum = restored.lineNum
+		c.selectedLine = restored.selectedLine
+	} else {
+		// Max lines apply to every buffer; --max-memory is shared (see
+		// budgetBuffers)
+		c.buffer = buffer.New(c.buffer.MaxRecords())
+		action.TailLineNum = 0
+		c.selectedLine = 0
+	}
+
+	c.breakLine = 0
+
+	c.pruneBuffers()
+	c.budgetBuffers()
+}
+
+// pruneBuffers drops the buffers of components that are no longer recent
+func (c *Cmd) pruneBuffers() {
+	recent := make(map[string]bool)
+
+	for _, entry := range c.recent.entries {
+		recent[recentKey(entry)] = true
+	}
+
+	for key := range c.buffers {
+		if !recent[key] {
+			delete(c.buffers, key)
+		}
+	}
+}
+
+// budgetBuffers keeps the buffers within --max-memory as a whole. Retained
+// buffers (which do not grow) share up to half of it, the most recently
+// tailed components first: the oldest records of a buffer that does not fit
+// are evicted and buffers that get nothing are dropped. The tailed buffer
+// gets the rest.
+func (c *Cmd) budgetBuffers() {
+	if c.maxMemory <= 0 {
+		return
+	}
+
+	remaining := c.maxMemory / 2
+
+	for _, entry := range c.recent.entries {
+		key := recentKey(entry)
+
+		b, ok := c.buffers[key]
+		if !ok {
+			continue
+		}
+
+		if remaining < b.buffer.Size() {
+			if remaining <= 0 {
+				delete(c.buffers, key)
+				continue
+			}
+
+			b.buffer.SetMaxBytes(remaining)
+		}
+
+		remaining -= b.buffer.Size()
+	}
+
+	active := c.maxMemory - c.maxMemory/2
+
+	// A buffer always keeps its newest record, even if it does not fit
+	if remaining > 0 {
+		active += remaining
+	}
+
+	c.buffer.SetMaxBytes(active)
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/api"
+	"github.com/streamdal/cli/archive"
+	"github.com/streamdal/cli/export"
+	"github.com/streamdal/cli/rotate"
+	"github.com/streamdal/cli/util"
+)
+
+// runCapture handles "capture"; it waits for --component to go live and writes
+// its decoded (and redacted) payloads to --out, one per line, until
+// interrupted, --duration is reached, --max-lines payloads have been written or
+// the source has ended. Progress is reported on stderr so that it can run
+// unattended (ex: under nohup during an incident).
+//
+// With --every, a capture is started on every tick of the schedule (ex: 1
+// minute every hour) until interrupted, each to its own timestamped file.
+// With --archive, every capture is also uploaded (see capture).
+func (c *Cmd) runCapture() error {
+	opts := c.options.Config.Capture
+
+	component, err := parseWaitFor(opts.Component)
+	if err != nil {
+		return errors.Wrap(err, "invalid --component")
+	}
+
+	if opts.Every > 0 && (opts.Duration <= 0 || opts.Duration >= opts.Every) {
+		return errors.New("--every requires a --duration shorter than the schedule")
+	}
+
+	rotateSize, err := util.ParseBytes(opts.RotateSize)
+	if err != nil {
+		return errors.Wrap(err, "invalid --rotate-size")
+	}
+
+	source, err := c.newHeadlessSource()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(c.shutdownCtx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var a *archive.Archive
+
+	if opts.Archive != "" {
+		if a, err = c.newArchive(ctx, opts.Archive); err != nil {
+			return err
+		}
+	}
+
+	if opts.Every <= 0 {
+		captured, err := c.capture(ctx, source, a, component, rotateSize, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if opts.Filter != "" && captured == 0 {
+			return ErrNoMatches
+		}
+
+		return nil
+	}
+
+	// Captures start on the schedule regardless of how long waiting for the
+	// component took; missed slots are skipped
+	next := time.Now()
+
+	for {
+		if _, err := c.capture(ctx, source, a, component, rotateSize, next); err != nil {
+			return err
+		}
+
+		for !next.After(time.Now()) {
+			next = next.Add(opts.Every)
+		}
+
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Next capture @ %s\n", util.Clock(next))
+		}
+
+		select {
+		case <-time.After(time.Until(next)):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// capture runs a single capture (see runCapture) that was scheduled for
+// scheduled; returns the number of payloads written. If a is set, the payloads
+// are also uploaded to an object named after the capture file (the whole
+// capture, regardless of rotation).
+func (c *Cmd) capture(ctx context.Context, source api.IAPI, a *archive.Archive, component *waitFor, rotateSize int64, scheduled time.Time) (captured int, err error) {
+	opts := c.options.Config.Capture
+
+	audience, err := c.waitForCapture(ctx, source, component)
+	if err != nil {
+		return 0, err
+	}
+
+	// Interrupted before the component went live
+	if audience == nil {
+		return 0, nil
+	}
+
+	path := capturePath(opts.Out, audience, scheduled, opts.Every > 0)
+
+	// Day-long captures are split into several files
+	w, err := rotate.New(&rotate.Options{
+		Path:    path,
+		MaxSize: rotateSize,
+		MaxAge:  opts.RotateEvery,
+		Gzip:    opts.RotateGzip,
+		Logger:  c.options.Logger,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to create capture file")
+	}
+
+	defer func() {
+		if closeErr := w.Close(); closeErr != nil && err == nil {
+			err = errors.Wrap(closeErr, "unable to write capture file")
+		}
+	}()
+
+	var out io.Writer = w
+
+	if a != nil {
+		// The upload must outlive ctx so that it can be completed once the
+		// capture ends (ex: when interrupted)
+		aw := a.Create(context.Background(), filepath.Base(path))
+
+		defer func() {
+			if closeErr := aw.Close(); closeErr != nil && err == nil {
+				err = errors.Wrap(closeErr, "unable to archive capture")
+			}
+		}()
+
+		out = io.MultiWriter(w, aw)
+	}
+
+	if opts.Duration > 0 {
+		var timeoutCancel context.CancelFunc
+
+		ctx, timeoutCancel = context.WithTimeout(ctx, opts.Duration)
+		defer timeoutCancel()
+	}
+
+	tailCh, err := source.Tail(ctx, audience)
+	if err != nil {
+		return 0, errors.Wrap(err, "error calling gRPC tail endpoint in server")
+	}
+
+	fmt.Fprintf(os.Stderr, "Capturing %s to %s\n", util.FormatAudience(audience), path)
+
+	// --filter is a substring or a CEL expression, like in the TUI
+	filter := newMatcher(opts.Filter, false)
+	started := time.Now()
+
+	finish := func(reason string) (int, error) {
+		fmt.Fprintf(os.Stderr, "Captured %d payloads in %s (%s)\n", captured, time.Since(started).Round(time.Second), reason)
+		return captured, nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return finish("--duration reached")
+			}
+
+			return finish("interrupted")
+		case err := <-c.sinkErrCh:
+			c.log.Error(err)
+		case tailResp, ok := <-tailCh:
+			// The source has ended (ex: the end of a --source-file was reached)
+			if !ok {
+				return finish("source ended")
+			}
+
+			if tailResp == nil {
+				continue
+			}
+
+			data := c.decode(tailResp.OriginalData)
+
+			if !filter.match(newMessage(data, tailResp)) {
+				continue
+			}
+
+			c.forward(ctx, tailResp, data)
+
+			data = util.RedactJSONPaths(data, c.options.Config.Redact)
+
+			if _, err := fmt.Fprintln(out, string(data)); err != nil {
+				return captured, errors.Wrap(err, "unable to write payload")
+			}
+
+			captured++
+
+			if opts.MaxLines > 0 && captured >= opts.MaxLines {
+				return finish("--max-lines reached")
+			}
+		}
+	}
+}
+
+// capturePath returns the file a capture is written to. Scheduled captures
+// add the time to --out (ex: orders.ndjson -> orders-20240101-120000.ndjson)
+// so that every capture has its own file.
+func capturePath(out string, audience *protos.Audience, scheduled time.Time, timestamped bool) string {
+	if out == "" {
+		return export.Filename(audience.OperationName, "ndjson", scheduled)
+	}
+
+	if !timestamped {
+		return out
+	}
+
+	ext := filepath.Ext(out)
+
+	return strings.TrimSuffix(out, ext) + "-" + scheduled.Format("20060102-150405") + ext
+}
+
+// waitForCapture polls the live components until the capture component goes
+// live; nil is returned if ctx is canceled first
+func (c *Cmd) waitForCapture(ctx context.Context, source api.IAPI, component *waitFor) (*protos.Audience, error) {
+	var waiting bool
+
+	for {
+		audiences, err := source.GetAllLiveAudiences(ctx)
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+
+		// Keep waiting; the server may be restarting as part of a deployment
+		if err != nil {
+			c.log.Debugf("unable to fetch live components while waiting for '%s': %s", component.input, err)
+		}
+
+		if aud := component.match(audiences); aud != nil {
+			return aud, nil
+		}
+
+		if !waiting {
+			waiting = true
+			fmt.Fprintf(os.Stderr, "Waiting for '%s' to go live\n", component.input)
+		}
+
+		select {
+		case <-time.After(WaitForInterval):
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/audit"
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/expr"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// Kinds of filter chips
+const (
+	chipFilter    = "filter"
+	chipWhere     = "where"
+	chipTrace     = "trace"
+	chipWindow    = "window"
+	chipOperation = "operation"
+)
+
+// MaxChipLabel is the number of characters of a filter displayed in its chip
+const MaxChipLabel = 40
+
+// chip is an active filter of the tail view; chips are displayed in a strip
+// above the tail view so that filters can be removed (or edited) one at a
+// time. Every condition of a CEL filter joined with && is a chip of its own.
+type chip struct {
+	kind   string // one of the chip* kinds
+	label  string
+	clause int // index of the condition in filterClauses() (chipFilter)
+}
+
+// tailChips returns the chips of the active filters, in the order they are
+// displayed
+func tailChips(action *types.Action) []*chip {
+	chips := make([]*chip, 0)
+
+	for i, clause := range filterClauses(action.TailFilter) {
+		chips = append(chips, &chip{kind: chipFilter, label: "Filter: " + clause, clause: i})
+	}
+
+	if w := action.TailWhere; w != nil {
+		chips = append(chips, &chip{kind: chipWhere, label: "Where: " + w.Path + " == " + w.Value})
+	}
+
+	if action.TailTraceID != "" {
+		chips = append(chips, &chip{kind: chipTrace, label: "Trace: " + shortTraceID(action.TailTraceID)})
+	}
+
+	if w := action.TailTimeWindow; w != nil {
+		chips = append(chips, &chip{kind: chipWindow, label: "Window: " + formatWindow(w)})
+	}
+
+	// Displayed as tabs when split (see updateOperationTabs)
+	if action.TailOperation != protos.OperationType_OPERATION_TYPE_UNSET && !splitOperations(action) {
+		chips = append(chips, &chip{kind: chipOperation, label: "Operation: " + util.ProtosOperationTypeToStr(action.TailOperation) + " only"})
+	}
+
+	return chips
+}
+
+// filterClauses splits a CEL filter into the conditions joined with && (ex:
+// "payload.status == 500 && metadata.region == 'us'" has two); substrings and
+// expressions that are not a plain conjunction (ex: "a && b || c") are a
+// single clause.
+func filterClauses(filter string) []string {
+	if filter == "" {
+		return nil
+	}
+
+	if !expr.IsExpression(filter) {
+		return []string{filter}
+	}
+
+	clauses, ok := splitConjunction(filter)
+	if !ok {
+		return []string{filter}
+	}
+
+	// Every condition must be an expression on its own; "true" in
+	// "payload.a == 1 && true" would become a substring once the other
+	// condition is removed
+	for _, clause := range clauses {
+		if !expr.IsExpression(clause) {
+			return []string{filter}
+		}
+	}
+
+	return clauses
+}
+
+// splitConjunction splits text at && operators that are not within brackets or
+// string literals; returns false if text has top-level operators that bind
+// less tightly than && (|| and the ternary operator).
+func splitConjunction(text string) ([]string, bool) {
+	var (
+		clauses []string
+		quote   byte
+		depth   int
+		start   int
+	)
+
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+		case strings.IndexByte("([{", ch) >= 0:
+			depth++
+		case strings.IndexByte(")]}", ch) >= 0:
+			depth--
+		case depth > 0:
+		case ch == '?' || strings.HasPrefix(text[i:], "||"):
+			return nil, false
+		case strings.HasPrefix(text[i:], "&&"):
+			clauses = append(clauses, strings.TrimSpace(text[start:i]))
+			start = i + 2
+			i++
+		}
+	}
+
+	return append(clauses, strings.TrimSpace(text[start:])), true
+}
+
+// replaceClause replaces the condition at index of a filter (see
+// filterClauses()); an empty clause removes it. Returns an error message if
+// the resulting filter would not be an expression anymore.
+func replaceClause(filter string, index int, clause string) (string, string) {
+	clauses := filterClauses(filter)
+
+	if index < 0 || index >= len(clauses) {
+		return filter, ""
+	}
+
+	if clause == "" {
+		clauses = append(clauses[:index], clauses[index+1:]...)
+		return strings.Join(clauses, " && "), ""
+	}
+
+	if len(clauses) > 1 {
+		if !expr.IsExpression(clause) {
+			return filter, "conditions of a filter with several conditions must be CEL expressions"
+		}
+
+		// Keep the other conditions applied to all of it
+		if _, ok := splitConjunction(clause); !ok {
+			clause = "(" + clause + ")"
+		}
+	}
+
+	clauses[index] = clause
+
+	return strings.Join(clauses, " && "), ""
+}
+
+// updateTailChips updates (or hides) the filter chips above the tail view
+func (c *Cmd) updateTailChips(action *types.Action) {
+	chips := tailChips(action)
+
+	if c.selectedChip > len(chips) {
+		c.selectedChip = len(chips)
+	}
+
+	if len(chips) == 0 {
+		c.options.Console.SetTailChips("")
+		return
+	}
+
+	parts := make([]string, 0, len(chips)+1)
+
+	for i, ch := range chips {
+		label := ch.label
+
+		if runes := []rune(label); len(runes) > MaxChipLabel {
+			label = string(runes[:MaxChipLabel-1]) + util.Glyph("…", "~", "")
+		}
+
+		label = tview.Escape(label)
+
+		if remove := util.Glyph("✕", "x", ""); remove != "" {
+			label += " " + remove
+		}
+
+		if i+1 == c.selectedChip {
+			parts = append(parts, fmt.Sprintf("[%s:%s:b] %s [-:-:-]",
+				console.Hex(console.ActiveButtonFg), console.Hex(console.ActiveButtonBg), label))
+
+			continue
+		}
+
+		parts = append(parts, fmt.Sprintf("[%s:%s] %s [-:-]",
+			console.Hex(console.InactiveButtonFg), console.Hex(console.InactiveButtonBg), label))
+	}
+
+	hint := "[gray](Left/Right: select)[-]"
+
+	if c.selectedChip != 0 {
+		hint = "[gray](Del: remove, Enter: edit, Esc: done)[-]"
+	}
+
+	c.options.Console.SetTailChips(" " + strings.Join(append(parts, hint), " "))
+}
+
+// selectChip moves the chip selection; args[0] is either "prev", "next" or
+// "clear". Selecting past the last chip clears the selection.
+func (c *Cmd) selectChip(action *types.Action, args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	chips := tailChips(action)
+
+	switch {
+	case len(chips) == 0 || args[0] == "clear":
+		c.selectedChip = 0
+	case args[0] == "prev" && c.selectedChip == 0:
+		c.selectedChip = len(chips)
+	case args[0] == "prev":
+		c.selectedChip--
+	case c.selectedChip < len(chips):
+		c.selectedChip++
+	default:
+		c.selectedChip = 0
+	}
+
+	c.updateTailChips(action)
+}
+
+// removeChip removes the filter of the selected chip; the next chip (if any)
+// is selected so that filters can be removed one after the other
+func (c *Cmd) removeChip(textView *tview.TextView, action *types.Action) {
+	chips := tailChips(action)
+
+	if c.selectedChip == 0 || c.selectedChip > len(chips) {
+		return
+	}
+
+	switch ch := chips[c.selectedChip-1]; ch.kind {
+	case chipFilter:
+		action.TailFilter, _ = replaceClause(action.TailFilter, ch.clause, "")
+
+		if action.TailFilter == "" {
+			action.TailFilterWholeWord = false
+			c.options.Console.SetMenuEntryOff("Filter")
+		}
+
+		c.audit(audit.ActionFilterSet, map[string]string{"filter": action.TailFilter})
+	case chipWhere:
+		action.TailWhere = nil
+		c.audit(audit.ActionFieldFilterSet, map[string]string{"path": "", "value": ""})
+	case chipWindow:
+		action.TailTimeWindow = nil
+		c.audit(audit.ActionTimeWindowSet, map[string]string{"window": ""})
+		c.options.Console.SetMenuEntryOff("Window")
+	case chipTrace:
+		// Toggled off; re-renders the tail view
+		c.toggleTraceFilter(textView, action)
+		return
+	case chipOperation:
+		c.setOperation(textView, action, protos.OperationType_OPERATION_TYPE_UNSET)
+		return
+	}
+
+	// Highlights of the removed filter (and lines it hid) are re-rendered
+	c.renderTail(textView, action)
+	c.updateTailHeader(action)
+}
+
+// editChip returns the action that edits the filter of the selected chip (ex:
+// the filter dialog with only the selected condition); nil if the filter was
+// changed in place or cannot be edited.
+func (c *Cmd) editChip(textView *tview.TextView, action *types.Action) *types.Action {
+	chips := tailChips(action)
+
+	if c.selectedChip == 0 || c.selectedChip > len(chips) {
+		return nil
+	}
+
+	ch := chips[c.selectedChip-1]
+
+	switch ch.kind {
+	case chipFilter:
+		c.selectedChip = 0
+		return &types.Action{Step: types.StepFilter, Args: []string{strconv.Itoa(ch.clause)}}
+	case chipWhere:
+		// The field filter is set from the line detail view
+		c.selectedChip = 0
+		return &types.Action{Step: types.StepLineDetail}
+	case chipWindow:
+		c.selectedChip = 0
+		return &types.Action{Step: types.StepTimeWindow}
+	case chipOperation:
+		c.cycleOperation(textView, action)
+	default:
+		c.options.Console.ShowToast("The trace filter can only be removed (Del)")
+	}
+
+	return nil
+}
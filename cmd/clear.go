@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/audit"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// The tail view can only be cleared from tail so we always go back to tail().
+// Snapshots are copies and are not affected.
+func (c *Cmd) actionClear(action *types.Action) (*types.Action, error) {
+	action.Step = types.StepTail
+
+	if c.options.Config.ConfirmClear {
+		// Disable input capture while confirming
+		origCapture := c.options.Console.GetInputCapture()
+		c.options.Console.SetInputCapture(nil)
+		defer c.options.Console.SetInputCapture(origCapture)
+
+		answerCh := make(chan bool)
+
+		crash.Go(func() {
+			c.options.Console.DisplayConfirm(fmt.Sprintf("Clear the %d messages in the tail view?", c.buffer.Len()), "Clear", answerCh)
+		})
+
+		if !<-answerCh {
+			return action, nil
+		}
+	}
+
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureClearTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	c.clearTail(c.textview, action, " Cleared @ "+util.Clock(time.Now()))
+	c.audit(audit.ActionTailCleared, nil)
+
+	return action, nil
+}
+
+// clearTail empties the buffer and the tail view, restarts line numbering and
+// writes banner as the first line
+func (c *Cmd) clearTail(textView *tview.TextView, action *types.Action, banner string) {
+	c.buffer.Clear()
+
+	action.TailLineNum = 0
+	c.selectedLine = 0
+	c.breakLine = 0
+
+	c.buffer.Add(&types.TailRecord{
+		Received: time.Now(),
+		Banner:   banner,
+	})
+
+	c.renderTail(textView, action)
+}
@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cactus/go-statsd-client/v5/statsd"
@@ -13,32 +15,116 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/pkg/errors"
 	"github.com/rivo/tview"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/streamdal/cli/api"
+	"github.com/streamdal/cli/audit"
+	"github.com/streamdal/cli/buffer"
 	"github.com/streamdal/cli/config"
 	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/decoder"
+	"github.com/streamdal/cli/demo"
+	"github.com/streamdal/cli/expr"
+	"github.com/streamdal/cli/preview"
+	"github.com/streamdal/cli/script"
+	"github.com/streamdal/cli/sink"
+	"github.com/streamdal/cli/slack"
+	"github.com/streamdal/cli/sshtunnel"
 	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
 )
 
 const (
-	SearchHighlightFmt = "[blue:gray]%s[-:-]"
+	// ThroughputWindow is the window used for calculating the observed
+	// msgs/sec that is displayed in the sample rate dialog
+	ThroughputWindow = 10 * time.Second
+
+	// MemoryTrimInterval is how often the tail view is re-rendered from the
+	// buffer while records are being evicted because of --max-memory
+	MemoryTrimInterval = 5 * time.Second
+
+	// WaitForInterval is how often live components are fetched while
+	// waiting for the --wait-for component
+	WaitForInterval = 2 * time.Second
+
+	// BurstMinRate is the minimum msgs/sec required before a burst is
+	// reported; prevents quiet components from triggering burst banners
+	BurstMinRate = 10
 )
 
 type Cmd struct {
-	api            *api.API
+	api            api.IAPI
+	demo           *demo.Demo
+	bench          *bench
+	decoders       *decoder.Registry
+	decoder        decoder.Decoder
+	preview        *preview.Preview
+	script         *script.Script
+	scriptTailed   string         // recentKey() of the components on_connect was last called for
+	scriptFailed   bool           // set once a hook failure has been displayed
+	session        *types.Session // tail view state; saved in the config file whenever it changes
+	sessionOffered bool           // set once the saved session has been offered for restoring
+	slack          *slack.Slack
+	sinks          []sink.Sink
+	sinkErrCh      chan error
+	auditLog       *audit.Audit
 	textview       *tview.TextView
-	previousSearch string
+	tailOut        tailWriter
+	buffer         *buffer.Buffer
+	buffers        map[string]*componentBuffer // buffers of the components that are not currently tailed
+	bufferKey      string                      // recentKey() of the components whose records are in buffer
+	maxMemory      int64                       // --max-memory; shared by buffer and buffers (see budgetBuffers)
+	selectedLine   int
+	selectedChip   int // 1-based index in tailChips(); 0 if none is selected
+	breakLine      int
+	search         *search
 	paused         bool
-	announceFilter bool
-	options        *Options
-	log            *log.Logger
-	shutdownCtx    context.Context
-	shutdownFunc   context.CancelFunc
+	announceSinks  bool
+
+	// setupNotice is displayed when tailing for the first time if the setup
+	// wizard was unable to persist the settings
+	setupNotice   string
+	sshTunnel     *sshtunnel.Tunnel
+	waitFor       *waitFor
+	macro         *macro
+	heartbeat     *heartbeat
+	notifications *notifications
+	comparison    *comparison
+	decimateCount int
+	latency       *util.RollingAverage
+	throughput    *util.Throughput
+	bandwidth     *bandwidth
+	duplicates    *util.DuplicateTracker
+	matchers      map[matcherKey]*matcher
+	lastMessage   *expr.Message // most recently received message; used for testing expressions
+	query         string        // last query run on the query page
+	snapshots     []*types.Snapshot
+	unseen        map[protos.OperationType]int // messages received for the inactive operation tab
+	rates         *rateHistory                 // recent msgs/sec of every audience; displayed in the select list
+	audiences     []*protos.Audience           // live audiences, as of the last time the select list was displayed
+	recent        *recentComponents
+	segments      *segmenter
+	burst         *util.BurstDetector
+	nav           *navigation
+	memoryNotice  bool
+	lastTrim      time.Time
+	latencyTitle  string
+	options       *Options
+	log           *log.Logger
+	shutdownCtx   context.Context
+	shutdownFunc  context.CancelFunc
+
+	// updateNoticeCh receives the notice displayed when a newer release is
+	// available (see checkUpdate)
+	updateNoticeCh chan string
 }
 
 type Options struct {
 	Config    *config.Config
 	Console   *console.Console
+	Crash     *crash.Reporter
 	Logger    *log.Logger
 	Telemetry statsd.Statter
 }
@@ -48,80 +134,333 @@ func New(opts *Options) (*Cmd, error) {
 		return nil, errors.Wrap(err, "unable to validate config")
 	}
 
+	maxMemory, err := util.ParseBytes(opts.Config.MaxMemory)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid --max-memory")
+	}
+
+	memoryWarning, err := util.ParseBytes(opts.Config.MemoryWarning)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid --memory-warning")
+	}
+
+	wf, err := parseWaitFor(opts.Config.WaitFor)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid --wait-for")
+	}
+
+	m, err := loadMacro(opts.Config.Macro)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid --macro")
+	}
+
+	// Whatever was opened is closed if a later step fails
+	var (
+		cleanups []func()
+		created  bool
+	)
+
+	defer func() {
+		if created {
+			return
+		}
+
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}()
+
+	auditLog, err := newAudit(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid --audit-log")
+	}
+
+	if auditLog != nil {
+		cleanups = append(cleanups, func() { _ = auditLog.Close() })
+	}
+
+	sinkErrCh := make(chan error, 10)
+
+	sinks, err := newSinks(opts, sinkErrCh)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanups = append(cleanups, func() { closeAll(sinks) })
+
+	decoders, err := decoder.New(&decoder.Options{
+		Plugins:     opts.Config.DecoderPlugin,
+		WASMModules: opts.Config.DecoderWasm,
+		Logger:      opts.Logger,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load decoders")
+	}
+
+	cleanups = append(cleanups, decoders.Close)
+
+	d, ok := decoders.Get(opts.Config.Decoder)
+	if !ok {
+		return nil, errors.Errorf("unknown decoder '%s' (available: %s)", opts.Config.Decoder, strings.Join(decoders.Names(), ", "))
+	}
+
+	var p *preview.Preview
+
+	if opts.Config.PreviewWasm != "" {
+		p, err = preview.New(&preview.Options{
+			WASMFile: opts.Config.PreviewWasm,
+			StepFile: opts.Config.PreviewStep,
+			Function: opts.Config.PreviewFunction,
+			Logger:   opts.Logger,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load preview step")
+		}
+
+		cleanups = append(cleanups, func() { _ = p.Close() })
+	}
+
+	var sc *script.Script
+
+	if opts.Config.Script != "" {
+		sc, err = script.New(&script.Options{
+			File:   opts.Config.Script,
+			Logger: opts.Logger,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load script")
+		}
+	}
+
+	var sl *slack.Slack
+
+	if opts.Config.SlackWebhookURL != "" {
+		sl, err = slack.New(&slack.Options{
+			WebhookURL: opts.Config.SlackWebhookURL,
+			Logger:     opts.Logger,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid --slack-webhook-url")
+		}
+	}
+
 	ctx, cxl := context.WithCancel(context.Background())
 
+	throughput := util.NewThroughput(ThroughputWindow)
+
 	c := &Cmd{
-		// TODO: Create an interface for API
-		//api:     api.NewUninitialized(),
-		options:      opts,
-		log:          opts.Logger.WithPrefix("cmd"),
-		shutdownCtx:  ctx,
-		shutdownFunc: cxl,
+		decoders:      decoders,
+		decoder:       d,
+		preview:       p,
+		slack:         sl,
+		script:        sc,
+		sinks:         sinks,
+		sinkErrCh:     sinkErrCh,
+		auditLog:      auditLog,
+		announceSinks: len(sinks) > 0,
+		waitFor:       wf,
+		macro:         m,
+		options:       opts,
+		log:           opts.Logger.WithPrefix("cmd"),
+		buffer:        buffer.New(opts.Config.MaxOutputLines),
+		buffers:       make(map[string]*componentBuffer),
+		latency:       util.NewRollingAverage(opts.Config.LatencyWindow),
+		throughput:    throughput,
+		bandwidth:     newBandwidth(),
+		duplicates:    util.NewDuplicateTracker(opts.Config.IDWindow),
+		matchers:      make(map[matcherKey]*matcher),
+		unseen:        make(map[protos.OperationType]int),
+		rates:         newRateHistory(),
+		recent:        &recentComponents{},
+		segments:      newSegmenter(opts.Config.SegmentInterval),
+		burst:         util.NewBurstDetector(throughput, opts.Config.BurstMultiplier, BurstMinRate),
+		nav:           &navigation{},
+		notifications: &notifications{},
+		shutdownCtx:   ctx,
+		shutdownFunc:  cxl,
+	}
+
+	c.buffer.SetMaxBytes(maxMemory)
+	c.maxMemory = maxMemory
+
+	c.updateNoticeCh = make(chan string, 1)
+
+	crash.Go(c.runUptime)
+
+	if memoryWarning > 0 {
+		crash.Go(func() { c.runMemoryMonitor(memoryWarning) })
 	}
 
-	go c.runUptime()
+	created = true
 
 	return c, nil
 }
 
 // Run is the main entrypoint for starting the CLI app
 func (c *Cmd) Run() error {
-	// Start with a connection attempt and go from there
+	// Non-interactive commands
+	if run, ok := c.headlessCommands()[c.options.Config.KongContext.Command()]; ok {
+		defer c.close()
+		return run()
+	}
+
+	crash.Go(c.checkUpdate)
+
+	// Start with a connection attempt (or with the setup wizard if the
+	// connection has not been configured yet) and go from there
+	step := types.StepConnect
+
+	if c.options.Config.Setup {
+		step = types.StepSetup
+	}
+
 	return c.run(&types.Action{
-		Step: types.StepConnect,
+		Step: step,
 		TailViewOptions: &types.ViewOptions{
 			PrettyJSON:         true,
 			EnableColors:       true,
 			DisplayTimestamp:   true,
 			DisplayLineNumbers: true,
+			Decimate:           c.options.Config.Decimate,
+			MaskSecrets:        c.options.Config.MaskSecrets,
+			DetectPII:          c.options.Config.DetectPII,
+			RawNumbers:         c.options.Config.RawNumbers,
 		},
+		CompareKey: c.options.Config.CorrelationKey,
 	})
 }
 
-// Run is a recursive method because the next step that will be executed is
-// determined by the current step (which passes back a resp). run() accepts
-// an action because it might contain arguments that the requested step might
-// use. NOTE: First run() call defines the *first* step that will be executed.
+// run executes steps until the user quits. The next step that will be
+// executed is determined by the current step (which passes back a resp). run()
+// accepts an action because it might contain arguments that the requested
+// step might use. NOTE: First run() call defines the *first* step that will be
+// executed.
+//
+// Every view (connect, select, tail) that is visited is recorded in the
+// navigation stack; StepBack returns to the previous view.
 func (c *Cmd) run(action *types.Action) error {
-	var (
-		resp *types.Action
-		err  error
-	)
+	for {
+		if action.Step == types.StepQuit {
+			c.audit(audit.ActionQuit, nil)
+
+			c.options.Console.Stop()
+			c.close()
+
+			if c.bench != nil {
+				c.printBenchResults()
+			}
+
+			// Return to caller so that any deferred cleanup (ex: profiling) runs
+			return nil
+		}
+
+		if action.Step == types.StepBack {
+			action = c.nav.back(action)
+			continue
+		}
+
+		c.nav.visit(action)
+		c.trackSession(action)
+		c.options.Console.SetBreadcrumb(c.nav.breadcrumbs())
+		c.options.Console.SetWindowTitle(c.windowTitle(action))
+
+		resp, err := c.step(action)
+		if err != nil {
+			return errors.Wrap(err, "unable to run action")
+		}
+
+		action = resp
+	}
+}
 
+// step executes a single step and returns the action for the next step
+func (c *Cmd) step(action *types.Action) (*types.Action, error) {
 	switch action.Step {
+	case types.StepSetup:
+		return c.actionSetup(action)
 	case types.StepConnect:
-		resp, err = c.actionConnect(action)
+		return c.actionConnect(action)
 	case types.StepSelect:
-		resp, err = c.actionSelect(action)
+		return c.actionSelect(action)
 	case types.StepTail:
-		resp, err = c.actionTail(action)
+		return c.actionTail(action)
 	case types.StepFilter:
-		resp, err = c.actionFilter(action)
+		return c.actionFilter(action)
 	case types.StepSearch:
-		resp, err = c.actionSearch(action)
+		return c.actionSearch(action)
 	case types.StepRate:
-		resp, err = c.actionRate(action)
+		return c.actionRate(action)
 	case types.StepViewOptions:
-		resp, err = c.actionViewOptions(action)
-	case types.StepQuit:
-		_ = c.options.Telemetry.Gauge(types.GaugeUptimeSeconds, 0, 1.0, c.options.Config.GetStatsdTags()...)
-		_ = c.options.Telemetry.Close()
-
-		c.options.Console.Stop()
-		c.shutdownFunc()
-		os.Exit(0)
+		return c.actionViewOptions(action)
+	case types.StepMaxLines:
+		return c.actionMaxLines(action)
+	case types.StepComponentSettings:
+		return c.actionComponentSettings(action)
+	case types.StepTimeWindow:
+		return c.actionTimeWindow(action)
+	case types.StepBreak:
+		return c.actionBreak(action)
+	case types.StepBookmarks:
+		return c.actionBookmarks(action)
+	case types.StepNotifications:
+		return c.actionNotifications(action)
+	case types.StepNote:
+		return c.actionNote(action)
+	case types.StepLineDetail:
+		return c.actionLineDetail(action)
+	case types.StepQuery:
+		return c.actionQuery(action)
+	case types.StepSnapshots:
+		return c.actionSnapshots(action)
+	case types.StepFinder:
+		return c.actionFinder(action)
+	case types.StepRecentSwitch:
+		return c.actionRecentSwitch(action)
+	case types.StepClear:
+		return c.actionClear(action)
+	case types.StepExport:
+		return c.actionExport(action)
+	case types.StepShare:
+		return c.actionShare(action)
+	case types.StepCompare:
+		return c.actionCompare(action)
+	case types.StepCorrelationKey:
+		return c.actionCorrelationKey(action)
+	case types.StepKeys:
+		return c.actionKeys(action)
+	case types.StepMacroRecord:
+		return c.actionMacroRecord(action)
+	case types.StepMacroPlay:
+		return c.actionMacroPlay(action)
 	case types.StepPause:
 		// Pause is only possible from tail() so that's where we want to go back
-		resp, err = c.actionTail(action)
+		return c.actionTail(action)
 	default:
-		err = errors.Errorf("unknown action step: %d", action.Step)
+		return nil, errors.Errorf("unknown action step: %d", action.Step)
 	}
+}
 
-	if err != nil {
-		return errors.Wrap(err, "unable to run action")
+// close releases everything that was set up in New()
+func (c *Cmd) close() {
+	_ = c.options.Telemetry.Gauge(types.GaugeUptimeSeconds, 0, 1.0, c.options.Config.GetStatsdTags()...)
+	_ = c.options.Telemetry.Close()
+
+	c.shutdownFunc()
+	c.decoders.Close()
+
+	if c.preview != nil {
+		_ = c.preview.Close()
+	}
+
+	c.closeSinks()
+
+	if c.auditLog != nil {
+		_ = c.auditLog.Close()
 	}
 
-	return c.run(resp)
+	if c.sshTunnel != nil {
+		_ = c.sshTunnel.Close()
+	}
 }
 
 // Filter view can only be triggered if we came from tail so it makes sense
@@ -135,19 +474,44 @@ func (c *Cmd) actionFilter(action *types.Action) (*types.Action, error) {
 	c.options.Console.SetInputCapture(nil)
 	defer c.options.Console.SetInputCapture(origCapture)
 
+	// A single condition is edited when set from its chip (see editChip())
+	clause := -1
+	current := action.TailFilter
+
+	if len(action.Args) > 0 {
+		if i, err := strconv.Atoi(action.Args[0]); err == nil && i < len(filterClauses(action.TailFilter)) {
+			clause, current = i, filterClauses(action.TailFilter)[i]
+		}
+
+		action.Args = nil
+	}
+
 	// Channel used for reading resp from filter dialog
-	answerCh := make(chan string)
+	answerCh := make(chan *types.TermRequest)
 
 	// Display modal
-	go func() {
-		c.options.Console.DisplayFilter(action.TailFilter, answerCh)
-	}()
+	crash.Go(func() {
+		c.options.Console.DisplayFilter(&types.TermRequest{
+			Term:      current,
+			WholeWord: action.TailFilterWholeWord,
+		}, c.testExpression, answerCh)
+	})
 
 	// Wait for an answer; if the user selects "Cancel", we will get back
 	// the original filter (if any); if the user selects "Reset" - we will get
 	// back an empty space; if the user clicks "OK" - we will get back the
 	// filter string they chose.
-	filterStr := <-answerCh
+	answer := <-answerCh
+	filterStr := answer.Term
+
+	if clause >= 0 {
+		var invalid string
+
+		filterStr, invalid = replaceClause(action.TailFilter, clause, answer.Term)
+		if invalid != "" {
+			c.writeBanner(c.textview, " Invalid filter: "+tview.Escape(invalid))
+		}
+	}
 
 	// Turn on/off "Filter" menu entry depending on if filter is set
 	if filterStr != "" {
@@ -156,12 +520,18 @@ func (c *Cmd) actionFilter(action *types.Action) (*types.Action, error) {
 		c.options.Console.SetMenuEntryOff("Filter")
 	}
 
-	c.announceFilter = true
+	if filterStr != action.TailFilter || answer.WholeWord != action.TailFilterWholeWord {
+		c.audit(audit.ActionFilterSet, map[string]string{
+			"filter":     filterStr,
+			"whole_word": strconv.FormatBool(answer.WholeWord),
+		})
+	}
 
-	// We want to go back to tail() with the same component as before + set the
-	// new filter string.
-	action.Step = types.StepTail
+	// We want to go back to the view the filter was set from (tail or compare)
+	// with the same component as before + set the new filter string.
+	action.Step = c.nav.current()
 	action.TailFilter = filterStr
+	action.TailFilterWholeWord = answer.WholeWord
 
 	return action, nil
 }
@@ -176,30 +546,85 @@ func (c *Cmd) actionSearch(action *types.Action) (*types.Action, error) {
 	defer c.options.Console.SetInputCapture(origCapture)
 
 	// Channel used for reading resp from filter dialog
-	answerCh := make(chan string)
+	answerCh := make(chan *types.TermRequest)
+
+	// Previews replace the current search; it is restored if the search is
+	// canceled (or unchanged) or invalid
+	saved := c.search
+
+	// Previews run in the background; none may run once answered
+	var (
+		previewMu sync.Mutex
+		answered  bool
+	)
+
+	preview := func(term string, wholeWord bool) string {
+		previewMu.Lock()
+		defer previewMu.Unlock()
+
+		if answered {
+			return ""
+		}
+
+		return c.previewSearch(action, term, wholeWord)
+	}
 
 	// Display modal
-	go func() {
-		c.options.Console.DisplaySearch(action.TailSearch, answerCh)
-	}()
+	crash.Go(func() {
+		c.options.Console.DisplaySearch(&types.TermRequest{
+			Term:      action.TailSearch,
+			WholeWord: action.TailSearchWholeWord,
+		}, preview, answerCh)
+	})
 
 	// Wait for an answer; if the user selects "Cancel", we will get back
 	// the original search (if any); if the user selects "Reset" - we will get
 	// back an empty string; if the user clicks "OK" - we will get back the
 	// search string they chose.
-	searchStr := <-answerCh
+	answer := <-answerCh
+	searchStr := answer.Term
 
-	// Turn on/off "Filter" menu entry depending on if filter is set
+	previewMu.Lock()
+	answered = true
+	previewMu.Unlock()
+
+	// Only way to get to "search" is via tail, so the next step is to go back
+	// to tail view (with the same component as before search).
+	action.Step = types.StepTail
+
+	parsed, err := parseSearch(searchStr, answer.WholeWord)
+	if err != nil {
+		c.search = saved
+		c.renderTail(c.textview, action)
+
+		c.writeBanner(c.textview, " Invalid search: "+tview.Escape(err.Error()))
+		return action, nil
+	}
+
+	if searchStr == action.TailSearch && answer.WholeWord == action.TailSearchWholeWord {
+		parsed = saved
+	}
+
+	// Turn on/off "Search" menu entry depending on if search is set
 	if searchStr != "" {
 		c.options.Console.SetMenuEntryOn("Search")
 	} else {
 		c.options.Console.SetMenuEntryOff("Search")
 	}
 
-	// Only way to get to "search" is via tail, so the next step is to go back
-	// to tail view (with the same component as before search).
-	action.Step = types.StepTail
+	if searchStr != action.TailSearch || answer.WholeWord != action.TailSearchWholeWord {
+		c.audit(audit.ActionSearchSet, map[string]string{
+			"search":     searchStr,
+			"whole_word": strconv.FormatBool(answer.WholeWord),
+		})
+	}
+
+	c.search = parsed
 	action.TailSearch = searchStr
+	action.TailSearchWholeWord = answer.WholeWord
+
+	// Re-render so that highlights of the previous search are replaced
+	c.renderTail(c.textview, action)
 
 	return action, nil
 }
@@ -217,15 +642,19 @@ func (c *Cmd) actionRate(action *types.Action) (*types.Action, error) {
 	answerCh := make(chan int)
 
 	// Display modal
-	go func() {
-		c.options.Console.DisplayRate(action.TailRate, answerCh)
-	}()
+	crash.Go(func() {
+		c.options.Console.DisplayRate(action.TailRate, c.throughput.Rate(time.Now()), answerCh)
+	})
 
 	// OK == rate the user chose; Cancel == original rate; Reset == 0
 	rate := <-answerCh
 
 	// TODO: Set sample rate on server
 
+	if rate != action.TailRate {
+		c.audit(audit.ActionSampleRateSet, map[string]string{"rate": strconv.Itoa(rate)})
+	}
+
 	// Turn on/off "Rate" menu entry depending on if Rate is not 0
 	if rate != 0 {
 		c.options.Console.SetMenuEntryOn("Set Sample Rate")
@@ -254,12 +683,34 @@ func (c *Cmd) actionViewOptions(action *types.Action) (*types.Action, error) {
 	answerCh := make(chan *types.ViewOptions)
 
 	// Display modal
-	go func() {
+	crash.Go(func() {
 		c.options.Console.DisplayViewOptions(action.TailViewOptions, answerCh)
-	}()
+	})
 
 	opts := <-answerCh
 
+	var prevDecimate int
+
+	if action.TailViewOptions != nil {
+		prevDecimate = action.TailViewOptions.Decimate
+	}
+
+	if prevDecimate != opts.Decimate {
+		c.decimateCount = 0
+	}
+
+	if action.TailViewOptions == nil || *opts != *action.TailViewOptions {
+		c.audit(audit.ActionViewOptionsSet, map[string]string{
+			"decimate":     strconv.Itoa(opts.Decimate),
+			"mask_secrets": strconv.FormatBool(opts.MaskSecrets),
+			"detect_pii":   strconv.FormatBool(opts.DetectPII),
+			"raw_numbers":  strconv.FormatBool(opts.RawNumbers),
+		})
+	}
+
+	// Stats are formatted globally (status bar, rate preview, snapshot diffs)
+	util.SetRawNumbers(opts.RawNumbers)
+
 	// Only way to get to "view options" is via Tail so we always tell resp
 	// to go back to that view.
 	action.Step = types.StepTail
@@ -268,100 +719,319 @@ func (c *Cmd) actionViewOptions(action *types.Action) (*types.Action, error) {
 	return action, nil
 }
 
-func (c *Cmd) actionConnect(action *types.Action) (*types.Action, error) {
-	msg := fmt.Sprintf("Connecting to [::u]%s[::-] ", c.options.Config.Server)
+// Break can only be set from tail so we always go back to tail()
+func (c *Cmd) actionBreak(action *types.Action) (*types.Action, error) {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureBreakTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
 
-	userQuit := false
-	inputCh := make(chan struct{}, 1)
-	outputCh := make(chan error, 1)
+	// Disable input capture while in break
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
 
-	// Channel to tell outputCh reader goroutine to exit
-	quitCh := make(chan struct{}, 1)
-	defer close(quitCh)
+	// Channel used for reading resp from break dialog
+	answerCh := make(chan string)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayBreak(action.TailBreak, c.testExpression, answerCh)
+	})
 
-	c.options.Console.DisplayInfoModal(msg, inputCh, outputCh)
+	breakStr := <-answerCh
 
-	// Goroutine used for reading user resp
-	go func() {
-		for {
-			select {
-			// user pressed "cancel" - tell connect() to exit early
-			case <-outputCh:
+	action.Step = types.StepTail
 
-				c.log.Error("user pressed cancel")
-				userQuit = true
-				cancel()
-				return
-			case <-quitCh:
-				// Tell connect() to exit early
-				cancel()
-				return
-			}
-		}
-	}()
+	if breakStr == action.TailBreak {
+		return action, nil
+	}
 
-	// Launch connection attempt
-	if err := c.connect(ctx); err != nil {
-		// If user pressed "cancel" - no need to display retry modal
-		if userQuit {
-			return &types.Action{Step: types.StepQuit}, nil
-		}
+	action.TailBreak = breakStr
 
-		retryMsg := fmt.Sprintf("[white:red]ERROR: Unable to connect![white:red]\n\n%s", err)
-		inputCh <- struct{}{} // tell displayInfoModal to quit because of error
+	c.audit(audit.ActionBreakSet, map[string]string{"break": breakStr})
 
-		// Display retry modal
-		retryCh := make(chan bool, 1)
+	if breakStr != "" {
+		c.options.Console.SetMenuEntryOn("Break")
+	} else {
+		c.options.Console.SetMenuEntryOff("Break")
+	}
 
-		c.options.Console.DisplayRetryModal(retryMsg, "page_connection_retry", retryCh)
-		retry := <-retryCh
+	return action, nil
+}
 
-		if retry {
-			return &types.Action{Step: types.StepConnect}, nil
-		} else {
-			return &types.Action{Step: types.StepQuit}, nil
-		}
-	}
+// Max lines can only be changed from tail so we always go back to tail().
+// The buffer (and tail view) are resized in place so that the records that are
+// currently displayed are retained.
+func (c *Cmd) actionMaxLines(action *types.Action) (*types.Action, error) {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureMaxLinesTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
 
-	// Need this in here in case user quit while we were connecting
-	if userQuit {
-		return &types.Action{Step: types.StepQuit}, nil
-	}
+	// Disable input capture while in max lines
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
 
-	action.Step = types.StepSelect
+	// Channel used for reading resp from max lines dialog
+	answerCh := make(chan int)
 
-	return action, nil
-}
+	current := c.buffer.MaxRecords()
 
-func (c *Cmd) actionRetry(msg string, retryStep types.Step, pageToSwitchTo string) (*types.Action, error) {
-	// Display retry modal
-	retryCh := make(chan bool, 1)
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayMaxLines(current, c.buffer.AverageSize(), answerCh)
+	})
 
-	c.options.Console.DisplayRetryModal(msg, pageToSwitchTo, retryCh)
-	retry := <-retryCh
+	maxLines := <-answerCh
 
-	if retry {
-		return &types.Action{Step: retryStep}, nil
-	} else {
-		return &types.Action{Step: types.StepQuit}, nil
+	action.Step = types.StepTail
+
+	if maxLines == current {
+		return action, nil
 	}
-}
 
-func (c *Cmd) actionSelect(action *types.Action) (*types.Action, error) {
-	// Send telemetry
-	_ = c.options.Telemetry.Inc(types.CounterFeatureSelectTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+	c.buffer.SetMaxRecords(maxLines)
 
-	// Only highlight 'q'
-	c.options.Console.ToggleAllMenuHighlights()
-	c.options.Console.ToggleMenuHighlight("Q")
+	c.audit(audit.ActionMaxLinesSet, map[string]string{"max_lines": strconv.Itoa(maxLines)})
 
-	// Set by dialog watching goroutine to tell us to return a quit step
-	userQuit := false
+	c.options.Console.Redraw(func() {
+		c.textview.SetMaxLines(maxLines)
+	})
 
-	// Channel used to tell animation goroutine in DisplayInfoModal to quit
-	quitAnimationCh := make(chan struct{}, 1)
+	// Shrinking evicts records from the buffer; the view must reflect that
+	if maxLines < current {
+		c.renderTail(c.textview, action)
+	}
+
+	return action, nil
+}
+
+// Time window can only be set from tail so we always go back to tail(). The
+// window is applied to the records that are already in the buffer; relative
+// windows (ex: "30s") are resolved when they are set.
+func (c *Cmd) actionTimeWindow(action *types.Action) (*types.Action, error) {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureTimeWindowTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	// Disable input capture while in time window
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	var current string
+
+	if action.TailTimeWindow != nil {
+		current = action.TailTimeWindow.Input
+	}
+
+	// Channel used for reading resp from time window dialog
+	answerCh := make(chan string)
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayTimeWindow(current, answerCh)
+	})
+
+	input := <-answerCh
+
+	action.Step = types.StepTail
+
+	if input == current {
+		return action, nil
+	}
+
+	now := time.Now()
+
+	if input == "" {
+		action.TailTimeWindow = nil
+
+		c.audit(audit.ActionTimeWindowSet, map[string]string{"window": ""})
+
+		c.options.Console.SetMenuEntryOff("Window")
+		c.renderTail(c.textview, action)
+
+		return action, nil
+	}
+
+	// Times are entered in the zone timestamps are displayed in
+	from, to, err := util.ParseTimeWindow(input, now.In(util.DisplayLocation()))
+	if err != nil {
+		c.writeBanner(c.textview, " Invalid time window: "+tview.Escape(err.Error()))
+		return action, nil
+	}
+
+	action.TailTimeWindow = &types.TimeWindow{
+		Input: input,
+		From:  from,
+		To:    to,
+	}
+
+	c.audit(audit.ActionTimeWindowSet, map[string]string{"window": input})
+
+	c.options.Console.SetMenuEntryOn("Window")
+	c.renderTail(c.textview, action)
+
+	return action, nil
+}
+
+// Component settings can only be changed from tail so we always go back to
+// tail() afterwards.
+func (c *Cmd) actionComponentSettings(action *types.Action) (*types.Action, error) {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureComponentSettingsTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	// Disable input capture while in component settings
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	components := tailedComponents(action)
+
+	// Channel used for reading resp from component settings dialog
+	answerCh := make(chan []string)
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayComponentSettings(components, answerCh)
+	})
+
+	filters := <-answerCh
+
+	var (
+		anySet  bool
+		details = make(map[string]string)
+	)
+
+	for i, component := range components {
+		if component.Filter != filters[i] {
+			details[component.Name] = filters[i]
+		}
+
+		component.Filter = filters[i]
+
+		if component.Filter != "" {
+			anySet = true
+		}
+	}
+
+	if len(details) > 0 {
+		c.audit(audit.ActionComponentFiltersSet, details)
+	}
+
+	// Turn on/off "Components" menu entry depending on if any filter is set
+	if anySet {
+		c.options.Console.SetMenuEntryOn("Components")
+	} else {
+		c.options.Console.SetMenuEntryOff("Components")
+	}
+
+	action.Step = types.StepTail
+
+	return action, nil
+}
+
+func (c *Cmd) actionConnect(action *types.Action) (*types.Action, error) {
+	msg := fmt.Sprintf("Connecting to [::u]%s[::-] ", c.options.Config.Server)
+
+	userQuit := false
+	inputCh := make(chan struct{}, 1)
+	outputCh := make(chan error, 1)
+
+	// Channel to tell outputCh reader goroutine to exit
+	quitCh := make(chan struct{}, 1)
+	defer close(quitCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c.options.Console.DisplayInfoModal(msg, inputCh, outputCh)
+
+	// Goroutine used for reading user resp
+	crash.Go(func() {
+		for {
+			select {
+			// user pressed "cancel" - tell connect() to exit early
+			case <-outputCh:
+
+				c.log.Error("user pressed cancel")
+				userQuit = true
+				cancel()
+				return
+			case <-quitCh:
+				// Tell connect() to exit early
+				cancel()
+				return
+			}
+		}
+	})
+
+	// Launch connection attempt
+	if err := c.connect(ctx); err != nil {
+		// If user pressed "cancel" - no need to display retry modal
+		if userQuit {
+			return &types.Action{Step: types.StepQuit}, nil
+		}
+
+		retryMsg := fmt.Sprintf("[white:red]ERROR: Unable to connect![white:red]\n\n%s", err)
+		inputCh <- struct{}{} // tell displayInfoModal to quit because of error
+
+		// Display retry modal
+		retryCh := make(chan bool, 1)
+
+		c.options.Console.DisplayRetryModal(retryMsg, "page_connection_retry", retryCh)
+		retry := <-retryCh
+
+		if retry {
+			return &types.Action{Step: types.StepConnect}, nil
+		} else {
+			return &types.Action{Step: types.StepQuit}, nil
+		}
+	}
+
+	// Need this in here in case user quit while we were connecting
+	if userQuit {
+		return &types.Action{Step: types.StepQuit}, nil
+	}
+
+	c.audit(audit.ActionConnected, nil)
+
+	c.watchServer()
+
+	action.Step = types.StepSelect
+
+	return action, nil
+}
+
+func (c *Cmd) actionRetry(msg string, retryStep types.Step, pageToSwitchTo string) (*types.Action, error) {
+	// Display retry modal
+	retryCh := make(chan bool, 1)
+
+	c.options.Console.DisplayRetryModal(msg, pageToSwitchTo, retryCh)
+	retry := <-retryCh
+
+	if retry {
+		return &types.Action{Step: retryStep}, nil
+	} else {
+		return &types.Action{Step: types.StepQuit}, nil
+	}
+}
+
+func (c *Cmd) actionSelect(action *types.Action) (*types.Action, error) {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureSelectTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	// Only highlight 'q'
+	c.options.Console.ToggleAllMenuHighlights()
+	c.options.Console.ToggleMenuHighlight("Q")
+
+	// --wait-for skips the component list (only once; selecting a component
+	// later displays the list)
+	if c.waitFor != nil {
+		return c.actionWaitFor(action)
+	}
+
+	// Set by dialog watching goroutine to tell us to return a quit step
+	userQuit := false
+
+	// Channel used to tell animation goroutine in DisplayInfoModal to quit
+	quitAnimationCh := make(chan struct{}, 1)
 	defer close(quitAnimationCh)
 
 	// Channel is written to by DisplayInfoModal() when user clicks "Quit"
@@ -381,7 +1051,7 @@ func (c *Cmd) actionSelect(action *types.Action) (*types.Action, error) {
 	c.options.Console.DisplayInfoModal("Fetching live component list", quitAnimationCh, answerCh)
 
 	// Goroutine used for reading user resp
-	go func() {
+	crash.Go(func() {
 		for {
 			select {
 			case <-answerCh:
@@ -399,7 +1069,7 @@ func (c *Cmd) actionSelect(action *types.Action) (*types.Action, error) {
 				return
 			}
 		}
-	}()
+	})
 
 	// Fetch the list of audiences; if it errors, display retry
 	audiences, err := c.api.GetAllLiveAudiences(ctx)
@@ -423,6 +1093,9 @@ func (c *Cmd) actionSelect(action *types.Action) (*types.Action, error) {
 	// OK we got a response, are there any to show?
 	// -------------------------------------------------------
 
+	// Displayed by the finder until it has fetched the components again
+	c.audiences = audiences
+
 	if len(audiences) == 0 {
 		return c.actionRetry(
 			fmt.Sprint("No [::b]live[-:-:-] components!\n\nRetry fetching live components?"),
@@ -431,6 +1104,25 @@ func (c *Cmd) actionSelect(action *types.Action) (*types.Action, error) {
 		)
 	}
 
+	// Benchmark always tails the first component
+	if c.options.Config.Bench {
+		action.Step = types.StepTail
+		action.TailComponent = util.AudienceToTailComponent(audiences[0])
+		action.TailComponents = nil
+
+		return action, nil
+	}
+
+	// Only offered once, before the component list is displayed for the
+	// first time
+	if !c.sessionOffered {
+		c.sessionOffered = true
+
+		if c.restoreSession(action, audiences) {
+			return action, nil
+		}
+	}
+
 	// ------------------------------------------
 	// We have a list of components, display them
 	// ------------------------------------------
@@ -441,6 +1133,8 @@ func (c *Cmd) actionSelect(action *types.Action) (*types.Action, error) {
 	// cause the app to deadlock.
 
 	selectQuitCh := make(chan struct{}, 1)
+	selectBackCh := make(chan struct{}, 1)
+	selectFinderCh := make(chan struct{}, 1)
 
 	// Grab the original input capture so we can reset it when the method exits
 	origCapture := c.options.Console.GetInputCapture()
@@ -449,18 +1143,32 @@ func (c *Cmd) actionSelect(action *types.Action) (*types.Action, error) {
 			selectQuitCh <- struct{}{}
 		}
 
+		if event.Key() == tcell.KeyEscape {
+			selectBackCh <- struct{}{}
+			return nil
+		}
+
+		if event.Key() == tcell.KeyCtrlP {
+			selectFinderCh <- struct{}{}
+			return nil
+		}
+
 		return event
 	})
 
 	defer c.options.Console.SetInputCapture(origCapture)
 
 	c.options.Console.ToggleAllMenuHighlights()
-	c.options.Console.ToggleMenuHighlight("Q")
+	c.options.Console.ToggleMenuHighlight("Q", "Find", "Back")
 
-	selectedComponentCh := make(chan *types.TailComponent, 1)
+	selectedComponentCh := make(chan []*types.TailComponent, 1)
+
+	// Sparklines are updated until a component is selected
+	ratesCtx, ratesCancel := context.WithCancel(c.shutdownCtx)
+	defer ratesCancel()
 
 	// Display select list
-	c.options.Console.DisplaySelectList("Select component", audiences, selectedComponentCh)
+	c.options.Console.DisplaySelectList("Select component", audiences, c.watchRates(ratesCtx), selectedComponentCh)
 
 	// Listen for "quit" or for component selection
 	select {
@@ -468,14 +1176,61 @@ func (c *Cmd) actionSelect(action *types.Action) (*types.Action, error) {
 		return &types.Action{
 			Step: types.StepQuit,
 		}, nil
-	case tailComponent := <-selectedComponentCh:
-		action.Step = types.StepTail
-		action.TailComponent = tailComponent
+	case <-selectBackCh:
+		action.Step = types.StepBack
+		return action, nil
+	case <-selectFinderCh:
+		action.Step = types.StepFinder
+		return action, nil
+	case tailComponents := <-selectedComponentCh:
+		c.selectComponents(action, tailComponents)
+		return action, nil
+	}
+}
+
+// selectComponents sets up action for tailing the given components (picked in
+// the select list or the finder)
+func (c *Cmd) selectComponents(action *types.Action, tailComponents []*types.TailComponent) {
+	action.Step = types.StepTail
+	action.TailComponent = tailComponents[0]
+	action.TailComponents = nil
 
-		// Reset line num when component is selected
-		action.TailLineNum = 0
+	// Several components are tailed as one interleaved stream
+	if len(tailComponents) > 1 {
+		assignComponentColors(tailComponents)
 
-		return action, nil
+		action.TailComponent = groupComponent(tailComponents)
+		action.TailComponents = tailComponents
+	}
+
+	names := make([]string, 0, len(tailComponents))
+
+	for _, component := range tailComponents {
+		names = append(names, util.FormatAudience(component.Audience))
+	}
+
+	c.audit(audit.ActionComponentsSelected, map[string]string{"components": strings.Join(names, ", ")})
+
+	// Restore the records, line num and selection of the component as they
+	// were when it was left
+	c.switchBuffer(action, tailComponents)
+
+	// Reset latency and throughput stats when component is selected
+	action.TailTraceID = ""
+	c.latency.Reset()
+	c.throughput.Reset()
+	c.bandwidth.reset()
+	c.duplicates.Reset()
+	c.burst.Reset()
+	c.latencyTitle = ""
+	c.comparison = nil
+
+	// Producers and consumers are displayed in separate tabs
+	action.TailOperation = defaultOperation(action)
+	c.unseen = make(map[protos.OperationType]int)
+
+	if c.textview != nil {
+		c.renderTail(c.textview, action)
 	}
 }
 
@@ -512,6 +1267,19 @@ func (c *Cmd) actionTail(action *types.Action) (*types.Action, error) {
 		c.options.Console.DisplayTail(c.textview, action.TailComponent, actionCh)
 	}
 
+	// DisplayTail() resets the title; force latency to be re-added to it
+	c.latencyTitle = ""
+
+	// --macro is played once the tail view is displayed for the first time
+	if c.macro != nil && c.macro.startup {
+		c.playMacro()
+	}
+
+	// Benchmark timer starts once we begin tailing
+	if c.options.Config.Bench && c.bench == nil {
+		c.startBench()
+	}
+
 	// TODO: Why is this a for loop?
 	for {
 		respAction, err := c.tail(action, c.textview, actionCh)
@@ -536,13 +1304,42 @@ func (c *Cmd) connect(ctx context.Context) error {
 		return fmt.Errorf("context canceled before connecting to server")
 	}
 
+	// Neither local files nor demo mode talk to a server at all
+	if c.options.Config.LocalSource() {
+		l, err := c.newLocalSource()
+		if err != nil {
+			return err
+		}
+
+		c.api = l
+
+		return nil
+	}
+
+	if c.options.Config.Demo {
+		d, err := demo.New(&demo.Options{
+			Rate:        c.options.Config.DemoRate,
+			PayloadSize: c.options.Config.DemoPayloadSize,
+			Shape:       c.options.Config.DemoShape,
+			Logger:      c.log,
+		})
+		if err != nil {
+			return errors.Wrap(err, "unable to create demo generator")
+		}
+
+		c.api = d
+		c.demo = d
+
+		return nil
+	}
+
 	// Attempt to talk to streamdal server
-	a, err := api.New(&api.Options{
-		Address:        c.options.Config.Server,
-		AuthToken:      c.options.Config.Auth,
-		ConnectTimeout: c.options.Config.ConnectTimeout,
-		DisableTLS:     c.options.Config.DisableTLS,
-	})
+	opts, err := c.apiOptions()
+	if err != nil {
+		return err
+	}
+
+	a, err := api.New(opts)
 	if err != nil {
 		return errors.Wrap(err, "unable to create server client")
 	}
@@ -569,76 +1366,72 @@ func (c *Cmd) tail(action *types.Action, textView *tview.TextView, actionCh <-ch
 		return nil, errors.New("tail(): bug? *action.TailComponent cannot be nil")
 	}
 
-	// If this is the first time we are seeing this filter, announce it
-	if c.announceFilter {
-		filterStatus := fmt.Sprintf(" Filter set to '%s' @ "+time.Now().Format("15:04:05"), action.TailFilter)
-		filterLine := "[gray:black]" + strings.Repeat("░", 16) + filterStatus + strings.Repeat("░", 16) + "[-:-]"
-		fmt.Fprintf(textView, filterLine+"\n")
+	c.updateTailHeader(action)
+	c.updateOperationTabs(action)
+
+	if c.announceSinks {
+		for _, s := range c.sinks {
+			c.writeBanner(textView, fmt.Sprintf(" Forwarding matching messages to '%s'", s.Name()))
+		}
+
+		c.announceSinks = false
+	}
+
+	if c.setupNotice != "" {
+		c.writeBanner(textView, c.setupNotice)
+		c.setupNotice = ""
+	}
 
-		c.announceFilter = false
+	select {
+	case notice := <-c.updateNoticeCh:
+		c.writeBanner(textView, notice)
+	default:
 	}
 
 	tailCtx, tailCancel := context.WithCancel(context.Background())
 	defer tailCancel() // This will stop the tail goroutine when this method exits
 
-	tailCh, err := c.api.Tail(tailCtx, action.TailComponent.Audience)
+	tailCh, err := c.tailComponents(tailCtx, action)
 	if err != nil {
 		return nil, errors.Wrap(err, "error calling gRPC tail endpoint in server")
 	}
 
-	// Set/unset search highlight
-	if action.TailSearch != "" || action.TailSearchPrev != "" {
-		// We need to split so that search does not hit line num and/or timestamp field
-		splitData := strings.Split(textView.GetText(false), "\n")
-
-		var updatedData string
-
-		for _, line := range splitData {
-			if line == "" {
-				continue
-			}
+	c.scriptConnect(textView, action)
 
-			if strings.Contains(line, "░░░") {
-				updatedData += line + "\n"
-				continue
-			}
+	c.setPeeked(tailedComponents(action))
+	c.recent.add(tailedComponents(action))
 
-			splitLine := strings.SplitN(line, " ", 3)
+	// Components are not always picked through selectComponents() (ex:
+	// --wait-for); the buffer belongs to whatever is being tailed
+	c.bufferKey = recentKey(tailedComponents(action))
 
-			if len(splitLine) < 3 {
-				updatedData += line + "\n"
-				continue
-			}
+	idle := newIdleDetector(c.options.Config.IdleTimeout)
+	defer idle.stop()
 
-			// splitLine[0]: line num
-			// splitLine[1]: timestamp
-			// splitLine[2]: content
+	c.segments.start()
+	defer c.segments.stop()
 
-			updatedContent := splitLine[2]
+	c.heartbeat = newHeartbeat(c.api, c.options.Config.DisableAnimations)
+	defer c.heartbeat.stop()
 
-			// If we are coming from a previous search, clear the old highlights first
-			if action.TailSearchPrev != "" &&
-				strings.Contains(updatedContent, fmt.Sprintf(SearchHighlightFmt, action.TailSearchPrev)) {
+	// Sequence numbers are tracked per tail: the stream is restarted whenever
+	// settings change and messages sent in between are never received
+	gaps := util.NewSequenceTracker()
 
-				updatedContent = strings.Replace(updatedContent, fmt.Sprintf(SearchHighlightFmt, action.TailSearchPrev), action.TailSearchPrev, -1)
-			}
+	statsInterval := StatsInterval
 
-			// This is a new search - highlight it but only if it's not already highlighted
-			if action.TailSearch != "" &&
-				!strings.Contains(updatedContent, fmt.Sprintf(SearchHighlightFmt, action.TailSearch)) &&
-				strings.Contains(updatedContent, action.TailSearch) {
+	if util.Accessible() {
+		statsInterval = AccessibleStatsInterval
+	}
 
-				updatedContent = strings.Replace(updatedContent, action.TailSearch, fmt.Sprintf(SearchHighlightFmt, action.TailSearch), -1)
-			}
+	statsTicker := time.NewTicker(statsInterval)
+	defer statsTicker.Stop()
 
-			updatedData += splitLine[0] + " " + splitLine[1] + " " + updatedContent + "\n"
-		}
+	c.options.Console.SetStats(c.bandwidth.String(time.Now()))
+	defer c.options.Console.SetStats("")
 
-		// SetText() does not auto-redraw, need to ask app to do it
-		c.options.Console.Redraw(func() {
-			textView.SetText(updatedData)
-		})
-	}
+	// Lines of the last frame are displayed before whatever comes next
+	defer c.flushTail(textView)
 
 	// Commands read here have been passed down from DisplayTail(); we need access
 	// to them here so we can potentially modify how we're interacting with the
@@ -652,130 +1445,762 @@ func (c *Cmd) tail(action *types.Action, textView *tview.TextView, actionCh <-ch
 	for {
 		select {
 		case cmd := <-actionCh:
+			// Commands apply to what has been received so far
+			c.flushTail(textView)
+
 			// "Pause" is special in that it does not display a modal so we
 			// handle all UI/related pieces from here. For all other commands,
 			// we pass the cmd back to the caller tail() (which will decide if
 			// it should pass the cmd/action back to run()).
 			if cmd.Step == types.StepPause {
-				// Tell tail reader to pause/resume
-				c.paused = !c.paused
-
-				// Update the menu pause button visual
-				if c.paused {
-					// Send telemetry
+				// Send telemetry
+				if !c.paused {
 					_ = c.options.Telemetry.Inc(types.CounterFeaturePauseTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+				}
+
+				pausedStatus := " PAUSED @ " + util.Clock(time.Now())
 
-					c.options.Console.SetMenuEntryOn("Pause")
-				} else {
-					c.options.Console.SetMenuEntryOff("Pause")
+				if c.paused {
+					pausedStatus = " RESUMED @ " + util.Clock(time.Now())
 				}
 
-				pausedStatus := " PAUSED @ " + time.Now().Format("15:04:05")
+				c.setPaused(textView, action, !c.paused, pausedStatus)
 
-				if !c.paused {
-					pausedStatus = " RESUMED @ " + time.Now().Format("15:04:05")
+				// Resume following the tail after a break
+				if !c.paused && c.breakLine != 0 && c.breakLine == c.selectedLine {
+					c.selectLine(textView, action, []string{"clear"})
+				}
+
+				c.breakLine = 0
+			}
+
+			// Line selection and trace filtering do not display a modal either
+			// and do not require the tail to be restarted.
+			if cmd.Step == types.StepSelectLine {
+				c.selectLine(textView, action, cmd.Args)
+				continue
+			}
+
+			if cmd.Step == types.StepChipSelect {
+				c.selectChip(action, cmd.Args)
+				continue
+			}
+
+			if cmd.Step == types.StepChipRemove {
+				c.removeChip(textView, action)
+				continue
+			}
+
+			// Enter edits the selected chip (if any) instead of displaying
+			// the line detail
+			if cmd.Step == types.StepLineDetail && c.selectedChip != 0 {
+				edit := c.editChip(textView, action)
+				if edit == nil {
+					continue
 				}
 
-				pauseLine := "[gray:black]" + strings.Repeat("░", 16) + pausedStatus + strings.Repeat("░", 16) + "[-:-]"
-				fmt.Fprint(textView, pauseLine+"\n")
+				cmd = edit
+			}
+
+			// Esc clears the chip selection, then the line selection; only go
+			// back if nothing is selected
+			if cmd.Step == types.StepBack && c.selectedChip != 0 {
+				c.selectChip(action, []string{"clear"})
+				continue
+			}
+
+			if cmd.Step == types.StepBack && c.selectedLine != 0 {
+				c.selectLine(textView, action, []string{"clear"})
+				continue
+			}
+
+			if cmd.Step == types.StepTraceFilter {
+				c.toggleTraceFilter(textView, action)
+				continue
+			}
+
+			if cmd.Step == types.StepBookmark {
+				c.toggleBookmark(textView, action)
+				continue
+			}
+
+			if cmd.Step == types.StepBookmarkJump {
+				c.jumpBookmark(textView, action, cmd.Args)
+				continue
+			}
+
+			if cmd.Step == types.StepOperationTab {
+				c.switchOperationTab(textView, action)
+				continue
+			}
+
+			if cmd.Step == types.StepOperationCycle {
+				c.cycleOperation(textView, action)
+				continue
+			}
+
+			if cmd.Step == types.StepTimeZone {
+				c.toggleTimeZone(textView, action)
+				continue
+			}
+
+			if cmd.Step == types.StepCompare && len(tailedComponents(action)) != 2 {
+				c.writeBanner(textView, " Select exactly two components (Space in the component list) to compare them")
+				continue
 			}
 
 			// Re-inject settings
 			cmd.TailComponent = action.TailComponent
+			cmd.TailComponents = action.TailComponents
 			cmd.TailFilter = action.TailFilter
 			cmd.TailSearch = action.TailSearch
-			cmd.TailSearchPrev = action.TailSearchPrev
+			cmd.TailFilterWholeWord = action.TailFilterWholeWord
+			cmd.TailSearchWholeWord = action.TailSearchWholeWord
 			cmd.TailRate = action.TailRate
 			cmd.TailViewOptions = action.TailViewOptions
 			cmd.TailLineNum = action.TailLineNum
+			cmd.TailTraceID = action.TailTraceID
+			cmd.TailTimeWindow = action.TailTimeWindow
+			cmd.TailBreak = action.TailBreak
+			cmd.TailWhere = action.TailWhere
+			cmd.TailOperation = action.TailOperation
+			cmd.CompareKey = action.CompareKey
 
 			return cmd, nil
-		case tailResp := <-tailCh:
-			if tailResp == nil {
+		case <-c.benchDoneCh():
+			return &types.Action{Step: types.StepQuit}, nil
+		case err := <-c.sinkErrCh:
+			c.writeBanner(textView, fmt.Sprintf(" Forwarding error @ %s: %s", util.Clock(time.Now()), tview.Escape(err.Error())))
+		case <-c.heartbeat.tick():
+			// The header is only redrawn when the indicator changes (always
+			// while pulsing)
+			indicator := c.heartbeat.indicator()
+			c.heartbeat.check()
+
+			if c.heartbeat.indicator() != indicator {
+				c.updateTailHeader(action)
+			}
+		case now := <-statsTicker.C:
+			c.options.Console.SetStats(c.bandwidth.String(now))
+			c.updateOperationTabs(action)
+		case now := <-c.segments.tick():
+			if c.segments.due(now) && !c.paused {
+				c.startSegment(textView, action, "every "+c.segments.interval.String())
+			}
+		case <-idle.tick():
+			c.checkIdle(tailCtx, textView, action, idle)
+		case result := <-idle.probeCh:
+			c.writeBanner(textView, result)
+		case msg := <-tailCh:
+			if msg == nil || msg.resp == nil {
 				c.log.Debug("got nil resp on tailCh - ignoring")
 				continue
 			}
 
+			now := time.Now()
+
+			c.throughput.Add(now)
+			c.bandwidth.add(now, proto.Size(msg.resp))
+
+			if since, resumed := idle.received(now); resumed && !c.paused {
+				c.writeBanner(textView, fmt.Sprintf(" Data resumed @ %s after %s without data",
+					util.Clock(now), since.Round(time.Second)))
+			}
+
+			// Mark where a burst started; paused output is not displayed
+			if started, rate, avg := c.burst.Check(now); started && !c.paused {
+				c.writeBanner(textView, fmt.Sprintf(" Burst detected @ %s: %s msgs/sec (%s msgs/sec average)",
+					util.Clock(now), util.FormatRate(float64(rate)), util.FormatRate(avg)))
+			}
+
 			// TODO: Differentiate between error and good payload
-			data := string(tailResp.OriginalData)
+			data := c.decode(msg.resp.OriginalData)
+
+			c.checkSequence(textView, action, gaps, data, msg.component)
+
+			seen := c.checkDuplicate(data, msg.component)
+
+			message := newMessage(data, msg.resp)
+			c.lastMessage = message
+
+			scripted := c.scriptMessage(textView, message, msg.component)
+			if scripted != nil && scripted.Drop {
+				continue
+			}
+
+			// Markers are checked before filtering so that filtered out
+			// markers still start a new segment
+			if marker := c.options.Config.SegmentMarker; marker != "" && !c.paused && c.matcher(marker).match(message) {
+				c.startSegment(textView, action, "matched '"+tview.Escape(marker)+"'")
+			}
+
+			if !c.filterMatcher(action).match(message) {
+				continue
+			}
+
+			if msg.component != nil && !c.matcher(msg.component.Filter).match(message) {
+				continue
+			}
+
+			if !matchesWhere(data, action.TailWhere) {
+				continue
+			}
+
+			c.forward(tailCtx, msg.resp, data)
 
-			if !strings.Contains(data, action.TailFilter) {
+			// Client-side sampling; independent of the server sample rate
+			if !c.decimate(action) {
 				continue
 			}
 
 			action.TailLineNum++
 
-			// Highlight filtered data
-			if action.TailFilter != "" {
-				data = strings.Replace(data, action.TailFilter, "[green:gray]"+action.TailFilter+"[-:-]", -1)
+			record := c.newRecord(data, action.TailLineNum)
+			record.Component = msg.component
+			record.Seen = seen
+
+			applyScript(record, scripted)
+
+			if c.options.Config.LatencyField != "" {
+				c.updateLatencyTitle(textView, action.TailComponent)
 			}
 
-			// This will highlight the search term + underline the entire entry
-			// for any new incoming data.
-			if action.TailSearch != "" {
-				if strings.Contains(data, action.TailSearch) {
-					// Highlight just the search term
-					data = strings.Replace(data, action.TailSearch, fmt.Sprintf(SearchHighlightFmt, action.TailSearch), -1)
-				}
+			if c.paused {
+				continue
 			}
 
-			var (
-				prefix        string
-				formattedData []byte
-			)
+			if evicted := c.buffer.Add(record); evicted > 0 && c.trimTail(textView, action) {
+				// Re-rendered view already contains the record
+				continue
+			}
 
-			formatter := pretty.NewFormatter(true)
-			formatter.Indent = 0
-			formatter.Newline = ""
-			formatter.DisabledColor = true
+			if !recordVisible(record, action) {
+				c.countUnseen(record, action)
+				continue
+			}
 
-			if action.TailViewOptions != nil {
-				// Enable colors
-				if action.TailViewOptions.EnableColors {
-					formatter.DisabledColor = false
-				}
+			c.tailOut.write(c.formatRecord(record, action), c.buffer.MaxRecords())
 
-				// Enable pretty JSON output
-				if action.TailViewOptions.PrettyJSON {
-					formatter.Indent = 2
-					formatter.Newline = "\n"
-				}
+			if c.bench != nil {
+				c.bench.displayed++
+			}
 
-				// Enable TS
-				if action.TailViewOptions.DisplayTimestamp {
-					prefix = `[gray:black]` + time.Now().Format("15:04:05") + ` [-:-:-]`
-				}
+			if action.TailBreak != "" && c.matcher(action.TailBreak).match(message) {
+				c.breakOnMatch(textView, record, action)
+			}
+		case <-c.tailOut.frame():
+			c.flushFrame(textView)
+		}
+	}
+}
 
-				// Enable line numbers
-				if action.TailViewOptions.DisplayLineNumbers {
-					// If we already have a TS, add a space to separate it from the line num
-					if action.TailViewOptions.DisplayTimestamp {
-						prefix = " " + prefix
-					}
-					prefix = fmt.Sprintf("[gray:black:b][%d][-:-:-]", action.TailLineNum) + prefix
-				}
+// setPaused pauses/resumes the tail reader, updates the menu pause button and
+// header and writes the given banner to the tail view
+func (c *Cmd) setPaused(textView *tview.TextView, action *types.Action, paused bool, banner string) {
+	c.setPauseState(action, paused)
+	c.updateTailHeader(action)
+	c.writeBanner(textView, banner)
+}
 
-				// If prefix exists, add a space to make it look better
-				if prefix != "" {
-					prefix += " "
-				}
+// setPauseState pauses/resumes the tail reader; the session and audit log are
+// updated along with the menu pause button. Shared by the tail and compare
+// views, which each update their own header.
+func (c *Cmd) setPauseState(action *types.Action, paused bool) {
+	c.paused = paused
+	c.trackSession(action)
+
+	if c.paused {
+		c.options.Console.SetMenuEntryOn("Pause")
+		c.audit(audit.ActionTailPaused, nil)
+	} else {
+		c.options.Console.SetMenuEntryOff("Pause")
+		c.audit(audit.ActionTailResumed, nil)
+	}
+}
+
+// breakOnMatch pauses the tail and selects the record that matched the break
+// expression, similar to hitting a breakpoint in a debugger. Pressing "P"
+// resumes the tail.
+func (c *Cmd) breakOnMatch(textView *tview.TextView, record *types.TailRecord, action *types.Action) {
+	c.setPaused(textView, action, true, fmt.Sprintf(" BREAK @ %s: line %d matched '%s' (press P to resume)",
+		util.Clock(time.Now()), record.LineNum, tview.Escape(action.TailBreak)))
+
+	c.breakLine = record.LineNum
+	c.jumpToLine(textView, record.LineNum)
+}
+
+// decimate returns true if the current message should be displayed when only
+// 1 in N messages are displayed (see ViewOptions.Decimate)
+func (c *Cmd) decimate(action *types.Action) bool {
+	if action.TailViewOptions == nil || action.TailViewOptions.Decimate <= 1 {
+		return true
+	}
+
+	c.decimateCount++
+
+	return c.decimateCount%action.TailViewOptions.Decimate == 1
+}
+
+// decode runs the payload through the configured decoder; if decoding fails,
+// the original payload is displayed.
+func (c *Cmd) decode(data []byte) []byte {
+	decoded, err := c.decoder.Decode(data)
+	if err != nil {
+		c.log.Debugf("unable to decode payload with decoder '%s': %s", c.decoder.Name(), err)
+		return data
+	}
+
+	return decoded
+}
+
+// newRecord creates a tail record from a payload and extracts any metadata
+// (latency, trace ID) that is displayed alongside it.
+func (c *Cmd) newRecord(data []byte, lineNum int) *types.TailRecord {
+	// Redact before storing so that redacted values are never rendered, exported
+	// or shared
+	data = util.RedactJSONPaths(data, c.options.Config.Redact)
+
+	record := &types.TailRecord{
+		LineNum:  lineNum,
+		Received: time.Now(),
+		Data:     data,
+		TraceID:  util.ExtractTraceID(data, c.options.Config.TraceIDField),
+	}
+
+	if c.options.Config.LatencyField != "" {
+		record.Latency, record.HasLatency = c.getLatency(data)
+	}
+
+	if c.preview != nil {
+		record.Preview = c.preview.Run(data)
+	}
+
+	return record
+}
+
+// formatRecord returns the tail view representation of a record. Each message
+// is wrapped in a region (named after its line number) so that it can be
+// selected in the tail view.
+func (c *Cmd) formatRecord(record *types.TailRecord, action *types.Action) string {
+	if record.Banner != "" {
+		if util.Accessible() {
+			return "[gray:black]Notice:" + record.Banner + "[-:-]"
+		}
+
+		fill := strings.Repeat(util.Glyph("░", "-", ""), 16)
+
+		return "[gray:black]" + fill + record.Banner + fill + "[-:-]"
+	}
+
+	data := string(record.Data)
+
+	// Mask secrets first so that highlights can't reveal them
+	if action.TailViewOptions != nil && action.TailViewOptions.MaskSecrets {
+		data = util.MaskSecrets(data)
+	}
+
+	var prefix string
+
+	formatter := pretty.NewFormatter(true)
+	formatter.Indent = 0
+	formatter.Newline = ""
+	formatter.DisabledColor = true
+
+	if action.TailViewOptions != nil {
+		// Enable colors
+		if action.TailViewOptions.EnableColors {
+			formatter.DisabledColor = false
+		}
+
+		// Enable pretty JSON output
+		if action.TailViewOptions.PrettyJSON {
+			formatter.Indent = 2
+			formatter.Newline = "\n"
+		}
+
+		// Enable TS
+		if action.TailViewOptions.DisplayTimestamp {
+			prefix = `[gray:black]` + util.Clock(record.Received) + ` [-:-:-]`
+		}
+
+		// Interleaved messages are colored by the component they came from
+		// so sources can be told apart at a glance.
+		lineColor := "gray"
+		multi := len(action.TailComponents) > 1 && record.Component != nil
+
+		if multi && record.Component.Color != "" {
+			lineColor = record.Component.Color
+		}
+
+		// Enable line numbers
+		if action.TailViewOptions.DisplayLineNumbers {
+			// If we already have a TS, add a space to separate it from the line num
+			if action.TailViewOptions.DisplayTimestamp {
+				prefix = " " + prefix
 			}
+			prefix = fmt.Sprintf("[%s:black:b][%d][-:-:-]", lineColor, record.LineNum) + prefix
+		}
 
-			if formatted, err := formatter.Format([]byte(data)); err != nil {
-				formattedData = []byte(data)
-			} else {
-				formattedData = formatted
+		// Tag interleaved messages with the component they came from
+		if multi {
+			if prefix != "" {
+				prefix += " "
 			}
+			prefix += `[` + lineColor + `:black]` + tview.Escape("["+record.Component.Name+"]") + `[-:-:-]`
+		}
 
-			if !c.paused {
-				if _, err := fmt.Fprint(textView, prefix+(string(formattedData))+"\n"); err != nil {
-					c.log.Errorf("unable to write to textview: %s", err)
-				}
+		// Display latency if a producer timestamp field is configured
+		if record.HasLatency {
+			if prefix != "" {
+				prefix += " "
+			}
+			prefix += `[gray:black]+` + formatLatency(record.Latency) + `[-:-:-]`
+		}
 
-				textView.ScrollToEnd()
+		// Display trace ID (shortened) in its own column
+		if record.TraceID != "" {
+			if prefix != "" {
+				prefix += " "
 			}
+			prefix += fmt.Sprintf("[%s:black]%s[-:-:-]", console.Hex(console.TextAccent2), shortTraceID(record.TraceID))
+		}
+
+		// If prefix exists, add a space to make it look better
+		if prefix != "" {
+			prefix += " "
 		}
 	}
+
+	styled := c.styleData(data, record, action, formatter)
+
+	// Lines containing probable PII are marked in the gutter
+	if action.TailViewOptions != nil && action.TailViewOptions.DetectPII && util.ContainsPII(record.Data) {
+		prefix = fmt.Sprintf("[%s:black]%s[-:-:-] ", console.Hex(console.TextAccent3), util.Glyph("⚑", "!", "PII")) + prefix
+	}
+
+	// Tags attached by --script
+	if len(record.Tags) > 0 {
+		prefix = fmt.Sprintf("[%s:black]%s[-:-:-] ", console.Hex(console.TextAccent2), tview.Escape(formatTags(record.Tags))) + prefix
+	}
+
+	// Duplicates (by --id-field) are marked with the number of times the ID
+	// was seen
+	if record.Seen > 1 {
+		prefix = fmt.Sprintf("[%s:black]"+util.Glyph("dup×%d", "dup x%d", "duplicate %d times")+"[-:-:-] ", console.Hex(console.TextAccent2), record.Seen) + prefix
+	}
+
+	// Bookmarked lines are marked in the gutter
+	if record.Bookmarked {
+		prefix = fmt.Sprintf("[%s:black]%s[-:-:-] ", console.Hex(console.TextAccent1), util.Glyph("★", "*", "bookmark")) + prefix
+	}
+
+	entry := prefix + styled.String()
+
+	if record.Note != "" {
+		entry += "\n" + fmt.Sprintf("[%s:black]  %s %s[-:-:-]", console.Hex(console.TextAccent1), util.Glyph("✎", ">", "note:"), tview.Escape(record.Note))
+	}
+
+	if record.Preview != nil {
+		entry += "\n" + formatPreview(record, formatter)
+	}
+
+	return fmt.Sprintf(`["%d"]`, record.LineNum) + entry + `[""]`
+}
+
+// styleData formats a payload (as JSON if it is) and highlights the filter,
+// the component filter and the search in it, in that order: search
+// highlights are on top.
+func (c *Cmd) styleData(data string, record *types.TailRecord, action *types.Action, formatter *pretty.Formatter) *styledText {
+	// Colors are added afterwards; they would be in the way of matching
+	plain := *formatter
+	plain.DisabledColor = true
+
+	styled := newStyledText(data)
+
+	if formatted, err := plain.Format([]byte(data)); err == nil {
+		styled = newStyledText(string(formatted))
+
+		if !formatter.DisabledColor {
+			styled.paintJSON()
+		}
+	}
+
+	// There is nothing to highlight for expressions
+	filter := c.filterMatcher(action)
+
+	if filter.highlight != nil {
+		styled.paintMatches(filter.highlight, filterStyle())
+	}
+
+	if record.Component != nil {
+		if componentFilter := c.matcher(record.Component.Filter); componentFilter.highlight != nil && componentFilter != filter {
+			styled.paintMatches(componentFilter.highlight, filterStyle())
+		}
+	}
+
+	// The search term (or regex match + its capture groups)
+	if action.TailSearch != "" && c.search != nil {
+		c.search.paint(styled)
+	}
+
+	return styled
+}
+
+// formatPreview displays the result of running the previewed pipeline step;
+// output is only displayed if the step modified the payload.
+func formatPreview(record *types.TailRecord, formatter *pretty.Formatter) string {
+	p := record.Preview
+
+	var status string
+
+	switch {
+	case p.Error != "":
+		status = "[" + console.Hex(console.StatusError) + "]ERROR[-]: " + p.Error
+	case p.Success:
+		status = "[" + console.Hex(console.StatusOK) + "]SUCCESS[-]"
+	default:
+		status = "[" + console.Hex(console.StatusWarning) + "]FAILURE[-]"
+	}
+
+	if p.Error == "" && p.Message != "" {
+		status += " (" + p.Message + ")"
+	}
+
+	line := fmt.Sprintf("[gray:black]  %s [::b]%s[::-][-:-:-] %s", util.Glyph("↳ step", "> step", "step"), p.Step, status)
+
+	if len(p.Output) > 0 && !bytes.Equal(p.Output, record.Data) {
+		output, err := formatter.Format(p.Output)
+		if err != nil {
+			output = p.Output
+		}
+
+		line += "\n[gray:black]  " + util.Glyph("↳ output:", "> output:", "output:") + "[-:-:-] " + string(output)
+	}
+
+	return line
+}
+
+// writeBanner adds an informational line to the buffer and the tail view
+func (c *Cmd) writeBanner(textView *tview.TextView, text string) {
+	record := &types.TailRecord{
+		Received: time.Now(),
+		Banner:   text,
+	}
+
+	c.buffer.Add(record)
+
+	// Written after the lines that are still pending
+	c.tailOut.write(c.formatRecord(record, nil), c.buffer.MaxRecords())
+	c.flushTail(textView)
+}
+
+// trimTail is called when records were evicted from the buffer because of
+// --max-memory. The user is notified once and the tail view is periodically
+// re-rendered from the buffer so that evicted records are released by the
+// view as well. Returns true if the view was re-rendered.
+func (c *Cmd) trimTail(textView *tview.TextView, action *types.Action) bool {
+	if !c.memoryNotice {
+		c.memoryNotice = true
+		c.writeBanner(textView, fmt.Sprintf(" Memory cap of %s reached; evicting oldest lines @ %s",
+			c.options.Config.MaxMemory, util.Clock(time.Now())))
+	}
+
+	if time.Since(c.lastTrim) < MemoryTrimInterval {
+		return false
+	}
+
+	c.lastTrim = time.Now()
+	c.renderTail(textView, action)
+
+	return true
+}
+
+// renderTail re-draws the tail view from the records stored in the buffer
+func (c *Cmd) renderTail(textView *tview.TextView, action *types.Action) {
+	// Pending lines are in the buffer too; the frame being drawn (if any) is
+	// overwritten
+	c.tailOut.discard()
+	c.tailOut.wait()
+
+	var sb strings.Builder
+
+	for _, record := range c.buffer.Records() {
+		if !recordVisible(record, action) {
+			continue
+		}
+
+		sb.WriteString(c.formatRecord(record, action) + "\n")
+	}
+
+	selected := c.selectedLine
+
+	// SetText() does not auto-redraw, need to ask app to do it
+	c.options.Console.Redraw(func() {
+		textView.SetText(sb.String())
+
+		if selected != 0 {
+			textView.Highlight(strconv.Itoa(selected)).ScrollToHighlight()
+		} else {
+			textView.ScrollToEnd()
+		}
+	})
+}
+
+// recordVisible determines if a record should be displayed in the tail view
+func recordVisible(record *types.TailRecord, action *types.Action) bool {
+	if record.Banner != "" {
+		return true
+	}
+
+	if action.TailTraceID != "" && record.TraceID != action.TailTraceID {
+		return false
+	}
+
+	if action.TailTimeWindow != nil && !action.TailTimeWindow.Contains(record.Received) {
+		return false
+	}
+
+	return operationVisible(record, action)
+}
+
+// selectLine moves the line selection cursor in the tail view. Args[0] is
+// either "prev", "next" or "clear".
+func (c *Cmd) selectLine(textView *tview.TextView, action *types.Action, args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	if args[0] == "clear" {
+		c.selectedLine = 0
+
+		c.options.Console.Redraw(func() {
+			textView.Highlight()
+			textView.ScrollToEnd()
+		})
+
+		return
+	}
+
+	lineNums := make([]int, 0)
+
+	for _, record := range c.buffer.Records() {
+		if record.Banner == "" && recordVisible(record, action) {
+			lineNums = append(lineNums, record.LineNum)
+		}
+	}
+
+	if len(lineNums) == 0 {
+		return
+	}
+
+	// Nothing selected yet - start from the most recent line
+	idx := len(lineNums) - 1
+
+	for i, lineNum := range lineNums {
+		if lineNum != c.selectedLine {
+			continue
+		}
+
+		idx = i
+
+		if args[0] == "prev" && i > 0 {
+			idx--
+		} else if args[0] == "next" && i < len(lineNums)-1 {
+			idx++
+		}
+
+		break
+	}
+
+	c.selectedLine = lineNums[idx]
+	selected := strconv.Itoa(c.selectedLine)
+
+	c.options.Console.Redraw(func() {
+		textView.Highlight(selected).ScrollToHighlight()
+	})
+}
+
+// toggleTraceFilter limits the tail view to the trace ID of the selected line;
+// if a trace filter is already active, it is removed.
+func (c *Cmd) toggleTraceFilter(textView *tview.TextView, action *types.Action) {
+	if action.TailTraceID != "" {
+		action.TailTraceID = ""
+
+		c.audit(audit.ActionTraceFilterSet, map[string]string{"trace_id": ""})
+		c.options.Console.SetMenuEntryOff("Trace")
+		c.updateTailHeader(action)
+		c.renderTail(textView, action)
+
+		return
+	}
+
+	record, ok := c.buffer.Get(c.selectedLine)
+	if !ok || record.TraceID == "" {
+		return
+	}
+
+	action.TailTraceID = record.TraceID
+
+	c.audit(audit.ActionTraceFilterSet, map[string]string{"trace_id": record.TraceID})
+	c.options.Console.SetMenuEntryOn("Trace")
+	c.updateTailHeader(action)
+	c.renderTail(textView, action)
+}
+
+// shortTraceID returns the first 8 characters of a trace ID for display
+func shortTraceID(traceID string) string {
+	if len(traceID) > 8 {
+		return traceID[:8]
+	}
+
+	return traceID
+}
+
+// getLatency calculates the latency between the producer timestamp embedded
+// in the payload and now. The latency is also added to the rolling average
+// which is displayed in the tail view title.
+func (c *Cmd) getLatency(data []byte) (time.Duration, bool) {
+	value, err := util.GetJSONPath(data, c.options.Config.LatencyField)
+	if err != nil {
+		c.log.Debugf("unable to find latency field '%s': %s", c.options.Config.LatencyField, err)
+		return 0, false
+	}
+
+	producedAt, err := util.ParseTimestamp(value)
+	if err != nil {
+		c.log.Debugf("unable to parse latency field '%s': %s", c.options.Config.LatencyField, err)
+		return 0, false
+	}
+
+	latency := time.Since(producedAt)
+
+	c.latency.Add(latency)
+
+	return latency, true
+}
+
+// updateLatencyTitle displays the rolling average latency in the tail view
+// title; the title is only redrawn when the displayed value changes.
+func (c *Cmd) updateLatencyTitle(textView *tview.TextView, component *types.TailComponent) {
+	title := fmt.Sprintf("%s (avg latency: %s)", component.Name, formatLatency(c.latency.Average()))
+
+	if title == c.latencyTitle {
+		return
+	}
+
+	c.latencyTitle = title
+
+	c.options.Console.Redraw(func() {
+		textView.SetTitle(title)
+	})
+}
+
+func formatLatency(d time.Duration) string {
+	if d < time.Millisecond && d > -time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+
+	return d.Round(time.Millisecond).String()
 }
 
 func (c *Cmd) runUptime() {
@@ -811,6 +2236,10 @@ func validateOptions(opts *Options) error {
 		return errors.New(".Console cannot be nil")
 	}
 
+	if opts.Crash == nil {
+		return errors.New(".Crash cannot be nil")
+	}
+
 	if opts.Logger == nil {
 		return errors.New(".Logger cannot be nil")
 	}
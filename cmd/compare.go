@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	// CompareMaxRecords is the number of records (for both components) kept
+	// in the comparison view; the view is re-rendered from them because
+	// aligned rows change when the matching message arrives.
+	CompareMaxRecords = 500
+
+	// CompareRenderInterval is how long new records are collected before the
+	// comparison view is re-rendered; nothing is rendered while no records
+	// arrive
+	CompareRenderInterval = 100 * time.Millisecond
+)
+
+// comparison holds the records displayed in the comparison view. It is kept
+// across dialogs (filter, correlation key) and reset when components are
+// (re)selected.
+type comparison struct {
+	entries  []*compareEntry
+	lineNums [2]int
+}
+
+type compareEntry struct {
+	side   int
+	record *types.TailRecord
+}
+
+// add stores a record for the given side (0 = left, 1 = right); the oldest
+// records are evicted once CompareMaxRecords is reached.
+func (cp *comparison) add(side int, record *types.TailRecord) {
+	cp.entries = append(cp.entries, &compareEntry{side: side, record: record})
+
+	if len(cp.entries) > CompareMaxRecords {
+		cp.entries = cp.entries[len(cp.entries)-CompareMaxRecords:]
+	}
+}
+
+// rows pairs up entries. Without a key, every entry gets its own row (the
+// panes are independent). With a key, an entry is placed next to the oldest
+// unpaired entry of the other side that has the same key value.
+func (cp *comparison) rows(key string) [][2]*compareEntry {
+	rows := make([][2]*compareEntry, 0, len(cp.entries))
+	pending := make(map[string]int)
+
+	for _, e := range cp.entries {
+		var (
+			value string
+			ok    bool
+		)
+
+		if key != "" {
+			value, ok = correlationValue(e.record.Data, key)
+		}
+
+		if ok {
+			if idx, found := pending[value]; found && rows[idx][e.side] == nil {
+				rows[idx][e.side] = e
+				delete(pending, value)
+
+				continue
+			}
+		}
+
+		var row [2]*compareEntry
+		row[e.side] = e
+		rows = append(rows, row)
+
+		if ok {
+			pending[value] = len(rows) - 1
+		}
+	}
+
+	return rows
+}
+
+// correlationValue returns the value found at path as a string
+func correlationValue(data []byte, path string) (string, bool) {
+	value, err := util.GetJSONPath(data, path)
+	if err != nil {
+		return "", false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// actionCompare displays the two tailed components side by side
+func (c *Cmd) actionCompare(action *types.Action) (*types.Action, error) {
+	components := tailedComponents(action)
+
+	if len(components) != 2 {
+		return nil, errors.New("actionCompare(): bug? exactly two components are required")
+	}
+
+	// Only count entering the view (not returning from a dialog)
+	if c.comparison == nil {
+		_ = c.options.Telemetry.Inc(types.CounterFeatureCompareTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+		c.comparison = &comparison{}
+	}
+
+	actionCh := make(chan *types.Action, 1)
+
+	left, right := c.options.Console.DisplayCompare(components[0], components[1], actionCh)
+
+	return c.compare(action, [2]*tview.TextView{left, right}, actionCh)
+}
+
+// compare tails both components and renders them into their panes until an
+// action that requires a different view or dialog is received. The shared
+// filter (and the component filters) are applied like in tail(). Forwarding
+// errors are not displayed here; they are displayed once back in tail().
+func (c *Cmd) compare(action *types.Action, panes [2]*tview.TextView, actionCh <-chan *types.Action) (*types.Action, error) {
+	components := tailedComponents(action)
+
+	tailCtx, tailCancel := context.WithCancel(context.Background())
+	defer tailCancel()
+
+	tailCh, err := c.tailComponents(tailCtx, action)
+	if err != nil {
+		return nil, errors.Wrap(err, "error calling gRPC tail endpoint in server")
+	}
+
+	c.setCompareTitles(action, panes)
+	c.renderCompare(action, panes)
+
+	// Armed by the first record received since the last render
+	var renderCh <-chan time.Time
+
+	for {
+		select {
+		case cmd := <-actionCh:
+			if cmd.Step == types.StepPause {
+				c.setPauseState(action, !c.paused)
+				c.setCompareTitles(action, panes)
+
+				// Display the records received before pausing that were
+				// not rendered yet
+				if !c.paused {
+					c.renderCompare(action, panes)
+				}
+
+				continue
+			}
+
+			action.Step = cmd.Step
+			action.Args = cmd.Args
+
+			return action, nil
+		case <-c.benchDoneCh():
+			return &types.Action{Step: types.StepQuit}, nil
+		case <-renderCh:
+			renderCh = nil
+
+			// Paused panes are left as-is; records are rendered on resume
+			if !c.paused {
+				c.renderCompare(action, panes)
+			}
+		case msg := <-tailCh:
+			if msg == nil || msg.resp == nil {
+				continue
+			}
+
+			data := c.decode(msg.resp.OriginalData)
+
+			message := newMessage(data, msg.resp)
+			c.lastMessage = message
+
+			// Failures are only logged; there is no room for banners
+			scripted := c.scriptMessage(nil, message, msg.component)
+			if scripted != nil && scripted.Drop {
+				continue
+			}
+
+			if !c.filterMatcher(action).match(message) {
+				continue
+			}
+
+			if msg.component != nil && !c.matcher(msg.component.Filter).match(message) {
+				continue
+			}
+
+			if !matchesWhere(data, action.TailWhere) {
+				continue
+			}
+
+			c.forward(tailCtx, msg.resp, data)
+
+			if c.paused {
+				continue
+			}
+
+			side := 0
+
+			if msg.component == components[1] {
+				side = 1
+			}
+
+			c.comparison.lineNums[side]++
+
+			record := c.newRecord(data, c.comparison.lineNums[side])
+			record.Component = msg.component
+
+			applyScript(record, scripted)
+
+			c.comparison.add(side, record)
+
+			if renderCh == nil {
+				renderCh = time.After(CompareRenderInterval)
+			}
+		}
+	}
+}
+
+// setCompareTitles displays the correlation key and pause state in the pane
+// titles
+func (c *Cmd) setCompareTitles(action *types.Action, panes [2]*tview.TextView) {
+	components := tailedComponents(action)
+
+	var suffix string
+
+	if action.CompareKey != "" {
+		suffix += " (aligned by " + action.CompareKey + ")"
+	}
+
+	if c.paused {
+		suffix += " [PAUSED]"
+	}
+
+	c.options.Console.Redraw(func() {
+		for i, pane := range panes {
+			pane.SetTitle(tview.Escape(components[i].Name + suffix))
+		}
+	})
+}
+
+// renderCompare re-draws both panes. Aligned rows are padded to the same
+// height so that paired messages start on the same line.
+func (c *Cmd) renderCompare(action *types.Action, panes [2]*tview.TextView) {
+	// Panes are per-component; don't tag lines with the component name
+	format := *action
+	format.TailComponents = nil
+
+	var texts [2]strings.Builder
+
+	for _, row := range c.comparison.rows(action.CompareKey) {
+		var (
+			cells  [2]string
+			height int
+		)
+
+		for side, e := range row {
+			switch {
+			case e != nil:
+				cells[side] = c.formatRecord(e.record, &format)
+			case action.CompareKey != "":
+				cells[side] = "[gray:black]  " + util.Glyph("…", "...", "...") + "[-:-:-]"
+			}
+
+			if lines := strings.Count(cells[side], "\n") + 1; lines > height {
+				height = lines
+			}
+		}
+
+		for side := range row {
+			// Without a key the panes are independent
+			if row[side] == nil && action.CompareKey == "" {
+				continue
+			}
+
+			texts[side].WriteString(cells[side])
+			texts[side].WriteString(strings.Repeat("\n", height-strings.Count(cells[side], "\n")))
+		}
+	}
+
+	c.options.Console.Redraw(func() {
+		for i, pane := range panes {
+			pane.SetText(texts[i].String())
+			pane.ScrollToEnd()
+		}
+	})
+}
+
+// actionCorrelationKey sets the JSONPath used for aligning lines in the
+// comparison view
+func (c *Cmd) actionCorrelationKey(action *types.Action) (*types.Action, error) {
+	// Disable input capture while in the dialog
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	answerCh := make(chan string)
+
+	crash.Go(func() {
+		c.options.Console.DisplayCorrelationKey(action.CompareKey, answerCh)
+	})
+
+	action.Step = types.StepCompare
+	action.CompareKey = strings.TrimSpace(<-answerCh)
+
+	return action, nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/config"
+)
+
+// runConfigShow handles "config show"; the effective value of every global
+// flag is printed, regardless of whether it was set via a flag, an env var or
+// a default. Values of config.SensitiveFlags are redacted.
+func (c *Cmd) runConfigShow() error {
+	kctx := c.options.Config.KongContext
+
+	for _, flag := range kctx.Model.Flags {
+		if flag.Hidden || flag.Name == "help" || flag.Name == "version" {
+			continue
+		}
+
+		value := fmt.Sprintf("%v", kctx.FlagValue(flag))
+
+		if config.SensitiveFlags[flag.Name] && value != "" {
+			value = "********"
+		}
+
+		fmt.Printf("%s=%s\n", flag.Name, value)
+	}
+
+	return nil
+}
+
+// runConfigPath handles "config path"
+func (c *Cmd) runConfigPath() error {
+	path, err := config.FilePath()
+	if err != nil {
+		return errors.Wrap(err, "unable to determine config file path")
+	}
+
+	fmt.Println(path)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/streamdal/cli/audit"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// Line detail can only be opened from tail so we always go back to tail().
+// Picking a field filters the stream to payloads where the field has the same
+// value, so that the filter doesn't have to be typed.
+func (c *Cmd) actionLineDetail(action *types.Action) (*types.Action, error) {
+	action.Step = types.StepTail
+
+	var record *types.TailRecord
+
+	if c.selectedLine != 0 {
+		record, _ = c.buffer.Get(c.selectedLine)
+	} else {
+		record = c.lastRecord(action)
+	}
+
+	if record == nil {
+		return action, nil
+	}
+
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureLineDetailTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	// Disable input capture while in line detail
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	// Not an error: the payload may not be JSON
+	fields, err := util.FlattenJSON(record.Data)
+	if err != nil {
+		c.log.Debugf("unable to list fields of line %d: %s", record.LineNum, err)
+	}
+
+	// Channel used for reading resp from line detail dialog
+	answerCh := make(chan *types.FieldFilter)
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayLineDetail(record.LineNum, filterableFields(fields), action.TailWhere, answerCh)
+	})
+
+	where := <-answerCh
+	if where == nil {
+		return action, nil
+	}
+
+	if where.Path == "" {
+		action.TailWhere = nil
+		c.audit(audit.ActionFieldFilterSet, map[string]string{"path": "", "value": ""})
+
+		return action, nil
+	}
+
+	action.TailWhere = where
+	c.audit(audit.ActionFieldFilterSet, map[string]string{"path": where.Path, "value": where.Value})
+
+	return action, nil
+}
+
+// filterableFields omits redacted fields; records only contain the redacted
+// value, filtering on it would never match
+func filterableFields(fields []*util.JSONField) []*util.JSONField {
+	filterable := make([]*util.JSONField, 0, len(fields))
+
+	for _, field := range fields {
+		if strings.Trim(field.Value, `"`) != util.Redacted {
+			filterable = append(filterable, field)
+		}
+	}
+
+	return filterable
+}
+
+// matchesWhere returns true if the payload passes the field filter set from
+// the line detail view (if any)
+func matchesWhere(data []byte, where *types.FieldFilter) bool {
+	if where == nil {
+		return true
+	}
+
+	return util.JSONPathEquals(data, where.Path, where.Value)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// checkDuplicate returns how many times the --id-field of a received payload
+// has been seen for its component (1 if it is not a duplicate); 0 if no ID
+// field is configured or the payload has no ID. Payloads are checked before
+// any client-side filtering so that duplicates are counted even if earlier
+// copies were not displayed.
+func (c *Cmd) checkDuplicate(data []byte, component *types.TailComponent) int {
+	if c.options.Config.IDField == "" {
+		return 0
+	}
+
+	value, err := util.GetJSONPath(data, c.options.Config.IDField)
+	if err != nil {
+		c.log.Debugf("unable to find ID field '%s': %s", c.options.Config.IDField, err)
+		return 0
+	}
+
+	id := fmt.Sprint(value)
+
+	// The same ID in different components is not a duplicate
+	if component != nil {
+		id = util.FormatAudience(component.Audience) + "\x00" + id
+	}
+
+	return c.duplicates.Seen(id)
+}
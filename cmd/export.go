@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/audit"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/export"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// Export can only be started from tail so we always go back to tail(). The
+// records that are currently visible (with highlights, bookmarks and notes),
+// or a range of them, are written to a standalone file.
+func (c *Cmd) actionExport(action *types.Action) (*types.Action, error) {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureExportTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	// Disable input capture while in export
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	action.Step = types.StepTail
+
+	now := time.Now()
+
+	// Default to exporting the selected line (if any)
+	var defaultLines string
+
+	if c.selectedLine != 0 {
+		defaultLines = strconv.Itoa(c.selectedLine)
+	}
+
+	filename := func(format string) string {
+		return export.Filename(action.TailComponent.Name, format, now)
+	}
+
+	// Channel used for reading resp from export dialog
+	answerCh := make(chan *types.ExportRequest)
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayExport(defaultLines, filename, answerCh)
+	})
+
+	req := <-answerCh
+
+	// Canceled
+	if req == nil {
+		return action, nil
+	}
+
+	from, to, err := util.ParseLineRange(req.Lines)
+	if err != nil {
+		c.writeBanner(c.textview, " Export failed: "+tview.Escape(err.Error()))
+		return action, nil
+	}
+
+	records := c.recordRange(action, from, to)
+
+	if err := c.writeExport(req.Path, req.Format, records, action, now); err != nil {
+		c.writeBanner(c.textview, " Export failed: "+tview.Escape(err.Error()))
+		return action, nil
+	}
+
+	c.audit(audit.ActionExported, map[string]string{
+		"path":   req.Path,
+		"format": req.Format,
+		"lines":  strconv.Itoa(export.CountLines(records)),
+	})
+
+	c.writeBanner(c.textview, fmt.Sprintf(" Exported %d lines to %s @ %s", export.CountLines(records), tview.Escape(req.Path), util.Clock(now)))
+
+	return action, nil
+}
+
+// recordRange returns the visible records with line numbers between from and
+// to (inclusive); banners are only included when all lines (0, 0) are requested
+func (c *Cmd) recordRange(action *types.Action, from, to int) []*types.TailRecord {
+	records := make([]*types.TailRecord, 0)
+
+	for _, record := range c.buffer.Records() {
+		if !recordVisible(record, action) {
+			continue
+		}
+
+		if from != 0 && (record.Banner != "" || record.LineNum < from || record.LineNum > to) {
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func (c *Cmd) writeExport(path, format string, records []*types.TailRecord, action *types.Action, now time.Time) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "unable to create export file")
+	}
+
+	defer f.Close()
+
+	opts := &export.Options{
+		Title:   action.TailComponent.Name,
+		Filter:  action.TailFilter,
+		Search:  action.TailSearch,
+		Created: now,
+	}
+
+	if err := export.Write(f, format, records, opts); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/expr"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// matcher matches messages against a filter or break expression: either a
+// CEL expression (see package expr) or, for anything that is not one, a
+// substring of the payload
+type matcher struct {
+	substring string
+	expr      *expr.Expr
+
+	// wholeWord is set if the substring only matches whole words (see
+	// termPattern()); highlight is matched instead of the substring then
+	wholeWord bool
+
+	// highlight matches the substring in displayed payloads; nil if there is
+	// nothing to highlight
+	highlight *regexp.Regexp
+}
+
+// matcherKey identifies a cached matcher
+type matcherKey struct {
+	text      string
+	wholeWord bool
+}
+
+func newMatcher(text string, wholeWord bool) *matcher {
+	m := &matcher{substring: text}
+
+	if text == "" {
+		return m
+	}
+
+	if !expr.IsExpression(text) {
+		m.wholeWord, m.highlight = wholeWord, termPattern(text, wholeWord)
+		return m
+	}
+
+	// IsExpression() already compiled it successfully
+	if e, err := expr.Compile(text); err == nil {
+		m.substring, m.expr = "", e
+	} else {
+		m.wholeWord, m.highlight = wholeWord, termPattern(text, wholeWord)
+	}
+
+	return m
+}
+
+// match returns true if msg matches; expressions that fail to evaluate (ex: a
+// field is missing from the payload) do not match
+func (m *matcher) match(msg *expr.Message) bool {
+	if m.wholeWord {
+		return m.highlight.Match(msg.Payload)
+	}
+
+	if m.expr == nil {
+		return strings.Contains(string(msg.Payload), m.substring)
+	}
+
+	matched, _ := m.expr.Match(msg)
+
+	return matched
+}
+
+// matcher returns the (cached) matcher for a filter or break expression so
+// that expressions are only compiled once
+func (c *Cmd) matcher(text string) *matcher {
+	return c.cachedMatcher(matcherKey{text: text})
+}
+
+// filterMatcher returns the (cached) matcher for the filter of the tail view,
+// which may only match whole words
+func (c *Cmd) filterMatcher(action *types.Action) *matcher {
+	return c.cachedMatcher(matcherKey{text: action.TailFilter, wholeWord: action.TailFilterWholeWord})
+}
+
+func (c *Cmd) cachedMatcher(key matcherKey) *matcher {
+	if m, ok := c.matchers[key]; ok {
+		return m
+	}
+
+	m := newMatcher(key.text, key.wholeWord)
+	c.matchers[key] = m
+
+	return m
+}
+
+// newMessage returns what filter and break expressions are evaluated against;
+// the timestamp is when the message was produced, if the server sent it
+func newMessage(data []byte, resp *protos.TailResponse) *expr.Message {
+	timestamp := time.Now()
+
+	if resp.TimestampNs > 0 {
+		timestamp = time.Unix(0, resp.TimestampNs)
+	}
+
+	return &expr.Message{
+		Payload:   data,
+		Metadata:  resp.XMetadata,
+		Timestamp: timestamp,
+	}
+}
+
+// testExpression describes how text will be interpreted and whether the last
+// received message matches it; displayed live in the filter and break dialogs
+func (c *Cmd) testExpression(text string, wholeWord bool) string {
+	last := c.lastMessage
+
+	if text == "" {
+		return "[gray]Substring or CEL expression (ex: payload.status == 500)[-]"
+	}
+
+	kind := "CEL expression"
+
+	if !expr.IsExpression(text) {
+		kind = "Substring"
+
+		// Point out typos in what is likely meant to be an expression
+		if _, err := expr.Compile(text); err != nil {
+			if _, syntaxErr, found := strings.Cut(err.Error(), "Syntax error: "); found {
+				kind = "Substring (CEL syntax error: " + firstLine(syntaxErr) + ")"
+			}
+		}
+	}
+
+	if wholeWord && kind != "CEL expression" {
+		kind = strings.Replace(kind, "Substring", "Whole-word substring", 1)
+	}
+
+	if last == nil {
+		return "[gray]" + kind + "; no message received yet[-]"
+	}
+
+	if kind != "CEL expression" {
+		if newMatcher(text, wholeWord).match(last) {
+			return "[" + console.Hex(console.StatusOK) + "]" + util.Glyph("✔ ", "ok ", "") + "[-]" + kind + "; matches the last message"
+		}
+
+		return "[" + console.Hex(console.StatusError) + "]" + util.Glyph("✘ ", "x ", "") + "[-]" + kind + "; does not match the last message"
+	}
+
+	e, err := expr.Compile(text)
+	if err != nil {
+		return "[" + console.Hex(console.StatusError) + "]" + util.Glyph("✘ ", "x ", "") + "[-]" + firstLine(err.Error())
+	}
+
+	matched, err := e.Match(last)
+
+	switch {
+	case err != nil:
+		return "[" + console.Hex(console.StatusError) + "]" + util.Glyph("✘ ", "x ", "") + "[-]" + kind + "; " + firstLine(err.Error())
+	case matched:
+		return "[" + console.Hex(console.StatusOK) + "]" + util.Glyph("✔ ", "ok ", "") + "[-]" + kind + "; matches the last message"
+	default:
+		return "[" + console.Hex(console.StatusError) + "]" + util.Glyph("✘ ", "x ", "") + "[-]" + kind + "; does not match the last message"
+	}
+}
+
+// firstLine returns the first line of an error, escaped for display
+func firstLine(text string) string {
+	return tview.Escape(strings.SplitN(text, "\n", 2)[0])
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/streamdal/cli/expr"
+)
+
+func TestMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		payload string
+		isExpr  bool
+		want    bool
+	}{
+		{name: "word", text: "error", payload: `{"status": "error"}`, want: true},
+		{name: "payload is a substring", text: "payload", payload: `{"payload": 1}`, want: true},
+		{name: "payload substring does not match", text: "payload", payload: `{"status": 500}`},
+		{name: "raw is a substring", text: "raw", payload: `raw bytes`, want: true},
+		{name: "non-bool field is a substring", text: "payload.status", payload: `payload.status = 500`, want: true},
+		{name: "literal is a substring", text: "true", payload: `{"ok": true}`, want: true},
+		{name: "comparison", text: "payload.status == 500", payload: `{"status": 500}`, isExpr: true, want: true},
+		{name: "comparison does not match", text: "payload.status == 500", payload: `{"status": 200}`, isExpr: true},
+		{name: "raw comparison", text: `raw.contains("bytes")`, payload: `raw bytes`, isExpr: true, want: true},
+		{name: "missing field", text: "payload.code == 1", payload: `{"status": 500}`, isExpr: true},
+		{name: "syntax error is a substring", text: "payload.status ==", payload: `payload.status == 1`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMatcher(tt.text, false)
+
+			if got := m.expr != nil; got != tt.isExpr {
+				t.Fatalf("newMatcher(%q) is an expression: %t, want %t", tt.text, got, tt.isExpr)
+			}
+
+			if got := m.match(&expr.Message{Payload: []byte(tt.payload)}); got != tt.want {
+				t.Fatalf("newMatcher(%q).match(%q) = %t, want %t", tt.text, tt.payload, got, tt.want)
+			}
+		})
+	}
+}
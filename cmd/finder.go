@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// The finder (Ctrl-P) can be opened from the select, tail and compare views.
+// Picking a component tails it right away; closing the finder goes back to the
+// view it was opened from.
+func (c *Cmd) actionFinder(action *types.Action) (*types.Action, error) {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureFinderTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	// Disable input capture while in finder
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	// Recently tailed components are listed first
+	recent := c.recent.copy()
+
+	// The last known components are displayed right away; the list is
+	// replaced once the live components have been fetched
+	updatesCh := c.fetchAudiences(recent)
+
+	// Channel used for reading resp from finder
+	answerCh := make(chan *protos.Audience)
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayFinder(recent.sort(c.audiences), updatesCh, answerCh)
+	})
+
+	audience := <-answerCh
+	if audience == nil {
+		action.Step = c.nav.current()
+		return action, nil
+	}
+
+	c.selectComponents(action, []*types.TailComponent{util.AudienceToTailComponent(audience)})
+
+	return action, nil
+}
+
+// fetchAudiences fetches the live audiences in the background, sorted by
+// recent; the channel is closed once they have been sent (or fetching failed)
+func (c *Cmd) fetchAudiences(recent *recentComponents) <-chan []*protos.Audience {
+	updatesCh := make(chan []*protos.Audience, 1)
+
+	crash.Go(func() {
+		defer close(updatesCh)
+
+		audiences, err := c.api.GetAllLiveAudiences(c.shutdownCtx)
+		if err != nil {
+			c.log.Errorf("unable to fetch live components: %s", err)
+			return
+		}
+
+		updatesCh <- recent.sort(audiences)
+	})
+
+	return updatesCh
+}
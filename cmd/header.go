@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// updateTailHeader refreshes the settings header that is pinned above the
+// tail view. The header replaces the banners that used to be written to the
+// tail view whenever a setting changed.
+func (c *Cmd) updateTailHeader(action *types.Action) {
+	c.options.Console.SetTailHeader(c.tailHeader(action))
+	c.updateTailChips(action)
+}
+
+// tailHeader summarizes the active tail settings; filter, search, sample rate
+// and pause state are always displayed, everything else only when set.
+func (c *Cmd) tailHeader(action *types.Action) string {
+	label := func(name, value string) string {
+		return fmt.Sprintf("[%s]%s:[-] %s", console.Hex(console.TextSecondary), name, value)
+	}
+
+	quoted := func(value string) string {
+		if value == "" {
+			return "[gray]none[-]"
+		}
+
+		return "[white]'" + tview.Escape(value) + "'[-]"
+	}
+
+	// Terms that only match whole words
+	term := func(value string, wholeWord bool) string {
+		if value == "" || !wholeWord {
+			return quoted(value)
+		}
+
+		return quoted(value) + " [gray](whole word)[-]"
+	}
+
+	state := "[" + console.Hex(console.StatusOK) + "::b]" + util.Glyph("● ", "* ", "") + "LIVE[-::-]"
+
+	if c.paused {
+		state = "[" + console.Hex(console.StatusWarning) + "::b]" + util.Glyph("❚❚ ", "|| ", "") + "PAUSED[-::-]"
+	}
+
+	if indicator := c.heartbeat.indicator(); indicator != "" {
+		state += " " + indicator
+	}
+
+	rate := "[gray]off[-]"
+
+	if action.TailRate > 0 {
+		rate = fmt.Sprintf("[white]%d/s[-]", action.TailRate)
+	}
+
+	entries := []string{
+		state,
+		label("Filter", term(action.TailFilter, action.TailFilterWholeWord)),
+		label("Search", term(action.TailSearch, action.TailSearchWholeWord)),
+		label("Sample rate", rate),
+	}
+
+	var componentFilters int
+
+	for _, component := range tailedComponents(action) {
+		if component.Filter != "" {
+			componentFilters++
+		}
+	}
+
+	if componentFilters > 0 {
+		entries = append(entries, label("Component filters", fmt.Sprintf("[white]%d[-]", componentFilters)))
+	}
+
+	// Displayed as tabs when split (see updateOperationTabs)
+	if action.TailOperation != protos.OperationType_OPERATION_TYPE_UNSET && !splitOperations(action) {
+		entries = append(entries, label("Operation", "[white]"+util.ProtosOperationTypeToStr(action.TailOperation)+" only[-]"))
+	}
+
+	if action.TailViewOptions != nil && action.TailViewOptions.Decimate > 1 {
+		entries = append(entries, label("Showing", fmt.Sprintf("[white]1 in %d[-]", action.TailViewOptions.Decimate)))
+	}
+
+	if action.TailViewOptions != nil && action.TailViewOptions.MaskSecrets {
+		entries = append(entries, label("Secrets", "[white]masked[-]"))
+	}
+
+	if action.TailViewOptions != nil && action.TailViewOptions.DetectPII {
+		entries = append(entries, label("PII", "[white]detecting[-]"))
+	}
+
+	if w := action.TailTimeWindow; w != nil {
+		entries = append(entries, label("Window", "[white]"+formatWindow(w)+"[-]"))
+	}
+
+	if action.TailTraceID != "" {
+		entries = append(entries, label("Trace", "[white]"+shortTraceID(action.TailTraceID)+"[-]"))
+	}
+
+	if w := action.TailWhere; w != nil {
+		entries = append(entries, label("Where", "[white]"+tview.Escape(w.Path+" == "+w.Value)+"[-]"))
+	}
+
+	if action.TailBreak != "" {
+		entries = append(entries, label("Break", quoted(action.TailBreak)))
+	}
+
+	return " " + strings.Join(entries, "  ")
+}
+
+// formatWindow describes a time window (ex: "since 14:02:00")
+func formatWindow(w *types.TimeWindow) string {
+	if w.To.IsZero() {
+		return "since " + util.Clock(w.From)
+	}
+
+	return util.Clock(w.From) + " - " + util.Clock(w.To)
+}
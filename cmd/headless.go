@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/api"
+	"github.com/streamdal/cli/demo"
+)
+
+// headlessCommands returns the handlers for all non-interactive commands,
+// keyed by their kong command path.
+func (c *Cmd) headlessCommands() map[string]func() error {
+	return map[string]func() error{
+		"tail":              c.runTail,
+		"capture":           c.runCapture,
+		"audience list":     c.runAudienceList,
+		"pipeline apply":    c.runPipelineApply,
+		"pipeline validate": c.runPipelineValidate,
+		"pipeline export":   c.runPipelineExport,
+		"config show":       c.runConfigShow,
+		"config path":       c.runConfigPath,
+		"auth login":        c.runAuthLogin,
+		"auth logout":       c.runAuthLogout,
+		"keys":              c.runKeys,
+		"update":            c.runUpdate,
+		"version":           c.runVersion,
+	}
+}
+
+// newHeadlessAPI connects to the server for non-interactive commands
+func (c *Cmd) newHeadlessAPI() (*api.API, error) {
+	opts, err := c.apiOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := api.New(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create server client")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.Config.ConnectTimeout)
+	defer cancel()
+
+	if err := a.Test(ctx); err != nil {
+		return nil, errors.Wrap(err, "unable to complete connection test")
+	}
+
+	return a, nil
+}
+
+// newHeadlessSource returns the data source for non-interactive commands that
+// only read audiences and payloads; in demo mode this is the demo generator and
+// with --source-file/--stdin it is the local source.
+func (c *Cmd) newHeadlessSource() (api.IAPI, error) {
+	if c.options.Config.LocalSource() {
+		return c.newLocalSource()
+	}
+
+	if !c.options.Config.Demo {
+		return c.newHeadlessAPI()
+	}
+
+	d, err := demo.New(&demo.Options{
+		Rate:        c.options.Config.DemoRate,
+		PayloadSize: c.options.Config.DemoPayloadSize,
+		Shape:       c.options.Config.DemoShape,
+		Logger:      c.log,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create demo generator")
+	}
+
+	return d, nil
+}
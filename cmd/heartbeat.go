@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/streamdal/cli/api"
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/util"
+)
+
+// HeartbeatInterval is how often the connection state is checked; the
+// heartbeat indicator in the tail header pulses on every check while the
+// connection is healthy (unless animations are disabled)
+const HeartbeatInterval = time.Second
+
+// heartbeat tracks the health of the connection to the server so that a
+// connection that is alive can be told from a broken one when the component
+// is silent. The server does not send keepalives on the tail stream, so the
+// state of the underlying gRPC connection (kept up to date by keepalive pings,
+// see --keepalive-interval, and failed stream receives) is used instead.
+type heartbeat struct {
+	source api.IConnState
+	ticker *time.Ticker
+	state  connectivity.State
+	beat   bool
+	static bool // do not pulse (--disable-animations)
+}
+
+// newHeartbeat returns a heartbeat for source; data sources without a
+// connection (demo, local files) have no heartbeat
+func newHeartbeat(source api.IAPI, static bool) *heartbeat {
+	h := &heartbeat{static: static}
+
+	if s, ok := source.(api.IConnState); ok {
+		h.source = s
+		h.state = s.ConnState()
+		h.ticker = time.NewTicker(HeartbeatInterval)
+	}
+
+	return h
+}
+
+// tick returns the channel used for periodic checks; nil (blocks forever) if
+// there is no connection
+func (h *heartbeat) tick() <-chan time.Time {
+	if h.ticker == nil {
+		return nil
+	}
+
+	return h.ticker.C
+}
+
+// check reads the connection state and advances the pulse
+func (h *heartbeat) check() {
+	h.state = h.source.ConnState()
+	h.beat = !h.beat && !h.static
+}
+
+func (h *heartbeat) stop() {
+	if h.ticker != nil {
+		h.ticker.Stop()
+	}
+}
+
+// indicator is displayed in the tail header next to the live/paused state
+func (h *heartbeat) indicator() string {
+	if h == nil || h.source == nil {
+		return ""
+	}
+
+	if h.state != connectivity.Ready {
+		return "[" + console.Hex(console.StatusError) + "]" + util.Glyph("♡ ", "", "connection ") + strings.ReplaceAll(strings.ToLower(h.state.String()), "_", " ") + "[-]"
+	}
+
+	// The pulse is only told apart by its color
+	if util.Accessible() {
+		return "connected"
+	}
+
+	heart := util.Glyph("♥", "<3", "")
+
+	// The color of the theme (see --theme)
+	if h.beat || h.static {
+		return "[" + console.Hex(console.TextAccent3) + "]" + heart + "[-]"
+	}
+
+	return "[" + console.DimHex(console.TextAccent3) + "]" + heart + "[-]"
+}
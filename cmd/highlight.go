@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/console"
+)
+
+// textStyle is the color of a part of the displayed text; empty colors are
+// the defaults of the view
+type textStyle struct {
+	fg, bg string
+}
+
+// tag returns the color tag that switches to the style
+func (s textStyle) tag() string {
+	fg, bg := s.fg, s.bg
+
+	if fg == "" {
+		fg = "-"
+	}
+
+	if bg == "" {
+		bg = "-"
+	}
+
+	return "[" + fg + ":" + bg + "]"
+}
+
+// styledText is a formatted payload with a style per byte. Payloads are
+// formatted before they are styled so that highlights are matched against the
+// text as displayed (never against color tags or the raw payload) and
+// compose: every highlight is painted over the previous ones.
+type styledText struct {
+	text    string
+	palette []textStyle // styles index palette; 0 is the default style
+	styles  []uint8
+}
+
+func newStyledText(text string) *styledText {
+	return &styledText{
+		text:    text,
+		palette: []textStyle{{}},
+		styles:  make([]uint8, len(text)),
+	}
+}
+
+// paint sets the style of text[start:end]
+func (t *styledText) paint(start, end int, style textStyle) {
+	idx := -1
+
+	for i, s := range t.palette {
+		if s == style {
+			idx = i
+			break
+		}
+	}
+
+	if idx < 0 {
+		// More styles than anyone can tell apart; keep the ones painted so far
+		if len(t.palette) > 255 {
+			return
+		}
+
+		t.palette = append(t.palette, style)
+		idx = len(t.palette) - 1
+	}
+
+	for i := start; i < end; i++ {
+		t.styles[i] = uint8(idx)
+	}
+}
+
+// paintMatches paints every match of re
+func (t *styledText) paintMatches(re *regexp.Regexp, style textStyle) {
+	for _, loc := range re.FindAllStringIndex(t.text, -1) {
+		t.paint(loc[0], loc[1], style)
+	}
+}
+
+// paintJSON colors the keys and values of a payload formatted as JSON with the
+// JSON colors of the theme
+func (t *styledText) paintJSON() {
+	var (
+		keyColor    = console.Hex(console.JSONKey)
+		stringColor = console.Hex(console.JSONString)
+		numberColor = console.Hex(console.JSONNumber)
+		boolColor   = console.Hex(console.JSONBool)
+		nullColor   = console.Hex(console.JSONNull)
+	)
+
+	text := t.text
+
+	for i := 0; i < len(text); {
+		c := text[i]
+
+		switch {
+		case c == '"':
+			end := i + 1
+
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			// Closing quote
+			if end < len(text) {
+				end++
+			}
+
+			color := stringColor
+
+			if strings.HasPrefix(strings.TrimLeft(text[end:], " "), ":") {
+				color = keyColor
+			}
+
+			t.paint(i, end, textStyle{fg: color})
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+
+			for end < len(text) && strings.IndexByte("0123456789.eE+-", text[end]) >= 0 {
+				end++
+			}
+
+			t.paint(i, end, textStyle{fg: numberColor})
+			i = end
+		case strings.HasPrefix(text[i:], "true"), strings.HasPrefix(text[i:], "false"):
+			end := i + strings.IndexByte(text[i:], 'e') + 1
+
+			t.paint(i, end, textStyle{fg: boolColor})
+			i = end
+		case strings.HasPrefix(text[i:], "null"):
+			t.paint(i, i+4, textStyle{fg: nullColor})
+			i += 4
+		default:
+			i++
+		}
+	}
+}
+
+// String returns the text with color tags; the text itself is escaped so that
+// brackets in payloads are not taken for tags
+func (t *styledText) String() string {
+	var (
+		sb      strings.Builder
+		current uint8
+		start   int
+	)
+
+	for i, s := range t.styles {
+		if s == current {
+			continue
+		}
+
+		sb.WriteString(tview.Escape(t.text[start:i]))
+		sb.WriteString(t.palette[s].tag())
+
+		current, start = s, i
+	}
+
+	sb.WriteString(tview.Escape(t.text[start:]))
+
+	if current != 0 {
+		sb.WriteString("[-:-]")
+	}
+
+	return sb.String()
+}
+
+// termPattern returns a regex matching term in payloads, whether formatted as
+// pretty JSON or not: whitespace in term matches any whitespace (ex: a line
+// break and the indentation that follows) and whitespace is optional around
+// JSON punctuation (ex: `"status":"ok"` and `"status": "ok"` match either
+// way). If wholeWord is set, a term that starts or ends with a letter, digit
+// or underscore does not match within a word (ex: "err" does not match
+// "error" or "stderr"); like \b, only ASCII letters are told apart.
+func termPattern(term string, wholeWord bool) *regexp.Regexp {
+	var sb strings.Builder
+
+	runes := []rune(term)
+
+	if wholeWord && len(runes) > 0 && isWordRune(runes[0]) {
+		sb.WriteString(`\b`)
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if unicode.IsSpace(r) {
+			start := i
+
+			for i+1 < len(runes) && unicode.IsSpace(runes[i+1]) {
+				i++
+			}
+
+			// Optional next to punctuation (ex: `"status": "ok"` matches
+			// `"status":"ok"`)
+			if (start > 0 && strings.ContainsRune("{[:,", runes[start-1])) || (i+1 < len(runes) && strings.ContainsRune("}]", runes[i+1])) {
+				sb.WriteString(`\s*`)
+			} else {
+				sb.WriteString(`\s+`)
+			}
+
+			continue
+		}
+
+		// Before closing brackets (ex: `1}` matches "1\n}")
+		if (r == '}' || r == ']') && i > 0 {
+			sb.WriteString(`\s*`)
+		}
+
+		sb.WriteString(regexp.QuoteMeta(string(r)))
+
+		// After opening brackets, colons and commas (ex: `1,"b"` matches
+		// "1,\n  \"b\"")
+		if strings.ContainsRune("{[:,", r) && i < len(runes)-1 && !unicode.IsSpace(runes[i+1]) {
+			sb.WriteString(`\s*`)
+		}
+	}
+
+	if wholeWord && len(runes) > 0 && isWordRune(runes[len(runes)-1]) {
+		sb.WriteString(`\b`)
+	}
+
+	return regexp.MustCompile(sb.String())
+}
+
+// isWordRune returns true if r is a word character for \b
+func isWordRune(r rune) bool {
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// filterStyle is the style of filter highlights
+func filterStyle() textStyle {
+	return textStyle{fg: console.Hex(console.FilterHighlightFg), bg: console.Hex(console.FilterHighlightBg)}
+}
+
+// searchStyle is the style of search highlights (except capture groups)
+func searchStyle() textStyle {
+	return textStyle{fg: console.Hex(console.SearchHighlightFg), bg: console.Hex(console.SearchHighlightBg)}
+}
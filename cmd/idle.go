@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// IdleCheckInterval is how often tail() checks whether the stream is idle
+const IdleCheckInterval = time.Second
+
+// idleDetector keeps track of when data was last received by tail()
+type idleDetector struct {
+	timeout      time.Duration
+	lastReceived time.Time
+	reported     bool
+	ticker       *time.Ticker
+	probeCh      chan string
+}
+
+// newIdleDetector returns a detector for the given --idle-timeout; a timeout
+// of 0 disables idle detection
+func newIdleDetector(timeout time.Duration) *idleDetector {
+	d := &idleDetector{
+		timeout:      timeout,
+		lastReceived: time.Now(),
+		probeCh:      make(chan string, 1),
+	}
+
+	if timeout > 0 {
+		d.ticker = time.NewTicker(IdleCheckInterval)
+	}
+
+	return d
+}
+
+// tick returns the channel used for periodic idle checks; nil (blocks
+// forever) if idle detection is disabled
+func (d *idleDetector) tick() <-chan time.Time {
+	if d.ticker == nil {
+		return nil
+	}
+
+	return d.ticker.C
+}
+
+// received records that data arrived; returns how long the stream was silent
+// and whether it was reported as idle
+func (d *idleDetector) received(now time.Time) (time.Duration, bool) {
+	since := now.Sub(d.lastReceived)
+	resumed := d.reported
+
+	d.lastReceived = now
+	d.reported = false
+
+	return since, resumed
+}
+
+func (d *idleDetector) stop() {
+	if d.ticker != nil {
+		d.ticker.Stop()
+	}
+}
+
+// checkIdle writes a banner (once per silence) when no data has arrived for
+// --idle-timeout and, with --idle-probe, checks why in the background
+func (c *Cmd) checkIdle(ctx context.Context, textView *tview.TextView, action *types.Action, idle *idleDetector) {
+	// Paused output is not displayed
+	if idle.reported || c.paused || time.Since(idle.lastReceived) < idle.timeout {
+		return
+	}
+
+	idle.reported = true
+
+	c.writeBanner(textView, fmt.Sprintf(" No data for %s (since %s)", idle.timeout, util.Clock(idle.lastReceived)))
+
+	if !c.options.Config.IdleProbe {
+		return
+	}
+
+	components := tailedComponents(action)
+
+	crash.Go(func() {
+		result := c.probeStream(ctx, components)
+
+		select {
+		case idle.probeCh <- result:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// probeStream tells a quiet component (still live, not sending anything) from
+// a broken stream (server unreachable or component no longer live)
+func (c *Cmd) probeStream(ctx context.Context, components []*types.TailComponent) string {
+	ctx, cancel := context.WithTimeout(ctx, c.options.Config.ConnectTimeout)
+	defer cancel()
+
+	audiences, err := c.api.GetAllLiveAudiences(ctx)
+	if err != nil {
+		return " Probe: unable to reach server: " + tview.Escape(err.Error())
+	}
+
+	offline := make([]string, 0)
+
+	for _, component := range components {
+		if !util.ContainsAudience(component.Audience, audiences) {
+			offline = append(offline, component.Name)
+		}
+	}
+
+	if len(offline) > 0 {
+		return fmt.Sprintf(" Probe: server is reachable but '%s' is no longer live", tview.Escape(strings.Join(offline, "', '")))
+	}
+
+	return " Probe: server is reachable and the component is live; it is quiet"
+}
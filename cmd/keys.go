@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+)
+
+// runKeys handles "keys"; the key bindings are printed from the same tables
+// the views dispatch keys from, including --key-binding overrides.
+func (c *Cmd) runKeys() error {
+	for i, view := range console.KeyViews() {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		if c.options.Config.Keys.Markdown {
+			fmt.Printf("#### %s\n\n", view.Name)
+			fmt.Println("| Key | Action | Description |")
+			fmt.Println("|-----|--------|-------------|")
+
+			for _, help := range view.Help() {
+				keys := make([]string, 0, len(help.Keys))
+
+				for _, key := range help.Keys {
+					keys = append(keys, "`"+key+"`")
+				}
+
+				fmt.Printf("| %s | %s | %s |\n", strings.Join(keys, ", "), markdownCell(help.Action), markdownCell(help.Description))
+			}
+
+			continue
+		}
+
+		fmt.Println(view.Name)
+
+		for _, help := range view.Help() {
+			fmt.Printf("  %-22s %-20s %s\n", strings.Join(help.Keys, ", "), help.Action, help.Description)
+		}
+	}
+
+	return nil
+}
+
+// markdownCell escapes pipes, which would end the cell of a Markdown table
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// The key bindings page can be opened from the tail and compare views; closing
+// it goes back to the view it was opened from.
+func (c *Cmd) actionKeys(action *types.Action) (*types.Action, error) {
+	// Disable input capture while in key bindings
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	// Channel used for reading resp from key bindings page
+	answerCh := make(chan struct{})
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayKeys(answerCh)
+	})
+
+	<-answerCh
+
+	action.Step = c.nav.current()
+
+	return action, nil
+}
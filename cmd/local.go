@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/local"
+)
+
+// newLocalSource creates the data source for --source-file and --stdin
+func (c *Cmd) newLocalSource() (*local.Local, error) {
+	opts := &local.Options{
+		Paths:  c.options.Config.SourceFile,
+		Follow: c.options.Config.Follow,
+		Logger: c.log,
+	}
+
+	if c.options.Config.Stdin {
+		// Reading a terminal would compete with the TUI for key presses
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return nil, errors.New("--stdin requires input to be piped (ex: some-producer | streamdal --stdin)")
+		}
+
+		opts.Stdin = os.Stdin
+	}
+
+	l, err := local.New(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create local source")
+	}
+
+	return l, nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/config"
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+)
+
+// DefaultMacroName is the name suggested for saving a recorded macro
+const DefaultMacroName = "default"
+
+// macro is the macro played with '@': the last one recorded (or set with
+// --macro)
+type macro struct {
+	name string
+	keys []*types.MacroKey
+
+	// startup is set if the macro was set with --macro and has not been
+	// played yet; it is played once the tail view is displayed
+	startup bool
+}
+
+// loadMacro returns the macro saved as name for --macro; nil if name is empty
+func loadMacro(name string) (*macro, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	macros, err := config.Macros()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read macros")
+	}
+
+	keys, ok := macros[name]
+	if !ok {
+		names := make([]string, 0, len(macros))
+
+		for n := range macros {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+
+		if len(names) == 0 {
+			return nil, errors.Errorf("unknown macro '%s' (no macros have been saved)", name)
+		}
+
+		return nil, errors.Errorf("unknown macro '%s' (saved: %s)", name, strings.Join(names, ", "))
+	}
+
+	return &macro{name: name, keys: keys, startup: true}, nil
+}
+
+// Recording starts with the macro-record key and stops with the same key;
+// every key pressed in between (in any view or dialog) is part of the macro.
+// Recorded macros can be saved under a name for --macro.
+func (c *Cmd) actionMacroRecord(action *types.Action) (*types.Action, error) {
+	action.Step = c.nav.current()
+
+	recordKey := console.BoundKey("macro-record")
+
+	if !c.options.Console.Recording() {
+		c.options.Console.StartRecording()
+		c.options.Console.SetMenuEntryOn("Record")
+		c.options.Console.ShowToast(fmt.Sprintf("Recording a macro (%s to stop)", recordKey))
+
+		return action, nil
+	}
+
+	keys := c.options.Console.StopRecording()
+	c.options.Console.SetMenuEntryOff("Record")
+
+	if len(keys) == 0 {
+		c.options.Console.ShowToast("Nothing was recorded")
+		return action, nil
+	}
+
+	// Disable input capture while in macro name
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	defaultName := DefaultMacroName
+
+	if c.macro != nil {
+		defaultName = c.macro.name
+	}
+
+	answerCh := make(chan string)
+
+	crash.Go(func() {
+		c.options.Console.DisplayMacroName(defaultName, answerCh)
+	})
+
+	name := <-answerCh
+
+	// Playable with the macro-play key either way
+	c.macro = &macro{name: name, keys: keys}
+
+	playKey := console.BoundKey("macro-play")
+
+	if name == "" {
+		c.options.Console.ShowToast(fmt.Sprintf("Recorded %d keys; not saved (%s to play)", len(keys), playKey))
+		return action, nil
+	}
+
+	if err := config.SaveMacro(name, keys); err != nil {
+		c.log.Errorf("unable to save macro: %s", err)
+		c.options.Console.ShowToast(fmt.Sprintf("Unable to save macro '%s' (%s to play): %s", name, playKey, err))
+
+		return action, nil
+	}
+
+	c.options.Console.ShowToast(fmt.Sprintf("Saved macro '%s' (%d keys); %s or --macro %s to play", name, len(keys), playKey, name))
+
+	return action, nil
+}
+
+// actionMacroPlay plays the last recorded macro
+func (c *Cmd) actionMacroPlay(action *types.Action) (*types.Action, error) {
+	action.Step = c.nav.current()
+
+	switch {
+	case c.options.Console.Recording():
+		// The macro would record itself
+		c.options.Console.ShowToast("Macros cannot be played while recording")
+	case c.macro == nil:
+		c.options.Console.ShowToast(fmt.Sprintf("No macro has been recorded (%s to record)", console.BoundKey("macro-record")))
+	default:
+		c.playMacro()
+	}
+
+	return action, nil
+}
+
+// playMacro plays the keys of the current macro in the background
+func (c *Cmd) playMacro() {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureMacroTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	c.macro.startup = false
+
+	if err := c.options.Console.PlayKeys(c.macro.keys); err != nil {
+		c.log.Errorf("unable to play macro '%s': %s", c.macro.name, err)
+		c.options.Console.ShowToast(fmt.Sprintf("Unable to play macro '%s': %s", c.macro.name, err))
+	}
+}
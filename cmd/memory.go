@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	// MemoryCheckInterval is how often the RSS of the CLI is compared to
+	// --memory-warning
+	MemoryCheckInterval = 5 * time.Second
+
+	// MemoryRearmRatio is the fraction of --memory-warning that the RSS must
+	// drop below before crossing the threshold is reported again
+	MemoryRearmRatio = 0.9
+)
+
+// runMemoryMonitor warns (once per crossing) when the RSS of the CLI exceeds
+// threshold so that output can be reduced before the OOM killer steps in
+func (c *Cmd) runMemoryMonitor(threshold int64) {
+	ticker := time.NewTicker(MemoryCheckInterval)
+	defer ticker.Stop()
+
+	warned := false
+
+	for {
+		select {
+		case <-ticker.C:
+			rss, err := util.RSS()
+			if err != nil {
+				c.log.Errorf("unable to determine memory usage: %s", err)
+				return
+			}
+
+			if warned && float64(rss) < float64(threshold)*MemoryRearmRatio {
+				warned = false
+			}
+
+			if warned || rss < threshold {
+				continue
+			}
+
+			warned = true
+
+			c.notify(fmt.Sprintf("Memory usage is %s (over %s): lower max lines (L, now %d) or the sample rate (R)",
+				util.HumanizeBytes(rss), util.HumanizeBytes(threshold), c.buffer.MaxRecords()))
+		case <-c.shutdownCtx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	// maxGroupNames is the max number of component names displayed in the
+	// title of an interleaved tail before falling back to a count
+	maxGroupNames = 3
+)
+
+// tailMessage is a tail response along with the component it was received for
+type tailMessage struct {
+	component *types.TailComponent
+	resp      *protos.TailResponse
+}
+
+// tailComponents starts a tail for every component in the action and
+// interleaves the responses into a single channel. Tails are stopped when ctx
+// is canceled.
+func (c *Cmd) tailComponents(ctx context.Context, action *types.Action) (<-chan *tailMessage, error) {
+	components := tailedComponents(action)
+
+	outCh := make(chan *tailMessage, len(components))
+
+	for _, component := range components {
+		tailCh, err := c.api.Tail(ctx, component.Audience)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to tail component '%s'", component.Name)
+		}
+
+		// Loop variables are shared between iterations
+		component := component
+
+		crash.Go(func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case resp, ok := <-tailCh:
+					if !ok {
+						return
+					}
+
+					select {
+					case outCh <- &tailMessage{component: component, resp: resp}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		})
+	}
+
+	return outCh, nil
+}
+
+// tailedComponents returns all components that are tailed by an action
+func tailedComponents(action *types.Action) []*types.TailComponent {
+	if len(action.TailComponents) > 0 {
+		return action.TailComponents
+	}
+
+	return []*types.TailComponent{action.TailComponent}
+}
+
+// assignComponentColors gives every component a color from
+// console.ComponentColors. The color is derived from a hash of the audience so
+// that a component keeps its color across sessions; on collision, the next
+// unused color is picked so that components in the same group stay
+// distinguishable.
+func assignComponentColors(components []*types.TailComponent) {
+	used := make(map[int]bool)
+	numColors := len(console.ComponentColors)
+
+	for _, component := range components {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(util.AudienceToStr(component.Audience)))
+
+		idx := int(h.Sum32() % uint32(numColors))
+
+		// Only probe if there are unused colors left
+		if len(used) < numColors {
+			for used[idx] {
+				idx = (idx + 1) % numColors
+			}
+		}
+
+		used[idx] = true
+		component.Color = console.HexColor(console.ComponentColors[idx])
+	}
+}
+
+// groupComponent returns a component that describes several components that
+// are tailed together; it is used for the tail view title and breadcrumb.
+func groupComponent(components []*types.TailComponent) *types.TailComponent {
+	names := make([]string, 0)
+	descriptions := make([]string, 0)
+
+	for _, component := range components {
+		names = append(names, component.Name)
+		descriptions = append(descriptions, component.Description)
+	}
+
+	name := strings.Join(names, " + ")
+
+	if len(names) > maxGroupNames {
+		name = fmt.Sprintf("%d components", len(names))
+	}
+
+	return &types.TailComponent{
+		Name:        name,
+		Description: strings.Join(descriptions, ", "),
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"github.com/streamdal/cli/types"
+)
+
+// navigation is a stack of the views (connect, select, tail, compare) the user has
+// visited, oldest first. Dialogs (filter, search, etc.) are not recorded as
+// they always return to the view they were opened from.
+type navigation struct {
+	stack []*types.Action
+}
+
+// isView returns true if the step displays a view (as opposed to a dialog)
+func isView(step types.Step) bool {
+	switch step {
+	case types.StepConnect, types.StepSelect, types.StepTail, types.StepCompare:
+		return true
+	default:
+		return false
+	}
+}
+
+// visit records that the action's view is being displayed. Re-visiting a
+// view that is already on the stack (ex: select -> tail -> select) unwinds the
+// stack back to it so that the stack never contains the same view twice.
+func (n *navigation) visit(action *types.Action) {
+	if !isView(action.Step) {
+		return
+	}
+
+	entry := copyAction(action)
+
+	for i, a := range n.stack {
+		if a.Step == action.Step {
+			n.stack = append(n.stack[:i], entry)
+			return
+		}
+	}
+
+	n.stack = append(n.stack, entry)
+}
+
+// back removes the current view from the stack and returns an action for
+// displaying the previous one. The settings of the given action (filter, view
+// options, etc.) are retained. If there is no previous view, the current view
+// is displayed again.
+func (n *navigation) back(action *types.Action) *types.Action {
+	switch len(n.stack) {
+	case 0:
+		action.Step = types.StepConnect
+	case 1:
+		action.Step = n.stack[0].Step
+	default:
+		n.stack = n.stack[:len(n.stack)-1]
+		action.Step = n.stack[len(n.stack)-1].Step
+	}
+
+	return action
+}
+
+// current returns the step of the view that is currently displayed
+func (n *navigation) current() types.Step {
+	if len(n.stack) == 0 {
+		return types.StepConnect
+	}
+
+	return n.stack[len(n.stack)-1].Step
+}
+
+// breadcrumbs returns the names of the views on the stack (oldest first)
+func (n *navigation) breadcrumbs() []string {
+	crumbs := make([]string, 0)
+
+	for _, a := range n.stack {
+		switch a.Step {
+		case types.StepConnect:
+			crumbs = append(crumbs, "Connect")
+		case types.StepSelect:
+			crumbs = append(crumbs, "Select")
+		case types.StepTail:
+			name := "Tail"
+
+			if a.TailComponent != nil {
+				name = a.TailComponent.Name
+			}
+
+			crumbs = append(crumbs, name)
+		case types.StepCompare:
+			crumbs = append(crumbs, "Compare")
+		}
+	}
+
+	return crumbs
+}
+
+// windowTitle returns the terminal window title for the current view (ex:
+// "streamdal: localhost:8082 / orders"); the component is only included in
+// the tail and compare views (and the dialogs opened from them)
+func (c *Cmd) windowTitle(action *types.Action) string {
+	title := "streamdal: " + serverName(c.options)
+
+	switch c.nav.current() {
+	case types.StepTail, types.StepCompare:
+		if action.TailComponent != nil {
+			title += " / " + action.TailComponent.Name
+		}
+	}
+
+	return title
+}
+
+// copyAction returns a shallow copy of an action so that later modifications
+// of the action (steps modify and pass along the same action) do not change
+// the recorded history.
+func copyAction(action *types.Action) *types.Action {
+	a := *action
+	return &a
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/streamdal/snitch-protos/build/go/protos"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamdal/cli/api"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// MaxNotifications is the number of notifications kept for review; older
+// notifications are discarded
+const MaxNotifications = 100
+
+// notifications are control-plane events reported while the CLI is running
+type notifications struct {
+	mu     sync.Mutex
+	list   []*types.Notification
+	peeked []*types.TailComponent // components currently being tailed
+	cancel context.CancelFunc     // stops watching the current server
+}
+
+// watchServer reports control-plane changes (clients registering, pipelines
+// changing, tailed components going offline) as notifications. Only the
+// server has a control plane; demo and local sources are not watched.
+func (c *Cmd) watchServer() {
+	w, ok := c.api.(api.IWatcher)
+	if !ok {
+		return
+	}
+
+	c.notifications.mu.Lock()
+
+	// Reconnecting replaces the watcher of the previous connection
+	if c.notifications.cancel != nil {
+		c.notifications.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(c.shutdownCtx)
+	c.notifications.cancel = cancel
+
+	c.notifications.mu.Unlock()
+
+	stateCh, err := w.WatchAll(ctx)
+	if err != nil {
+		c.log.Errorf("unable to watch server for changes: %s", err)
+		return
+	}
+
+	crash.Go(func() {
+		var prev *protos.GetAllResponse
+
+		for state := range stateCh {
+			if prev != nil {
+				c.notifications.mu.Lock()
+				peeked := c.notifications.peeked
+				c.notifications.mu.Unlock()
+
+				for _, text := range serverChanges(prev, state, peeked) {
+					c.notify(text)
+				}
+			}
+
+			prev = state
+		}
+	})
+}
+
+// setPeeked sets the components that are checked for going offline
+func (c *Cmd) setPeeked(components []*types.TailComponent) {
+	c.notifications.mu.Lock()
+	defer c.notifications.mu.Unlock()
+
+	c.notifications.peeked = components
+}
+
+// notify records a notification and displays it as a toast
+func (c *Cmd) notify(text string) {
+	c.notifications.mu.Lock()
+
+	c.notifications.list = append(c.notifications.list, &types.Notification{
+		Time: time.Now(),
+		Text: text,
+	})
+
+	if len(c.notifications.list) > MaxNotifications {
+		c.notifications.list = c.notifications.list[len(c.notifications.list)-MaxNotifications:]
+	}
+
+	c.notifications.mu.Unlock()
+
+	c.options.Console.ShowToast(text)
+}
+
+// Notifications can only be reviewed from tail so we always go back to tail()
+func (c *Cmd) actionNotifications(action *types.Action) (*types.Action, error) {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureNotificationsTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	// Disable input capture while in notifications
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	c.notifications.mu.Lock()
+	list := append([]*types.Notification(nil), c.notifications.list...)
+	c.notifications.mu.Unlock()
+
+	// Channel used for reading resp from notifications dialog
+	answerCh := make(chan struct{})
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayNotifications(list, answerCh)
+	})
+
+	<-answerCh
+
+	action.Step = types.StepTail
+
+	return action, nil
+}
+
+// serverChanges describes the control-plane changes between two snapshots of
+// the server state; peeked components are reported when they go offline or
+// come back online.
+func serverChanges(prev, cur *protos.GetAllResponse, peeked []*types.TailComponent) []string {
+	changes := make([]string, 0)
+
+	// Clients
+	prevClients, curClients := liveClients(prev), liveClients(cur)
+
+	for _, id := range clientIDs(curClients) {
+		if _, ok := prevClients[id]; !ok {
+			changes = append(changes, fmt.Sprintf("Client '%s' registered", clientName(curClients[id])))
+		}
+	}
+
+	for _, id := range clientIDs(prevClients) {
+		if _, ok := curClients[id]; !ok {
+			changes = append(changes, fmt.Sprintf("Client '%s' deregistered", clientName(prevClients[id])))
+		}
+	}
+
+	// Pipelines
+	for _, id := range pipelineIDs(cur.Pipelines) {
+		info := cur.Pipelines[id]
+		name := info.GetPipeline().GetName()
+
+		prevInfo, ok := prev.Pipelines[id]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("Pipeline '%s' created", name))
+			continue
+		}
+
+		if !proto.Equal(prevInfo.GetPipeline(), info.GetPipeline()) {
+			changes = append(changes, fmt.Sprintf("Pipeline '%s' updated", name))
+		}
+
+		changes = append(changes, audienceChanges(prevInfo.Audiences, info.Audiences, "Pipeline '"+name+"' attached to '%s'", "Pipeline '"+name+"' detached from '%s'")...)
+		changes = append(changes, audienceChanges(prevInfo.Paused, info.Paused, "Pipeline '"+name+"' paused for '%s'", "Pipeline '"+name+"' resumed for '%s'")...)
+	}
+
+	for _, id := range pipelineIDs(prev.Pipelines) {
+		if _, ok := cur.Pipelines[id]; !ok {
+			changes = append(changes, fmt.Sprintf("Pipeline '%s' deleted", prev.Pipelines[id].GetPipeline().GetName()))
+		}
+	}
+
+	// Tailed components
+	prevLive, curLive := liveAudiences(prev), liveAudiences(cur)
+
+	for _, component := range peeked {
+		wasLive, isLive := util.ContainsAudience(component.Audience, prevLive), util.ContainsAudience(component.Audience, curLive)
+
+		if wasLive && !isLive {
+			changes = append(changes, fmt.Sprintf("Component '%s' went offline", component.Name))
+		}
+
+		if !wasLive && isLive {
+			changes = append(changes, fmt.Sprintf("Component '%s' is back online", component.Name))
+		}
+	}
+
+	return changes
+}
+
+// audienceChanges returns addedFmt/removedFmt for every audience that was
+// added to/removed from prev
+func audienceChanges(prev, cur []*protos.Audience, addedFmt, removedFmt string) []string {
+	changes := make([]string, 0)
+
+	for _, aud := range cur {
+		if !util.ContainsAudience(aud, prev) {
+			changes = append(changes, fmt.Sprintf(addedFmt, util.FormatAudience(aud)))
+		}
+	}
+
+	for _, aud := range prev {
+		if !util.ContainsAudience(aud, cur) {
+			changes = append(changes, fmt.Sprintf(removedFmt, util.FormatAudience(aud)))
+		}
+	}
+
+	return changes
+}
+
+// liveClients returns the connected clients keyed by session ID
+func liveClients(state *protos.GetAllResponse) map[string]*protos.ClientInfo {
+	clients := make(map[string]*protos.ClientInfo)
+
+	for _, live := range state.Live {
+		if id := live.GetClient().GetXSessionId(); id != "" {
+			clients[id] = live.GetClient()
+		}
+	}
+
+	return clients
+}
+
+// liveAudiences returns the audiences announced by connected clients
+func liveAudiences(state *protos.GetAllResponse) []*protos.Audience {
+	audiences := make([]*protos.Audience, 0)
+
+	for _, live := range state.Live {
+		audiences = append(audiences, live.Audiences...)
+	}
+
+	return audiences
+}
+
+// clientName returns a human readable name for a client (ex: "billing on node-1")
+func clientName(client *protos.ClientInfo) string {
+	name := client.GetXServiceName()
+
+	if name == "" {
+		name = client.GetXSessionId()
+	}
+
+	if node := client.GetXNodeName(); node != "" {
+		name += " on " + node
+	}
+
+	return name
+}
+
+// clientIDs returns the session IDs of clients in order so that
+// notifications are deterministic
+func clientIDs(clients map[string]*protos.ClientInfo) []string {
+	ids := make([]string, 0, len(clients))
+
+	for id := range clients {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// pipelineIDs returns the IDs of pipelines in order so that notifications are
+// deterministic
+func pipelineIDs(pipelines map[string]*protos.PipelineInfo) []string {
+	ids := make([]string, 0, len(pipelines))
+
+	for id := range pipelines {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// When the tailed components include both producers and consumers, the tail
+// view is split into one tab per operation type so that reads and writes are
+// not mixed in one stream. Messages of the other tab are still received (and
+// buffered); Tab switches between the tabs. "I" cycles the operation type
+// that is displayed (all, producer, consumer) with or without tabs.
+
+// tabOperations are the operation types that have a tab, in display order
+var tabOperations = []protos.OperationType{
+	protos.OperationType_OPERATION_TYPE_PRODUCER,
+	protos.OperationType_OPERATION_TYPE_CONSUMER,
+}
+
+// cycleOperations is the order in which "I" cycles the displayed operation
+// type; unset displays all messages
+var cycleOperations = []protos.OperationType{
+	protos.OperationType_OPERATION_TYPE_UNSET,
+	protos.OperationType_OPERATION_TYPE_PRODUCER,
+	protos.OperationType_OPERATION_TYPE_CONSUMER,
+}
+
+// splitOperations returns true if the tailed components include both
+// producers and consumers
+func splitOperations(action *types.Action) bool {
+	for _, op := range tabOperations {
+		if !tailsOperation(action, op) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tailsOperation returns true if one of the tailed components has the given
+// operation type
+func tailsOperation(action *types.Action, op protos.OperationType) bool {
+	for _, component := range tailedComponents(action) {
+		if component != nil && component.Audience.GetOperationType() == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultOperation returns the tab displayed when components are selected:
+// the first tab if they are split into tabs, otherwise none (all messages)
+func defaultOperation(action *types.Action) protos.OperationType {
+	if splitOperations(action) {
+		return tabOperations[0]
+	}
+
+	return protos.OperationType_OPERATION_TYPE_UNSET
+}
+
+// operationVisible returns true if the record belongs to the active tab
+func operationVisible(record *types.TailRecord, action *types.Action) bool {
+	if action.TailOperation == protos.OperationType_OPERATION_TYPE_UNSET || record.Component == nil {
+		return true
+	}
+
+	return record.Component.Audience.GetOperationType() == action.TailOperation
+}
+
+// countUnseen counts a record received for the inactive tab so that the tab
+// can show how many messages were not seen yet. The tabs are refreshed right
+// away for the first one only, then every StatsInterval (see tail()) so that
+// busy components do not trigger a redraw per message.
+func (c *Cmd) countUnseen(record *types.TailRecord, action *types.Action) {
+	if operationVisible(record, action) {
+		return
+	}
+
+	op := record.Component.Audience.GetOperationType()
+
+	c.unseen[op]++
+
+	if c.unseen[op] == 1 {
+		c.updateOperationTabs(action)
+	}
+}
+
+// switchOperationTab displays the next tab (the first one if all messages are
+// displayed); if the tail view is not split into tabs, only explains why
+func (c *Cmd) switchOperationTab(textView *tview.TextView, action *types.Action) {
+	if !splitOperations(action) {
+		c.options.Console.ShowToast("Tabs are only displayed when both producers and consumers are tailed (I: cycle operation type)")
+		return
+	}
+
+	c.setOperation(textView, action, nextOperation(tabOperations, action.TailOperation))
+}
+
+// cycleOperation switches the displayed operation type: all -> producer ->
+// consumer -> all
+func (c *Cmd) cycleOperation(textView *tview.TextView, action *types.Action) {
+	op := nextOperation(cycleOperations, action.TailOperation)
+
+	c.setOperation(textView, action, op)
+
+	if op == protos.OperationType_OPERATION_TYPE_UNSET {
+		c.options.Console.ShowToast("Displaying all operations")
+		return
+	}
+
+	name := util.ProtosOperationTypeToStr(op)
+	toast := fmt.Sprintf("Displaying %s messages only", name)
+
+	if !tailsOperation(action, op) {
+		toast += fmt.Sprintf(" (none of the tailed components is a %s)", name)
+	}
+
+	c.options.Console.ShowToast(toast)
+}
+
+// setOperation only displays messages of the given operation type (all if
+// unset) and re-renders the tail view
+func (c *Cmd) setOperation(textView *tview.TextView, action *types.Action, op protos.OperationType) {
+	action.TailOperation = op
+	c.unseen[op] = 0
+
+	// Every message is displayed
+	if op == protos.OperationType_OPERATION_TYPE_UNSET {
+		c.unseen = make(map[protos.OperationType]int)
+	}
+
+	// The selected line may not be displayed anymore
+	c.selectedLine = 0
+
+	c.renderTail(textView, action)
+	c.updateTailHeader(action)
+	c.updateOperationTabs(action)
+}
+
+// nextOperation returns the operation type following current in ops; the
+// first one if current is not in ops
+func nextOperation(ops []protos.OperationType, current protos.OperationType) protos.OperationType {
+	for i, op := range ops {
+		if op == current {
+			return ops[(i+1)%len(ops)]
+		}
+	}
+
+	return ops[0]
+}
+
+// updateOperationTabs updates (or hides) the tabs above the tail view; "All"
+// is only listed while all messages are displayed (see cycleOperation)
+func (c *Cmd) updateOperationTabs(action *types.Action) {
+	if !splitOperations(action) {
+		c.options.Console.SetTailTabs("")
+		return
+	}
+
+	ops := tabOperations
+
+	if action.TailOperation == protos.OperationType_OPERATION_TYPE_UNSET {
+		ops = cycleOperations
+	}
+
+	tabs := make([]string, 0, len(ops))
+
+	for _, op := range ops {
+		name := operationTabName(op)
+
+		if op == action.TailOperation {
+			tabs = append(tabs, fmt.Sprintf("[%s:%s:b] %s [-:-:-]",
+				console.Hex(console.ActiveButtonFg), console.Hex(console.ActiveButtonBg), name))
+
+			continue
+		}
+
+		if n := c.unseen[op]; n > 0 {
+			name += fmt.Sprintf(" [%s](%d new)[-]", console.Hex(console.StatusWarning), n)
+		}
+
+		tabs = append(tabs, fmt.Sprintf("[%s] %s [-]", console.Hex(console.TextSecondary), name))
+	}
+
+	c.options.Console.SetTailTabs(" " + strings.Join(tabs, " ") + " [gray](Tab: switch)[-]")
+}
+
+// operationTabName returns the tab label of an operation type (ex: "Producers")
+func operationTabName(op protos.OperationType) string {
+	switch op {
+	case protos.OperationType_OPERATION_TYPE_PRODUCER:
+		return "Producers"
+	case protos.OperationType_OPERATION_TYPE_CONSUMER:
+		return "Consumers"
+	default:
+		return "All"
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/audit"
+	"github.com/streamdal/cli/pipeline"
+)
+
+// runPipelineValidate handles "pipeline validate -f <file>"
+func (c *Cmd) runPipelineValidate() error {
+	file := c.options.Config.Pipeline.Validate.File
+
+	p, err := pipeline.Load(file)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load pipeline from '%s'", file)
+	}
+
+	if err := pipeline.Validate(p); err != nil {
+		return errors.Wrapf(err, "pipeline '%s' is invalid", file)
+	}
+
+	fmt.Printf("Pipeline '%s' in '%s' is valid\n", p.Name, file)
+
+	return nil
+}
+
+// runPipelineApply handles "pipeline apply -f <file>"; the pipeline is updated
+// if one with the same ID (or name) already exists, otherwise it is created.
+func (c *Cmd) runPipelineApply() error {
+	file := c.options.Config.Pipeline.Apply.File
+
+	p, err := pipeline.Load(file)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load pipeline from '%s'", file)
+	}
+
+	if err := pipeline.Validate(p); err != nil {
+		return errors.Wrapf(err, "pipeline '%s' is invalid", file)
+	}
+
+	a, err := c.newHeadlessAPI()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.Config.ConnectTimeout)
+	defer cancel()
+
+	existing, err := a.GetPipelines(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to fetch existing pipelines")
+	}
+
+	if p.Id == "" {
+		for _, e := range existing {
+			if e.Name == p.Name {
+				p.Id = e.Id
+				break
+			}
+		}
+	}
+
+	if p.Id == "" {
+		id, err := a.CreatePipeline(ctx, p)
+		if err != nil {
+			return errors.Wrapf(err, "unable to create pipeline '%s'", p.Name)
+		}
+
+		c.audit(audit.ActionPipelineApplied, map[string]string{"name": p.Name, "id": id, "file": file})
+
+		fmt.Printf("Created pipeline '%s' (id: %s)\n", p.Name, id)
+
+		return nil
+	}
+
+	if err := a.UpdatePipeline(ctx, p); err != nil {
+		return errors.Wrapf(err, "unable to update pipeline '%s'", p.Name)
+	}
+
+	c.audit(audit.ActionPipelineApplied, map[string]string{"name": p.Name, "id": p.Id, "file": file})
+
+	fmt.Printf("Updated pipeline '%s' (id: %s)\n", p.Name, p.Id)
+
+	return nil
+}
+
+// runPipelineExport handles "pipeline export"; each pipeline is written to its
+// own file in the same format that is accepted by "pipeline apply".
+func (c *Cmd) runPipelineExport() error {
+	opts := c.options.Config.Pipeline.Export
+
+	a, err := c.newHeadlessAPI()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.Config.ConnectTimeout)
+	defer cancel()
+
+	pipelines, err := a.GetPipelines(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to fetch pipelines")
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return errors.Wrapf(err, "unable to create directory '%s'", opts.Dir)
+	}
+
+	var exported int
+
+	for _, p := range pipelines {
+		if opts.Name != "" && p.Name != opts.Name {
+			continue
+		}
+
+		data, err := pipeline.Marshal(p, opts.Format)
+		if err != nil {
+			return errors.Wrapf(err, "unable to marshal pipeline '%s'", p.Name)
+		}
+
+		path := filepath.Join(opts.Dir, pipeline.Filename(p, opts.Format))
+
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return errors.Wrapf(err, "unable to write pipeline file '%s'", path)
+		}
+
+		fmt.Printf("Exported pipeline '%s' to '%s'\n", p.Name, path)
+
+		exported++
+	}
+
+	if opts.Name != "" && exported == 0 {
+		return errors.Errorf("pipeline '%s' not found", opts.Name)
+	}
+
+	return nil
+}
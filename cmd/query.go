@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/query"
+	"github.com/streamdal/cli/types"
+)
+
+// DefaultQuery is displayed the first time the query page is opened
+const DefaultQuery = "SELECT * FROM " + query.DefaultSource + " LIMIT 100"
+
+// Queries can only be run from tail so we always go back to tail(). Queries
+// only read the buffer; the tail view is left as-is.
+func (c *Cmd) actionQuery(action *types.Action) (*types.Action, error) {
+	action.Step = types.StepTail
+
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureQueryTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	// Disable input capture while in query view
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	if c.query == "" {
+		c.query = DefaultQuery
+	}
+
+	// Channel used for reading resp from query view
+	answerCh := make(chan string)
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayQuery(c.query, c.runQuery, answerCh)
+	})
+
+	// Remember the query for the next time the page is opened
+	c.query = <-answerCh
+
+	return action, nil
+}
+
+// runQuery parses and runs a query; called by the query page
+func (c *Cmd) runQuery(text string) (*query.Result, error) {
+	q, err := query.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.Run(c.querySource)
+}
+
+// querySource returns the records of the source named in FROM: the buffer or
+// a snapshot
+func (c *Cmd) querySource(name string) ([]*types.TailRecord, error) {
+	if strings.EqualFold(name, query.DefaultSource) {
+		return c.buffer.Records(), nil
+	}
+
+	if snapshot := c.snapshot(name); snapshot != nil {
+		return snapshot.Records, nil
+	}
+
+	available := []string{query.DefaultSource}
+
+	for _, snapshot := range c.snapshots {
+		available = append(available, snapshot.Name)
+	}
+
+	return nil, errors.Errorf("unknown source '%s' (available: %s)", name, strings.Join(available, ", "))
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/api"
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/util"
+)
+
+// SparklineSamples is the number of rates (one per update from the server)
+// displayed per component in the select list; two samples per character
+const SparklineSamples = 20
+
+// rateHistory keeps the recent msgs/sec of every audience. It is kept across
+// visits to the select list so that the sparklines are not empty when going
+// back to it.
+type rateHistory struct {
+	samples map[string][]float64 // util.AudienceToStr() => msgs/sec, oldest first
+	mu      sync.Mutex
+}
+
+func newRateHistory() *rateHistory {
+	return &rateHistory{
+		samples: make(map[string][]float64),
+	}
+}
+
+// add records the rates of an update; audiences that are missing from it
+// (ex: the client went offline) are recorded as idle
+func (h *rateHistory) add(resp *protos.GetAudienceRatesResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key := range h.samples {
+		if _, ok := resp.GetRates()[key]; !ok {
+			h.samples[key] = append(h.samples[key], 0)
+		}
+	}
+
+	for key, rate := range resp.GetRates() {
+		h.samples[key] = append(h.samples[key], float64(rate.GetProcessed()))
+	}
+
+	for key, samples := range h.samples {
+		if len(samples) > SparklineSamples {
+			h.samples[key] = samples[len(samples)-SparklineSamples:]
+		}
+	}
+}
+
+// sparklines returns the sparkline and current rate of every audience. All
+// sparklines share the same scale so that busy components stand out.
+func (h *rateHistory) sparklines() map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var max float64
+
+	for _, samples := range h.samples {
+		for _, v := range samples {
+			if v > max {
+				max = v
+			}
+		}
+	}
+
+	lines := make(map[string]string, len(h.samples))
+
+	for key, samples := range h.samples {
+		if len(samples) == 0 {
+			continue
+		}
+
+		// The rate is enough in screen reader friendly mode
+		if util.Accessible() {
+			lines[key] = fmt.Sprintf("[%s]%s[-]", console.Hex(console.TextSecondary), formatRate(samples[len(samples)-1]))
+			continue
+		}
+
+		// Right-align so that the latest samples line up
+		padded := make([]float64, SparklineSamples-len(samples), SparklineSamples)
+		padded = append(padded, samples...)
+
+		lines[key] = fmt.Sprintf("[%s]%s[-] [%s]%s[-]", console.Hex(console.TextAccent2), util.Sparkline(padded, max),
+			console.Hex(console.TextSecondary), formatRate(samples[len(samples)-1]))
+	}
+
+	return lines
+}
+
+// watchRates streams the sparklines displayed in the select list until ctx is
+// canceled; nil if the data source does not report audience rates
+func (c *Cmd) watchRates(ctx context.Context) <-chan map[string]string {
+	r, ok := c.api.(api.IRates)
+	if !ok {
+		return nil
+	}
+
+	respCh, err := r.WatchAudienceRates(ctx)
+	if err != nil {
+		c.log.Errorf("unable to watch audience rates: %s", err)
+		return nil
+	}
+
+	sparklinesCh := make(chan map[string]string, 1)
+
+	crash.Go(func() {
+		defer close(sparklinesCh)
+
+		// Display what is known from the previous visit right away
+		prev := c.rates.sparklines()
+		sparklinesCh <- prev
+
+		for resp := range respCh {
+			c.rates.add(resp)
+
+			// The list is not redrawn when nothing changed (ex: idle
+			// components); screen readers would also read it out again
+			lines := c.rates.sparklines()
+
+			if sameSparklines(prev, lines) {
+				continue
+			}
+
+			prev = lines
+
+			select {
+			case sparklinesCh <- lines:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return sparklinesCh
+}
+
+// formatRate formats msgs/sec compactly (ex: "12/s", "1.5k/s")
+func formatRate(rate float64) string {
+	return util.FormatRate(rate) + "/s"
+}
+
+// sameSparklines returns true if a and b display the same lines
+func sameSparklines(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for key, line := range a {
+		if b[key] != line {
+			return false
+		}
+	}
+
+	return true
+}
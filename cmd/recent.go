@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// MaxRecentComponents is the number of recently tailed components (or groups
+// of components) that are remembered
+const MaxRecentComponents = 10
+
+// recentComponents lists the recently tailed components, most recent first.
+// Every entry is what was tailed at once (a single component or a group).
+type recentComponents struct {
+	entries [][]*types.TailComponent
+}
+
+// add moves components to the front of the list
+func (r *recentComponents) add(components []*types.TailComponent) {
+	key := recentKey(components)
+
+	for i, entry := range r.entries {
+		if recentKey(entry) == key {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			break
+		}
+	}
+
+	r.entries = append([][]*types.TailComponent{components}, r.entries...)
+
+	if len(r.entries) > MaxRecentComponents {
+		r.entries = r.entries[:MaxRecentComponents]
+	}
+}
+
+// copy returns a copy that is not modified by later calls to add() (ex: for
+// use in another goroutine)
+func (r *recentComponents) copy() *recentComponents {
+	return &recentComponents{
+		entries: append([][]*types.TailComponent(nil), r.entries...),
+	}
+}
+
+// previous returns the components that were tailed before the current ones;
+// nil if there are none
+func (r *recentComponents) previous() []*types.TailComponent {
+	if len(r.entries) < 2 {
+		return nil
+	}
+
+	return r.entries[1]
+}
+
+// sort returns audiences with the recently tailed ones first (most recent
+// first); the order of the other audiences is retained
+func (r *recentComponents) sort(audiences []*protos.Audience) []*protos.Audience {
+	sorted := make([]*protos.Audience, 0, len(audiences))
+	added := make(map[string]bool)
+
+	for _, entry := range r.entries {
+		for _, component := range entry {
+			key := util.AudienceToStr(component.Audience)
+
+			for _, aud := range audiences {
+				if !added[key] && util.AudienceToStr(aud) == key {
+					sorted = append(sorted, aud)
+					added[key] = true
+				}
+			}
+		}
+	}
+
+	for _, aud := range audiences {
+		if !added[util.AudienceToStr(aud)] {
+			sorted = append(sorted, aud)
+		}
+	}
+
+	return sorted
+}
+
+func recentKey(components []*types.TailComponent) string {
+	keys := make([]string, 0, len(components))
+
+	for _, component := range components {
+		keys = append(keys, util.AudienceToStr(component.Audience))
+	}
+
+	return strings.Join(keys, ",")
+}
+
+// Switching can only be done from tail; like alternate-file in vim, switching
+// again goes back to the component that was just left.
+func (c *Cmd) actionRecentSwitch(action *types.Action) (*types.Action, error) {
+	previous := c.recent.previous()
+	if previous == nil {
+		action.Step = types.StepTail
+		c.options.Console.ShowToast("No previously tailed component to switch to")
+
+		return action, nil
+	}
+
+	c.selectComponents(action, previous)
+
+	return action, nil
+}
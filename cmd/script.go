@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/expr"
+	"github.com/streamdal/cli/script"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// scriptConnect calls the on_connect hook of --script for every tailed
+// component; the tail is restarted whenever settings change, so the hook is
+// only called when what is tailed changes. Returned strings are displayed as
+// banners.
+func (c *Cmd) scriptConnect(textView *tview.TextView, action *types.Action) {
+	components := tailedComponents(action)
+
+	if c.script == nil || recentKey(components) == c.scriptTailed {
+		return
+	}
+
+	c.scriptTailed = recentKey(components)
+
+	for _, component := range components {
+		banner, err := c.script.OnConnect(component.Name)
+		if err != nil {
+			c.log.Debugf("script: %s", err)
+			c.writeBanner(textView, " Script error: "+tview.Escape(err.Error()))
+
+			continue
+		}
+
+		if banner != "" {
+			c.writeBanner(textView, " "+tview.Escape(banner))
+		}
+	}
+}
+
+// scriptMessage calls the on_message hook of --script (if any) for a message;
+// nil is returned if there is no hook. The first failure is displayed as a
+// banner (if textView is set); failures are debug logged either way since
+// hooks fail for every message alike.
+func (c *Cmd) scriptMessage(textView *tview.TextView, message *expr.Message, component *types.TailComponent) *script.Result {
+	if c.script == nil {
+		return nil
+	}
+
+	var name string
+
+	if component != nil {
+		name = component.Name
+	}
+
+	result := c.script.OnMessage(message, name)
+	if result == nil || result.Error == "" {
+		return result
+	}
+
+	c.log.Debugf("script: %s", result.Error)
+
+	if textView != nil && !c.scriptFailed {
+		c.scriptFailed = true
+		c.writeBanner(textView, " Script error: "+tview.Escape(result.Error)+" (further errors are only logged)")
+	}
+
+	return result
+}
+
+// applyScript attaches the tags and note of the on_message hook to a record
+func applyScript(record *types.TailRecord, result *script.Result) {
+	if result == nil {
+		return
+	}
+
+	record.Tags = result.Tags
+
+	if result.Note != "" {
+		record.Note = result.Note
+	}
+}
+
+// formatTags returns the tags of a record as displayed in the gutter (ex:
+// "#slow #retry")
+func formatTags(tags []string) string {
+	if util.Accessible() {
+		return "tags: " + strings.Join(tags, ", ")
+	}
+
+	return "#" + strings.Join(tags, " #")
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// search is a parsed search term; terms wrapped in slashes (ex: /user=(\w+)/)
+// are regular expressions, everything else is a plain substring (see
+// termPattern()). Only substrings can be limited to whole words; regexes can
+// use \b.
+type search struct {
+	term      string
+	wholeWord bool
+	pattern   *regexp.Regexp // substring
+	re        *regexp.Regexp
+}
+
+// parseSearch parses the search term entered in the search dialog
+func parseSearch(term string, wholeWord bool) (*search, error) {
+	s := &search{term: term, wholeWord: wholeWord}
+
+	if len(term) < 3 || !strings.HasPrefix(term, "/") || !strings.HasSuffix(term, "/") {
+		s.pattern = termPattern(term, wholeWord)
+		return s, nil
+	}
+
+	re, err := regexp.Compile(term[1 : len(term)-1])
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to compile regex")
+	}
+
+	s.re = re
+
+	return s, nil
+}
+
+// match returns true if data contains the search term (or a match of the
+// regex)
+func (s *search) match(data string) bool {
+	if s.re == nil {
+		return s.pattern.MatchString(data)
+	}
+
+	return s.re.MatchString(data)
+}
+
+// paint highlights every match; capture groups of a regex search are
+// highlighted in their own color. Nested groups are highlighted as part of
+// the outermost group.
+func (s *search) paint(t *styledText) {
+	if s.re == nil {
+		t.paintMatches(s.pattern, searchStyle())
+		return
+	}
+
+	for _, loc := range s.re.FindAllStringSubmatchIndex(t.text, -1) {
+		t.paint(loc[0], loc[1], searchStyle())
+
+		pos := loc[0]
+
+		for group := 1; group < len(loc)/2; group++ {
+			start, end := loc[group*2], loc[group*2+1]
+
+			// Group did not participate in the match, is empty or is nested
+			// within the previous group
+			if start < pos || start == end {
+				continue
+			}
+
+			color := console.SearchGroupColors[(group-1)%len(console.SearchGroupColors)]
+			t.paint(start, end, textStyle{fg: "black", bg: console.HexColor(color)})
+
+			pos = end
+		}
+	}
+}
+
+// previewSearch highlights a search that is being typed in the tail view (see
+// DisplaySearch()); returns the number of matching lines for the dialog
+func (c *Cmd) previewSearch(action *types.Action, term string, wholeWord bool) string {
+	if term == "" {
+		c.search = nil
+		c.renderTail(c.textview, action)
+
+		return "[gray]Matches are highlighted as you type[-]"
+	}
+
+	parsed, err := parseSearch(term, wholeWord)
+	if err != nil {
+		return "[" + console.Hex(console.StatusError) + "]" + util.Glyph("✘ ", "x ", "") + "[-]Invalid regex"
+	}
+
+	preview := *action
+	preview.TailSearch = term
+	preview.TailSearchWholeWord = wholeWord
+
+	c.search = parsed
+	c.renderTail(c.textview, &preview)
+
+	var matches int64
+
+	for _, record := range c.buffer.Records() {
+		if record.Banner != "" || !recordVisible(record, &preview) {
+			continue
+		}
+
+		data := string(record.Data)
+
+		// Same as displayed; masked secrets are not matched
+		if preview.TailViewOptions != nil && preview.TailViewOptions.MaskSecrets {
+			data = util.MaskSecrets(data)
+		}
+
+		if parsed.match(data) {
+			matches++
+		}
+	}
+
+	if matches == 0 {
+		return "[" + console.Hex(console.StatusError) + "]" + util.Glyph("✘ ", "x ", "") + "[-]No matching lines"
+	}
+
+	summary := util.FormatCount(matches) + " matching lines"
+
+	if matches == 1 {
+		summary = "1 matching line"
+	}
+
+	return "[" + console.Hex(console.StatusOK) + "]" + util.Glyph("✔ ", "ok ", "") + "[-]" + summary
+}
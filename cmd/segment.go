@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// SegmentCheckInterval is how often tail() checks whether --segment-interval
+// has elapsed
+const SegmentCheckInterval = time.Second
+
+// segmenter keeps track of when the next segment of the tail view starts
+// (see --segment-interval). It is kept across tail restarts (ex: the filter
+// was changed) so that restarting does not postpone the next segment.
+type segmenter struct {
+	interval time.Duration
+	next     time.Time
+	count    int
+	ticker   *time.Ticker
+}
+
+// newSegmenter returns a segmenter for the given --segment-interval; an
+// interval of 0 disables interval segments (markers still start segments)
+func newSegmenter(interval time.Duration) *segmenter {
+	return &segmenter{
+		interval: interval,
+	}
+}
+
+// start starts the checks done while tailing; the first segment starts one
+// interval after tailing for the first time
+func (s *segmenter) start() {
+	if s.interval <= 0 {
+		return
+	}
+
+	if s.next.IsZero() {
+		s.next = time.Now().Add(s.interval)
+	}
+
+	s.ticker = time.NewTicker(SegmentCheckInterval)
+}
+
+// tick returns the channel used for periodic checks; nil (blocks forever) if
+// interval segments are disabled
+func (s *segmenter) tick() <-chan time.Time {
+	if s.ticker == nil {
+		return nil
+	}
+
+	return s.ticker.C
+}
+
+// due returns true if the interval has elapsed since the last segment
+func (s *segmenter) due(now time.Time) bool {
+	return !s.next.IsZero() && !now.Before(s.next)
+}
+
+// started records that a new segment started; every segment (interval or
+// marker) restarts the interval
+func (s *segmenter) started(now time.Time) int {
+	s.count++
+
+	if s.interval > 0 {
+		s.next = now.Add(s.interval)
+	}
+
+	return s.count
+}
+
+func (s *segmenter) stop() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+		s.ticker = nil
+	}
+}
+
+// startSegment inserts a numbered separator or, with --segment-mode clear,
+// clears the tail view as Ctrl-L does
+func (c *Cmd) startSegment(textView *tview.TextView, action *types.Action, reason string) {
+	now := time.Now()
+
+	banner := fmt.Sprintf(" Segment %d @ %s (%s)", c.segments.started(now), util.Clock(now), reason)
+
+	if c.options.Config.SegmentMode == "clear" {
+		c.clearTail(textView, action, banner)
+		return
+	}
+
+	c.writeBanner(textView, banner)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// checkSequence flags gaps in the --sequence-field of received payloads with
+// a banner (ex: "missing seq 1042–1049"). Payloads are checked before any
+// client-side filtering; gaps are expected (and not checked) when the server
+// samples the stream.
+func (c *Cmd) checkSequence(textView *tview.TextView, action *types.Action, gaps *util.SequenceTracker, data []byte, component *types.TailComponent) {
+	if c.options.Config.SequenceField == "" || action.TailRate > 0 {
+		return
+	}
+
+	value, err := util.GetJSONPath(data, c.options.Config.SequenceField)
+	if err != nil {
+		c.log.Debugf("unable to find sequence field '%s': %s", c.options.Config.SequenceField, err)
+		return
+	}
+
+	seq, err := util.ParseSequence(value)
+	if err != nil {
+		c.log.Debugf("unable to parse sequence field '%s': %s", c.options.Config.SequenceField, err)
+		return
+	}
+
+	// Every component (and partition) has its own sequence
+	var key string
+
+	if component != nil {
+		key = util.FormatAudience(component.Audience)
+	}
+
+	partition := c.sequencePartition(data)
+	if partition != "" {
+		key += "/" + partition
+	}
+
+	from, to, gap := gaps.Check(key, seq)
+	if !gap || c.paused {
+		return
+	}
+
+	missing := fmt.Sprintf("missing seq %d", from)
+
+	if to > from {
+		missing = fmt.Sprintf("missing seq %d%s%d (%d msgs)", from, util.Glyph("–", "-", " to "), to, to-from+1)
+	}
+
+	if component != nil && len(action.TailComponents) > 1 {
+		missing += fmt.Sprintf(" in '%s'", tview.Escape(component.Name))
+	}
+
+	if partition != "" {
+		missing += fmt.Sprintf(" (partition %s)", tview.Escape(partition))
+	}
+
+	c.writeBanner(textView, fmt.Sprintf(" Gap @ %s: %s", util.Clock(time.Now()), missing))
+}
+
+// sequencePartition returns the --partition-field of a payload; empty if it
+// is not set or not found
+func (c *Cmd) sequencePartition(data []byte) string {
+	if c.options.Config.PartitionField == "" {
+		return ""
+	}
+
+	value, err := util.GetJSONPath(data, c.options.Config.PartitionField)
+	if err != nil {
+		c.log.Debugf("unable to find partition field '%s': %s", c.options.Config.PartitionField, err)
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
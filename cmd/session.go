@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/config"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// newSession returns the state of the tail view of an action; nil is returned
+// if no component is tailed
+func (c *Cmd) newSession(action *types.Action) *types.Session {
+	if action.TailComponent == nil {
+		return nil
+	}
+
+	components := make([]string, 0)
+
+	for _, component := range tailedComponents(action) {
+		if component.Audience != nil {
+			components = append(components, util.FormatAudience(component.Audience))
+		}
+	}
+
+	session := &types.Session{
+		Components:      components,
+		Filter:          action.TailFilter,
+		FilterWholeWord: action.TailFilterWholeWord,
+		Search:          action.TailSearch,
+		SearchWholeWord: action.TailSearchWholeWord,
+		Rate:            action.TailRate,
+		Paused:          c.paused,
+	}
+
+	if action.TailViewOptions != nil {
+		viewOptions := *action.TailViewOptions
+		session.ViewOptions = &viewOptions
+	}
+
+	return session
+}
+
+// trackSession saves the state of the tail view in the config file whenever
+// it changes; saving as it changes (instead of on quit) also covers the CLI
+// being killed (ex: reboot). Dialogs opened from the tail view are tracked too
+// so that a crash in a dialog restores the tail view it was opened from.
+func (c *Cmd) trackSession(action *types.Action) {
+	if c.options.Config.RestoreSession == "never" || c.nav.current() != types.StepTail {
+		return
+	}
+
+	session := c.newSession(action)
+	if session == nil || reflect.DeepEqual(session, c.session) {
+		return
+	}
+
+	c.session = session
+	c.options.Crash.SetSession(session)
+
+	if err := config.SaveSession(session); err != nil {
+		c.log.Debugf("unable to save session: %s", err)
+	}
+}
+
+// restoreSession offers to restore the session of the previous run (if any);
+// returns true if action was set up for tailing its components. The session
+// is only offered once and only if its components are live.
+func (c *Cmd) restoreSession(action *types.Action, audiences []*protos.Audience) bool {
+	if c.options.Config.RestoreSession == "never" {
+		return false
+	}
+
+	session, err := config.Session()
+	if err != nil {
+		c.log.Debugf("unable to read session: %s", err)
+		return false
+	}
+
+	if session == nil {
+		return false
+	}
+
+	// The crash is only mentioned once
+	if session.CrashReport != "" {
+		saved := *session
+		saved.CrashReport = ""
+
+		if err := config.SaveSession(&saved); err != nil {
+			c.log.Debugf("unable to save session: %s", err)
+		}
+	}
+
+	components := sessionComponents(session, audiences)
+	if len(components) == 0 {
+		c.log.Debugf("not restoring session; none of its components are live (%s)", strings.Join(session.Components, ", "))
+		return false
+	}
+
+	if c.options.Config.RestoreSession == "ask" && !c.confirmRestore(session, components) {
+		return false
+	}
+
+	c.selectComponents(action, components)
+
+	action.TailFilter = session.Filter
+	action.TailFilterWholeWord = session.FilterWholeWord
+	action.TailSearch = session.Search
+	action.TailSearchWholeWord = session.SearchWholeWord
+	action.TailRate = session.Rate
+
+	if session.ViewOptions != nil {
+		action.TailViewOptions = session.ViewOptions
+	}
+
+	if parsed, err := parseSearch(session.Search, session.SearchWholeWord); err == nil {
+		c.search = parsed
+	}
+
+	c.paused = session.Paused
+
+	for entry, on := range map[string]bool{
+		"Filter":          session.Filter != "",
+		"Search":          session.Search != "",
+		"Set Sample Rate": session.Rate != 0,
+		"Pause":           session.Paused,
+	} {
+		if on {
+			c.options.Console.SetMenuEntryOn(entry)
+		}
+	}
+
+	return true
+}
+
+// confirmRestore asks whether the session should be restored
+func (c *Cmd) confirmRestore(session *types.Session, components []*types.TailComponent) bool {
+	names := make([]string, 0, len(components))
+
+	for _, component := range components {
+		names = append(names, component.Name)
+	}
+
+	msg := fmt.Sprintf("Restore the previous session?\n\nTailing [::b]%s[-:-:-]", tview.Escape(strings.Join(names, ", ")))
+
+	if session.CrashReport != "" {
+		msg = fmt.Sprintf(
+			"The CLI crashed during the previous session; a report was written to\n\n%s\n\nResume tailing [::b]%s[-:-:-]?",
+			tview.Escape(session.CrashReport),
+			tview.Escape(strings.Join(names, ", ")),
+		)
+	}
+
+	details := make([]string, 0)
+
+	if session.Filter != "" {
+		details = append(details, "filter: "+session.Filter)
+	}
+
+	if session.Search != "" {
+		details = append(details, "search: "+session.Search)
+	}
+
+	if session.Rate != 0 {
+		details = append(details, fmt.Sprintf("sample rate: %d/s", session.Rate))
+	}
+
+	if session.Paused {
+		details = append(details, "paused")
+	}
+
+	if len(details) > 0 {
+		msg += "\n(" + tview.Escape(strings.Join(details, ", ")) + ")"
+	}
+
+	// Disable input capture while confirming
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	answerCh := make(chan bool)
+
+	crash.Go(func() {
+		c.options.Console.DisplayConfirm(msg, "Restore", answerCh)
+	})
+
+	return <-answerCh
+}
+
+// sessionComponents returns the components of a session that are live
+func sessionComponents(session *types.Session, audiences []*protos.Audience) []*types.TailComponent {
+	components := make([]*types.TailComponent, 0)
+
+	for _, name := range session.Components {
+		aud, err := util.ParseAudience(name)
+		if err != nil {
+			continue
+		}
+
+		for _, live := range audiences {
+			if util.AudienceEquals(aud, live) {
+				components = append(components, util.AudienceToTailComponent(live))
+				break
+			}
+		}
+	}
+
+	return components
+}
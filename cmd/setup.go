@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/streamdal/cli/config"
+	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/types"
+)
+
+// actionSetup walks first-time users through choosing the server, auth token
+// and TLS settings. The connection is tested before the settings are saved to
+// the config file (and the token to the OS keychain).
+func (c *Cmd) actionSetup(_ *types.Action) (*types.Action, error) {
+	answerCh := make(chan *types.SetupRequest, 1)
+
+	defaults := &types.SetupRequest{
+		Server:     c.options.Config.Server,
+		DisableTLS: c.options.Config.DisableTLS,
+	}
+
+	c.options.Console.DisplaySetup(defaults, answerCh)
+
+	for {
+		req := <-answerCh
+		if req == nil {
+			return &types.Action{Step: types.StepQuit}, nil
+		}
+
+		c.options.Console.SetSetupStatus(fmt.Sprintf("Testing connection to [::u]%s[::-]...", req.Server))
+
+		c.options.Config.Server = req.Server
+		c.options.Config.Auth = req.Auth
+		c.options.Config.DisableTLS = req.DisableTLS
+
+		if _, err := c.newHeadlessAPI(); err != nil {
+			c.options.Console.SetSetupStatus(fmt.Sprintf("[%s]Unable to connect: %s[-]", console.Hex(console.StatusError), err))
+			continue
+		}
+
+		break
+	}
+
+	if err := config.SaveSetup(c.options.Config.Server, c.options.Config.DisableTLS); err != nil {
+		c.log.Errorf("unable to save setup: %s", err)
+		c.setupNotice = " Unable to save settings to the config file; pass --server next time"
+	}
+
+	if err := config.SetKeychainToken(c.options.Config.Server, c.options.Config.Auth); err != nil {
+		c.log.Errorf("unable to store auth token in keychain: %s", err)
+		c.setupNotice = " Unable to store auth token in the OS keychain; pass --auth next time"
+	}
+
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureSetupTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	return &types.Action{Step: types.StepConnect}, nil
+}
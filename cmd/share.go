@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/audit"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/export"
+	"github.com/streamdal/cli/slack"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// Sharing can only be started from tail so we always go back to tail(). The
+// selected line (or a range of lines) is posted to the configured Slack
+// webhook along with the component and server it was tailed from.
+func (c *Cmd) actionShare(action *types.Action) (*types.Action, error) {
+	action.Step = types.StepTail
+
+	if c.slack == nil {
+		c.writeBanner(c.textview, " Slack is not configured; set --slack-webhook-url to share lines")
+		return action, nil
+	}
+
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureShareTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	// Disable input capture while in share
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	// Default to the selected (or most recent) line
+	var defaultLines string
+
+	if c.selectedLine != 0 {
+		defaultLines = strconv.Itoa(c.selectedLine)
+	} else if record := c.lastRecord(action); record != nil {
+		defaultLines = strconv.Itoa(record.LineNum)
+	}
+
+	// Channel used for reading resp from share dialog
+	answerCh := make(chan *types.ShareRequest)
+
+	// Display modal
+	crash.Go(func() {
+		c.options.Console.DisplayShare(defaultLines, answerCh)
+	})
+
+	req := <-answerCh
+
+	// Canceled
+	if req == nil {
+		return action, nil
+	}
+
+	from, to, err := util.ParseLineRange(req.Lines)
+	if err == nil && from == 0 {
+		err = errors.New("no lines selected")
+	}
+
+	if err != nil {
+		c.writeBanner(c.textview, " Share failed: "+tview.Escape(err.Error()))
+		return action, nil
+	}
+
+	records := c.recordRange(action, from, to)
+
+	if len(records) == 0 {
+		c.writeBanner(c.textview, fmt.Sprintf(" Share failed: no lines in range '%s'", tview.Escape(req.Lines)))
+		return action, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), slack.DefaultTimeout)
+	defer cancel()
+
+	if err := c.slack.Post(ctx, c.shareText(action, records, req.Message)); err != nil {
+		c.writeBanner(c.textview, " Share failed: "+tview.Escape(err.Error()))
+		return action, nil
+	}
+
+	c.audit(audit.ActionShared, map[string]string{
+		"destination": "slack",
+		"lines":       strconv.Itoa(export.CountLines(records)),
+	})
+
+	c.writeBanner(c.textview, fmt.Sprintf(" Shared %d lines to Slack @ %s", export.CountLines(records), util.Clock(time.Now())))
+
+	return action, nil
+}
+
+// shareText formats records for Slack with the context they were tailed in;
+// the oldest records are dropped if the message would be too long for Slack.
+func (c *Cmd) shareText(action *types.Action, records []*types.TailRecord, message string) string {
+	server := serverName(c.options)
+
+	header := make([]string, 0)
+
+	if message = strings.TrimSpace(message); message != "" {
+		header = append(header, message)
+	}
+
+	source := fmt.Sprintf("*%s*", action.TailComponent.Name)
+
+	if aud := action.TailComponent.Audience; aud != nil {
+		source += fmt.Sprintf(" · %s / %s / %s", aud.ServiceName, util.ProtosOperationTypeToStr(aud.OperationType), aud.ComponentName)
+	}
+
+	header = append(header, source+fmt.Sprintf(" · server `%s`", server))
+
+	for {
+		text := strings.Join(header, "\n") + "\n" + export.CodeBlock(records)
+
+		if len(text) <= slack.MaxTextLength || len(records) == 1 {
+			return text
+		}
+
+		records = records[1:]
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/sink"
+	"github.com/streamdal/cli/util"
+)
+
+// newSinks creates the sinks that tailed messages are forwarded to; errors
+// that occur while forwarding are sent to errCh (and dropped if it is full) so
+// that they can be displayed by the tail loop.
+func newSinks(opts *Options, errCh chan error) ([]sink.Sink, error) {
+	cfg := opts.Config
+	sinks := make([]sink.Sink, 0)
+
+	onError := func(err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+	}
+
+	if cfg.KafkaTopic != "" {
+		k, err := sink.NewKafka(&sink.KafkaOptions{
+			Brokers:       cfg.KafkaBrokers,
+			Topic:         cfg.KafkaTopic,
+			Username:      cfg.KafkaUsername,
+			Password:      cfg.KafkaPassword,
+			SASLMechanism: cfg.KafkaSaslMechanism,
+			TLS:           cfg.KafkaTLS,
+			OnError:       onError,
+			Logger:        opts.Logger,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create kafka sink")
+		}
+
+		sinks = append(sinks, k)
+	}
+
+	if cfg.NatsSubject != "" {
+		n, err := sink.NewNATS(&sink.NATSOptions{
+			URL:       cfg.NatsURL,
+			Subject:   cfg.NatsSubject,
+			JetStream: cfg.NatsJetstream,
+			CredsFile: cfg.NatsCredsFile,
+			Token:     cfg.NatsToken,
+			OnError:   onError,
+			Logger:    opts.Logger,
+		})
+		if err != nil {
+			closeAll(sinks)
+			return nil, errors.Wrap(err, "unable to create nats sink")
+		}
+
+		sinks = append(sinks, n)
+	}
+
+	if cfg.SyslogAddress != "" {
+		s, err := sink.NewSyslog(&sink.SyslogOptions{
+			Address:  cfg.SyslogAddress,
+			Tag:      cfg.SyslogTag,
+			Facility: cfg.SyslogFacility,
+			OnError:  onError,
+			Logger:   opts.Logger,
+		})
+		if err != nil {
+			closeAll(sinks)
+			return nil, errors.Wrap(err, "unable to create syslog sink")
+		}
+
+		sinks = append(sinks, s)
+	}
+
+	if cfg.GelfAddress != "" {
+		g, err := sink.NewGELF(&sink.GELFOptions{
+			Address: cfg.GelfAddress,
+			OnError: onError,
+			Logger:  opts.Logger,
+		})
+		if err != nil {
+			closeAll(sinks)
+			return nil, errors.Wrap(err, "unable to create gelf sink")
+		}
+
+		sinks = append(sinks, g)
+	}
+
+	if cfg.ElasticsearchIndex != "" {
+		e, err := sink.NewElasticsearch(&sink.ElasticsearchOptions{
+			URL:      cfg.ElasticsearchURL,
+			Index:    cfg.ElasticsearchIndex,
+			Username: cfg.ElasticsearchUsername,
+			Password: cfg.ElasticsearchPassword,
+			APIKey:   cfg.ElasticsearchAPIKey,
+			CACert:   cfg.ElasticsearchCACert,
+			OnError:  onError,
+			Logger:   opts.Logger,
+		})
+		if err != nil {
+			closeAll(sinks)
+			return nil, errors.Wrap(err, "unable to create elasticsearch sink")
+		}
+
+		sinks = append(sinks, e)
+	}
+
+	if cfg.HTTPSinkURL != "" {
+		h, err := sink.NewHTTP(&sink.HTTPOptions{
+			URL:       cfg.HTTPSinkURL,
+			Method:    cfg.HTTPSinkMethod,
+			Headers:   cfg.HTTPSinkHeader,
+			Body:      cfg.HTTPSinkBody,
+			BatchSize: cfg.HTTPSinkBatchSize,
+			Timeout:   cfg.HTTPSinkTimeout,
+			OnError:   onError,
+			Logger:    opts.Logger,
+		})
+		if err != nil {
+			closeAll(sinks)
+			return nil, errors.Wrap(err, "unable to create http sink")
+		}
+
+		sinks = append(sinks, h)
+	}
+
+	return sinks, nil
+}
+
+// closeAll closes sinks without reporting errors; used so that the sinks
+// created so far are not leaked if creating another one failed
+func closeAll(sinks []sink.Sink) {
+	for _, s := range sinks {
+		_ = s.Close()
+	}
+}
+
+// forward sends a message that passed the filter(s) to all sinks; data is the
+// decoded payload and is redacted before it is forwarded, the same way it is
+// before it is displayed. Errors are logged as forwarding should never
+// interrupt the tail.
+func (c *Cmd) forward(ctx context.Context, resp *protos.TailResponse, data []byte) {
+	if len(c.sinks) == 0 {
+		return
+	}
+
+	msg := &sink.Message{
+		Audience:  resp.Audience,
+		Data:      util.RedactJSONPaths(data, c.options.Config.Redact),
+		Timestamp: time.Unix(0, resp.TimestampNs),
+	}
+
+	for _, s := range c.sinks {
+		if err := s.Write(ctx, msg); err != nil {
+			c.log.Errorf("unable to forward message to '%s': %s", s.Name(), err)
+		}
+	}
+}
+
+// closeSinks flushes pending messages
+func (c *Cmd) closeSinks() {
+	for _, s := range c.sinks {
+		if err := s.Close(); err != nil {
+			c.log.Errorf("unable to close sink '%s': %s", s.Name(), err)
+		}
+	}
+}
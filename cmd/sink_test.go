@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/log"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/config"
+	"github.com/streamdal/cli/decoder"
+	"github.com/streamdal/cli/sink"
+)
+
+type fakeSink struct {
+	messages []*sink.Message
+}
+
+func (s *fakeSink) Name() string { return "fake" }
+
+func (s *fakeSink) Write(_ context.Context, msg *sink.Message) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func (s *fakeSink) Close() error { return nil }
+
+func TestForwardRedactsDecodedPayload(t *testing.T) {
+	logger := log.New(io.Discard)
+
+	decoders, err := decoder.New(&decoder.Options{Logger: logger})
+	if err != nil {
+		t.Fatalf("unable to create decoders: %s", err)
+	}
+
+	base64Decoder, ok := decoders.Get("base64")
+	if !ok {
+		t.Fatal("base64 decoder is not registered")
+	}
+
+	fake := &fakeSink{}
+
+	c := &Cmd{
+		decoder: base64Decoder,
+		sinks:   []sink.Sink{fake},
+		options: &Options{Config: &config.Config{Redact: []string{"$.user.password"}}},
+		log:     logger,
+	}
+
+	payload := `{"user":{"name":"bob","password":"hunter2"}}`
+
+	resp := &protos.TailResponse{
+		OriginalData: []byte(base64.StdEncoding.EncodeToString([]byte(payload))),
+	}
+
+	c.forward(context.Background(), resp, c.decode(resp.OriginalData))
+
+	if len(fake.messages) != 1 {
+		t.Fatalf("sink received %d messages, want 1", len(fake.messages))
+	}
+
+	data := string(fake.messages[0].Data)
+
+	if strings.Contains(data, "hunter2") {
+		t.Fatalf("redacted field reached the sink: %s", data)
+	}
+
+	if !strings.Contains(data, `"name":"bob"`) {
+		t.Fatalf("sink did not receive the decoded payload: %s", data)
+	}
+}
@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/query"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// MaxDiffValues is the number of distinct values above which a field is not
+// broken down by value when comparing snapshots (ex: IDs, timestamps);
+// numeric fields are compared by their average instead
+const MaxDiffValues = 10
+
+// Snapshots can only be taken from tail so we always go back to tail(). The
+// snapshots dialog is displayed again after viewing, comparing or deleting a
+// snapshot so that it is easy to switch between them.
+func (c *Cmd) actionSnapshots(action *types.Action) (*types.Action, error) {
+	action.Step = types.StepTail
+
+	// Disable input capture while in snapshots
+	origCapture := c.options.Console.GetInputCapture()
+	c.options.Console.SetInputCapture(nil)
+	defer c.options.Console.SetInputCapture(origCapture)
+
+	// Name of the last viewed/compared snapshot
+	var current string
+
+	for {
+		snapshots := append([]*types.Snapshot(nil), c.snapshots...)
+
+		// Channel used for reading resp from snapshots dialog
+		answerCh := make(chan *types.SnapshotRequest)
+
+		// Display modal
+		crash.Go(func() {
+			c.options.Console.DisplaySnapshots(snapshots, current, answerCh)
+		})
+
+		req := <-answerCh
+		if req == nil {
+			return action, nil
+		}
+
+		current = req.Name
+
+		switch req.Action {
+		case types.SnapshotTake:
+			// Go back to capturing right away
+			c.takeSnapshot()
+			return action, nil
+		case types.SnapshotView:
+			c.viewSnapshot(action, req.Name)
+		case types.SnapshotDiff:
+			c.diffSnapshot(req.Name, req.Other)
+		case types.SnapshotDelete:
+			c.deleteSnapshot(req.Name)
+		}
+	}
+}
+
+// takeSnapshot asks for a name and copies the buffer under it; an existing
+// snapshot with the same name is replaced
+func (c *Cmd) takeSnapshot() {
+	answerCh := make(chan string)
+
+	crash.Go(func() {
+		c.options.Console.DisplaySnapshotName(fmt.Sprintf("snapshot-%d", len(c.snapshots)+1), answerCh)
+	})
+
+	name := <-answerCh
+	if name == "" {
+		return
+	}
+
+	// The buffer is always available to queries as "buffer"
+	if strings.EqualFold(name, query.DefaultSource) {
+		c.options.Console.ShowToast(fmt.Sprintf("'%s' is reserved for the live buffer; pick another name", name))
+		return
+	}
+
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureSnapshotTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	records := c.buffer.Records()
+
+	// Bookmarks and notes added later must not change the snapshot
+	for i, record := range records {
+		r := *record
+		records[i] = &r
+	}
+
+	snapshot := &types.Snapshot{
+		Name:    name,
+		Taken:   time.Now(),
+		Records: records,
+	}
+
+	if i := c.snapshotIndex(name); i >= 0 {
+		c.snapshots[i] = snapshot
+	} else {
+		c.snapshots = append(c.snapshots, snapshot)
+	}
+
+	c.options.Console.ShowToast(fmt.Sprintf("Snapshot '%s' taken (%d lines)", name, len(records)))
+}
+
+// viewSnapshot displays the lines of a snapshot the same way as the tail view
+func (c *Cmd) viewSnapshot(action *types.Action, name string) {
+	snapshot := c.snapshot(name)
+	if snapshot == nil {
+		return
+	}
+
+	var sb strings.Builder
+
+	for _, record := range snapshot.Records {
+		sb.WriteString(c.formatRecord(record, action) + "\n")
+	}
+
+	answerCh := make(chan struct{})
+
+	crash.Go(func() {
+		title := fmt.Sprintf("Snapshot '%s' (%d lines, taken %s)", snapshot.Name, len(snapshot.Records), util.Clock(snapshot.Taken))
+		c.options.Console.DisplaySnapshot(title, sb.String(), answerCh)
+	})
+
+	<-answerCh
+}
+
+// diffSnapshot compares the snapshot name with the live buffer or, if other is
+// set, the snapshot other (before) with name (after)
+func (c *Cmd) diffSnapshot(name, other string) {
+	snapshot := c.snapshot(name)
+	if snapshot == nil {
+		return
+	}
+
+	beforeName, before := snapshot.Name, snapshot.Records
+	afterName, after := query.DefaultSource, c.buffer.Records()
+
+	if o := c.snapshot(other); o != nil {
+		beforeName, before = o.Name, o.Records
+		afterName, after = snapshot.Name, snapshot.Records
+	}
+
+	answerCh := make(chan struct{})
+
+	crash.Go(func() {
+		title := fmt.Sprintf("Snapshot diff: '%s' %s '%s'", beforeName, util.Glyph("→", "->", "to"), afterName)
+		c.options.Console.DisplaySnapshotDiff(title, diffRecords(beforeName, before, afterName, after), answerCh)
+	})
+
+	<-answerCh
+}
+
+func (c *Cmd) deleteSnapshot(name string) {
+	if i := c.snapshotIndex(name); i >= 0 {
+		c.snapshots = append(c.snapshots[:i], c.snapshots[i+1:]...)
+	}
+}
+
+// snapshot returns the snapshot with the given name; nil if there is none
+func (c *Cmd) snapshot(name string) *types.Snapshot {
+	if i := c.snapshotIndex(name); i >= 0 {
+		return c.snapshots[i]
+	}
+
+	return nil
+}
+
+func (c *Cmd) snapshotIndex(name string) int {
+	for i, snapshot := range c.snapshots {
+		if snapshot.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// recordStats summarizes records for comparing snapshots
+type recordStats struct {
+	lines       int
+	bytes       int
+	first, last time.Time
+	components  map[string]int
+	values      map[string]map[string]int // field path => JSON encoded value => count
+	sums        map[string]float64        // field path => sum of numeric values
+	numbers     map[string]int            // field path => number of numeric values
+}
+
+func newRecordStats(records []*types.TailRecord) *recordStats {
+	s := &recordStats{
+		components: make(map[string]int),
+		values:     make(map[string]map[string]int),
+		sums:       make(map[string]float64),
+		numbers:    make(map[string]int),
+	}
+
+	for _, record := range records {
+		if record.Banner != "" {
+			continue
+		}
+
+		s.lines++
+		s.bytes += len(record.Data)
+
+		if s.first.IsZero() || record.Received.Before(s.first) {
+			s.first = record.Received
+		}
+
+		if record.Received.After(s.last) {
+			s.last = record.Received
+		}
+
+		if record.Component != nil {
+			s.components[record.Component.Name]++
+		}
+
+		// Not JSON: only the totals are compared
+		fields, _ := util.FlattenJSON(record.Data)
+
+		for _, field := range fields {
+			if s.values[field.Path] == nil {
+				s.values[field.Path] = make(map[string]int)
+			}
+
+			s.values[field.Path][field.Value]++
+
+			if n, err := strconv.ParseFloat(field.Value, 64); err == nil {
+				s.sums[field.Path] += n
+				s.numbers[field.Path]++
+			}
+		}
+	}
+
+	return s
+}
+
+// rate returns the msgs/sec observed while the records were received
+func (s *recordStats) rate() float64 {
+	elapsed := s.last.Sub(s.first).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(s.lines) / elapsed
+}
+
+// diffRecords compares two sets of records: totals, the share of every value
+// of low-cardinality fields (ex: status) and the average of numeric fields
+func diffRecords(beforeName string, before []*types.TailRecord, afterName string, after []*types.TailRecord) *query.Result {
+	b, a := newRecordStats(before), newRecordStats(after)
+
+	result := &query.Result{
+		Columns: []string{"field", "value", beforeName, afterName, "change"},
+		Rows:    make([][]string, 0),
+	}
+
+	add := func(field, value, before, after, change string) {
+		result.Rows = append(result.Rows, []string{field, value, before, after, change})
+	}
+
+	add("lines", "", util.FormatCount(int64(b.lines)), util.FormatCount(int64(a.lines)), relativeChange(float64(b.lines), float64(a.lines)))
+
+	if b.lines > 0 && a.lines > 0 {
+		bSize, aSize := float64(b.bytes)/float64(b.lines), float64(a.bytes)/float64(a.lines)
+		add("avg size", "", util.FormatSize(int64(bSize)), util.FormatSize(int64(aSize)), relativeChange(bSize, aSize))
+	}
+
+	if b.rate() > 0 && a.rate() > 0 {
+		add("msgs/sec", "", util.FormatRate(b.rate()), util.FormatRate(a.rate()), relativeChange(b.rate(), a.rate()))
+	}
+
+	// Only worth listing when several components were tailed
+	if len(b.components) > 1 || len(a.components) > 1 {
+		for _, name := range unionKeys(b.components, a.components) {
+			add(query.FieldComponent, name, formatShare(b.components[name], b.lines), formatShare(a.components[name], a.lines),
+				shareChange(b.components[name], b.lines, a.components[name], a.lines))
+		}
+	}
+
+	paths := make([]string, 0)
+
+	for path := range b.values {
+		paths = append(paths, path)
+	}
+
+	for path := range a.values {
+		if _, ok := b.values[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		values := unionKeys(b.values[path], a.values[path])
+
+		if len(values) <= MaxDiffValues {
+			for _, value := range values {
+				bCount, aCount := b.values[path][value], a.values[path][value]
+				add(path, value, formatShare(bCount, b.lines), formatShare(aCount, a.lines), shareChange(bCount, b.lines, aCount, a.lines))
+			}
+
+			continue
+		}
+
+		if b.numbers[path] > 0 && a.numbers[path] > 0 {
+			bAvg, aAvg := b.sums[path]/float64(b.numbers[path]), a.sums[path]/float64(a.numbers[path])
+			add(path, "avg", formatNumber(bAvg), formatNumber(aAvg), relativeChange(bAvg, aAvg))
+		}
+	}
+
+	return result
+}
+
+// unionKeys returns the keys of both maps, sorted
+func unionKeys(a, b map[string]int) []string {
+	keys := make([]string, 0, len(a)+len(b))
+
+	for k := range a {
+		keys = append(keys, k)
+	}
+
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// formatShare formats a count and its share of total (ex: "12 (40.0%)")
+func formatShare(count, total int) string {
+	if total == 0 {
+		return util.FormatCount(int64(count))
+	}
+
+	return fmt.Sprintf("%s (%.1f%%)", util.FormatCount(int64(count)), float64(count)/float64(total)*100)
+}
+
+// shareChange returns the change of a share in percentage points (ex:
+// "+5.0 pts"); empty if the share did not change
+func shareChange(before, beforeTotal, after, afterTotal int) string {
+	if beforeTotal == 0 || afterTotal == 0 {
+		return ""
+	}
+
+	change := (float64(after)/float64(afterTotal) - float64(before)/float64(beforeTotal)) * 100
+
+	if math.Abs(change) < 0.05 {
+		return ""
+	}
+
+	return fmt.Sprintf("%+.1f pts", change)
+}
+
+// relativeChange returns the change from before to after in percent (ex:
+// "-12.5%"); empty if the value did not change
+func relativeChange(before, after float64) string {
+	if before == 0 {
+		if after == 0 {
+			return ""
+		}
+
+		return "new"
+	}
+
+	change := (after - before) / before * 100
+
+	if math.Abs(change) < 0.05 {
+		return ""
+	}
+
+	return fmt.Sprintf("%+.1f%%", change)
+}
+
+// formatNumber formats n with at most 2 decimals
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/archive"
+	"github.com/streamdal/cli/util"
+)
+
+// ErrNoMatches is returned by headless tail when a filter was set but no
+// payload matched it; main() translates it into a non-zero exit code (like
+// grep) so that tail can be used for gating CI smoke tests.
+var ErrNoMatches = errors.New("no payloads matched filter")
+
+// runTail handles "tail"; decoded payloads are printed to stdout (one per
+// line) until interrupted, --timeout is reached, --max-count payloads have
+// been printed or the source has ended. If --archive is set, printed payloads are also uploaded.
+func (c *Cmd) runTail() (err error) {
+	opts := c.options.Config.Tail
+
+	audience, err := util.ParseAudience(opts.Audience)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse audience")
+	}
+
+	source, err := c.newHeadlessSource()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(c.shutdownCtx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if opts.Timeout > 0 {
+		var timeoutCancel context.CancelFunc
+
+		ctx, timeoutCancel = context.WithTimeout(ctx, opts.Timeout)
+		defer timeoutCancel()
+	}
+
+	tailCh, err := source.Tail(ctx, audience)
+	if err != nil {
+		return errors.Wrap(err, "error calling gRPC tail endpoint in server")
+	}
+
+	var out io.Writer = os.Stdout
+
+	if opts.Archive != "" {
+		a, err := c.newArchive(ctx, opts.Archive)
+		if err != nil {
+			return err
+		}
+
+		// The upload must outlive ctx so that it can be completed on exit
+		name := strings.NewReplacer(":", "_", "/", "_").Replace(opts.Audience) +
+			"-" + time.Now().UTC().Format("20060102T150405Z") + ".ndjson"
+
+		w := a.Create(context.Background(), name)
+
+		defer func() {
+			if closeErr := w.Close(); closeErr != nil && (err == nil || errors.Is(err, ErrNoMatches)) {
+				err = errors.Wrap(closeErr, "unable to archive capture")
+			}
+		}()
+
+		out = io.MultiWriter(os.Stdout, w)
+	}
+
+	var matched int
+
+	// --filter is a substring or a CEL expression, like in the TUI
+	filter := newMatcher(opts.Filter, false)
+
+	finish := func() error {
+		if opts.Filter != "" && matched == 0 {
+			return ErrNoMatches
+		}
+
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return finish()
+		case err := <-c.sinkErrCh:
+			c.log.Error(err)
+		case tailResp, ok := <-tailCh:
+			// The source has ended (ex: the end of a --source-file was reached)
+			if !ok {
+				return finish()
+			}
+
+			if tailResp == nil {
+				continue
+			}
+
+			data := c.decode(tailResp.OriginalData)
+
+			if !filter.match(newMessage(data, tailResp)) {
+				continue
+			}
+
+			c.forward(ctx, tailResp, data)
+
+			data = util.RedactJSONPaths(data, c.options.Config.Redact)
+
+			if _, err := fmt.Fprintln(out, string(data)); err != nil {
+				return errors.Wrap(err, "unable to write payload")
+			}
+
+			matched++
+
+			if opts.MaxCount > 0 && matched >= opts.MaxCount {
+				return nil
+			}
+		}
+	}
+}
+
+// newArchive sets up uploading to an --archive location (tail and capture)
+func (c *Cmd) newArchive(ctx context.Context, archiveURL string) (*archive.Archive, error) {
+	a, err := archive.New(ctx, &archive.Options{
+		URL:    archiveURL,
+		Logger: c.options.Logger,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to set up archive")
+	}
+
+	return a, nil
+}
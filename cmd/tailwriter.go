@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/crash"
+)
+
+// TailFrameInterval is how long lines are collected before they are written
+// to the tail view in one go; nothing is written while no lines arrive. Frames
+// are further apart if drawing one takes longer.
+const TailFrameInterval = 50 * time.Millisecond
+
+// tailWriter buffers the lines of the tail view. Writing every message to the
+// TextView (and redrawing it) does not keep up with busy components; the
+// lines of a frame are written and drawn at once instead.
+type tailWriter struct {
+	pending      []string
+	pendingLines int // lines of pending as displayed (records can span several)
+	frameCh      <-chan time.Time
+
+	// drawnCh is closed once the frame being drawn in the background has
+	// been drawn; nil if there is none. Frames are postponed meanwhile so
+	// that a slow terminal gets fewer, larger frames instead of blocking the
+	// tail.
+	drawnCh chan struct{}
+
+	// drawTime is how long the last frame took to draw (a time.Duration)
+	drawTime atomic.Int64
+}
+
+// write adds a line to the current frame; the frame starts with the first
+// line. Only the last maxLines lines of a frame are kept: the view would drop
+// the others right away (after parsing them, which is what takes time).
+func (w *tailWriter) write(line string, maxLines int) {
+	w.pending = append(w.pending, line)
+	w.pendingLines += strings.Count(line, "\n") + 1
+
+	for len(w.pending) > 1 {
+		first := strings.Count(w.pending[0], "\n") + 1
+		if w.pendingLines-first < maxLines {
+			break
+		}
+
+		w.pending = w.pending[1:]
+		w.pendingLines -= first
+	}
+
+	if w.frameCh == nil {
+		w.frameCh = time.After(w.interval())
+	}
+}
+
+// interval returns the time until the next frame. Drawing large views takes a
+// while (the text is re-parsed once --max-output-lines is reached); frames
+// are spaced out so that drawing does not starve the tail.
+func (w *tailWriter) interval() time.Duration {
+	if drawTime := time.Duration(w.drawTime.Load()); drawTime > TailFrameInterval {
+		return drawTime
+	}
+
+	return TailFrameInterval
+}
+
+// frame returns the channel that fires when the current frame is due; nil
+// (blocks forever) if there are no pending lines
+func (w *tailWriter) frame() <-chan time.Time {
+	return w.frameCh
+}
+
+// take returns the pending lines and starts over
+func (w *tailWriter) take() string {
+	if len(w.pending) == 0 {
+		return ""
+	}
+
+	text := strings.Join(w.pending, "\n") + "\n"
+
+	w.discard()
+
+	return text
+}
+
+// discard drops the pending lines, ex: when the view is re-rendered from the
+// buffer, which already contains them
+func (w *tailWriter) discard() {
+	w.pending = nil
+	w.pendingLines = 0
+	w.frameCh = nil
+}
+
+// drawing returns true while a frame is being drawn in the background
+func (w *tailWriter) drawing() bool {
+	if w.drawnCh == nil {
+		return false
+	}
+
+	select {
+	case <-w.drawnCh:
+		w.drawnCh = nil
+		return false
+	default:
+		return true
+	}
+}
+
+// wait blocks until the frame being drawn in the background (if any) has
+// been drawn so that whatever is written next comes after it
+func (w *tailWriter) wait() {
+	if w.drawnCh != nil {
+		<-w.drawnCh
+		w.drawnCh = nil
+	}
+}
+
+// flushTail writes the pending lines to the tail view and redraws it; returns
+// once the view has been redrawn
+func (c *Cmd) flushTail(textView *tview.TextView) {
+	c.tailOut.wait()
+
+	if text := c.tailOut.take(); text != "" {
+		c.options.Console.Redraw(c.writeTail(textView, text))
+	}
+}
+
+// flushFrame is called when a frame is due. The frame is drawn in the
+// background: Redraw() only returns once the screen has been drawn, which
+// takes a while with large views. It is postponed if the previous frame has
+// not been drawn yet.
+func (c *Cmd) flushFrame(textView *tview.TextView) {
+	w := &c.tailOut
+
+	if w.drawing() {
+		w.frameCh = time.After(w.interval())
+		return
+	}
+
+	drawnCh := make(chan struct{})
+	w.drawnCh = drawnCh
+
+	update := c.writeTail(textView, w.take())
+
+	crash.Go(func() {
+		defer close(drawnCh)
+
+		started := time.Now()
+		c.options.Console.Redraw(update)
+
+		w.drawTime.Store(int64(time.Since(started)))
+	})
+}
+
+// writeTail returns the update that appends text to the tail view
+func (c *Cmd) writeTail(textView *tview.TextView, text string) func() {
+	// Do not scroll away from the line the user is looking at
+	follow := c.selectedLine == 0
+
+	return func() {
+		if _, err := textView.Write([]byte(text)); err != nil {
+			c.log.Errorf("unable to write to textview: %s", err)
+		}
+
+		if follow {
+			textView.ScrollToEnd()
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestTailWriterTake(t *testing.T) {
+	var w tailWriter
+
+	if w.frame() != nil {
+		t.Fatal("expected no frame before the first write")
+	}
+
+	for _, line := range []string{"one", "two", "three"} {
+		w.write(line, 100)
+	}
+
+	if w.frame() == nil {
+		t.Fatal("expected a frame after a write")
+	}
+
+	if got, want := w.take(), "one\ntwo\nthree\n"; got != want {
+		t.Fatalf("take() = %q, want %q", got, want)
+	}
+
+	if w.frame() != nil {
+		t.Fatal("expected no frame after take()")
+	}
+
+	if got := w.take(); got != "" {
+		t.Fatalf("second take() = %q, want nothing", got)
+	}
+
+	w.write("four", 100)
+
+	if got, want := w.take(), "four\n"; got != want {
+		t.Fatalf("take() after a new write = %q, want %q", got, want)
+	}
+}
+
+func TestTailWriterMaxLines(t *testing.T) {
+	var w tailWriter
+
+	// Records spanning several lines count as several lines
+	w.write("a1\na2", 3)
+	w.write("b", 3)
+	w.write("c1\nc2", 3)
+
+	if got, want := w.take(), "b\nc1\nc2\n"; got != want {
+		t.Fatalf("take() = %q, want %q", got, want)
+	}
+
+	// The last record is kept even if it alone is longer than maxLines
+	w.write("a", 2)
+	w.write("b1\nb2\nb3", 2)
+
+	if got, want := w.take(), "b1\nb2\nb3\n"; got != want {
+		t.Fatalf("take() = %q, want %q", got, want)
+	}
+}
+
+// renderTail() (ex: when clearing the view) discards the pending lines as the
+// buffer it renders from already contains them
+func TestTailWriterDiscardOnClear(t *testing.T) {
+	var w tailWriter
+
+	w.write("before clear", 100)
+	w.discard()
+
+	if w.frame() != nil {
+		t.Fatal("expected no frame after discard()")
+	}
+
+	if got := w.take(); got != "" {
+		t.Fatalf("take() after discard() = %q, want nothing", got)
+	}
+
+	w.write("after clear", 100)
+
+	if got, want := w.take(), "after clear\n"; got != want {
+		t.Fatalf("take() = %q, want %q", got, want)
+	}
+
+	if w.pendingLines != 0 {
+		t.Fatalf("pendingLines = %d after take(), want 0", w.pendingLines)
+	}
+}
+
+func TestTailWriterDrawing(t *testing.T) {
+	var w tailWriter
+
+	if w.drawing() {
+		t.Fatal("expected no frame being drawn")
+	}
+
+	w.drawnCh = make(chan struct{})
+
+	if !w.drawing() {
+		t.Fatal("expected a frame being drawn")
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		w.wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("wait() returned before the frame was drawn")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(w.drawnCh)
+	<-done
+
+	if w.drawing() {
+		t.Fatal("expected no frame being drawn after wait()")
+	}
+}
+
+const (
+	// benchTailMessages is 1s worth of a 10k msgs/s component
+	benchTailMessages = 10000
+
+	// benchTailMaxLines is the default --max-output-lines
+	benchTailMaxLines = 5000
+)
+
+// BenchmarkTailPerMessage writes and draws every message, as the tail view
+// did before lines were batched per frame
+func BenchmarkTailPerMessage(b *testing.B) {
+	benchmarkTail(b, func(textView *tview.TextView, screen tcell.Screen, lines []string) {
+		for _, line := range lines {
+			fmt.Fprint(textView, line+"\n")
+			textView.Draw(screen)
+		}
+	})
+}
+
+// BenchmarkTailPerFrame writes and draws the messages of a frame at once;
+// at 10k msgs/s, a frame (TailFrameInterval) has 500 messages
+func BenchmarkTailPerFrame(b *testing.B) {
+	perFrame := benchTailMessages * int(TailFrameInterval) / int(time.Second)
+
+	benchmarkTail(b, func(textView *tview.TextView, screen tcell.Screen, lines []string) {
+		var w tailWriter
+
+		for i, line := range lines {
+			w.write(line, benchTailMaxLines)
+
+			if (i+1)%perFrame == 0 || i == len(lines)-1 {
+				if _, err := textView.Write([]byte(w.take())); err != nil {
+					b.Fatal(err)
+				}
+
+				textView.Draw(screen)
+			}
+		}
+	})
+}
+
+func benchmarkTail(b *testing.B, write func(textView *tview.TextView, screen tcell.Screen, lines []string)) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		b.Fatal(err)
+	}
+
+	defer screen.Fini()
+
+	screen.SetSize(160, 45)
+
+	lines := make([]string, benchTailMessages)
+
+	for i := range lines {
+		lines[i] = fmt.Sprintf(`[gray]%d[-] {"id":%d,"status":"ok","payload":"%s"}`, i, i, strings.Repeat("x", 80))
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		textView := tview.NewTextView().SetDynamicColors(true).SetMaxLines(benchTailMaxLines)
+		textView.SetRect(0, 0, 160, 45)
+
+		write(textView, screen, lines)
+	}
+
+	b.ReportMetric(float64(benchTailMessages*b.N)/b.Elapsed().Seconds(), "msgs/s")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// toggleTimeZone switches every displayed timestamp between local time and UTC
+// and re-renders the tail view. Banners keep the time they were written with.
+func (c *Cmd) toggleTimeZone(textView *tview.TextView, action *types.Action) {
+	// Send telemetry
+	_ = c.options.Telemetry.Inc(types.CounterFeatureTimeZoneTotal, 1, 1.0, c.options.Config.GetStatsdTags()...)
+
+	util.SetUTC(!util.UTC())
+
+	c.options.Console.UpdateTimeZone()
+	c.updateTailHeader(action)
+	c.renderTail(textView, action)
+
+	if util.UTC() {
+		c.options.Console.ShowToast("Displaying timestamps in UTC")
+		return
+	}
+
+	c.options.Console.ShowToast("Displaying timestamps in local time (" + util.TimeZone() + ")")
+}
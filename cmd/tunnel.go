@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/api"
+	"github.com/streamdal/cli/sshtunnel"
+)
+
+// apiOptions returns the options for connecting to the server. The SSH tunnel
+// (if any) is established on first use and shared by every connection
+// (ex: reconnects) until the CLI exits.
+func (c *Cmd) apiOptions() (*api.Options, error) {
+	opts := &api.Options{
+		Address:           c.options.Config.Server,
+		AuthToken:         c.options.Config.Auth,
+		ConnectTimeout:    c.options.Config.ConnectTimeout,
+		KeepaliveInterval: c.options.Config.KeepaliveInterval,
+		DisableTLS:        c.options.Config.DisableTLS,
+		Transport:         c.options.Config.Transport,
+		Logger:            c.options.Logger,
+		TracePayloads:     c.options.Config.TracePayloads,
+	}
+
+	if c.options.Config.SSHTunnel == "" {
+		return opts, nil
+	}
+
+	if c.sshTunnel == nil {
+		t, err := sshtunnel.New(&sshtunnel.Options{
+			Destination:    c.options.Config.SSHTunnel,
+			KeyFile:        c.options.Config.SSHKey,
+			ConnectTimeout: c.options.Config.ConnectTimeout,
+			Logger:         c.log,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to establish SSH tunnel")
+		}
+
+		c.sshTunnel = t
+	}
+
+	opts.Dialer = c.sshTunnel.DialContext
+
+	return opts, nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/config"
+	"github.com/streamdal/cli/update"
+)
+
+const (
+	// UpdateCheckInterval is how often GitHub is asked for the latest
+	// release on start; the result is cached in the config file in between
+	UpdateCheckInterval = 24 * time.Hour
+
+	// UpdateCheckTimeout keeps a slow network from delaying the notice
+	// indefinitely; the check runs in the background
+	UpdateCheckTimeout = 5 * time.Second
+)
+
+func (c *Cmd) newUpdater() (*update.Updater, error) {
+	return update.New(&update.Options{
+		Version:   c.options.Config.GetVersion(),
+		LatestURL: c.options.Config.UpdateURL,
+		Logger:    c.options.Logger,
+	})
+}
+
+// runUpdate handles "update"
+func (c *Cmd) runUpdate() error {
+	u, err := c.newUpdater()
+	if err != nil {
+		return errors.Wrap(err, "unable to create updater")
+	}
+
+	ctx := context.Background()
+
+	release, err := u.Latest(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := c.options.Config.GetVersion()
+
+	fmt.Printf("Current version: %s\n", current)
+	fmt.Printf("Latest version:  %s (%s)\n", release.Version, release.URL)
+
+	// The startup notice should reflect what was just found
+	if err := config.SaveLatestVersion(release.Version); err != nil {
+		c.log.Debugf("unable to save latest version: %s", err)
+	}
+
+	newer, ok := update.Newer(current, release.Version)
+
+	switch {
+	case c.options.Config.Update.Check:
+		switch {
+		case !ok:
+			fmt.Println("Unable to compare versions (development build?)")
+		case newer:
+			fmt.Printf("Run 'streamdal update' to install %s\n", release.Version)
+		default:
+			fmt.Println("Already up to date")
+		}
+
+		return nil
+	case c.options.Config.Update.Force:
+	case !ok:
+		return errors.Errorf("unable to compare version '%s' with %s (development build?); pass --force to install %s", current, release.Version, release.Version)
+	case !newer:
+		fmt.Println("Already up to date")
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "unable to locate the running binary")
+	}
+
+	if err := u.Apply(ctx, release, exe); err != nil {
+		return errors.Wrap(err, "unable to update")
+	}
+
+	fmt.Printf("Updated %s to %s\n", exe, release.Version)
+
+	return nil
+}
+
+// checkUpdate looks for a newer release in the background (at most once per
+// UpdateCheckInterval); if there is one, a notice is displayed the first time
+// the tail view is displayed
+func (c *Cmd) checkUpdate() {
+	if c.options.Config.DisableUpdateCheck {
+		return
+	}
+
+	latest, checkedAt, err := config.LatestVersion()
+	if err != nil {
+		c.log.Debugf("unable to read latest version: %s", err)
+	}
+
+	if time.Since(checkedAt) > UpdateCheckInterval {
+		latest, err = c.fetchLatestVersion()
+		if err != nil {
+			c.log.Debugf("unable to check for a newer release: %s", err)
+			return
+		}
+	}
+
+	current := c.options.Config.GetVersion()
+
+	if newer, ok := update.Newer(current, latest); ok && newer {
+		c.updateNoticeCh <- fmt.Sprintf(" streamdal %s is available (running %s); run 'streamdal update' to install it (--disable-update-check turns this notice off)", tview.Escape(latest), current)
+	}
+}
+
+// fetchLatestVersion asks GitHub for the latest release and caches it in the
+// config file
+func (c *Cmd) fetchLatestVersion() (string, error) {
+	u, err := c.newUpdater()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(c.shutdownCtx, UpdateCheckTimeout)
+	defer cancel()
+
+	release, err := u.Latest(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := config.SaveLatestVersion(release.Version); err != nil {
+		c.log.Debugf("unable to save latest version: %s", err)
+	}
+
+	return release.Version, nil
+}
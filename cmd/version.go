@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/api"
+)
+
+// ServerInfoTimeout caps how long "version" waits for the server (connecting
+// and probing features) so that an unreachable server does not hold it up
+// for --connect-timeout
+const ServerInfoTimeout = 10 * time.Second
+
+// versionInfo is the output of "version"
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+
+	Server *serverVersionInfo `json:"server"`
+}
+
+type serverVersionInfo struct {
+	Address string `json:"address"`
+
+	// Error is set if the server was not queried or could not be reached
+	Error string `json:"error,omitempty"`
+
+	*api.ServerInfo
+}
+
+// runVersion handles "version"; the server part is best effort and never
+// makes the command fail
+func (c *Cmd) runVersion() error {
+	info := &versionInfo{
+		Version:   c.options.Config.GetVersion(),
+		Commit:    buildCommit(c.options.Config.GetVersion()),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Server:    c.serverVersionInfo(),
+	}
+
+	if c.options.Config.VersionCmd.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(info); err != nil {
+			return errors.Wrap(err, "unable to write version")
+		}
+
+		return nil
+	}
+
+	return printVersion(info)
+}
+
+func (c *Cmd) serverVersionInfo() *serverVersionInfo {
+	server := &serverVersionInfo{Address: c.options.Config.Server}
+
+	switch {
+	case c.options.Config.Demo:
+		server.Error = "not queried in demo mode"
+		return server
+	case c.options.Config.LocalSource():
+		server.Error = "not queried when reading from --source-file or --stdin"
+		return server
+	case c.options.Config.Auth == "":
+		server.Error = "not queried without --auth (or a token stored with 'auth login')"
+		return server
+	}
+
+	opts, err := c.apiOptions()
+	if err != nil {
+		server.Error = err.Error()
+		return server
+	}
+
+	a, err := api.New(opts)
+	if err != nil {
+		server.Error = err.Error()
+		return server
+	}
+
+	ctx, cancel := context.WithTimeout(c.shutdownCtx, ServerInfoTimeout)
+	defer cancel()
+
+	server.ServerInfo, err = a.ServerInfo(ctx)
+	if err != nil {
+		server.Error = "unreachable: " + err.Error()
+	}
+
+	return server
+}
+
+func printVersion(info *versionInfo) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "CLI")
+	fmt.Fprintf(w, "  Version:\t%s\n", info.Version)
+	fmt.Fprintf(w, "  Commit:\t%s\n", valueOr(info.Commit, "unknown"))
+	fmt.Fprintf(w, "  Go version:\t%s\n", info.GoVersion)
+	fmt.Fprintf(w, "  Platform:\t%s\n", info.Platform)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Server (%s)\n", info.Server.Address)
+
+	if info.Server.ServerInfo == nil {
+		fmt.Fprintf(w, "  %s\n", info.Server.Error)
+		return flushVersion(w)
+	}
+
+	fmt.Fprintf(w, "  Version:\t%s\n", valueOr(info.Server.Version, "unknown (not reported by the server)"))
+	fmt.Fprintln(w, "  Features:")
+
+	for _, feature := range info.Server.Features {
+		supported := "no"
+		if feature.Supported {
+			supported = "yes"
+		}
+
+		fmt.Fprintf(w, "    %s\t%s\t%s (%s)\n", feature.Name, supported, feature.Description, feature.Method)
+	}
+
+	return flushVersion(w)
+}
+
+func flushVersion(w *tabwriter.Writer) error {
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, "unable to write version")
+	}
+
+	return nil
+}
+
+// buildCommit returns the commit the CLI was built from; release builds have
+// it in the version (ex: v0.1.2-abc1234), others in the build info (if built
+// from a git checkout)
+func buildCommit(version string) string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && len(setting.Value) >= 7 {
+				return setting.Value[:7]
+			}
+		}
+	}
+
+	if i := strings.LastIndex(version, "-"); i >= 0 {
+		return version[i+1:]
+	}
+
+	return ""
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/audit"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// waitFor is the component passed via --wait-for; either a full audience or
+// only the operation name (ie. the name displayed in the component list)
+type waitFor struct {
+	input    string
+	audience *protos.Audience
+}
+
+// parseWaitFor parses --wait-for; nil is returned if it is not set
+func parseWaitFor(input string) (*waitFor, error) {
+	if input == "" {
+		return nil, nil
+	}
+
+	w := &waitFor{input: input}
+
+	if strings.Contains(input, ":") {
+		aud, err := util.ParseAudience(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse audience")
+		}
+
+		w.audience = aud
+	}
+
+	return w, nil
+}
+
+// match returns the first audience matching the --wait-for component
+func (w *waitFor) match(audiences []*protos.Audience) *protos.Audience {
+	for _, aud := range audiences {
+		if w.audience != nil && util.AudienceEquals(w.audience, aud) {
+			return aud
+		}
+
+		if w.audience == nil && strings.EqualFold(w.input, aud.OperationName) {
+			return aud
+		}
+	}
+
+	return nil
+}
+
+// actionWaitFor polls the live components until the --wait-for component goes
+// live and then tails it; ex: for catching the first messages of a service
+// that is being deployed.
+func (c *Cmd) actionWaitFor(action *types.Action) (*types.Action, error) {
+	// Channel used to tell animation goroutine in DisplayInfoModal to quit
+	quitAnimationCh := make(chan struct{}, 1)
+	defer close(quitAnimationCh)
+
+	// Channel is written to by DisplayInfoModal() when user clicks "Quit"
+	answerCh := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(c.shutdownCtx)
+	defer cancel()
+
+	msg := fmt.Sprintf("Waiting for [::b]%s[-:-:-] to go live ", c.waitFor.input)
+
+	c.options.Console.DisplayInfoModal(msg, quitAnimationCh, answerCh)
+
+	// Goroutine used for reading user resp
+	crash.Go(func() {
+		select {
+		case <-answerCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	})
+
+	for {
+		audiences, err := c.api.GetAllLiveAudiences(ctx)
+		if ctx.Err() != nil {
+			return &types.Action{Step: types.StepQuit}, nil
+		}
+
+		// Keep waiting; the server may be restarting as part of the deployment
+		if err != nil {
+			c.log.Debugf("unable to fetch live components while waiting for '%s': %s", c.waitFor.input, err)
+		}
+
+		if aud := c.waitFor.match(audiences); aud != nil {
+			c.waitFor = nil
+			c.audit(audit.ActionComponentsSelected, map[string]string{"components": util.FormatAudience(aud)})
+
+			action.Step = types.StepTail
+			action.TailComponent = util.AudienceToTailComponent(aud)
+			action.TailComponents = nil
+			action.TailOperation = protos.OperationType_OPERATION_TYPE_UNSET
+
+			return action, nil
+		}
+
+		select {
+		case <-time.After(WaitForInterval):
+		case <-ctx.Done():
+			return &types.Action{Step: types.StepQuit}, nil
+		}
+	}
+}
@@ -15,37 +15,288 @@ const (
 	EnvConfigPrefix = "STREAMDAL_CLI"
 )
 
+// noAuthCommands do not talk to the server and can run without --auth
+var noAuthCommands = map[string]bool{
+	"pipeline validate": true,
+	"config show":       true,
+	"config path":       true,
+	"auth login":        true,
+	"auth logout":       true,
+	"keys":              true,
+	"update":            true,
+	"version":           true,
+}
+
+// SensitiveFlags are flags whose values are secrets or may embed credentials
+// (ex: user:password@ or tokens in URLs); their values are redacted wherever
+// flags are displayed ("config show", crash reports)
+var SensitiveFlags = map[string]bool{
+	"auth":                   true,
+	"kafka-password":         true,
+	"nats-url":               true,
+	"nats-token":             true,
+	"elasticsearch-url":      true,
+	"elasticsearch-password": true,
+	"elasticsearch-api-key":  true,
+	"http-sink-url":          true,
+	"http-sink-header":       true, // ex: Authorization: Bearer ...
+	"slack-webhook-url":      true,
+}
+
+// optionalAuthCommands use the server if a token is set (or stored with "auth
+// login") but do not require one
+var optionalAuthCommands = map[string]bool{
+	"version": true,
+}
+
 type Config struct {
-	Version           kong.VersionFlag `help:"Show version and exit" short:"v" env:"-"`
-	Debug             bool             `help:"Enable debug logging" short:"d" default:"false"`
-	Auth              string           `help:"Authentication token" required:"true" short:"a"`
-	Server            string           `help:"Streamdal server URL (gRPC)" default:"localhost:8082"`
-	ConnectTimeout    time.Duration    `help:"Initial gRPC connection timeout in seconds" default:"5s"`
-	DisableTLS        bool             `help:"Disable TLS" default:"false"`
-	EnableFileLogging bool             `help:"Enable file logging" default:"false"`
-	LogFile           string           `help:"Log file" default:"./streamdal-cli.log"`
-	MaxOutputLines    int              `help:"Maximum number of output lines" default:"5000"`
-	TelemetryDisable  bool             `help:"Disable sending usage analytics to Streamdal" default:"false"`
-	TelemetryAddress  string           `help:"Address to send telemetry to" default:"telemetry.streamdal.com:8125" hidden:"true"`
+	Version               kong.VersionFlag  `help:"Show version and exit" short:"v" env:"-"`
+	Debug                 bool              `help:"Enable debug logging (same as --log-level debug)" short:"d" default:"false"`
+	LogLevel              string            `help:"Minimum level of logged messages; debug includes gRPC request/response tracing" enum:"debug,info,warn,error" default:"info"`
+	TracePayloads         bool              `help:"Include tail payloads in debug gRPC traces (only their size is traced otherwise)" default:"false"`
+	Quiet                 bool              `help:"Only log errors (same as --log-level error)" short:"q" default:"false"`
+	LogFormat             string            `help:"Format of logged messages; auto is json with --enable-file-logging and text otherwise" enum:"auto,text,json,logfmt" default:"auto"`
+	Auth                  string            `help:"Authentication token (required unless running in demo mode or a token is stored with 'auth login')" short:"a"`
+	Server                string            `help:"Streamdal server URL (gRPC); unix:///path for a Unix domain socket" default:"localhost:8082"`
+	ConnectTimeout        time.Duration     `help:"Initial gRPC connection timeout in seconds" default:"5s"`
+	KeepaliveInterval     time.Duration     `help:"Ping the server this often while streaming so that a broken connection is detected (0 = disabled; min 10s); servers may close connections that ping more often than they allow (grpc-go default: 5m)" default:"5m"`
+	DisableTLS            bool              `help:"Disable TLS" default:"false"`
+	Transport             string            `help:"Transport used for talking to the server; grpc-web and ws (WebSocket) go through a gateway for environments where raw gRPC is blocked" enum:"grpc,grpc-web,ws" default:"grpc"`
+	SSHTunnel             string            `help:"Dial the server through an SSH tunnel ([user@]host[:port]); uses ssh-agent or ~/.ssh keys and ~/.ssh/known_hosts"`
+	SSHKey                string            `help:"Private key used for --ssh-tunnel instead of ssh-agent and the default ~/.ssh keys"`
+	EnableFileLogging     bool              `help:"Enable file logging" default:"false"`
+	LogFile               string            `help:"Log file" default:"./streamdal-cli.log"`
+	MaxOutputLines        int               `help:"Maximum number of output lines" default:"5000"`
+	Decimate              int               `help:"Client-side sampling: only display 1 in N messages (can be changed in view options)" default:"1"`
+	MaskSecrets           bool              `help:"Mask common secrets (bearer tokens, AWS keys, passwords in URLs) in displayed payloads (can be changed in view options)" default:"false"`
+	DetectPII             bool              `help:"Mark lines containing probable PII (emails, credit card numbers, SSNs) in the gutter (can be changed in view options)" default:"false"`
+	BurstMultiplier       float64           `help:"Display a banner when msgs/sec exceeds this multiple of the average rate (0 = disabled)" default:"3"`
+	IdleTimeout           time.Duration     `help:"Display a banner when no data has arrived for this long (0 = disabled)" default:"30s"`
+	IdleProbe             bool              `help:"When no data has arrived for --idle-timeout, check the server and component to tell a quiet component from a broken stream" default:"false"`
+	MaxMemory             string            `help:"Approximate memory cap for buffered output (ex: 256MB, 1GiB); oldest lines are evicted once reached (0 = unlimited)" default:"0"`
+	MemoryWarning         string            `help:"Warn when the resident memory (RSS) of the CLI exceeds this size (ex: 512MB, 2GiB; 0 = disabled)" default:"1GiB"`
+	LatencyField          string            `help:"JSONPath to a producer timestamp in payloads (ex: $.meta.created_at); enables latency display"`
+	LatencyWindow         int               `help:"Number of messages used for calculating the rolling average latency" default:"100"`
+	CorrelationKey        string            `help:"JSONPath to a field (ex: $.order_id) used for aligning lines in the comparison view"`
+	SequenceField         string            `help:"JSONPath to a sequence number or offset in payloads (ex: $.seq); gaps in the sequence are flagged in the tail view"`
+	PartitionField        string            `help:"JSONPath to the partition in payloads (ex: $.partition); --sequence-field is tracked per partition (ex: Kafka offsets)"`
+	IDField               string            `help:"JSONPath to a message ID in payloads (ex: $.id); duplicates of recently seen IDs are flagged in the tail view"`
+	IDWindow              int               `help:"Number of recently seen IDs remembered for --id-field" default:"10000"`
+	RawNumbers            bool              `help:"Display counters, rates and sizes in stats as plain numbers (ex: 1234567 instead of 1.2M) for copy/paste (can be changed in view options)" default:"false"`
+	ASCII                 bool              `help:"Only use ASCII characters (borders, spinners, banners, sparklines) for terminals and fonts that render box drawing and braille characters poorly" default:"false"`
+	Theme                 string            `help:"Color palette: default, high-contrast (low vision), deuteranopia or protanopia (red-green color blindness)" enum:"default,high-contrast,deuteranopia,protanopia" default:"default"`
+	Accessible            bool              `help:"Screen reader friendly mode: no decorative characters or animations, states spelled out instead of signalled by color only and fewer redraws" default:"false"`
+	UTC                   bool              `help:"Display timestamps in UTC instead of local time (can be toggled with u in the tail view)" default:"false"`
+	KeyBinding            map[string]string `help:"Rebind keys of the TUI as action=keys (ex: search=Ctrl-F;filter=g,f); several keys are separated by commas (see the keys command for actions and key names)"`
+	Macro                 string            `help:"Play a macro saved from the tail view (recorded with Ctrl-R by default) once a component is tailed"`
+	ConfirmClear          bool              `help:"Ask for confirmation before clearing the tail view (Ctrl-L)" default:"false"`
+	RestoreSession        string            `help:"Restore the tail view of the previous run (components, filter, search, sample rate, pause) on start: ask, always or never (the session is not saved either)" enum:"ask,always,never" default:"ask"`
+	DisableAnimations     bool              `help:"Do not animate the connection spinner and the heartbeat indicator; saves CPU while waiting on the connecting screen" default:"false"`
+	DisableWindowTitle    bool              `help:"Do not set the terminal (and tmux) window title to the server and component being viewed" default:"false"`
+	SegmentInterval       time.Duration     `help:"Start a new segment of the tail view every interval (ex: 5m; 0 = disabled); see --segment-mode" default:"0s"`
+	SegmentMarker         string            `help:"Start a new segment of the tail view whenever a message matches this substring or CEL expression (ex: payload.event == \"test_started\")"`
+	SegmentMode           string            `help:"How a new segment is started: insert a separator or clear the tail view (line numbers start over)" enum:"separator,clear" default:"separator"`
+	WaitFor               string            `help:"Wait for a component (operation name or service:operation_type:operation_name:component) to go live and tail it automatically"`
+	Redact                []string          `help:"JSONPath to a field whose value is redacted before rendering or exporting (ex: $.user.email; * matches every key/element; can be specified multiple times)"`
+	AuditLog              string            `help:"Append actions taken in the CLI (ex: component selected, sample rate changed, pipeline applied) to this file with timestamps"`
+	TraceIDField          string            `help:"JSONPath to a trace ID in payloads; if not set, W3C traceparent values are detected automatically"`
+	Decoder               string            `help:"Decoder used for displaying payloads (built-in: none, base64, hex; or the name of a loaded plugin/WASM module)" default:"none"`
+	DecoderPlugin         []string          `help:"Path to a Go plugin exporting a payload decoder (can be specified multiple times)"`
+	DecoderWasm           []string          `help:"Path to a WASM module implementing a payload decoder (can be specified multiple times)"`
+	PreviewWasm           string            `help:"Path to a pipeline step WASM module to run client-side against tailed payloads"`
+	PreviewStep           string            `help:"Path to a JSON pipeline step definition used with --preview-wasm"`
+	Script                string            `help:"Path to a Starlark script with hooks (on_connect, on_message) that can tag, annotate or drop messages in the tail view"`
+	PreviewFunction       string            `help:"Name of the function to execute in the preview WASM module" default:"f"`
+	KafkaBrokers          []string          `help:"Kafka brokers for forwarding messages that pass the filter (ex: localhost:9092)" default:"localhost:9092"`
+	KafkaTopic            string            `help:"Forward messages that pass the filter to this Kafka topic (enables the Kafka sink)"`
+	KafkaUsername         string            `help:"Kafka SASL username"`
+	KafkaPassword         string            `help:"Kafka SASL password"`
+	KafkaSaslMechanism    string            `help:"Kafka SASL mechanism" enum:"plain,scram-sha-256,scram-sha-512" default:"plain"`
+	KafkaTLS              bool              `help:"Use TLS when connecting to Kafka brokers" default:"false"`
+	NatsURL               string            `help:"NATS server(s) for forwarding messages that pass the filter (ex: nats://localhost:4222)" default:"nats://localhost:4222"`
+	NatsSubject           string            `help:"Forward messages that pass the filter to this NATS subject (enables the NATS sink)"`
+	NatsJetstream         bool              `help:"Publish to the NATS subject via JetStream (waits for acks)" default:"false"`
+	NatsCredsFile         string            `help:"NATS credentials file"`
+	NatsToken             string            `help:"NATS auth token"`
+	SyslogAddress         string            `help:"Forward messages that pass the filter to this syslog server (udp://host[:514], tcp://host[:514] or tls://host[:6514]; enables the syslog sink)"`
+	SyslogTag             string            `help:"APP-NAME of messages forwarded to syslog" default:"streamdal"`
+	SyslogFacility        string            `help:"Facility of messages forwarded to syslog" enum:"user,daemon,local0,local1,local2,local3,local4,local5,local6,local7" default:"local0"`
+	GelfAddress           string            `help:"Forward messages that pass the filter to this GELF endpoint, ex: Graylog (udp://host[:12201], tcp://host[:12201] or tls://host[:12201]; enables the GELF sink)"`
+	ElasticsearchURL      string            `help:"Elasticsearch or OpenSearch cluster for forwarding messages that pass the filter (ex: https://localhost:9200)" default:"http://localhost:9200"`
+	ElasticsearchIndex    string            `help:"Index (or data stream) that messages that pass the filter are bulk-written to (enables the Elasticsearch sink)"`
+	ElasticsearchUsername string            `help:"Elasticsearch basic auth username"`
+	ElasticsearchPassword string            `help:"Elasticsearch basic auth password"`
+	ElasticsearchAPIKey   string            `help:"Elasticsearch API key (base64 encoded id:key)"`
+	ElasticsearchCACert   string            `help:"PEM file with the CA certificate of the Elasticsearch cluster" type:"path"`
+	HTTPSinkURL           string            `help:"Send messages that pass the filter to this URL (enables the HTTP sink)"`
+	HTTPSinkMethod        string            `help:"HTTP method used by the HTTP sink" enum:"POST,PUT,PATCH" default:"POST"`
+	HTTPSinkHeader        []string          `help:"Header sent by the HTTP sink as 'Name: value'; the value is a template like --http-sink-body (can be specified multiple times)" sep:"none"`
+	HTTPSinkBody          string            `help:"Go template of the request body (ex: {\"text\": {{json .Payload}}}; default: the payload, or ndjson when batching)"`
+	HTTPSinkBatchSize     int               `help:"Maximum number of messages sent per request by the HTTP sink ({{.Messages}} in templates)" default:"1"`
+	HTTPSinkTimeout       time.Duration     `help:"Timeout of requests sent by the HTTP sink" default:"10s"`
+	SlackWebhookURL       string            `help:"Slack incoming webhook URL used for sharing lines from the tail view"`
+	Pprof                 string            `help:"Expose net/http/pprof endpoints on this address (ex: localhost:6060)"`
+	CPUProfile            string            `help:"Write a CPU profile to this file on exit"`
+	MemProfile            string            `help:"Write a memory profile to this file on exit"`
+	SourceFile            []string          `help:"Tail a local ndjson or plain log file (one payload per line) instead of a streamdal server (can be specified multiple times)"`
+	Follow                bool              `help:"Keep reading --source-file as it grows (like tail -f)" default:"false"`
+	Stdin                 bool              `help:"Tail lines piped to stdin instead of a streamdal server (ex: some-producer | streamdal --stdin)" default:"false"`
+	Demo                  bool              `help:"Run against a synthetic data generator instead of a streamdal server" default:"false"`
+	DemoRate              int               `help:"Number of messages per second generated in demo mode" default:"10"`
+	DemoPayloadSize       int               `help:"Approximate size of generated payloads in bytes" default:"256"`
+	DemoShape             string            `help:"JSON shape of generated payloads (flat, nested, array)" enum:"flat,nested,array" default:"nested"`
+	Bench                 bool              `help:"Benchmark the tail view using the demo generator; prints results and exits" default:"false"`
+	BenchDuration         time.Duration     `help:"How long to run the benchmark for" default:"30s"`
+	TelemetryDisable      bool              `help:"Disable sending usage analytics to Streamdal" default:"false"`
+	TelemetryAddress      string            `help:"Address to send telemetry to" default:"telemetry.streamdal.com:8125" hidden:"true"`
+	DisableUpdateCheck    bool              `help:"Do not check for a newer release on start (checked at most once a day; a notice is displayed in the tail view)" default:"false"`
+	UpdateURL             string            `help:"GitHub API endpoint returning the latest release" default:"https://api.github.com/repos/streamdal/cli/releases/latest" hidden:"true"`
+
+	TUI        struct{}    `cmd:"" default:"withargs" help:"Launch the interactive TUI (default)"`
+	Tail       TailCmd     `cmd:"" help:"Tail an audience and print payloads to stdout"`
+	Capture    CaptureCmd  `cmd:"" help:"Capture the payloads of a component to an ndjson file unattended"`
+	Audience   AudienceCmd `cmd:"" help:"Inspect audiences"`
+	Pipeline   PipelineCmd `cmd:"" help:"Manage pipelines"`
+	Conf       ConfCmd     `cmd:"" name:"config" help:"Inspect CLI configuration"`
+	AuthCmd    AuthCmd     `cmd:"" name:"auth" help:"Manage auth tokens stored in the OS keychain"`
+	Keys       KeysCmd     `cmd:"" help:"Print the key bindings of the TUI, including --key-binding overrides"`
+	Update     UpdateCmd   `cmd:"" help:"Replace this binary with the latest release (after verifying its checksum and signature)"`
+	VersionCmd VersionCmd  `cmd:"" name:"version" help:"Show the version of the CLI and, if it is reachable, of the server and the features it supports"`
 
 	InstallID   string        `kong:"-"`
+	Setup       bool          `kong:"-"` // set when the setup wizard should be displayed
 	KongContext *kong.Context `kong:"-"`
 }
 
+type TailCmd struct {
+	Audience string        `help:"Audience to tail (service:operation_type:operation_name:component)" required:"" env:"-"`
+	Filter   string        `help:"Only print payloads containing this string or matching this CEL expression (ex: payload.status == 500); exits with 1 if no payload matched" env:"-"`
+	MaxCount int           `help:"Exit after this many payloads have been printed (0 = unlimited)" short:"m" default:"0" env:"-"`
+	Timeout  time.Duration `help:"Exit after this long (0 = run until interrupted)" default:"0s" env:"-"`
+	Archive  string        `help:"Also upload printed payloads (ndjson) to this location (ex: s3://bucket/prefix); uses standard AWS credentials" env:"-"`
+}
+
+type CaptureCmd struct {
+	Component   string        `help:"Component to capture (operation name or service:operation_type:operation_name:component); waits for it to go live" required:"" env:"-"`
+	Filter      string        `help:"Only capture payloads containing this string or matching this CEL expression (ex: payload.status == 500); exits with 1 if no payload matched" env:"-"`
+	Duration    time.Duration `help:"Stop capturing after this long, starting once the component is live (0 = until interrupted)" default:"0s" env:"-"`
+	MaxLines    int           `help:"Stop capturing after this many payloads (0 = unlimited)" default:"0" env:"-"`
+	Out         string        `help:"File to write captured payloads to (ndjson); defaults to streamdal-<component>-<time>.ndjson" type:"path" env:"-"`
+	RotateSize  string        `help:"Rotate --out once it is larger than this size (ex: 1GB; 0 = never); rotated files are named after the time they were started" default:"0" env:"-"`
+	RotateEvery time.Duration `help:"Rotate --out once it has been written to for this long (ex: 1h; 0 = never)" default:"0s" env:"-"`
+	RotateGzip  bool          `help:"Gzip rotated files" default:"false" env:"-"`
+	Every       time.Duration `help:"Repeat the capture on this schedule (ex: --duration 1m --every 1h) until interrupted; every capture is written to a timestamped file (0 = capture once)" default:"0s" env:"-"`
+	Archive     string        `help:"Also upload captured payloads (ndjson) to this location (ex: s3://bucket/prefix), one object per capture named after --out; uses standard AWS credentials" env:"-"`
+}
+
+type AudienceCmd struct {
+	List AudienceListCmd `cmd:"" help:"List live audiences"`
+}
+
+type AudienceListCmd struct {
+	Output string `help:"Output format (table, json)" short:"o" enum:"table,json" default:"table" env:"-"`
+}
+
+type AuthCmd struct {
+	Login  struct{} `cmd:"" help:"Store the auth token for --server in the OS keychain (the token is prompted for or read from stdin)"`
+	Logout struct{} `cmd:"" help:"Remove the auth token for --server from the OS keychain"`
+}
+
+type ConfCmd struct {
+	Show struct{} `cmd:"" help:"Show the effective configuration (flags, env vars and defaults)"`
+	Path struct{} `cmd:"" help:"Show the path to the CLI config file"`
+}
+
+type KeysCmd struct {
+	Markdown bool `help:"Print the key bindings as Markdown tables (ex: for documentation)" default:"false" env:"-"`
+}
+
+type UpdateCmd struct {
+	Check bool `help:"Only check whether a newer release is available" default:"false" env:"-"`
+	Force bool `help:"Install the latest release even if it is not newer (ex: development builds)" default:"false" env:"-"`
+}
+
+type VersionCmd struct {
+	Output string `help:"Output format (text, json)" short:"o" enum:"text,json" default:"text" env:"-"`
+}
+
+type PipelineCmd struct {
+	Apply    PipelineFileCmd   `cmd:"" help:"Create or update a pipeline from a YAML/JSON definition"`
+	Validate PipelineFileCmd   `cmd:"" help:"Validate a YAML/JSON pipeline definition without applying it"`
+	Export   PipelineExportCmd `cmd:"" help:"Export pipelines from the server to YAML/JSON files"`
+}
+
+type PipelineExportCmd struct {
+	Dir    string `help:"Directory to write pipeline files to" default:"." type:"path" env:"-"`
+	Format string `help:"Output format (yaml, json)" enum:"yaml,json" default:"yaml" env:"-"`
+	Name   string `help:"Only export the pipeline with this name" env:"-"`
+}
+
+type PipelineFileCmd struct {
+	File string `help:"Pipeline definition file (YAML or JSON)" short:"f" required:"" type:"existingfile" env:"-"`
+}
+
 func New(version string) *Config {
 	if err := godotenv.Load(EnvFile); err != nil {
 		log.Debug("unable to load dotenv file", "err", err.Error(), "filename", EnvFile)
 	}
 
-	cfg := &Config{}
-	cfg.KongContext = kong.Parse(cfg,
+	options := []kong.Option{
 		kong.Name("streamdal"),
 		kong.Description("Streamdal CLI"),
 		kong.DefaultEnvars(EnvConfigPrefix),
 		kong.Vars{
 			"version": version,
 		},
-	)
+	}
+
+	// Settings saved by the setup wizard
+	if resolver := fileResolver(); resolver != nil {
+		options = append(options, kong.Resolvers(resolver))
+	}
+
+	cfg := &Config{}
+	cfg.KongContext = kong.Parse(cfg, options...)
+
+	// Benchmarking is always done against the demo generator
+	if cfg.Bench {
+		cfg.Demo = true
+	}
+
+	if (cfg.PreviewWasm == "") != (cfg.PreviewStep == "") {
+		cfg.KongContext.Fatalf("--preview-wasm and --preview-step must be specified together")
+	}
+
+	if cfg.Quiet && cfg.Debug {
+		cfg.KongContext.Fatalf("--quiet and --debug cannot be used together")
+	}
+
+	if cfg.Demo && cfg.LocalSource() {
+		cfg.KongContext.Fatalf("--demo cannot be used together with --source-file or --stdin")
+	}
+
+	needsAuth := !cfg.Demo && !cfg.LocalSource() && !noAuthCommands[cfg.KongContext.Command()]
+
+	// Tokens stored with "auth login" are used if --auth is not set so that
+	// the token does not have to be passed on the command line (visible in ps)
+	if cfg.Auth == "" && (needsAuth || optionalAuthCommands[cfg.KongContext.Command()]) {
+		token, err := KeychainToken(cfg.Server)
+		if err != nil {
+			log.Debug("unable to read auth token from keychain", "err", err.Error(), "server", cfg.Server)
+		}
+
+		cfg.Auth = token
+	}
+
+	// The TUI walks first-time users through setting up the connection
+	// instead of failing
+	if cfg.Auth == "" && needsAuth && cfg.KongContext.Command() == "tui" {
+		cfg.Setup = true
+	}
+
+	if cfg.Auth == "" && needsAuth && !cfg.Setup {
+		cfg.KongContext.Fatalf("missing flags: --auth=STRING (or store a token with 'streamdal auth login')")
+	}
 
 	// Get/Set installID
 	cfg.InstallID = cfg.GetInstallID()
@@ -53,6 +304,44 @@ func New(version string) *Config {
 	return cfg
 }
 
+// LocalSource returns true if payloads are read from local files and/or stdin
+// instead of a streamdal server
+func (c *Config) LocalSource() bool {
+	return len(c.SourceFile) > 0 || c.Stdin
+}
+
+// GetLogLevel returns the level of the logger; --quiet and --debug take
+// precedence over --log-level
+func (c *Config) GetLogLevel() log.Level {
+	switch {
+	case c.Quiet:
+		return log.ErrorLevel
+	case c.Debug:
+		return log.DebugLevel
+	default:
+		return log.ParseLevel(c.LogLevel)
+	}
+}
+
+// GetLogFormatter returns the formatter of the logger; the log file defaults
+// to JSON so that it can be ingested by log tooling
+func (c *Config) GetLogFormatter() log.Formatter {
+	switch c.LogFormat {
+	case "json":
+		return log.JSONFormatter
+	case "logfmt":
+		return log.LogfmtFormatter
+	case "text":
+		return log.TextFormatter
+	}
+
+	if c.EnableFileLogging {
+		return log.JSONFormatter
+	}
+
+	return log.TextFormatter
+}
+
 func (c *Config) GetVersion() string {
 	if ver, ok := c.KongContext.Model.Vars()["version"]; ok {
 		return ver
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"github.com/zalando/go-keyring"
+)
+
+// KeychainService is the service name that auth tokens are stored under in
+// the OS keychain (macOS Keychain, Secret Service, Windows Credential Manager);
+// tokens are keyed by server address.
+const KeychainService = "streamdal-cli"
+
+// KeychainToken returns the auth token stored for server; an empty string is
+// returned if no token is stored.
+func KeychainToken(server string) (string, error) {
+	token, err := keyring.Get(KeychainService, server)
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", nil
+		}
+
+		return "", errors.Wrap(err, "unable to read auth token from keychain")
+	}
+
+	return token, nil
+}
+
+// SetKeychainToken stores the auth token for server
+func SetKeychainToken(server, token string) error {
+	if err := keyring.Set(KeychainService, server, token); err != nil {
+		return errors.Wrap(err, "unable to store auth token in keychain")
+	}
+
+	return nil
+}
+
+// DeleteKeychainToken removes the auth token stored for server; returns false
+// if no token was stored
+func DeleteKeychainToken(server string) (bool, error) {
+	if err := keyring.Delete(KeychainService, server); err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, "unable to delete auth token from keychain")
+	}
+
+	return true, nil
+}
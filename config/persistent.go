@@ -1,14 +1,20 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"os"
 	"path"
+	"sync"
+	"time"
 
+	"github.com/alecthomas/kong"
 	"github.com/charmbracelet/log"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/types"
 )
 
 const (
@@ -21,8 +27,25 @@ const (
 
 type configFile struct {
 	InstallID string `json:"install_id"`
+
+	// Written by the setup wizard; used as flag defaults (see fileResolver)
+	Server     string `json:"server,omitempty"`
+	DisableTLS bool   `json:"disable_tls,omitempty"`
+
+	// Keys of the macros recorded in the tail view, by name (see --macro)
+	Macros map[string][]*types.MacroKey `json:"macros,omitempty"`
+
+	// State of the tail view that is offered for resuming on the next start
+	Session *types.Session `json:"session,omitempty"`
+
+	// Result of the last check for a newer release (see --disable-update-check)
+	LatestVersion   string     `json:"latest_version,omitempty"`
+	LatestCheckedAt *time.Time `json:"latest_checked_at,omitempty"`
 }
 
+// configFileMu serializes modifications of the config file
+var configFileMu sync.Mutex
+
 // GetInstallID returns the unique node ID for this running instance of streamdal server
 func (c *Config) GetInstallID() string {
 	// Check if we already have an install ID stored in ~/.streamdal/cli_config.json
@@ -80,14 +103,131 @@ func (c *Config) getCurrentInstallID() string {
 }
 
 func saveInstallID(installID string) error {
+	return updateConfigFile(func(cfg *configFile) {
+		cfg.InstallID = installID
+	})
+}
+
+// SaveSetup stores the server settings chosen in the setup wizard in the
+// config file; they are used as defaults on subsequent runs
+func SaveSetup(server string, disableTLS bool) error {
+	return updateConfigFile(func(cfg *configFile) {
+		cfg.Server = server
+		cfg.DisableTLS = disableTLS
+	})
+}
+
+// SaveMacro stores the keys of a macro in the config file; a macro with the
+// same name is replaced
+func SaveMacro(name string, keys []*types.MacroKey) error {
+	return updateConfigFile(func(cfg *configFile) {
+		if cfg.Macros == nil {
+			cfg.Macros = make(map[string][]*types.MacroKey)
+		}
+
+		cfg.Macros[name] = keys
+	})
+}
+
+// Macros returns the macros stored in the config file, by name
+func Macros() (map[string][]*types.MacroKey, error) {
+	cfg, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.Macros, nil
+}
+
+// SaveSession stores the state of the tail view in the config file; nil
+// removes it
+func SaveSession(session *types.Session) error {
+	return updateConfigFile(func(cfg *configFile) {
+		cfg.Session = session
+	})
+}
+
+// SaveLatestVersion stores the latest release found by the update check
+func SaveLatestVersion(version string) error {
+	return updateConfigFile(func(cfg *configFile) {
+		now := time.Now().UTC()
+
+		cfg.LatestVersion = version
+		cfg.LatestCheckedAt = &now
+	})
+}
+
+// LatestVersion returns the latest release found by the last update check
+// and when it was checked (zero if never)
+func LatestVersion() (string, time.Time, error) {
+	cfg, err := readConfigFile()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if cfg.LatestCheckedAt == nil {
+		return "", time.Time{}, nil
+	}
+
+	return cfg.LatestVersion, *cfg.LatestCheckedAt, nil
+}
+
+// Session returns the session stored in the config file; nil is returned if
+// there is none
+func Session() (*types.Session, error) {
+	cfg, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.Session, nil
+}
+
+// updateConfigFile modifies the config file with update; the file is written
+// from several goroutines (ex: the update check and the tail view)
+func updateConfigFile(update func(cfg *configFile)) error {
+	configFileMu.Lock()
+	defer configFileMu.Unlock()
+
+	cfg, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+
+	update(cfg)
+
+	return writeConfigFile(cfg)
+}
+
+// readConfigFile returns the contents of the config file; an empty config is
+// returned if the file does not exist yet
+func readConfigFile() (*configFile, error) {
+	cfg := &configFile{}
+
+	if !exists(configFileName) {
+		return cfg, nil
+	}
+
+	data, err := getConfigFile(configFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal config file")
+	}
+
+	return cfg, nil
+}
+
+func writeConfigFile(cfg *configFile) error {
 	configDir, err := getConfigDir()
 	if err != nil {
 		return errors.Wrap(err, "unable to locate config directory")
 	}
-	configPath := path.Join(configDir, configFileName)
 
-	cfg := &configFile{
-		InstallID: installID,
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return errors.Wrap(err, "unable to create config directory")
 	}
 
 	data, err := json.Marshal(cfg)
@@ -95,13 +235,40 @@ func saveInstallID(installID string) error {
 		return errors.Wrap(err, "unable to marshal config file")
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := os.WriteFile(path.Join(configDir, configFileName), data, 0644); err != nil {
 		return errors.Wrap(err, "unable to write config file")
 	}
 
 	return nil
 }
 
+// fileResolver uses the values in the config file (ex: "server") as defaults
+// for the flags of the same name; nil is returned if there is no config file.
+// Kong applies resolvers after env vars so env vars are checked here in order
+// for them to still take precedence over the file.
+func fileResolver() kong.Resolver {
+	data, err := getConfigFile(configFileName)
+	if err != nil {
+		return nil
+	}
+
+	resolver, err := kong.JSON(bytes.NewReader(data))
+	if err != nil {
+		log.Debug("unable to parse config file", "err", err.Error())
+		return nil
+	}
+
+	return kong.ResolverFunc(func(kctx *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		for _, env := range flag.Envs {
+			if os.Getenv(env) != "" {
+				return nil, nil
+			}
+		}
+
+		return resolver.Resolve(kctx, parent, flag)
+	})
+}
+
 func getConfigFile(fileName string) ([]byte, error) {
 	configDir, err := getConfigDir()
 	if err != nil {
@@ -138,6 +305,21 @@ func exists(fileName string) bool {
 	return true
 }
 
+// FilePath returns the path to the CLI config file
+func FilePath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to locate config directory")
+	}
+
+	return path.Join(configDir, configFileName), nil
+}
+
+// Dir returns the directory of the CLI config file (ex: ~/.streamdal)
+func Dir() (string, error) {
+	return getConfigDir()
+}
+
 // getConfigDir returns a directory where the batch configuration will be stored
 func getConfigDir() (string, error) {
 	// Get user's home directory
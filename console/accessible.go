@@ -0,0 +1,68 @@
+package console
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// hideBorders draws borders as blank space for the screen reader friendly
+// mode (see util.Accessible); box drawing characters are otherwise read out
+// one by one. Titles are still displayed.
+func hideBorders() {
+	tview.Borders.Horizontal = ' '
+	tview.Borders.Vertical = ' '
+	tview.Borders.TopLeft = ' '
+	tview.Borders.TopRight = ' '
+	tview.Borders.BottomLeft = ' '
+	tview.Borders.BottomRight = ' '
+
+	tview.Borders.LeftT = ' '
+	tview.Borders.RightT = ' '
+	tview.Borders.TopT = ' '
+	tview.Borders.BottomT = ' '
+	tview.Borders.Cross = ' '
+
+	tview.Borders.HorizontalFocus = ' '
+	tview.Borders.VerticalFocus = ' '
+	tview.Borders.TopLeftFocus = ' '
+	tview.Borders.TopRightFocus = ' '
+	tview.Borders.BottomLeftFocus = ' '
+	tview.Borders.BottomRightFocus = ' '
+}
+
+// asciiBorders draws borders with plain ASCII characters for the ASCII-only
+// mode (see util.ASCII); focused borders use '=' instead of '-'
+func asciiBorders() {
+	tview.Borders.Horizontal = '-'
+	tview.Borders.Vertical = '|'
+	tview.Borders.TopLeft = '+'
+	tview.Borders.TopRight = '+'
+	tview.Borders.BottomLeft = '+'
+	tview.Borders.BottomRight = '+'
+
+	tview.Borders.LeftT = '+'
+	tview.Borders.RightT = '+'
+	tview.Borders.TopT = '+'
+	tview.Borders.BottomT = '+'
+	tview.Borders.Cross = '+'
+
+	tview.Borders.HorizontalFocus = '='
+	tview.Borders.VerticalFocus = '|'
+	tview.Borders.TopLeftFocus = '+'
+	tview.Borders.TopRightFocus = '+'
+	tview.Borders.BottomLeftFocus = '+'
+	tview.Borders.BottomRightFocus = '+'
+}
+
+// menuEntryHighlighted returns true if the region of a menu entry (ex:
+// `[white]Q[-] ["Q"][#9D87D7]Quit[-][""]`) is one of highlights
+func menuEntryHighlighted(entry string, highlights []string) bool {
+	for _, region := range highlights {
+		if strings.Contains(entry, `["`+region+`"]`) {
+			return true
+		}
+	}
+
+	return false
+}
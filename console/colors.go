@@ -22,6 +22,18 @@ const (
 	InputFieldBg
 	WindowBg
 	CLIBg
+	StatusOK
+	StatusWarning
+	StatusError
+	FilterHighlightFg
+	FilterHighlightBg
+	SearchHighlightFg
+	SearchHighlightBg
+	JSONKey
+	JSONString
+	JSONNumber
+	JSONBool
+	JSONNull
 )
 
 const (
@@ -43,7 +55,7 @@ var (
 			Name:       "light purple",
 			Hex256:     fmt.Sprintf("#%X", tcell.Color141.Hex()),
 			Tcell256:   tcell.Color141,
-			Hex24Bit:   fmt.Sprintf("#%X", tcell.NewRGBColor(157, 135, 215)),
+			Hex24Bit:   fmt.Sprintf("#%X", tcell.NewRGBColor(157, 135, 215).Hex()),
 			Tcell24Bit: tcell.NewRGBColor(157, 135, 215),
 		},
 		TextAccent1: {
@@ -69,14 +81,14 @@ var (
 		},
 		ActiveButtonBg: {
 			Name:       "red",
-			Hex256:     fmt.Sprintf("%X", tcell.Color203.Hex()),
+			Hex256:     fmt.Sprintf("#%X", tcell.Color203.Hex()),
 			Tcell256:   tcell.Color203,
 			Hex24Bit:   fmt.Sprintf("#%X", tcell.NewRGBColor(255, 114, 93).Hex()),
 			Tcell24Bit: tcell.NewRGBColor(255, 114, 93),
 		},
 		ActiveButtonFg: {
 			Name:       "white",
-			Hex256:     fmt.Sprintf("%X", tcell.ColorWhite.Hex()),
+			Hex256:     fmt.Sprintf("#%X", tcell.ColorWhite.Hex()),
 			Tcell256:   tcell.ColorWhite,
 			Hex24Bit:   fmt.Sprintf("#%X", tcell.NewRGBColor(255, 255, 255).Hex()),
 			Tcell24Bit: tcell.ColorWhite,
@@ -92,7 +104,7 @@ var (
 			Name:       "dark grey",
 			Hex256:     fmt.Sprintf("#%X", tcell.Color239.Hex()),
 			Tcell256:   tcell.Color239,
-			Hex24Bit:   fmt.Sprintf("%X", tcell.Color239.Hex()),
+			Hex24Bit:   fmt.Sprintf("#%X", tcell.Color239.Hex()),
 			Tcell24Bit: tcell.ColorWhite,
 		},
 		MenuActiveBg: {
@@ -137,16 +149,54 @@ var (
 			Hex24Bit:   fmt.Sprintf("#%X", tcell.NewRGBColor(40, 40, 40).Hex()),
 			Tcell24Bit: tcell.NewRGBColor(40, 40, 40),
 		},
+		StatusOK:          themeColor("green", tcell.ColorGreen),
+		StatusWarning:     themeColor("yellow", tcell.ColorYellow),
+		StatusError:       themeColor("red", tcell.ColorRed),
+		FilterHighlightFg: themeColor("green", tcell.ColorGreen),
+		FilterHighlightBg: themeColor("gray", tcell.ColorGray),
+		SearchHighlightFg: themeColor("blue", tcell.ColorBlue),
+		SearchHighlightBg: themeColor("gray", tcell.ColorGray),
+
+		// JSON syntax colors of the tail view (see View Options > Colors)
+		JSONKey:    themeColor("light purple", tcell.NewRGBColor(157, 135, 215)),
+		JSONString: themeColor("cyan", tcell.NewRGBColor(33, 196, 199)),
+		JSONNumber: themeColor("yellow", tcell.NewRGBColor(255, 204, 85)),
+		JSONBool:   themeColor("white", tcell.ColorWhite),
+		JSONNull:   themeColor("red", tcell.NewRGBColor(255, 114, 93)),
 	}
 
 	DefaultColor = Color{
 		Name:       "default white",
-		Hex256:     fmt.Sprintf("%X", tcell.ColorWhite.Hex()),
+		Hex256:     fmt.Sprintf("#%X", tcell.ColorWhite.Hex()),
 		Tcell256:   tcell.ColorWhite,
-		Hex24Bit:   fmt.Sprintf("%X", tcell.ColorWhite.Hex()),
+		Hex24Bit:   fmt.Sprintf("#%X", tcell.ColorWhite.Hex()),
 		Tcell24Bit: tcell.ColorWhite,
 	}
 
+	// ComponentColors are used for telling apart lines from different
+	// components when several components are tailed at once
+	ComponentColors = []tcell.Color{
+		tcell.Color81,
+		tcell.Color214,
+		tcell.Color120,
+		tcell.Color213,
+		tcell.Color227,
+		tcell.Color141,
+		tcell.Color203,
+		tcell.Color51,
+	}
+
+	// SearchGroupColors are used (in order, wrapping around) for highlighting
+	// the capture groups of a regex search; the rest of the match uses
+	// SearchHighlightFg/SearchHighlightBg
+	SearchGroupColors = []tcell.Color{
+		tcell.ColorYellow,
+		tcell.ColorFuchsia,
+		tcell.ColorAqua,
+		tcell.ColorLime,
+		tcell.ColorOrange,
+	}
+
 	TerminalColorMode ColorMode // Set during init()
 )
 
@@ -193,3 +243,16 @@ func Tcell(e Element) tcell.Color {
 
 	return DefaultColor.Tcell256
 }
+
+// DimHex returns the color of e at a third of its brightness (ex: the off
+// beat of the heartbeat pulse), as a tview color tag
+func DimHex(e Element) string {
+	r, g, b := Tcell(e).RGB()
+
+	return HexColor(tcell.NewRGBColor(r/3, g/3, b/3))
+}
+
+// HexColor returns the tview color tag representation of a tcell color
+func HexColor(c tcell.Color) string {
+	return fmt.Sprintf("#%06X", c.Hex())
+}
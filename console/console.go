@@ -2,8 +2,11 @@ package console
 
 import (
 	"fmt"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/log"
@@ -13,6 +16,9 @@ import (
 	"github.com/streamdal/snitch-protos/build/go/protos"
 
 	"github.com/streamdal/cli/config"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/export"
+	"github.com/streamdal/cli/query"
 	"github.com/streamdal/cli/types"
 	"github.com/streamdal/cli/util"
 )
@@ -26,6 +32,28 @@ const (
 	PrimitiveFilter     = "filter"
 	PrimitiveSearch     = "search"
 	PrimitiveRate       = "rate"
+	PrimitiveMaxLines   = "max_lines"
+	PrimitiveComponents = "components"
+	PrimitiveTimeWindow = "time_window"
+	PrimitiveBreak      = "break"
+	PrimitiveBookmarks  = "bookmarks"
+	PrimitiveNote       = "note"
+	PrimitiveExport     = "export"
+	PrimitiveShare      = "share"
+	PrimitiveCompare    = "compare"
+	PrimitiveCorrKey    = "correlation_key"
+	PrimitiveSetup      = "setup"
+	PrimitiveEvents     = "events"
+	PrimitiveDetail     = "detail"
+	PrimitiveQuery      = "query"
+	PrimitiveSnapshots  = "snapshots"
+	PrimitiveSnapName   = "snapshot_name"
+	PrimitiveSnapshot   = "snapshot"
+	PrimitiveSnapDiff   = "snapshot_diff"
+	PrimitiveFinder     = "finder"
+	PrimitiveConfirm    = "confirm"
+	PrimitiveKeys       = "keys"
+	PrimitiveMacroName  = "macro_name"
 
 	PageConnectionAttempt = "page_" + PrimitiveInfoModal
 	PageConnectionRetry   = "page_" + PrimitiveRetryModal
@@ -35,6 +63,45 @@ const (
 	PageFilter            = "page_" + PrimitiveFilter
 	PageSearch            = "page_" + PrimitiveSearch
 	PageRate              = "page_" + PrimitiveRate
+	PageMaxLines          = "page_" + PrimitiveMaxLines
+	PageComponents        = "page_" + PrimitiveComponents
+	PageTimeWindow        = "page_" + PrimitiveTimeWindow
+	PageBreak             = "page_" + PrimitiveBreak
+	PageBookmarks         = "page_" + PrimitiveBookmarks
+	PageNote              = "page_" + PrimitiveNote
+	PageExport            = "page_" + PrimitiveExport
+	PageShare             = "page_" + PrimitiveShare
+	PageCompare           = "page_" + PrimitiveCompare
+	PageCorrelationKey    = "page_" + PrimitiveCorrKey
+	PageSetup             = "page_" + PrimitiveSetup
+	PageEvents            = "page_" + PrimitiveEvents
+	PageDetail            = "page_" + PrimitiveDetail
+	PageQuery             = "page_" + PrimitiveQuery
+	PageSnapshots         = "page_" + PrimitiveSnapshots
+	PageSnapshotName      = "page_" + PrimitiveSnapName
+	PageSnapshot          = "page_" + PrimitiveSnapshot
+	PageSnapshotDiff      = "page_" + PrimitiveSnapDiff
+	PageFinder            = "page_" + PrimitiveFinder
+	PageConfirm           = "page_" + PrimitiveConfirm
+	PageKeys              = "page_" + PrimitiveKeys
+	PageMacroName         = "page_" + PrimitiveMacroName
+
+	// QueryMaxCellWidth is the width values are truncated to on the query page
+	QueryMaxCellWidth = 80
+
+	// DiffMaxCellWidth is the width values are truncated to in snapshot diffs
+	DiffMaxCellWidth = 40
+
+	// SelectPageSize is the number of components displayed per page of the
+	// select list
+	SelectPageSize = 8
+
+	// ToastDuration is how long a toast is displayed above the tail view
+	ToastDuration = 5 * time.Second
+
+	// SearchPreviewDelay is how long typing has to pause before the search
+	// being typed is previewed in the tail view
+	SearchPreviewDelay = 300 * time.Millisecond
 
 	DefaultViewOptionsPrettyJSON         = true
 	DefaultViewOptionsEnableColors       = true
@@ -43,100 +110,1242 @@ const (
 )
 
 var (
+	// ExportFormatLabels are displayed in the export dialog
+	ExportFormatLabels = map[string]string{
+		export.FormatHTML:     "HTML report",
+		export.FormatMarkdown: "Markdown",
+		export.FormatParquet:  "Parquet (columns from JSON fields)",
+	}
+
+	// RateSliderValues are the sample rates selectable in the rate dialog
+	// (0 == sampling disabled)
+	RateSliderValues = []int{0, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
 	MenuString = `[white]Q[-] ["Q"][#9D87D7]Quit[-][""]  ` +
 		`[white]S[-] ["S"][#9D87D7]Select Component[-][""]  ` +
 		`[white]R[-] ["R"][#9D87D7::s]Set Sample Rate[-:-:-][""]  ` +
 		`[white]F[-] ["F"][#9D87D7]Filter[-][""]  ` +
 		`[white]P[-] ["P"][#9D87D7]Pause[-][""]  ` +
-		`[white]O[-] ["O"][#9D87D7]View Options[-][""] ` +
-		`[white]/[-] ["Search"][#9D87D7]Search[-][""]`
+		`[white]O[-] ["O"][#9D87D7]View Options[-][""]  ` +
+		`[white]T[-] ["T"][#9D87D7]Trace[-][""]  ` +
+		`[white]L[-] ["L"][#9D87D7]Max Lines[-][""]  ` +
+		`[white]M[-] ["M"][#9D87D7]Mark[-][""]  ` +
+		`[white]J[-] ["J"][#9D87D7]Jump[-][""]  ` +
+		`[white]N[-] ["N"][#9D87D7]Note[-][""]  ` +
+		`[white]X[-] ["X"][#9D87D7]Export[-][""]  ` +
+		`[white]H[-] ["H"][#9D87D7]Share[-][""]  ` +
+		`[white]C[-] ["C"][#9D87D7]Components[-][""]  ` +
+		`[white]W[-] ["W"][#9D87D7]Window[-][""]  ` +
+		`[white]B[-] ["B"][#9D87D7]Break[-][""]  ` +
+		`[white]V[-] ["V"][#9D87D7]Compare[-][""]  ` +
+		`[white]K[-] ["K"][#9D87D7]Key[-][""]  ` +
+		`[white]E[-] ["E"][#9D87D7]Events[-][""]  ` +
+		`[white]A[-] ["A"][#9D87D7]Query[-][""]  ` +
+		`[white]Z[-] ["Z"][#9D87D7]Snapshots[-][""]  ` +
+		`[white]I[-] ["I"][#9D87D7]Operation[-][""]  ` +
+		`[white]U[-] ["U"][#9D87D7]UTC[-][""]  ` +
+		`[white]Tab[-] ["Tab"][#9D87D7]Producer/Consumer[-][""]  ` +
+		`[white]←/→[-] ["Chips"][#9D87D7]Filters[-][""]  ` +
+		`[white]^P[-] ["Find"][#9D87D7]Find[-][""]  ` +
+		`[white]^^[-] ["Previous"][#9D87D7]Previous[-][""]  ` +
+		`[white]^L[-] ["Clear"][#9D87D7]Clear[-][""]  ` +
+		`[white]Enter[-] ["Detail"][#9D87D7]Detail[-][""]  ` +
+		`[white]/[-] ["Search"][#9D87D7]Search[-][""]  ` +
+		`[white]^R[-] ["Macro"][#9D87D7]Record[-][""]  ` +
+		`[white]@[-] ["Play"][#9D87D7]Play[-][""]  ` +
+		`[white]?[-] ["Keys"][#9D87D7]Keys[-][""]  ` +
+		`[white]Esc[-] ["Back"][#9D87D7]Back[-][""]`
 )
 
-type Console struct {
-	app     *tview.Application
-	layout  *tview.Flex
-	menu    *tview.TextView
-	pages   *tview.Pages
-	options *Options
-	log     *log.Logger
-	started bool
+type Console struct {
+	app        *tview.Application
+	layout     *tview.Flex
+	menu       *tview.TextView
+	breadcrumb *tview.TextView
+	stats      *tview.TextView
+	zone       *tview.TextView
+	statusBar  *tview.Flex
+
+	// menuText is the (unwrapped) menu; wrapMenu() only re-wraps the menu
+	// when it, the screen width or the stats/breadcrumb changed
+	menuText       string
+	menuWrapped    string
+	menuHighlights string
+	menuWidth      int
+	menuRightWidth int
+	pages          *tview.Pages
+
+	// setupStatus displays the result of testing the connection in the setup
+	// wizard
+	setupStatus *tview.TextView
+
+	// tailHeader is pinned above the tail view and summarizes its settings
+	tailHeader *tview.TextView
+
+	// tailChips lists the active filters of the tail view (see SetTailChips)
+	tailChips *tview.TextView
+
+	tailLayout *tview.Flex
+
+	// tailToast is displayed below the header (and hidden after
+	// ToastDuration); toastSeq prevents an older toast from hiding a newer one
+	tailToast *tview.TextView
+	toastSeq  int
+
+	// tailTabs lists the operation tabs (producer/consumer) of the tail view;
+	// collapsed when the tailed components are not split into tabs
+	tailTabs *tview.TextView
+
+	// windowTitle is the terminal window title that was last set; empty if
+	// it has not been set (see SetWindowTitle)
+	windowTitle string
+
+	// displayed is the text last queued for display in the widgets that are
+	// updated periodically (see changed); text that did not change does not
+	// cause a redraw
+	displayed   map[string]string
+	displayedMu sync.Mutex
+
+	// inputCapture is the input capture set with SetInputCapture; macro
+	// records the keys that go through it
+	inputCapture func(event *tcell.EventKey) *tcell.EventKey
+	macro        macroRecorder
+
+	options *Options
+	log     *log.Logger
+	started bool
+}
+
+type Options struct {
+	Config *config.Config
+	Crash  *crash.Reporter
+	Logger *log.Logger
+}
+
+func New(opts *Options) (*Console, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate config")
+	}
+
+	c := &Console{
+		options:   opts,
+		log:       opts.Logger.WithPrefix("console"),
+		displayed: make(map[string]string),
+	}
+
+	if err := SetTheme(opts.Config.Theme); err != nil {
+		return nil, errors.Wrap(err, "unable to set theme")
+	}
+
+	if err := SetKeyBindings(opts.Config.KeyBinding); err != nil {
+		return nil, errors.Wrap(err, "unable to set key bindings")
+	}
+
+	if err := c.initializeComponents(); err != nil {
+		return nil, errors.Wrap(err, "unable to initialize components")
+	}
+
+	return c, nil
+}
+
+// SetInputCapture sets the function keys go through before the focused
+// primitive; keys are recorded for macros beforehand (see captureInput)
+func (c *Console) SetInputCapture(f func(event *tcell.EventKey) *tcell.EventKey) {
+	c.inputCapture = f
+}
+
+func (c *Console) GetInputCapture() func(event *tcell.EventKey) *tcell.EventKey {
+	return c.inputCapture
+}
+
+// SetBreadcrumb displays the path of views the user has navigated through
+func (c *Console) SetBreadcrumb(crumbs []string) {
+	for i, crumb := range crumbs {
+		crumbs[i] = tview.Escape(crumb)
+	}
+
+	// Current view is highlighted
+	if len(crumbs) > 0 {
+		crumbs[len(crumbs)-1] = fmt.Sprintf("[%s::b]%s[-::-]", Hex(TextPrimary), crumbs[len(crumbs)-1])
+	}
+
+	text := "[gray]" + strings.Join(crumbs, util.Glyph(" › ", " > ", " > ")) + "[-] "
+
+	update := func() {
+		c.breadcrumb.SetText(text)
+		c.statusBar.ResizeItem(c.breadcrumb, tview.TaggedStringWidth(text), 0)
+	}
+
+	// QueueUpdateDraw() blocks until the app is running
+	if !c.started {
+		update()
+		return
+	}
+
+	c.app.QueueUpdateDraw(update)
+}
+
+// SetWindowTitle sets the title of the terminal window (and of the tmux
+// window when running inside tmux). The original title is saved the first
+// time and restored by Stop(). Nothing is done if stdout is not a terminal or
+// --disable-window-title is set.
+func (c *Console) SetWindowTitle(title string) {
+	if c.options.Config.DisableWindowTitle || !isTerminal(os.Stdout) {
+		return
+	}
+
+	// Names come from the server; never pass on control characters
+	title = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+
+		return r
+	}, title)
+
+	if title == c.windowTitle {
+		return
+	}
+
+	sequence := "\x1b]2;" + title + "\x07"
+
+	if c.windowTitle == "" {
+		// Push the current title on the xterm title stack
+		sequence = "\x1b[22;2t" + sequence
+	}
+
+	if os.Getenv("TMUX") != "" {
+		sequence += "\x1bk" + title + "\x1b\\"
+	}
+
+	c.windowTitle = title
+
+	// Written from the UI goroutine so that it is not interleaved with
+	// screen updates
+	update := func() {
+		fmt.Fprint(os.Stdout, sequence)
+	}
+
+	if !c.started {
+		update()
+		return
+	}
+
+	c.app.QueueUpdate(update)
+}
+
+// isTerminal returns true if f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// SetStats displays stats (ex: bandwidth) in the status bar, left of the
+// breadcrumb; an empty text hides them
+func (c *Console) SetStats(text string) {
+	if text != "" {
+		text = "  [gray]" + text + "[-]  "
+	}
+
+	if !c.changed("stats", text) {
+		return
+	}
+
+	c.app.QueueUpdateDraw(func() {
+		c.stats.SetText(text)
+		c.statusBar.ResizeItem(c.stats, tview.TaggedStringWidth(text), 0)
+	})
+}
+
+// changed records text as the text displayed in widget; false if it already
+// was, in which case there is nothing to redraw. Idle tail views are then not
+// redrawn every second.
+func (c *Console) changed(widget, text string) bool {
+	c.displayedMu.Lock()
+	defer c.displayedMu.Unlock()
+
+	if prev, ok := c.displayed[widget]; ok && prev == text {
+		return false
+	}
+
+	c.displayed[widget] = text
+
+	return true
+}
+
+// UpdateTimeZone displays the time zone timestamps are currently displayed in
+// (see util.SetUTC) in the status bar
+func (c *Console) UpdateTimeZone() {
+	c.app.QueueUpdateDraw(c.setTimeZone)
+}
+
+func (c *Console) setTimeZone() {
+	text := "  [gray]" + util.TimeZone() + "[-] "
+
+	if !util.UTC() {
+		text = "  [gray]local " + util.TimeZone() + "[-] "
+	}
+
+	c.zone.SetText(text)
+	c.statusBar.ResizeItem(c.zone, tview.TaggedStringWidth(text), 0)
+}
+
+func (c *Console) ToggleAllMenuHighlights() {
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight(c.menu.GetHighlights()...)
+	})
+}
+
+func (c *Console) ToggleMenuHighlight(regions ...string) {
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight(regions...)
+	})
+}
+
+func (c *Console) SetMenuEntryOn(item string) {
+	c.toggleMenuEntry(item, true)
+}
+
+func (c *Console) SetMenuEntryOff(item string) {
+	c.toggleMenuEntry(item, false)
+}
+
+func (c *Console) toggleMenuEntry(text string, on bool) {
+	replaceOld := fmt.Sprintf("[%s]%s[-]", Hex(MenuInactiveFg), text)
+	replaceNew := fmt.Sprintf("[%s]%s[-]", Hex(MenuActiveBg), text)
+
+	if !on {
+		replaceOld = fmt.Sprintf("[%s]%s[-]", Hex(MenuActiveBg), text)
+		replaceNew = fmt.Sprintf("[%s]%s[-]", Hex(MenuInactiveFg), text)
+	}
+
+	// The menu is re-wrapped (and redrawn) by wrapMenu()
+	c.app.QueueUpdateDraw(func() {
+		c.menuText = strings.Replace(c.menuText, replaceOld, replaceNew, -1)
+	})
+}
+
+// DisplayFilter asks for the filter (a substring or a CEL expression) and
+// whether substrings only match whole words; test describes how the current
+// input is interpreted and whether the last received message matches it.
+func (c *Console) DisplayFilter(defaultValue *types.TermRequest, test func(string, bool) string, answerCh chan<- *types.TermRequest) {
+	c.displayExpression(PageFilter, "Filter", defaultValue, true, test, func(req *types.TermRequest) {
+		answerCh <- req
+	})
+}
+
+// DisplaySearch asks for the search term and whether it only matches whole
+// words. While typing, preview is called with the term once typing pauses (see
+// SearchPreviewDelay), from a goroutine other than the UI goroutine; it
+// highlights the term in the tail view and returns a summary (ex: the number
+// of matching lines) for the dialog.
+func (c *Console) DisplaySearch(defaultValue *types.TermRequest, preview func(string, bool) string, answerCh chan<- *types.TermRequest) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in search view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	input := *defaultValue
+
+	result := tview.NewTextView().SetDynamicColors(true)
+	result.SetBackgroundColor(Tcell(WindowBg))
+	result.SetBorderPadding(0, 0, 1, 1)
+
+	// Restarted on every change; stopped once the dialog is answered
+	var timer *time.Timer
+
+	stopPreview := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+
+	startPreview := func() {
+		stopPreview()
+
+		term, wholeWord := input.Term, input.WholeWord
+
+		timer = time.AfterFunc(SearchPreviewDelay, func() {
+			summary := preview(term, wholeWord)
+
+			c.app.QueueUpdateDraw(func() {
+				result.SetText(summary)
+			})
+		})
+	}
+
+	answer := func(value *types.TermRequest) {
+		stopPreview()
+		answerCh <- value
+	}
+
+	form := tview.NewForm().
+		AddInputField("Term", input.Term, 30, nil, func(text string) {
+			input.Term = text
+			startPreview()
+		}).
+		AddCheckbox("Whole word", input.WholeWord, func(checked bool) {
+			input.WholeWord = checked
+			startPreview()
+		}).
+		AddButton("OK", func() {
+			answer(&input)
+		}).
+		AddButton("Reset", func() {
+			answer(&types.TermRequest{})
+		}).
+		AddButton("Cancel", func() {
+			// Return the original value
+			answer(defaultValue)
+		})
+
+	form.SetBackgroundColor(Tcell(WindowBg))
+	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	form.SetFieldTextColor(Tcell(InputFieldFg))
+	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(form, 7, 0, true).
+		AddItem(result, 1, 0, false)
+
+	layout.SetBorder(true).SetTitle("Search (text or /regex/)")
+	layout.SetBackgroundColor(Tcell(WindowBg))
+	layout.SetTitleColor(Tcell(TextPrimary))
+
+	inputDialog := Center(layout, 48, 10)
+	c.pages.AddPage(PageSearch, inputDialog, true, true)
+}
+
+// DisplayBookmarks displays a jump menu with all bookmarked records; the line
+// number of the selected bookmark is sent to answerCh (0 if canceled).
+func (c *Console) DisplayBookmarks(bookmarks []*types.TailRecord, answerCh chan<- int) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in bookmarks view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	list := tview.NewList()
+
+	list.SetBackgroundColor(Tcell(WindowBg))
+	list.SetMainTextColor(Tcell(TextPrimary))
+	list.SetSecondaryTextColor(Tcell(TextSecondary))
+	list.SetBorder(true)
+	list.SetTitle("Bookmarks (Enter: jump, Esc: cancel)")
+	list.SetTitleColor(Tcell(TextPrimary))
+
+	for _, record := range bookmarks {
+		lineNum := record.LineNum
+
+		main := fmt.Sprintf("[::b][%d][-:-:-] %s", lineNum, util.Clock(record.Received))
+
+		if record.Note != "" {
+			main += fmt.Sprintf(" [%s]%s %s[-]", Hex(TextAccent1), util.Glyph("✎", ">", "note:"), tview.Escape(record.Note))
+		}
+
+		list.AddItem(main, tview.Escape(bookmarkPreview(record.Data)), 0, func() {
+			answerCh <- lineNum
+		})
+	}
+
+	if len(bookmarks) == 0 {
+		list.AddItem("No bookmarks", "Press M in the tail view to bookmark a line", 0, func() {
+			answerCh <- 0
+		})
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			answerCh <- 0
+			return nil
+		}
+
+		return event
+	})
+
+	dialog := Center(list, 64, 12)
+	c.pages.AddPage(PageBookmarks, dialog, true, true)
+}
+
+// DisplayLineDetail lists the fields of a line; picking a field sends a filter
+// for payloads where the field has the same value to answerCh. If where is
+// set, the first entry clears it (an empty FieldFilter is sent). nil is sent
+// if the dialog was closed.
+func (c *Console) DisplayLineDetail(lineNum int, fields []*util.JSONField, where *types.FieldFilter, answerCh chan<- *types.FieldFilter) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in line detail view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	list := tview.NewList()
+
+	list.SetBackgroundColor(Tcell(WindowBg))
+	list.SetMainTextColor(Tcell(TextPrimary))
+	list.SetSecondaryTextColor(Tcell(TextSecondary))
+	list.SetBorder(true)
+	list.SetTitle(fmt.Sprintf("Line %d (Enter: filter where field equals value, Esc: close)", lineNum))
+	list.SetTitleColor(Tcell(TextPrimary))
+
+	if where != nil {
+		list.AddItem(fmt.Sprintf("[%s]%sClear filter[-]", Hex(TextAccent1), util.Glyph("✕ ", "x ", "")), tview.Escape(where.Path+" == "+where.Value), 0, func() {
+			answerCh <- &types.FieldFilter{}
+		})
+	}
+
+	for _, field := range fields {
+		field := field
+
+		list.AddItem(tview.Escape(field.Path), tview.Escape(field.Value), 0, func() {
+			answerCh <- &types.FieldFilter{Path: field.Path, Value: field.Value}
+		})
+	}
+
+	if len(fields) == 0 {
+		list.AddItem("No fields", "Only JSON payloads can be filtered by field", 0, func() {
+			answerCh <- nil
+		})
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			answerCh <- nil
+			return nil
+		}
+
+		return event
+	})
+
+	dialog := Center(list, 80, 20)
+	c.pages.AddPage(PageDetail, dialog, true, true)
+}
+
+// DisplayQuery displays the query page: queries are run with run when Enter
+// is pressed and the result is displayed in a table. The last query is sent to
+// answerCh when the page is closed.
+func (c *Console) DisplayQuery(defaultQuery string, run func(string) (*query.Result, error), answerCh chan<- string) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in query view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	input := tview.NewInputField().SetLabel(" > ").SetText(defaultQuery)
+	input.SetBackgroundColor(Tcell(WindowBg))
+	input.SetLabelColor(Tcell(TextSecondary))
+	input.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	input.SetFieldTextColor(Tcell(InputFieldFg))
+
+	status := tview.NewTextView().SetDynamicColors(true)
+	status.SetBackgroundColor(Tcell(WindowBg))
+	status.SetBorderPadding(0, 0, 1, 1)
+
+	table := tview.NewTable().SetFixed(1, 0).SetSelectable(true, false).SetSeparator(tview.Borders.Vertical)
+	table.SetBackgroundColor(Tcell(WindowBg))
+	table.SetBordersColor(Tcell(TextSecondary))
+	table.SetSelectedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+
+	execute := func() {
+		result, err := run(input.GetText())
+		if err != nil {
+			status.SetText(fmt.Sprintf("[%s]%s[-]", Hex(TextAccent1), tview.Escape(err.Error())))
+			return
+		}
+
+		displayQueryResult(table, result, QueryMaxCellWidth)
+
+		rows := fmt.Sprintf("%d rows", len(result.Rows))
+
+		if len(result.Rows) == 1 {
+			rows = "1 row"
+		}
+
+		status.SetText("[gray]" + rows + "[-]")
+	}
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(status, 1, 0, false).
+		AddItem(table, 0, 1, false)
+
+	layout.SetBorder(true)
+	layout.SetTitle("Query (Enter: run, Tab: switch to results/query, Esc: close)")
+	layout.SetBackgroundColor(Tcell(WindowBg))
+	layout.SetTitleColor(Tcell(TextPrimary))
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			execute()
+		case tcell.KeyTab:
+			c.app.SetFocus(table)
+		case tcell.KeyEscape:
+			answerCh <- input.GetText()
+		}
+	})
+
+	table.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyTab, tcell.KeyBacktab:
+			c.app.SetFocus(input)
+		case tcell.KeyEscape:
+			answerCh <- input.GetText()
+		}
+	})
+
+	if defaultQuery != "" {
+		execute()
+	}
+
+	c.pages.AddPage(PageQuery, layout, true, true)
+}
+
+// DisplayFinder is a fuzzy finder over audiences: typing narrows down the
+// list (best matches first), Up/Down move the selection and Enter sends the
+// selected audience to answerCh; nil is sent if the finder was closed. The
+// list is replaced by every list read from updatesCh until it is closed.
+func (c *Console) DisplayFinder(audiences []*protos.Audience, updatesCh <-chan []*protos.Audience, answerCh chan<- *protos.Audience) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in finder
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	input := tview.NewInputField().SetLabel(" > ")
+	input.SetBackgroundColor(Tcell(WindowBg))
+	input.SetLabelColor(Tcell(TextSecondary))
+	input.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	input.SetFieldTextColor(Tcell(InputFieldFg))
+
+	status := tview.NewTextView().SetDynamicColors(true)
+	status.SetBackgroundColor(Tcell(WindowBg))
+	status.SetTextColor(Tcell(TextSecondary))
+	status.SetBorderPadding(0, 0, 1, 1)
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBackgroundColor(Tcell(WindowBg))
+	list.SetMainTextColor(Tcell(TextPrimary))
+	list.SetHighlightFullLine(true)
+
+	fetching := updatesCh != nil
+	matches := make([]*protos.Audience, 0)
+
+	// refresh lists the audiences matching the input; must be called from the
+	// UI goroutine
+	refresh := func() {
+		type match struct {
+			audience *protos.Audience
+			score    int
+			text     string
+		}
+
+		found := make([]*match, 0)
+
+		for _, aud := range audiences {
+			text := util.FormatAudience(aud)
+
+			score, positions, ok := util.FuzzyMatch(input.GetText(), text)
+			if !ok {
+				continue
+			}
+
+			found = append(found, &match{audience: aud, score: score, text: fuzzyHighlight(text, positions)})
+		}
+
+		sort.SliceStable(found, func(i, j int) bool {
+			return found[i].score > found[j].score
+		})
+
+		list.Clear()
+		matches = matches[:0]
+
+		for _, m := range found {
+			list.AddItem(m.text, "", 0, nil)
+			matches = append(matches, m.audience)
+		}
+
+		switch {
+		case len(audiences) == 0 && fetching:
+			status.SetText("Fetching live components...")
+		case len(audiences) == 0:
+			status.SetText("No live components")
+		default:
+			status.SetText(fmt.Sprintf("%d/%d components", len(matches), len(audiences)))
+		}
+	}
+
+	input.SetChangedFunc(func(_ string) {
+		refresh()
+	})
+
+	// The input keeps the focus; the selection is moved from here
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyCtrlP:
+			// Negative indexes count from the end of the list
+			if current := list.GetCurrentItem(); current > 0 {
+				list.SetCurrentItem(current - 1)
+			}
+		case tcell.KeyDown, tcell.KeyCtrlN:
+			list.SetCurrentItem(list.GetCurrentItem() + 1)
+		default:
+			return event
+		}
+
+		return nil
+	})
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			if len(matches) == 0 {
+				return
+			}
+
+			c.pages.RemovePage(PageFinder)
+			answerCh <- matches[list.GetCurrentItem()]
+		case tcell.KeyEscape:
+			c.pages.RemovePage(PageFinder)
+			answerCh <- nil
+		}
+	})
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(status, 1, 0, false).
+		AddItem(list, 0, 1, false)
+
+	layout.SetBorder(true)
+	layout.SetTitle("Find component (Enter: peek, Esc: close)")
+	layout.SetBackgroundColor(Tcell(WindowBg))
+	layout.SetTitleColor(Tcell(TextPrimary))
+
+	if updatesCh != nil {
+		crash.Go(func() {
+			for update := range updatesCh {
+				update := update
+
+				c.app.QueueUpdateDraw(func() {
+					audiences = update
+					refresh()
+				})
+			}
+
+			c.app.QueueUpdateDraw(func() {
+				fetching = false
+				refresh()
+			})
+		})
+	}
+
+	c.app.QueueUpdateDraw(func() {
+		refresh()
+
+		c.pages.AddPage(PageFinder, Center(layout, 70, 20), true, true)
+	})
+}
+
+// fuzzyHighlight highlights the runes of text at the given positions (see
+// util.FuzzyMatch)
+func fuzzyHighlight(text string, positions []int) string {
+	matched := make(map[int]bool, len(positions))
+
+	for _, p := range positions {
+		matched[p] = true
+	}
+
+	var sb strings.Builder
+
+	for i, r := range []rune(text) {
+		if matched[i] {
+			sb.WriteString(fmt.Sprintf("[%s::b]%s[-::-]", Hex(TextAccent2), tview.Escape(string(r))))
+			continue
+		}
+
+		sb.WriteString(tview.Escape(string(r)))
+	}
+
+	return sb.String()
+}
+
+// DisplaySnapshots lists the snapshots of the buffer. Enter views a snapshot
+// (or takes a new one), Space marks a snapshot, d compares the marked
+// snapshot (before) with the current one or, if none is marked, the current
+// snapshot with the live buffer and Delete removes it. The snapshot named
+// current (if any) is selected. nil is sent to answerCh if the dialog was
+// closed.
+func (c *Console) DisplaySnapshots(snapshots []*types.Snapshot, current string, answerCh chan<- *types.SnapshotRequest) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in snapshots view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	list := tview.NewList()
+
+	list.SetBackgroundColor(Tcell(WindowBg))
+	list.SetMainTextColor(Tcell(TextPrimary))
+	list.SetSecondaryTextColor(Tcell(TextSecondary))
+	list.SetBorder(true)
+	list.SetTitle("Snapshots (Enter: view, Space: mark, D: diff, Del: delete, Esc: close)")
+	list.SetTitleColor(Tcell(TextPrimary))
+
+	list.AddItem(fmt.Sprintf("[%s]%sTake snapshot[-]", Hex(TextAccent1), util.Glyph("＋ ", "+ ", "")), "Copy the lines of the tail view and keep capturing", 0, func() {
+		answerCh <- &types.SnapshotRequest{Action: types.SnapshotTake}
+	})
+
+	// Index of the marked snapshot; -1 if none
+	marked := -1
+
+	snapshotText := func(i int) string {
+		text := "[::b]" + tview.Escape(snapshots[i].Name) + "[-:-:-]"
+
+		if i == marked {
+			text += fmt.Sprintf(" [%s]%s[-]", Hex(TextAccent2), util.Glyph("● marked", "* marked", "(marked)"))
+		}
+
+		return text
+	}
+
+	snapshotInfo := func(i int) string {
+		return fmt.Sprintf("%d lines, taken %s", len(snapshots[i].Records), util.Clock(snapshots[i].Taken))
+	}
+
+	for i, snapshot := range snapshots {
+		name := snapshot.Name
+
+		list.AddItem(snapshotText(i), snapshotInfo(i), 0, func() {
+			answerCh <- &types.SnapshotRequest{Action: types.SnapshotView, Name: name}
+		})
+
+		if name == current {
+			list.SetCurrentItem(i + 1)
+		}
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// The first item takes a new snapshot
+		current := list.GetCurrentItem() - 1
+
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			answerCh <- nil
+			return nil
+		case current < 0:
+			return event
+		case event.Key() == tcell.KeyRune && event.Rune() == ' ':
+			prev := marked
+
+			if marked == current {
+				marked = -1
+			} else {
+				marked = current
+			}
+
+			// Already running in the UI goroutine
+			if prev >= 0 {
+				list.SetItemText(prev+1, snapshotText(prev), snapshotInfo(prev))
+			}
+
+			list.SetItemText(current+1, snapshotText(current), snapshotInfo(current))
+
+			return nil
+		case event.Key() == tcell.KeyRune && (event.Rune() == 'd' || event.Rune() == 'D'):
+			req := &types.SnapshotRequest{Action: types.SnapshotDiff, Name: snapshots[current].Name}
+
+			if marked >= 0 && marked != current {
+				req.Other = snapshots[marked].Name
+			}
+
+			answerCh <- req
+
+			return nil
+		case event.Key() == tcell.KeyDelete || event.Key() == tcell.KeyBackspace2:
+			answerCh <- &types.SnapshotRequest{Action: types.SnapshotDelete, Name: snapshots[current].Name}
+			return nil
+		}
+
+		return event
+	})
+
+	dialog := Center(list, 80, 16)
+	c.pages.AddPage(PageSnapshots, dialog, true, true)
+}
+
+// DisplaySnapshotName asks for the name of a new snapshot; an empty string is
+// sent to answerCh if canceled
+func (c *Console) DisplaySnapshotName(defaultValue string, answerCh chan<- string) {
+	c.displayName(PageSnapshotName, "Snapshot Name", defaultValue, answerCh)
+}
+
+// DisplayMacroName asks for the name a recorded macro is saved as; an empty
+// string is sent to answerCh if canceled
+func (c *Console) DisplayMacroName(defaultValue string, answerCh chan<- string) {
+	c.displayName(PageMacroName, "Save Macro As", defaultValue, answerCh)
+}
+
+// displayName asks for a name; the name is trimmed and an empty string is sent
+// to answerCh if canceled
+func (c *Console) displayName(page, title, defaultValue string, answerCh chan<- string) {
+	c.Start()
+
+	input := defaultValue
+
+	form := tview.NewForm().
+		AddInputField("", defaultValue, 30, nil, func(text string) {
+			input = text
+		}).
+		AddButton("OK", func() {
+			answerCh <- strings.TrimSpace(input)
+		}).
+		AddButton("Cancel", func() {
+			answerCh <- ""
+		})
+
+	form.SetBorder(true).SetTitle(title)
+	form.SetBackgroundColor(Tcell(WindowBg))
+	form.SetTitleColor(Tcell(TextPrimary))
+	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	form.SetFieldTextColor(Tcell(InputFieldFg))
+	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	form.SetCancelFunc(func() {
+		answerCh <- ""
+	})
+
+	dialog := Center(form, 36, 7)
+	c.pages.AddPage(page, dialog, true, true)
+}
+
+// DisplaySnapshot displays the (formatted) lines of a snapshot; answerCh is
+// notified when the page is closed
+func (c *Console) DisplaySnapshot(title, text string, answerCh chan<- struct{}) {
+	c.Start()
+
+	view := tview.NewTextView().SetDynamicColors(true).SetRegions(true)
+	view.SetBorder(true)
+	view.SetTitle(title + " (Esc: close)")
+	view.SetTitleColor(Tcell(TextPrimary))
+	view.SetText(text)
+	view.ScrollToEnd()
+
+	view.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			// Otherwise it stays visible below the snapshots dialog
+			c.pages.RemovePage(PageSnapshot)
+			answerCh <- struct{}{}
+		}
+	})
+
+	c.pages.AddPage(PageSnapshot, view, true, true)
+}
+
+// DisplaySnapshotDiff displays the comparison of two snapshots as a table;
+// answerCh is notified when the page is closed
+func (c *Console) DisplaySnapshotDiff(title string, result *query.Result, answerCh chan<- struct{}) {
+	c.Start()
+
+	table := tview.NewTable().SetFixed(1, 0).SetSelectable(true, false).SetSeparator(tview.Borders.Vertical)
+	table.SetBorder(true)
+	table.SetTitle(title + " (Esc: close)")
+	table.SetTitleColor(Tcell(TextPrimary))
+	table.SetBackgroundColor(Tcell(WindowBg))
+	table.SetBordersColor(Tcell(TextSecondary))
+	table.SetSelectedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+
+	displayQueryResult(table, result, DiffMaxCellWidth)
+
+	table.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			// Otherwise it stays visible below the snapshots dialog
+			c.pages.RemovePage(PageSnapshotDiff)
+			answerCh <- struct{}{}
+		}
+	})
+
+	c.pages.AddPage(PageSnapshotDiff, table, true, true)
+}
+
+// displayQueryResult replaces the content of table with result; values are
+// truncated to maxWidth and newlines are removed so that every row is a single
+// line
+func displayQueryResult(table *tview.Table, result *query.Result, maxWidth int) {
+	table.Clear()
+
+	for i, column := range result.Columns {
+		table.SetCell(0, i, tview.NewTableCell(tview.Escape(column)).
+			SetTextColor(Tcell(TextSecondary)).
+			SetAttributes(tcell.AttrBold).
+			SetSelectable(false))
+	}
+
+	for i, row := range result.Rows {
+		for j, value := range row {
+			value = strings.Join(strings.Fields(value), " ")
+
+			table.SetCell(i+1, j, tview.NewTableCell(tview.Escape(value)).
+				SetTextColor(Tcell(TextPrimary)).
+				SetMaxWidth(maxWidth))
+		}
+	}
+
+	table.ScrollToBeginning()
+	table.Select(1, 0)
 }
 
-type Options struct {
-	Config *config.Config
-	Logger *log.Logger
-}
+// bookmarkPreview returns the first line of a payload, truncated so that it
+// fits in the bookmarks dialog
+func bookmarkPreview(data []byte) string {
+	preview := strings.SplitN(string(data), "\n", 2)[0]
 
-func New(opts *Options) (*Console, error) {
-	if err := validateOptions(opts); err != nil {
-		return nil, errors.Wrap(err, "unable to validate config")
+	if runes := []rune(preview); len(runes) > 56 {
+		preview = string(runes[:56]) + util.Glyph("…", "...", "...")
 	}
 
-	c := &Console{
-		options: opts,
-		log:     opts.Logger.WithPrefix("console"),
+	return preview
+}
+
+// DisplayExport asks for the format, file and (optional) range of lines the
+// tail view should be exported to; nil is sent to answerCh if the export is
+// canceled. The filename is updated when the format changes unless the user
+// already edited it.
+func (c *Console) DisplayExport(defaultLines string, filename func(format string) string, answerCh chan<- *types.ExportRequest) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in export view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	req := &types.ExportRequest{
+		Format: export.Formats[0],
+		Path:   filename(export.Formats[0]),
+		Lines:  defaultLines,
 	}
 
-	if err := c.initializeComponents(); err != nil {
-		return nil, errors.Wrap(err, "unable to initialize components")
+	labels := make([]string, len(export.Formats))
+
+	for i, format := range export.Formats {
+		labels[i] = ExportFormatLabels[format]
 	}
 
-	return c, nil
-}
+	form := tview.NewForm()
 
-func (c *Console) SetInputCapture(f func(event *tcell.EventKey) *tcell.EventKey) {
-	c.app.SetInputCapture(f)
-}
+	pathField := tview.NewInputField().
+		SetLabel("File").
+		SetText(req.Path).
+		SetFieldWidth(48)
 
-func (c *Console) GetInputCapture() func(event *tcell.EventKey) *tcell.EventKey {
-	return c.app.GetInputCapture()
+	pathField.SetChangedFunc(func(text string) {
+		req.Path = text
+	})
+
+	form.AddDropDown("Format", labels, 0, func(_ string, idx int) {
+		if idx < 0 {
+			return
+		}
+
+		// Only replace the filename if it was not edited
+		if req.Path == filename(req.Format) {
+			pathField.SetText(filename(export.Formats[idx]))
+		}
+
+		req.Format = export.Formats[idx]
+	})
+
+	form.AddFormItem(pathField)
+
+	form.AddInputField("Lines", defaultLines, 16, nil, func(text string) {
+		req.Lines = text
+	})
+
+	form.AddButton("Export", func() {
+		req.Path = strings.TrimSpace(req.Path)
+
+		if req.Path == "" {
+			answerCh <- nil
+			return
+		}
+
+		answerCh <- req
+	}).
+		AddButton("Cancel", func() {
+			answerCh <- nil
+		})
+
+	form.SetBorder(true).SetTitle("Export (Lines: empty for all, ex: 10-20)")
+	form.SetBackgroundColor(Tcell(WindowBg))
+	form.SetTitleColor(Tcell(TextPrimary))
+	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	form.SetFieldTextColor(Tcell(InputFieldFg))
+	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	dialog := Center(form, 64, 11)
+	c.pages.AddPage(PageExport, dialog, true, true)
 }
 
-func (c *Console) ToggleAllMenuHighlights() {
+// DisplaySetup displays the first-run setup wizard for choosing the server,
+// auth token and TLS settings; nil is sent to answerCh if the user quits.
+func (c *Console) DisplaySetup(defaults *types.SetupRequest, answerCh chan<- *types.SetupRequest) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in setup view
 	c.app.QueueUpdateDraw(func() {
-		c.menu.Highlight(c.menu.GetHighlights()...)
+		c.menu.Highlight()
 	})
-}
 
-func (c *Console) ToggleMenuHighlight(regions ...string) {
+	req := &types.SetupRequest{
+		Server:     defaults.Server,
+		Auth:       defaults.Auth,
+		DisableTLS: defaults.DisableTLS,
+	}
+
+	intro := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true).
+		SetText(" Enter the address of your Streamdal server and an auth token;\n" +
+			" the connection is tested before the settings are saved.")
+	intro.SetBackgroundColor(Tcell(WindowBg))
+	intro.SetTextColor(Tcell(TextPrimary))
+
+	c.setupStatus = tview.NewTextView().SetDynamicColors(true).SetWordWrap(true)
+	c.setupStatus.SetBackgroundColor(Tcell(WindowBg))
+
+	form := tview.NewForm().
+		AddInputField("Server", req.Server, 40, nil, func(text string) {
+			req.Server = strings.TrimSpace(text)
+		}).
+		AddPasswordField("Auth token", req.Auth, 40, '*', func(text string) {
+			req.Auth = strings.TrimSpace(text)
+		}).
+		AddCheckbox("Use TLS", !req.DisableTLS, func(checked bool) {
+			req.DisableTLS = !checked
+		}).
+		AddButton("Connect", func() {
+			if req.Server == "" || req.Auth == "" {
+				// Already running in the UI goroutine
+				c.setupStatus.SetText(" [" + Hex(StatusError) + "]Server and auth token are required[-]")
+				return
+			}
+
+			answerCh <- req
+		}).
+		AddButton("Quit", func() {
+			answerCh <- nil
+		})
+
+	form.SetBackgroundColor(Tcell(WindowBg))
+	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	form.SetFieldTextColor(Tcell(InputFieldFg))
+	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(intro, 2, 0, false).
+		AddItem(form, 0, 1, true).
+		AddItem(c.setupStatus, 3, 0, false)
+
+	layout.SetBorder(true).SetTitle("Setup")
+	layout.SetBackgroundColor(Tcell(WindowBg))
+	layout.SetTitleColor(Tcell(TextPrimary))
+
+	dialog := Center(layout, 68, 17)
+	// Nothing else triggers a redraw on first launch
 	c.app.QueueUpdateDraw(func() {
-		c.menu.Highlight(regions...)
+		c.pages.AddPage(PageSetup, dialog, true, true)
+		c.pages.SwitchToPage(PageSetup)
 	})
 }
 
-func (c *Console) SetMenuEntryOn(item string) {
-	c.toggleMenuEntry(item, true)
-}
+// SetSetupStatus updates the status line of the setup wizard
+func (c *Console) SetSetupStatus(text string) {
+	if c.setupStatus == nil {
+		return
+	}
 
-func (c *Console) SetMenuEntryOff(item string) {
-	c.toggleMenuEntry(item, false)
+	c.app.QueueUpdateDraw(func() {
+		c.setupStatus.SetText(" " + text)
+	})
 }
 
-func (c *Console) toggleMenuEntry(text string, on bool) {
-	menu := c.menu.GetText(false)
-
-	replaceOld := fmt.Sprintf("[%s]%s[-]", Hex(MenuInactiveFg), text)
-	replaceNew := fmt.Sprintf("[%s]%s[-]", Hex(MenuActiveBg), text)
+// DisplayShare asks for the range of lines to share to Slack and an optional
+// message; nil is sent to answerCh if sharing is canceled.
+func (c *Console) DisplayShare(defaultLines string, answerCh chan<- *types.ShareRequest) {
+	c.Start()
 
-	var updatedMenu string
+	// Remove all menu highlights - you cannot access menu while in share view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
 
-	if !on {
-		replaceOld = fmt.Sprintf("[%s]%s[-]", Hex(MenuActiveBg), text)
-		replaceNew = fmt.Sprintf("[%s]%s[-]", Hex(MenuInactiveFg), text)
+	req := &types.ShareRequest{
+		Lines: defaultLines,
 	}
 
-	updatedMenu = strings.Replace(menu, replaceOld, replaceNew, -1)
+	form := tview.NewForm().
+		AddInputField("Lines", defaultLines, 16, nil, func(text string) {
+			req.Lines = text
+		}).
+		AddInputField("Message", "", 42, nil, func(text string) {
+			req.Message = text
+		}).
+		AddButton("Share", func() {
+			answerCh <- req
+		}).
+		AddButton("Cancel", func() {
+			answerCh <- nil
+		})
 
-	c.app.QueueUpdateDraw(func() {
-		c.menu.Clear()
-		fmt.Fprint(c.menu, updatedMenu)
+	form.SetBorder(true).SetTitle("Share to Slack (Lines ex: 10-20)")
+	form.SetBackgroundColor(Tcell(WindowBg))
+	form.SetTitleColor(Tcell(TextPrimary))
+	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	form.SetFieldTextColor(Tcell(InputFieldFg))
+	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	dialog := Center(form, 58, 9)
+	c.pages.AddPage(PageShare, dialog, true, true)
+}
+
+// DisplayBreak asks for the "break" expression (a substring or a CEL
+// expression); the tail is automatically paused when a payload matches it.
+func (c *Console) DisplayBreak(defaultValue string, test func(string, bool) string, answerCh chan<- string) {
+	c.displayExpression(PageBreak, "Break On", &types.TermRequest{Term: defaultValue}, false, test, func(req *types.TermRequest) {
+		answerCh <- req.Term
 	})
 }
 
-func (c *Console) DisplayFilter(defaultValue string, answerCh chan<- string) {
+// DisplayNote asks for a short note to attach to the given line
+func (c *Console) DisplayNote(lineNum int, defaultValue string, answerCh chan<- string) {
+	c.displayInput(PageNote, fmt.Sprintf("Note for line %d", lineNum), defaultValue, 48, answerCh)
+}
+
+// displayInput displays a dialog with a single input field; the original value
+// is sent to answerCh on "Cancel" and an empty string on "Reset".
+func (c *Console) displayInput(page, title, defaultValue string, width int, answerCh chan<- string) {
 	c.Start()
 
-	// Remove all menu highlights - you cannot access menu while in filter view
+	// Remove all menu highlights - you cannot access menu while in an input dialog
 	c.app.QueueUpdateDraw(func() {
 		c.menu.Highlight()
 	})
@@ -145,12 +1354,12 @@ func (c *Console) DisplayFilter(defaultValue string, answerCh chan<- string) {
 	var input string
 
 	form := tview.NewForm().
-		AddInputField("", defaultValue, 30, nil, func(text string) {
+		AddInputField("", defaultValue, width, nil, func(text string) {
 			hit = true
 			input = text
 		}).
 		AddButton("OK", func() {
-			// Use the original value if te user didn't edit input field
+			// Use the original value if the user didn't edit input field
 			if !hit {
 				input = defaultValue
 			}
@@ -165,7 +1374,7 @@ func (c *Console) DisplayFilter(defaultValue string, answerCh chan<- string) {
 			answerCh <- defaultValue
 		})
 
-	form.SetBorder(true).SetTitle("Filter")
+	form.SetBorder(true).SetTitle(title)
 	form.SetBackgroundColor(Tcell(WindowBg))
 	form.SetTitleColor(Tcell(TextPrimary))
 	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
@@ -174,53 +1383,79 @@ func (c *Console) DisplayFilter(defaultValue string, answerCh chan<- string) {
 	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
 	form.SetButtonsAlign(tview.AlignCenter)
 
-	inputDialog := Center(form, 36, 7)
-	c.pages.AddPage(PageFilter, inputDialog, true, true)
+	inputDialog := Center(form, width+6, 7)
+	c.pages.AddPage(page, inputDialog, true, true)
 }
 
-func (c *Console) DisplaySearch(defaultValue string, answerCh chan<- string) {
+// displayExpression is displayInput() for expressions; the result of test is
+// displayed below the input field and updated as the user types. The "Whole
+// word" checkbox is only displayed if wholeWord is set.
+func (c *Console) displayExpression(page, title string, defaultValue *types.TermRequest, wholeWord bool, test func(string, bool) string, answer func(*types.TermRequest)) {
 	c.Start()
 
-	// Remove all menu highlights - you cannot access menu while in search view
+	// Remove all menu highlights - you cannot access menu while in an input dialog
 	c.app.QueueUpdateDraw(func() {
 		c.menu.Highlight()
 	})
 
-	var hit bool
-	var input string
+	input := *defaultValue
+
+	result := tview.NewTextView().SetDynamicColors(true).SetWrap(true)
+	result.SetBackgroundColor(Tcell(WindowBg))
+	result.SetBorderPadding(0, 0, 2, 2)
+	result.SetText(test(input.Term, input.WholeWord))
+
+	// Labels are aligned; the input field is labeled along with the checkbox
+	label, width, formHeight := "", 56, 5
+
+	if wholeWord {
+		label, width, formHeight = "Expression", 48, 7
+	}
 
 	form := tview.NewForm().
-		AddInputField("", defaultValue, 30, nil, func(text string) {
-			hit = true
-			input = text
-		}).
-		AddButton("OK", func() {
-			// Use the original value if the user didn't edit input field
-			if !hit {
-				input = defaultValue
-			}
+		AddInputField(label, input.Term, width, nil, func(text string) {
+			input.Term = text
 
-			answerCh <- input
+			// Called from the UI goroutine; no need to queue a redraw
+			result.SetText(test(input.Term, input.WholeWord))
+		})
+
+	if wholeWord {
+		form.AddCheckbox("Whole word", input.WholeWord, func(checked bool) {
+			input.WholeWord = checked
+			result.SetText(test(input.Term, input.WholeWord))
+		})
+	}
+
+	form.
+		AddButton("OK", func() {
+			answer(&input)
 		}).
 		AddButton("Reset", func() {
-			answerCh <- ""
+			answer(&types.TermRequest{})
 		}).
 		AddButton("Cancel", func() {
 			// Return the original value
-			answerCh <- defaultValue
+			answer(defaultValue)
 		})
 
-	form.SetBorder(true).SetTitle("Search")
 	form.SetBackgroundColor(Tcell(WindowBg))
-	form.SetTitleColor(Tcell(TextPrimary))
 	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
 	form.SetFieldTextColor(Tcell(InputFieldFg))
 	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
 	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
 	form.SetButtonsAlign(tview.AlignCenter)
 
-	inputDialog := Center(form, 36, 7)
-	c.pages.AddPage(PageSearch, inputDialog, true, true)
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(form, formHeight, 0, true).
+		AddItem(result, 2, 0, false)
+
+	layout.SetBorder(true).SetTitle(title + " (substring or CEL expression)")
+	layout.SetBackgroundColor(Tcell(WindowBg))
+	layout.SetTitleColor(Tcell(TextPrimary))
+
+	dialog := Center(layout, 64, formHeight+4)
+	c.pages.AddPage(page, dialog, true, true)
 }
 
 func (c *Console) DisplayViewOptions(defaultViewOptions *types.ViewOptions, answerCh chan<- *types.ViewOptions) {
@@ -246,6 +1481,15 @@ func (c *Console) DisplayViewOptions(defaultViewOptions *types.ViewOptions, answ
 		EnableColors:       defaultViewOptions.EnableColors,
 		DisplayLineNumbers: defaultViewOptions.DisplayLineNumbers,
 		DisplayTimestamp:   defaultViewOptions.DisplayTimestamp,
+		Decimate:           defaultViewOptions.Decimate,
+		MaskSecrets:        defaultViewOptions.MaskSecrets,
+		DetectPII:          defaultViewOptions.DetectPII,
+		RawNumbers:         defaultViewOptions.RawNumbers,
+	}
+
+	decimate := defaultViewOptions.Decimate
+	if decimate < 1 {
+		decimate = 1
 	}
 
 	optsDialog := tview.NewForm().
@@ -261,6 +1505,20 @@ func (c *Console) DisplayViewOptions(defaultViewOptions *types.ViewOptions, answ
 		AddCheckbox("Display Line Numbers", defaultViewOptions.DisplayLineNumbers, func(checked bool) {
 			selectedOptions.DisplayLineNumbers = checked
 		}).
+		AddCheckbox("Mask Secrets", defaultViewOptions.MaskSecrets, func(checked bool) {
+			selectedOptions.MaskSecrets = checked
+		}).
+		AddCheckbox("Detect PII", defaultViewOptions.DetectPII, func(checked bool) {
+			selectedOptions.DetectPII = checked
+		}).
+		AddCheckbox("Raw Numbers", defaultViewOptions.RawNumbers, func(checked bool) {
+			selectedOptions.RawNumbers = checked
+		}).
+		AddInputField("Show 1 in N", strconv.Itoa(decimate), 6, tview.InputFieldInteger, func(text string) {
+			// Invalid (or empty) input displays all messages
+			n, _ := strconv.Atoi(text)
+			selectedOptions.Decimate = n
+		}).
 		AddButton("OK", func() {
 			answerCh <- selectedOptions
 		}).
@@ -288,64 +1546,241 @@ func (c *Console) DisplayViewOptions(defaultViewOptions *types.ViewOptions, answ
 
 		// TODO: Figure out left/right/up/down capture + SetFocus (doesn't seem to work?)
 
-		return event
-	})
+		return event
+	})
+
+	viewOptionsDialog := Center(optsDialog, 30, 21)
+	c.pages.AddPage(PageRate, viewOptionsDialog, true, true)
+}
+
+// DisplayRate displays the sample rate dialog. The rate is picked with a
+// slider; the resulting msgs/sec is previewed based on the throughput that was
+// observed while tailing (observedRate).
+func (c *Console) DisplayRate(defaultValue int, observedRate float64, answerCh chan<- int) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in rate view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	slider := NewSlider("Rate Per Second", RateSliderValues, defaultValue)
+
+	preview := tview.NewTextView().
+		SetLabel("Resulting Rate").
+		SetSize(1, 0).
+		SetScrollable(false).
+		SetText(formatRatePreview(defaultValue, observedRate))
+
+	slider.SetChangedFunc(func(value int) {
+		preview.SetText(formatRatePreview(value, observedRate))
+	})
+
+	form := tview.NewForm().
+		AddFormItem(slider).
+		AddFormItem(preview).
+		AddButton("OK", func() {
+			answerCh <- slider.GetValue()
+		}).
+		AddButton("Reset", func() {
+			answerCh <- 0
+		}).
+		AddButton("Cancel", func() {
+			// Return the original value
+			answerCh <- defaultValue
+		})
+
+	form.SetBorder(true).SetTitle("Set Sample Rate (" + util.Glyph("←/→", "Left/Right", "Left/Right") + " to adjust)")
+	form.SetBackgroundColor(Tcell(WindowBg))
+	form.SetTitleColor(Tcell(TextPrimary))
+	form.SetLabelColor(Tcell(TextPrimary))
+	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	form.SetFieldTextColor(Tcell(InputFieldFg))
+	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	inputDialog := Center(form, 56, 9)
+	c.pages.AddPage(PageRate, inputDialog, true, true)
+}
+
+// formatRatePreview describes the msgs/sec that will be displayed with the
+// given sample rate (0 == sampling disabled).
+func formatRatePreview(rate int, observedRate float64) string {
+	if rate == 0 || float64(rate) >= observedRate {
+		return fmt.Sprintf("~%s msgs/sec (all)", util.FormatRate(observedRate))
+	}
+
+	return fmt.Sprintf("~%s msgs/sec (%.0f%% of %s)", util.FormatCount(int64(rate)), float64(rate)/observedRate*100,
+		util.FormatRate(observedRate))
+}
+
+// DisplayMaxLines displays a dialog for changing the maximum number of lines
+// kept in the tail view. avgSize is the average size of the payloads seen so
+// far and is used for estimating how much memory the new setting will use.
+func (c *Console) DisplayMaxLines(defaultValue, avgSize int, answerCh chan<- int) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in max lines view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	warning := tview.NewTextView().
+		SetDynamicColors(true).
+		SetSize(2, 0).
+		SetScrollable(false).
+		SetText(formatMaxLinesWarning(defaultValue, avgSize))
+
+	input := strconv.Itoa(defaultValue)
+
+	form := tview.NewForm().
+		AddInputField("Max Lines", input, 10, tview.InputFieldInteger, func(text string) {
+			input = text
+
+			if n, err := strconv.Atoi(text); err == nil {
+				warning.SetText(formatMaxLinesWarning(n, avgSize))
+			}
+		}).
+		AddFormItem(warning).
+		AddButton("OK", func() {
+			n, err := strconv.Atoi(input)
+			if err != nil || n < 1 {
+				// Invalid input; keep the current value
+				answerCh <- defaultValue
+				return
+			}
+
+			answerCh <- n
+		}).
+		AddButton("Cancel", func() {
+			// Return the original value
+			answerCh <- defaultValue
+		})
+
+	form.SetBorder(true).SetTitle("Max Output Lines")
+	form.SetBackgroundColor(Tcell(WindowBg))
+	form.SetTitleColor(Tcell(TextPrimary))
+	form.SetLabelColor(Tcell(TextPrimary))
+	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	form.SetFieldTextColor(Tcell(InputFieldFg))
+	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	inputDialog := Center(form, 56, 10)
+	c.pages.AddPage(PageMaxLines, inputDialog, true, true)
+}
+
+func formatMaxLinesWarning(maxLines, avgSize int) string {
+	if avgSize == 0 {
+		return "[" + Hex(StatusWarning) + "]All lines are kept in memory; large values can use a lot of RAM[-]"
+	}
+
+	return fmt.Sprintf("[%s]All lines are kept in memory: ~%s at the current avg payload size (%s)[-]",
+		Hex(StatusWarning),
+		util.HumanizeBytes(int64(maxLines)*int64(avgSize)),
+		util.HumanizeBytes(int64(avgSize)),
+	)
+}
+
+// DisplayTimeWindow displays a dialog for limiting the tail view to a time
+// window (ex: "30s" or "14:02-14:05")
+func (c *Console) DisplayTimeWindow(defaultValue string, answerCh chan<- string) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in time window view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	input := defaultValue
+
+	help := tview.NewTextView().
+		SetSize(1, 0).
+		SetScrollable(false).
+		SetText("ex: 30s, 5m, 14:02 or 14:02-14:05")
+
+	form := tview.NewForm().
+		AddInputField("", defaultValue, 30, nil, func(text string) {
+			input = text
+		}).
+		AddFormItem(help).
+		AddButton("OK", func() {
+			answerCh <- input
+		}).
+		AddButton("Reset", func() {
+			answerCh <- ""
+		}).
+		AddButton("Cancel", func() {
+			// Return the original value
+			answerCh <- defaultValue
+		})
+
+	form.SetBorder(true).SetTitle("Time Window")
+	form.SetBackgroundColor(Tcell(WindowBg))
+	form.SetTitleColor(Tcell(TextPrimary))
+	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
+	form.SetFieldTextColor(Tcell(InputFieldFg))
+	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
+	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
+	form.SetButtonsAlign(tview.AlignCenter)
 
-	viewOptionsDialog := Center(optsDialog, 30, 13)
-	c.pages.AddPage(PageRate, viewOptionsDialog, true, true)
+	inputDialog := Center(form, 40, 9)
+	c.pages.AddPage(PageTimeWindow, inputDialog, true, true)
 }
 
-func (c *Console) DisplayRate(defaultValue int, answerCh chan<- int) {
+// DisplayComponentSettings displays a dialog with a filter for every
+// component that is being tailed. The filters are written to answerCh in the
+// same order as components.
+func (c *Console) DisplayComponentSettings(components []*types.TailComponent, answerCh chan<- []string) {
 	c.Start()
 
-	// Remove all menu highlights - you cannot access menu while in rate view
+	// Remove all menu highlights - you cannot access menu while in component settings
 	c.app.QueueUpdateDraw(func() {
 		c.menu.Highlight()
 	})
 
-	var hit bool
-	var inputStr string
-	var inputInt int
+	original := make([]string, len(components))
+	filters := make([]string, len(components))
 
-	form := tview.NewForm().
-		AddInputField("Rate Per Second", strconv.Itoa(defaultValue), 8, tview.InputFieldInteger, func(text string) {
-			hit = true
-			inputStr = text
-		}).
-		AddButton("OK", func() {
-			// Use the original value if te user didn't edit input field
-			if !hit {
-				inputStr = strconv.Itoa(defaultValue)
-			}
+	form := tview.NewForm()
 
-			var err error
+	for i, component := range components {
+		i := i
 
-			inputInt, err = strconv.Atoi(inputStr)
-			if err != nil {
-				panic(fmt.Sprintf("unexpected rate '%s' cannot be converted to int: %s", inputStr, err))
-			}
+		original[i] = component.Filter
+		filters[i] = component.Filter
 
-			answerCh <- inputInt
+		form.AddInputField(tview.Escape(component.Name), component.Filter, 24, nil, func(text string) {
+			filters[i] = text
+		})
+	}
+
+	form.
+		AddButton("OK", func() {
+			answerCh <- filters
 		}).
 		AddButton("Reset", func() {
-			answerCh <- 0
+			answerCh <- make([]string, len(components))
 		}).
 		AddButton("Cancel", func() {
-			// Return the original value
-			answerCh <- defaultValue
+			// Return the original values
+			answerCh <- original
 		})
 
-	form.SetBorder(true).SetTitle("Set Sample Rate")
+	form.SetBorder(true).SetTitle("Component Filters")
 	form.SetBackgroundColor(Tcell(WindowBg))
 	form.SetTitleColor(Tcell(TextPrimary))
+	form.SetLabelColor(Tcell(TextPrimary))
 	form.SetFieldBackgroundColor(Tcell(InputFieldBg))
 	form.SetFieldTextColor(Tcell(InputFieldFg))
 	form.SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg)))
 	form.SetButtonStyle(tcell.StyleDefault.Background(Tcell(InactiveButtonBg)).Foreground(Tcell(InactiveButtonFg)))
 	form.SetButtonsAlign(tview.AlignCenter)
 
-	inputDialog := Center(form, 36, 7)
-	c.pages.AddPage(PageRate, inputDialog, true, true)
+	dialog := Center(form, 56, 2*len(components)+5)
+	c.pages.AddPage(PageComponents, dialog, true, true)
 }
 
 // DisplayTail will display tail + write any actions we receive from the user
@@ -360,74 +1795,289 @@ func (c *Console) DisplayTail(pageTail *tview.TextView, tailComponent *types.Tai
 		pageTail = tview.NewTextView()
 		pageTail.SetBorder(true)
 		pageTail.SetDynamicColors(true)
+		pageTail.SetRegions(true)
 		pageTail.SetMaxLines(c.options.Config.MaxOutputLines)
 	}
 
 	// Always update title
 	pageTail.SetTitle(tailComponent.Name)
 
+	// The header is a separate (non-scrollable) row so that it stays visible
+	// regardless of how far the tail view is scrolled
+	if c.tailLayout == nil {
+		c.tailHeader = tview.NewTextView()
+		c.tailHeader.SetDynamicColors(true)
+		c.tailHeader.SetScrollable(false)
+		c.tailHeader.SetWrap(false)
+
+		c.tailToast = tview.NewTextView()
+		c.tailToast.SetDynamicColors(true)
+		c.tailToast.SetScrollable(false)
+		c.tailToast.SetWrap(false)
+		c.tailToast.SetBackgroundColor(Tcell(ActiveButtonBg))
+		c.tailToast.SetTextColor(Tcell(ActiveButtonFg))
+
+		c.tailTabs = tview.NewTextView()
+		c.tailTabs.SetDynamicColors(true)
+		c.tailTabs.SetScrollable(false)
+		c.tailTabs.SetWrap(false)
+
+		c.tailChips = tview.NewTextView()
+		c.tailChips.SetDynamicColors(true)
+		c.tailChips.SetScrollable(false)
+		c.tailChips.SetWrap(false)
+
+		// The chips, tabs and toast rows are collapsed until there is
+		// something to display
+		c.tailLayout = tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(c.tailHeader, 1, 0, false).
+			AddItem(c.tailChips, 0, 0, false).
+			AddItem(c.tailTabs, 0, 0, false).
+			AddItem(c.tailToast, 0, 0, false).
+			AddItem(pageTail, 0, 1, true)
+	}
+
 	// Highlight available keystrokes
 	c.app.QueueUpdateDraw(func() {
-		c.menu.Highlight("Q", "S", "P", "R", "F", "O", "Search")
+		c.menu.Highlight("Q", "S", "P", "R", "F", "O", "T", "L", "M", "J", "N", "X", "H", "C", "W", "B", "V", "E", "A", "Z", "I", "U", "Tab", "Chips", "Find", "Previous", "Clear", "Detail", "Search", "Macro", "Play", "Keys", "Back")
 	})
 
-	c.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyRune && event.Rune() == 'q' {
-			actionCh <- &types.Action{
-				Step: types.StepQuit,
-			}
+	c.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		binding := lookupKey(TailKeys, event)
+		if binding == nil {
+			return event
 		}
 
-		if event.Key() == tcell.KeyRune && event.Rune() == 's' {
-			actionCh <- &types.Action{
-				Step: types.StepSelect,
-			}
+		// Pass along TailComponent so that once a dialog (ex: filter) is
+		// done, tail() knows what component it was operating on.
+		actionCh <- &types.Action{
+			Step:          binding.Step,
+			Args:          binding.Args,
+			TailComponent: tailComponent,
 		}
 
-		if event.Key() == tcell.KeyRune && event.Rune() == 'o' {
-			actionCh <- &types.Action{
-				Step: types.StepViewOptions,
-			}
+		// Runes are also passed on to the tail view; other keys are consumed
+		if event.Key() == tcell.KeyRune {
+			return event
 		}
 
-		// TODO: Disabled until sampling is fully implemented in SDKs
-		//if event.Key() == tcell.KeyRune && event.Rune() == 'r' {
-		//	actionCh <- &types.Action{
-		//		Step: types.StepRate,
-		//	}
-		//}
+		return nil
+	})
 
-		if event.Key() == tcell.KeyRune && event.Rune() == 'p' {
-			actionCh <- &types.Action{
-				Step: types.StepPause,
-			}
+	c.pages.AddPage(PageTailView, c.tailLayout, true, true)
+	c.pages.SwitchToPage(PageTailView)
+
+	return pageTail
+}
+
+// SetTailHeader updates the settings header displayed above the tail view
+func (c *Console) SetTailHeader(text string) {
+	if c.tailHeader == nil || !c.changed("header", text) {
+		return
+	}
+
+	c.app.QueueUpdateDraw(func() {
+		c.tailHeader.SetText(text)
+	})
+}
+
+// SetTailChips updates the filter chips displayed above the tail view; the
+// row is hidden if text is empty
+func (c *Console) SetTailChips(text string) {
+	if c.tailChips == nil || !c.changed("chips", text) {
+		return
+	}
+
+	c.app.QueueUpdateDraw(func() {
+		height := 1
+
+		if text == "" {
+			height = 0
 		}
 
-		// Pass along TailComponent so that once filter view is done, tail()
-		// knows what component it was operating on.
-		if event.Key() == tcell.KeyRune && event.Rune() == 'f' {
-			actionCh <- &types.Action{
-				Step:          types.StepFilter,
-				TailComponent: tailComponent,
-			}
+		c.tailChips.SetText(text)
+		c.tailLayout.ResizeItem(c.tailChips, height, 0)
+	})
+}
+
+// SetTailTabs updates the operation tabs displayed above the tail view; the
+// row is hidden if text is empty
+func (c *Console) SetTailTabs(text string) {
+	if c.tailTabs == nil || !c.changed("tabs", text) {
+		return
+	}
+
+	c.app.QueueUpdateDraw(func() {
+		height := 1
+
+		if text == "" {
+			height = 0
 		}
 
-		// Pass along TailComponent so that once search view is done, tail()
-		// knows what component it was operating on.
-		if event.Key() == tcell.KeyRune && event.Rune() == '/' {
-			actionCh <- &types.Action{
-				Step:          types.StepSearch,
-				TailComponent: tailComponent,
-			}
+		c.tailTabs.SetText(text)
+		c.tailLayout.ResizeItem(c.tailTabs, height, 0)
+	})
+}
+
+// ShowToast briefly displays text below the tail view header; it is a no-op
+// if the tail view has not been displayed yet.
+func (c *Console) ShowToast(text string) {
+	if c.tailToast == nil {
+		return
+	}
+
+	c.app.QueueUpdateDraw(func() {
+		c.toastSeq++
+		seq := c.toastSeq
+
+		c.tailToast.SetText(" " + text)
+		c.tailLayout.ResizeItem(c.tailToast, 1, 0)
+
+		time.AfterFunc(ToastDuration, func() {
+			c.app.QueueUpdateDraw(func() {
+				if seq != c.toastSeq {
+					return
+				}
+
+				c.tailToast.SetText("")
+				c.tailLayout.ResizeItem(c.tailToast, 0, 0)
+			})
+		})
+	})
+}
+
+// DisplayNotifications lists notifications (newest first); answerCh is
+// notified when the list is closed
+func (c *Console) DisplayNotifications(notifications []*types.Notification, answerCh chan<- struct{}) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in events view
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	list := tview.NewList().ShowSecondaryText(false)
+
+	list.SetBackgroundColor(Tcell(WindowBg))
+	list.SetMainTextColor(Tcell(TextPrimary))
+	list.SetBorder(true)
+	list.SetTitle("Events (Esc: close)")
+	list.SetTitleColor(Tcell(TextPrimary))
+
+	for i := len(notifications) - 1; i >= 0; i-- {
+		n := notifications[i]
+
+		list.AddItem(fmt.Sprintf("[::b]%s[-:-:-] %s", util.Clock(n.Time), tview.Escape(n.Text)), "", 0, func() {
+			answerCh <- struct{}{}
+		})
+	}
+
+	if len(notifications) == 0 {
+		list.AddItem("No events yet", "", 0, func() {
+			answerCh <- struct{}{}
+		})
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			answerCh <- struct{}{}
+			return nil
 		}
 
 		return event
 	})
 
-	c.pages.AddPage(PageTailView, pageTail, true, true)
-	c.pages.SwitchToPage(PageTailView)
+	dialog := Center(list, 80, 16)
+	c.pages.AddPage(PageEvents, dialog, true, true)
+}
 
-	return pageTail
+// DisplayCompare displays two components in adjacent panes. Lines are not
+// wrapped so that aligned lines stay next to each other; both panes are always
+// scrolled together.
+func (c *Console) DisplayCompare(left, right *types.TailComponent, actionCh chan<- *types.Action) (*tview.TextView, *tview.TextView) {
+	c.Start()
+
+	newPane := func(component *types.TailComponent) *tview.TextView {
+		pane := tview.NewTextView()
+		pane.SetBorder(true)
+		pane.SetDynamicColors(true)
+		pane.SetRegions(true)
+		pane.SetWrap(false)
+		pane.SetTitle(component.Name)
+
+		return pane
+	}
+
+	leftPane, rightPane := newPane(left), newPane(right)
+
+	scroll := func(rows, columns int) {
+		row, column := leftPane.GetScrollOffset()
+
+		if row += rows; row < 0 {
+			row = 0
+		}
+
+		if column += columns; column < 0 {
+			column = 0
+		}
+
+		leftPane.ScrollTo(row, column)
+		rightPane.ScrollTo(row, column)
+	}
+
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight("Q", "S", "P", "F", "K", "Macro", "Play", "Keys", "Find", "Back")
+	})
+
+	c.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		_, _, _, height := leftPane.GetInnerRect()
+
+		// Scrolling is handled here (see the Local bindings of CompareKeys)
+		switch event.Key() {
+		case tcell.KeyUp:
+			scroll(-1, 0)
+		case tcell.KeyDown:
+			scroll(1, 0)
+		case tcell.KeyPgUp:
+			scroll(-height, 0)
+		case tcell.KeyPgDn:
+			scroll(height, 0)
+		case tcell.KeyLeft:
+			scroll(0, -4)
+		case tcell.KeyRight:
+			scroll(0, 4)
+		case tcell.KeyHome:
+			leftPane.ScrollToBeginning()
+			rightPane.ScrollToBeginning()
+		case tcell.KeyEnd:
+			leftPane.ScrollToEnd()
+			rightPane.ScrollToEnd()
+		default:
+			binding := lookupKey(CompareKeys, event)
+			if binding == nil {
+				return event
+			}
+
+			actionCh <- &types.Action{Step: binding.Step, Args: binding.Args}
+		}
+
+		return nil
+	})
+
+	layout := tview.NewFlex().
+		AddItem(leftPane, 0, 1, false).
+		AddItem(rightPane, 0, 1, false)
+
+	c.pages.AddPage(PageCompare, layout, true, true)
+	c.pages.SwitchToPage(PageCompare)
+
+	return leftPane, rightPane
+}
+
+// DisplayCorrelationKey asks for the JSONPath used for aligning lines in the
+// comparison view
+func (c *Console) DisplayCorrelationKey(defaultValue string, answerCh chan<- string) {
+	c.displayInput(PageCorrelationKey, "Correlation key (ex: $.order_id)", defaultValue, 36, answerCh)
 }
 
 func (c *Console) Start() {
@@ -435,13 +2085,17 @@ func (c *Console) Start() {
 		return
 	}
 
-	go func() {
+	crash.Go(func() {
+		// tview restores the terminal on panic but the CLI would still exit
+		// without a word about what happened
+		defer c.options.Crash.Recover()
+
 		c.app.SetRoot(c.layout, true).SetFocus(c.pages)
 
 		if err := c.app.Run(); err != nil {
 			panic("unable to .Run app: " + err.Error())
 		}
-	}()
+	})
 
 	time.Sleep(100 * time.Millisecond) // Hack to give tview app enough time to start
 
@@ -485,6 +2139,30 @@ func (c *Console) DisplayRetryModal(msg, pageName string, answerCh chan bool) {
 	})
 }
 
+// DisplayConfirm asks a yes/no question; Esc answers no
+func (c *Console) DisplayConfirm(msg, confirmLabel string, answerCh chan<- bool) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while confirming
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	confirmModal := tview.NewModal().
+		SetText(msg).
+		AddButtons([]string{confirmLabel, "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, _ string) {
+			c.pages.RemovePage(PageConfirm)
+			answerCh <- buttonIndex == 0
+		}).
+		SetBackgroundColor(Tcell(WindowBg)).
+		SetTextColor(tcell.ColorWhite).
+		SetButtonActivatedStyle(tcell.StyleDefault.Background(Tcell(ActiveButtonBg)).Foreground(Tcell(ActiveButtonFg))).
+		SetButtonStyle(tcell.StyleDefault.Foreground(Tcell(InactiveButtonFg)).Background(Tcell(InactiveButtonBg)))
+
+	c.pages.AddPage(PageConfirm, confirmModal, true, true)
+}
+
 // DisplayInfoModal will display an animated modal with the given message.
 // InputCh is used by caller to indicate that the modal can be closed (in this
 // case, it will cause the method to stop the animation goroutine).
@@ -518,8 +2196,27 @@ func (c *Console) DisplayInfoModal(msg string, quitAnimationCh chan struct{}, an
 
 	// First time seeing this component - launch progress update goroutine; once
 	// goroutine exits, it removes the component from the primitives map as well
-	go func() {
+	crash.Go(func() {
+		// The spinner redraws the screen 10 times a second; it is replaced by
+		// a static ellipsis when animations are disabled and left out in
+		// screen reader friendly mode (it would be read out)
+		if util.Accessible() || c.options.Config.DisableAnimations {
+			if !util.Accessible() {
+				c.app.QueueUpdateDraw(func() {
+					infoModal.SetText(fmt.Sprintf("%s[%s]%s[-]", msg, Hex(TextAccent3), util.Glyph("…", "...", "")))
+				})
+			}
+
+			<-quitAnimationCh
+			return
+		}
+
 		animationElements := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+		if util.ASCII() {
+			animationElements = []string{"|", "/", "-", "\\"}
+		}
+
 		ticker := time.NewTicker(time.Millisecond * 100)
 
 		iter := 0
@@ -537,14 +2234,17 @@ func (c *Console) DisplayInfoModal(msg string, quitAnimationCh chan struct{}, an
 					iter = 0
 				}
 
+				// The update runs later, on the UI goroutine
+				frame := animationElements[iter]
+
 				c.app.QueueUpdateDraw(func() {
-					infoModal.SetText(fmt.Sprintf("%s[%s]%s[-]", msg, Hex(TextAccent3), animationElements[iter]))
+					infoModal.SetText(fmt.Sprintf("%s[%s]%s[-]", msg, Hex(TextAccent3), frame))
 				})
 
 				iter += 1
 			}
 		}
-	}()
+	})
 
 	c.pages.AddPage(PageConnectionAttempt, infoModal, true, true)
 	c.pages.SwitchToPage(PageConnectionAttempt)
@@ -554,6 +2254,11 @@ func (c *Console) Stop() {
 	if c.started {
 		c.app.Stop()
 	}
+
+	// Restore the title saved by SetWindowTitle()
+	if c.windowTitle != "" {
+		fmt.Fprint(os.Stdout, "\x1b[23;2t")
+	}
 }
 
 func (c *Console) DisplayErrorModal(msg string) {
@@ -588,25 +2293,67 @@ func (c *Console) Redraw(f func()) {
 	c.app.QueueUpdateDraw(f)
 }
 
-// DisplaySelectList will display a list of items and return the select item on the
-// output channel
-func (c *Console) DisplaySelectList(title string, audiences []*protos.Audience, answerCh chan<- *types.TailComponent) {
+// DisplaySelectList displays the list of live components. Space toggles a
+// component; Enter confirms the toggled components (or the current component
+// if none are toggled). Several components are tailed as one interleaved
+// stream. Sparklines read from sparklinesCh (keyed by util.AudienceToStr();
+// optional) are displayed next to the component names.
+func (c *Console) DisplaySelectList(title string, audiences []*protos.Audience, sparklinesCh <-chan map[string]string, answerCh chan<- []*types.TailComponent) {
 	selectComponent := tview.NewList()
 
 	selectComponent.SetBackgroundColor(Tcell(WindowBg))
 	selectComponent.SetMainTextColor(Tcell(TextPrimary))
 	selectComponent.SetSecondaryTextColor(Tcell(TextSecondary))
 	selectComponent.SetBorder(true)
-	selectComponent.SetTitle(title)
+	selectComponent.SetTitle(title + " (Space: toggle, Enter: confirm)")
 
-	// I spent a good 15 minutes trying to find how to dynamically generate a
-	// rune from an int - couldn't find anything. So, this is what we're doing.
-	// ¯\_(ツ)_/¯
-	i := 0
+	// Number shortcuts are only assigned if there is one for every component;
+	// longer lists are paged instead
 	shortcuts := []rune{'1', '2', '3', '4', '5', '6', '7', '8', '9'}
+	paged := len(audiences) > SelectPageSize
+
+	components := make([]*types.TailComponent, 0)
+	toggled := make([]bool, len(audiences))
+	sparklines := make(map[string]string)
+
+	var nameWidth int
 
 	for _, aud := range audiences {
-		name := aud.OperationName
+		if w := tview.TaggedStringWidth(util.AudienceToTailComponent(aud).Name); w > nameWidth {
+			nameWidth = w
+		}
+	}
+
+	// itemName returns the main text of an item; must be called from the UI
+	// goroutine
+	itemName := func(idx int) string {
+		name := components[idx].Name
+
+		if toggled[idx] {
+			name = fmt.Sprintf("[%s]%s%s[-]", Hex(TextAccent2), util.Glyph("✔ ", "x ", "selected "), name)
+		}
+
+		// Line up the sparklines; toggled names are 2 columns wider (more
+		// in screen reader friendly mode)
+		if sparkline, ok := sparklines[util.AudienceToStr(audiences[idx])]; ok {
+			pad := nameWidth + 2 - tview.TaggedStringWidth(name) + 2
+
+			if pad < 1 {
+				pad = 1
+			}
+
+			name += strings.Repeat(" ", pad) + sparkline
+		}
+
+		return name
+	}
+
+	for idx, aud := range audiences {
+		idx := idx
+
+		component := util.AudienceToTailComponent(aud)
+		components = append(components, component)
+
 		desc := fmt.Sprintf("[::b]%s[-:-:-] / [::b]%s / [::b]%s[-:-:-]",
 			aud.ServiceName,
 			util.ProtosOperationTypeToStr(aud.OperationType),
@@ -615,51 +2362,246 @@ func (c *Console) DisplaySelectList(title string, audiences []*protos.Audience,
 
 		var shortcut rune
 
-		if i >= len(shortcuts) {
-			shortcut = '0'
-		} else {
-			shortcut = shortcuts[i]
+		if len(audiences) <= len(shortcuts) {
+			shortcut = shortcuts[idx]
 		}
 
-		selectComponent.AddItem(name, desc, shortcut, func() {
-			answerCh <- util.SelectedToTailComponent(name, desc)
+		selectComponent.AddItem(component.Name, desc, shortcut, func() {
+			selected := make([]*types.TailComponent, 0)
+
+			for j, on := range toggled {
+				if on {
+					selected = append(selected, components[j])
+				}
+			}
+
+			// Nothing toggled - tail the component that enter was pressed on
+			if len(selected) == 0 {
+				selected = append(selected, components[idx])
+			}
+
+			answerCh <- selected
 		})
+	}
+
+	// The footer displays the position in the list; only for paged lists
+	footer := tview.NewTextView()
+	footer.SetDynamicColors(true)
+	footer.SetBackgroundColor(Tcell(WindowBg))
+	footer.SetTextColor(Tcell(TextSecondary))
+
+	pages := (len(audiences) + SelectPageSize - 1) / SelectPageSize
+
+	// showPage scrolls to the page of the component at idx and updates the
+	// footer. The list only scrolls by whole pages.
+	showPage := func(idx int) {
+		page := idx / SelectPageSize
+		last := (page + 1) * SelectPageSize
 
-		i++
+		if last > len(audiences) {
+			last = len(audiences)
+		}
+
+		selectComponent.SetOffset(page*SelectPageSize, 0)
+		sep := util.Glyph(" · ", " - ", ", ")
+
+		footer.SetText(fmt.Sprintf(" %d/%d%spage %d/%d (%d-%d)%sPgUp/PgDn: page",
+			idx+1, len(audiences), sep, page+1, pages, page*SelectPageSize+1, last, sep))
 	}
 
-	// Put this in a flex primitive so we can center it
-	selectComponentFlex := tview.NewFlex().
-		AddItem(nil, 0, 1, false).
-		AddItem(tview.NewFlex().
-			SetDirection(tview.FlexRow).
-			AddItem(nil, 0, 1, false).
-			AddItem(selectComponent, 10, 1, true).
-			AddItem(nil, 0, 1, false), 48, 1, true).
-		AddItem(nil, 0, 1, false)
+	if paged {
+		selectComponent.SetChangedFunc(func(idx int, _, _ string, _ rune) {
+			showPage(idx)
+		})
+
+		showPage(0)
+	}
+
+	selectComponent.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// The list would move by rows instead of components
+		if event.Key() == tcell.KeyPgDn || event.Key() == tcell.KeyPgUp {
+			idx := selectComponent.GetCurrentItem()
+			page := idx/SelectPageSize + 1
+
+			if event.Key() == tcell.KeyPgUp {
+				page = idx/SelectPageSize - 1
+			}
+
+			if page >= 0 && page < pages {
+				selectComponent.SetCurrentItem(page * SelectPageSize)
+			}
+
+			return nil
+		}
+
+		if event.Key() != tcell.KeyRune || event.Rune() != ' ' || selectComponent.GetItemCount() == 0 {
+			return event
+		}
+
+		idx := selectComponent.GetCurrentItem()
+		toggled[idx] = !toggled[idx]
+
+		_, desc := selectComponent.GetItemText(idx)
+		selectComponent.SetItemText(idx, itemName(idx), desc)
+
+		return nil
+	})
+
+	if sparklinesCh != nil {
+		crash.Go(func() {
+			for update := range sparklinesCh {
+				update := update
+
+				c.app.QueueUpdateDraw(func() {
+					sparklines = update
+
+					for idx := range components {
+						_, desc := selectComponent.GetItemText(idx)
+						selectComponent.SetItemText(idx, itemName(idx), desc)
+					}
+				})
+			}
+		})
+	}
+
+	// Up to a page of components is displayed without paging; two rows per
+	// component
+	var content tview.Primitive = selectComponent
+	height := 10
+
+	if rows := 2*len(audiences) + 2; rows > height && !paged {
+		height = rows
+	}
+
+	if paged {
+		selectComponent.SetBorder(false)
+
+		layout := tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(selectComponent, 2*SelectPageSize, 0, true).
+			AddItem(footer, 1, 0, false)
+
+		layout.SetBorder(true)
+		layout.SetTitle(selectComponent.GetTitle())
+		layout.SetBackgroundColor(Tcell(WindowBg))
+
+		content = layout
+		height = 2*SelectPageSize + 3
+	}
 
 	// Add Page
-	c.pages.AddPage(PageSelectComponent, selectComponentFlex, true, true)
+	c.pages.AddPage(PageSelectComponent, Center(content, 64, height), true, true)
 	c.pages.SwitchToPage(PageSelectComponent)
 }
 
 func (c *Console) initializeComponents() error {
 	c.app = tview.NewApplication()
+	c.app.SetInputCapture(c.captureInput)
 	c.pages = tview.NewPages()
 
+	switch {
+	case util.Accessible():
+		hideBorders()
+	case util.ASCII():
+		asciiBorders()
+	}
+
+	// Keys as bound (see SetKeyBindings) and displayable (arrows are not
+	// ASCII and are not read out)
+	MenuString = menuKeys(MenuString)
+
 	// Only highlight Quit at this time
+	c.menuText = MenuString
 	c.menu = c.newMenu()
 	c.menu.Highlight("Q")
 
+	// Breadcrumb shows where the user is in the flow (ex: Connect › Select › orders)
+	c.breadcrumb = tview.NewTextView().SetWrap(false).SetDynamicColors(true).SetTextAlign(tview.AlignRight)
+
+	// Stats (ex: bandwidth while tailing) are displayed next to the breadcrumb
+	c.stats = tview.NewTextView().SetWrap(false).SetDynamicColors(true)
+
+	// The time zone timestamps are displayed in is always shown on the right
+	c.zone = tview.NewTextView().SetWrap(false).SetDynamicColors(true)
+
+	c.statusBar = tview.NewFlex().
+		AddItem(c.menu, 0, 1, false).
+		AddItem(c.stats, 0, 0, false).
+		AddItem(c.breadcrumb, 0, 0, false).
+		AddItem(c.zone, 0, 0, false)
+
+	c.setTimeZone()
+
 	// Create Layout
 	c.layout = tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(c.pages, 0, 1, true).
-		AddItem(c.menu, 1, 1, false)
+		AddItem(c.statusBar, 1, 1, false)
+
+	// The menu wraps onto multiple lines on narrow terminals
+	c.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		width, _ := screen.Size()
+		c.wrapMenu(width)
+
+		return false
+	})
 
 	return nil
 }
 
+// wrapMenu splits the menu into as many lines as needed to fit next to the
+// stats and breadcrumb and resizes the status bar accordingly. Entries are
+// never split. Must be called from the draw loop.
+func (c *Console) wrapMenu(width int) {
+	rightWidth := tview.TaggedStringWidth(c.stats.GetText(false)) + tview.TaggedStringWidth(c.breadcrumb.GetText(false)) +
+		tview.TaggedStringWidth(c.zone.GetText(false))
+	available := width - rightWidth
+
+	// Entries that are not available are only told apart by their color; they
+	// are left out in screen reader friendly mode
+	regions := c.menu.GetHighlights()
+	sort.Strings(regions)
+
+	highlights := strings.Join(regions, ",")
+
+	if width == c.menuWidth && rightWidth == c.menuRightWidth && c.menuText == c.menuWrapped && highlights == c.menuHighlights {
+		return
+	}
+
+	c.menuWidth = width
+	c.menuRightWidth = rightWidth
+	c.menuWrapped = c.menuText
+	c.menuHighlights = highlights
+
+	lines := []string{""}
+
+	for _, entry := range strings.Split(c.menuText, "  ") {
+		if entry == "" {
+			continue
+		}
+
+		if util.Accessible() && !menuEntryHighlighted(entry, regions) {
+			continue
+		}
+
+		current := lines[len(lines)-1]
+
+		if current != "" && tview.TaggedStringWidth(current+"  "+entry) > available {
+			lines = append(lines, entry)
+			continue
+		}
+
+		if current != "" {
+			current += "  "
+		}
+
+		lines[len(lines)-1] = current + entry
+	}
+
+	// Highlighting regions scrolls to them; entries may have moved since
+	c.menu.SetText(strings.Join(lines, "\n")).ScrollToBeginning()
+	c.layout.ResizeItem(c.statusBar, len(lines), 0)
+}
+
 func (c *Console) newMenu() *tview.TextView {
 	menu := tview.NewTextView().SetWrap(false).SetDynamicColors(true)
 
@@ -679,6 +2621,10 @@ func validateOptions(opts *Options) error {
 		return errors.New(".Config cannot be nil")
 	}
 
+	if opts.Crash == nil {
+		return errors.New(".Crash cannot be nil")
+	}
+
 	if opts.Logger == nil {
 		return errors.New(".Logger cannot be nil")
 	}
@@ -0,0 +1,466 @@
+package console
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pkg/errors"
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// KeyBinding is a key of a view and the step it triggers. The views dispatch
+// keys from their key bindings (see TailKeys and CompareKeys), which are also
+// what the menu, the "keys" command and the key bindings page display;
+// documentation is generated from them so that it cannot drift from the
+// actual bindings.
+type KeyBinding struct {
+	// Action is the name of the binding for --key-binding (ex: "search");
+	// an action can have several keys
+	Action string
+
+	Key  tcell.Key
+	Rune rune // set if Key is tcell.KeyRune
+
+	Description string
+	Step        types.Step
+	Args        []string
+
+	// Menu is the region of the menu entry that displays the key (ex:
+	// "Search"); empty if the key is not in the menu
+	Menu string
+
+	// Local is set for keys that are handled by the view itself (ex:
+	// scrolling) instead of triggering a step; they cannot be overridden
+	Local bool
+}
+
+// KeyView is a view and its key bindings
+type KeyView struct {
+	Name     string
+	Bindings []*KeyBinding
+}
+
+var (
+	// TailKeys are the key bindings of the tail view
+	TailKeys = []*KeyBinding{
+		{Action: "quit", Key: tcell.KeyRune, Rune: 'q', Description: "Quit", Step: types.StepQuit, Menu: "Q"},
+		{Action: "select", Key: tcell.KeyRune, Rune: 's', Description: "Select components", Step: types.StepSelect, Menu: "S"},
+		{Action: "filter", Key: tcell.KeyRune, Rune: 'f', Description: "Filter (substring or CEL expression)", Step: types.StepFilter, Menu: "F"},
+		{Action: "search", Key: tcell.KeyRune, Rune: '/', Description: "Search (text or /regex/)", Step: types.StepSearch, Menu: "Search"},
+		{Action: "pause", Key: tcell.KeyRune, Rune: 'p', Description: "Pause/resume", Step: types.StepPause, Menu: "P"},
+		{Action: "view-options", Key: tcell.KeyRune, Rune: 'o', Description: "View options", Step: types.StepViewOptions, Menu: "O"},
+		// TODO: 'r' (sample rate) is disabled until sampling is fully
+		// implemented in SDKs
+		{Action: "trace", Key: tcell.KeyRune, Rune: 't', Description: "Only display the trace of the selected line (again to clear)", Step: types.StepTraceFilter, Menu: "T"},
+		{Action: "time-window", Key: tcell.KeyRune, Rune: 'w', Description: "Time window", Step: types.StepTimeWindow, Menu: "W"},
+		{Action: "break", Key: tcell.KeyRune, Rune: 'b', Description: "Break expression (pause when a payload matches)", Step: types.StepBreak, Menu: "B"},
+		{Action: "component-settings", Key: tcell.KeyRune, Rune: 'c', Description: "Component settings", Step: types.StepComponentSettings, Menu: "C"},
+		{Action: "max-lines", Key: tcell.KeyRune, Rune: 'l', Description: "Max output lines", Step: types.StepMaxLines, Menu: "L"},
+		{Action: "bookmark", Key: tcell.KeyRune, Rune: 'm', Description: "Bookmark the selected (or most recent) line", Step: types.StepBookmark, Menu: "M"},
+		{Action: "bookmark-prev", Key: tcell.KeyRune, Rune: '[', Description: "Jump to the previous bookmark", Step: types.StepBookmarkJump, Args: []string{"prev"}},
+		{Action: "bookmark-next", Key: tcell.KeyRune, Rune: ']', Description: "Jump to the next bookmark", Step: types.StepBookmarkJump, Args: []string{"next"}},
+		{Action: "bookmarks", Key: tcell.KeyRune, Rune: 'j', Description: "List bookmarks", Step: types.StepBookmarks, Menu: "J"},
+		{Action: "note", Key: tcell.KeyRune, Rune: 'n', Description: "Attach a note to the selected (or most recent) line", Step: types.StepNote, Menu: "N"},
+		{Action: "export", Key: tcell.KeyRune, Rune: 'x', Description: "Export the visible lines", Step: types.StepExport, Menu: "X"},
+		{Action: "share", Key: tcell.KeyRune, Rune: 'h', Description: "Share the selected line (or a range) to Slack", Step: types.StepShare, Menu: "H"},
+		{Action: "compare", Key: tcell.KeyRune, Rune: 'v', Description: "Compare the two tailed components side by side", Step: types.StepCompare, Menu: "V"},
+		{Action: "query", Key: tcell.KeyRune, Rune: 'a', Description: "Run SQL-like queries against the buffer", Step: types.StepQuery, Menu: "A"},
+		{Action: "snapshots", Key: tcell.KeyRune, Rune: 'z', Description: "Take, view and compare snapshots of the buffer", Step: types.StepSnapshots, Menu: "Z"},
+		{Action: "operation", Key: tcell.KeyRune, Rune: 'i', Description: "Cycle the displayed operation type", Step: types.StepOperationCycle, Menu: "I"},
+		{Action: "utc", Key: tcell.KeyRune, Rune: 'u', Description: "Switch between local time and UTC", Step: types.StepTimeZone, Menu: "U"},
+		{Action: "events", Key: tcell.KeyRune, Rune: 'e', Description: "Review events", Step: types.StepNotifications, Menu: "E"},
+		{Action: "macro-play", Key: tcell.KeyRune, Rune: '@', Description: "Play the last recorded macro", Step: types.StepMacroPlay, Menu: "Play"},
+		{Action: "keys", Key: tcell.KeyRune, Rune: '?', Description: "Key bindings", Step: types.StepKeys, Menu: "Keys"},
+		{Action: "line-prev", Key: tcell.KeyUp, Description: "Select the previous line", Step: types.StepSelectLine, Args: []string{"prev"}},
+		{Action: "line-next", Key: tcell.KeyDown, Description: "Select the next line", Step: types.StepSelectLine, Args: []string{"next"}},
+		{Action: "chip-prev", Key: tcell.KeyLeft, Description: "Select the previous filter chip", Step: types.StepChipSelect, Args: []string{"prev"}, Menu: "Chips"},
+		{Action: "chip-next", Key: tcell.KeyRight, Description: "Select the next filter chip", Step: types.StepChipSelect, Args: []string{"next"}, Menu: "Chips"},
+		{Action: "chip-remove", Key: tcell.KeyDelete, Description: "Remove the selected filter chip", Step: types.StepChipRemove},
+		{Action: "chip-remove", Key: tcell.KeyBackspace, Description: "Remove the selected filter chip", Step: types.StepChipRemove},
+		{Action: "chip-remove", Key: tcell.KeyBackspace2, Description: "Remove the selected filter chip", Step: types.StepChipRemove},
+		{Action: "detail", Key: tcell.KeyEnter, Description: "Fields of the selected (or most recent) line; edit the selected filter chip", Step: types.StepLineDetail, Menu: "Detail"},
+		{Action: "operation-tab", Key: tcell.KeyTab, Description: "Switch between the producer and consumer tabs", Step: types.StepOperationTab, Menu: "Tab"},
+		{Action: "find", Key: tcell.KeyCtrlP, Description: "Jump to another component", Step: types.StepFinder, Menu: "Find"},
+		{Action: "previous", Key: tcell.KeyCtrlCarat, Description: "Switch back to the previously tailed component(s)", Step: types.StepRecentSwitch, Menu: "Previous"},
+		{Action: "macro-record", Key: tcell.KeyCtrlR, Description: "Start/stop recording a macro", Step: types.StepMacroRecord, Menu: "Macro"},
+		{Action: "clear", Key: tcell.KeyCtrlL, Description: "Start over with an empty tail view", Step: types.StepClear, Menu: "Clear"},
+		{Action: "back", Key: tcell.KeyEscape, Description: "Clear the selection or go back", Step: types.StepBack, Menu: "Back"},
+	}
+
+	// CompareKeys are the key bindings of the compare view
+	CompareKeys = []*KeyBinding{
+		{Action: "quit", Key: tcell.KeyRune, Rune: 'q', Description: "Quit", Step: types.StepQuit, Menu: "Q"},
+		{Action: "select", Key: tcell.KeyRune, Rune: 's', Description: "Select components", Step: types.StepSelect, Menu: "S"},
+		{Action: "pause", Key: tcell.KeyRune, Rune: 'p', Description: "Pause/resume", Step: types.StepPause, Menu: "P"},
+		{Action: "filter", Key: tcell.KeyRune, Rune: 'f', Description: "Filter (substring or CEL expression)", Step: types.StepFilter, Menu: "F"},
+		{Action: "correlation-key", Key: tcell.KeyRune, Rune: 'k', Description: "JSONPath used for aligning lines", Step: types.StepCorrelationKey, Menu: "K"},
+		{Action: "macro-play", Key: tcell.KeyRune, Rune: '@', Description: "Play the last recorded macro", Step: types.StepMacroPlay, Menu: "Play"},
+		{Action: "keys", Key: tcell.KeyRune, Rune: '?', Description: "Key bindings", Step: types.StepKeys, Menu: "Keys"},
+		{Key: tcell.KeyUp, Description: "Scroll up", Local: true},
+		{Key: tcell.KeyDown, Description: "Scroll down", Local: true},
+		{Key: tcell.KeyPgUp, Description: "Scroll up a page", Local: true},
+		{Key: tcell.KeyPgDn, Description: "Scroll down a page", Local: true},
+		{Key: tcell.KeyLeft, Description: "Scroll left", Local: true},
+		{Key: tcell.KeyRight, Description: "Scroll right", Local: true},
+		{Key: tcell.KeyHome, Description: "Scroll to the top", Local: true},
+		{Key: tcell.KeyEnd, Description: "Scroll to the bottom", Local: true},
+		{Action: "find", Key: tcell.KeyCtrlP, Description: "Jump to another component", Step: types.StepFinder, Menu: "Find"},
+		{Action: "macro-record", Key: tcell.KeyCtrlR, Description: "Start/stop recording a macro", Step: types.StepMacroRecord, Menu: "Macro"},
+		{Action: "back", Key: tcell.KeyEscape, Description: "Go back", Step: types.StepBack, Menu: "Back"},
+	}
+
+	// menuKeyPattern matches the key of a menu entry (ex: `[white]Q[-] ["Q"]`)
+	menuKeyPattern = regexp.MustCompile(`\[white\][^\[]*\[-\] \["([^"]+)"\]`)
+)
+
+// KeyViews returns the views with key bindings, in the order they are
+// documented
+func KeyViews() []*KeyView {
+	return []*KeyView{
+		{Name: "Tail view", Bindings: TailKeys},
+		{Name: "Compare view", Bindings: CompareKeys},
+	}
+}
+
+// SetKeyBindings replaces the keys of actions (ex: "search" => "Ctrl-F");
+// several keys are separated by commas. The keys of the menu entries are
+// updated accordingly. Must be called before New().
+func SetKeyBindings(overrides map[string]string) error {
+	actions := make([]string, 0, len(overrides))
+
+	for action := range overrides {
+		actions = append(actions, action)
+	}
+
+	sort.Strings(actions)
+
+	for _, action := range actions {
+		keys, err := parseKeys(overrides[action])
+		if err != nil {
+			return errors.Wrapf(err, "invalid key binding for '%s'", action)
+		}
+
+		var found bool
+
+		for _, bindings := range []*[]*KeyBinding{&TailKeys, &CompareKeys} {
+			if rebind(bindings, action, keys) {
+				found = true
+			}
+		}
+
+		if !found {
+			return errors.Errorf("unknown key binding action '%s' (see the keys command)", action)
+		}
+	}
+
+	for _, view := range KeyViews() {
+		bound := make(map[string]*KeyBinding)
+
+		for _, b := range view.Bindings {
+			if other, ok := bound[b.Name()]; ok && other.Action != b.Action {
+				return errors.Errorf("key '%s' is bound to both '%s' and '%s' in the %s", b.Name(), other.actionName(), b.actionName(), strings.ToLower(view.Name))
+			}
+
+			bound[b.Name()] = b
+		}
+	}
+
+	return nil
+}
+
+// rebind replaces the bindings of action with one per key (at the position of
+// the first one); returns false if action is not bound in bindings
+func rebind(bindings *[]*KeyBinding, action string, keys []*KeyBinding) bool {
+	updated := make([]*KeyBinding, 0, len(*bindings)+len(keys))
+
+	var found bool
+
+	for _, b := range *bindings {
+		if b.Action != action {
+			updated = append(updated, b)
+			continue
+		}
+
+		if found {
+			continue
+		}
+
+		found = true
+
+		for _, key := range keys {
+			rebound := *b
+			rebound.Key, rebound.Rune = key.Key, key.Rune
+
+			updated = append(updated, &rebound)
+		}
+	}
+
+	*bindings = updated
+
+	return found
+}
+
+// parseKeys parses comma separated keys (see parseKey)
+func parseKeys(text string) ([]*KeyBinding, error) {
+	if text == "" {
+		return nil, errors.New("no keys")
+	}
+
+	keys := make([]*KeyBinding, 0)
+
+	for _, name := range strings.Split(text, ",") {
+		// A comma or a space on their own are keys too
+		if trimmed := strings.TrimSpace(name); trimmed != "" {
+			name = trimmed
+		}
+
+		if name == "" {
+			name = ","
+		}
+
+		key, err := parseKey(name)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// parseKey parses a single character (ex: "/") or a key name as displayed by
+// the keys command (ex: "Ctrl-F", "F2", "Enter")
+func parseKey(name string) (*KeyBinding, error) {
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return &KeyBinding{Key: tcell.KeyRune, Rune: r}, nil
+	}
+
+	key, ok := keyByName(name)
+	if !ok {
+		return nil, errors.Errorf("unknown key '%s'", name)
+	}
+
+	return &KeyBinding{Key: key}, nil
+}
+
+// keyByName returns the key with the given name (case insensitive)
+func keyByName(name string) (tcell.Key, bool) {
+	if strings.EqualFold(name, "escape") {
+		return tcell.KeyEscape, true
+	}
+
+	for key, keyName := range tcell.KeyNames {
+		if strings.EqualFold(keyName, name) {
+			// Displayed the same as Backspace (see Name())
+			if key == tcell.KeyBackspace2 {
+				key = tcell.KeyBackspace
+			}
+
+			return key, true
+		}
+	}
+
+	return 0, false
+}
+
+// Name returns the key as displayed (ex: "q", "Enter", "Ctrl-P")
+func (b *KeyBinding) Name() string {
+	switch b.Key {
+	case tcell.KeyRune:
+		return string(b.Rune)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		return "Backspace"
+	}
+
+	if name, ok := tcell.KeyNames[b.Key]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Key %d", b.Key)
+}
+
+// actionName returns the action of the binding for error messages
+func (b *KeyBinding) actionName() string {
+	if b.Local {
+		return strings.ToLower(b.Description)
+	}
+
+	return b.Action
+}
+
+// menuName returns the key as displayed in the menu (ex: "Q", "^P", "←")
+func (b *KeyBinding) menuName() string {
+	switch {
+	case b.Key == tcell.KeyRune:
+		return string(unicode.ToUpper(b.Rune))
+	case b.Key == tcell.KeyCtrlCarat:
+		return "^^"
+	case b.Key >= tcell.KeyCtrlA && b.Key <= tcell.KeyCtrlZ && b.Key != tcell.KeyTab && b.Key != tcell.KeyEnter && b.Key != tcell.KeyBackspace:
+		return "^" + string(rune('A'+b.Key-tcell.KeyCtrlA))
+	case b.Key == tcell.KeyLeft:
+		return util.Glyph("←", "Left", "Left")
+	case b.Key == tcell.KeyRight:
+		return util.Glyph("→", "Right", "Right")
+	default:
+		return b.Name()
+	}
+}
+
+// menuKeys replaces the keys of the menu entries in menu with the keys they
+// are bound to (ex: "^F" for Search if bound to Ctrl-F)
+func menuKeys(menu string) string {
+	return menuKeyPattern.ReplaceAllStringFunc(menu, func(entry string) string {
+		region := menuKeyPattern.FindStringSubmatch(entry)[1]
+
+		var (
+			names []string
+			seen  = make(map[string]bool)
+		)
+
+		for _, view := range KeyViews() {
+			for _, b := range view.Bindings {
+				if b.Menu != region || seen[b.Action] {
+					continue
+				}
+
+				seen[b.Action] = true
+				names = append(names, b.menuName())
+			}
+		}
+
+		if len(names) == 0 {
+			return entry
+		}
+
+		return fmt.Sprintf(`[white]%s[-] ["%s"]`, tview.Escape(strings.Join(names, "/")), region)
+	})
+}
+
+// KeyHelp is a line of key binding documentation: the keys of an action are
+// documented together (ex: "Delete, Backspace")
+type KeyHelp struct {
+	Action      string
+	Keys        []string
+	Description string
+}
+
+// Help returns the documentation of the key bindings of a view
+func (v *KeyView) Help() []*KeyHelp {
+	help := make([]*KeyHelp, 0, len(v.Bindings))
+
+	for _, b := range v.Bindings {
+		if last := len(help) - 1; last >= 0 && b.Action != "" && help[last].Action == b.Action {
+			if name := b.Name(); help[last].Keys[len(help[last].Keys)-1] != name {
+				help[last].Keys = append(help[last].Keys, name)
+			}
+
+			continue
+		}
+
+		help = append(help, &KeyHelp{Action: b.Action, Keys: []string{b.Name()}, Description: b.Description})
+	}
+
+	return help
+}
+
+// BoundKey returns the (first) key an action is bound to, as displayed (ex:
+// "Ctrl-R"); used in messages that refer to keys
+func BoundKey(action string) string {
+	for _, view := range KeyViews() {
+		for _, b := range view.Bindings {
+			if b.Action == action {
+				return b.Name()
+			}
+		}
+	}
+
+	return action
+}
+
+// lookupKey returns the binding of a key event; nil if the key is not bound
+// (or is handled by the view itself)
+func lookupKey(bindings []*KeyBinding, event *tcell.EventKey) *KeyBinding {
+	for _, b := range bindings {
+		if b.Local || !b.matches(event) {
+			continue
+		}
+
+		return b
+	}
+
+	return nil
+}
+
+// matches returns true if event is the key of the binding; Backspace is the
+// same key whichever code the terminal sends for it
+func (b *KeyBinding) matches(event *tcell.EventKey) bool {
+	key := event.Key()
+
+	if key == tcell.KeyBackspace2 {
+		key = tcell.KeyBackspace
+	}
+
+	switch {
+	case b.Key == tcell.KeyRune:
+		return key == tcell.KeyRune && b.Rune == event.Rune()
+	case b.Key == tcell.KeyBackspace2:
+		return key == tcell.KeyBackspace
+	default:
+		return b.Key == key
+	}
+}
+
+// DisplayKeys lists the key bindings of every view; answerCh is notified
+// when the list is closed
+func (c *Console) DisplayKeys(answerCh chan<- struct{}) {
+	c.Start()
+
+	// Remove all menu highlights - you cannot access menu while in key bindings
+	c.app.QueueUpdateDraw(func() {
+		c.menu.Highlight()
+	})
+
+	var sb strings.Builder
+
+	for i, view := range KeyViews() {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		fmt.Fprintf(&sb, "[%s::b]%s[-::-]\n", Hex(TextSecondary), view.Name)
+
+		for _, help := range view.Help() {
+			fmt.Fprintf(&sb, "  [white]%-18s[-] %s\n", tview.Escape(strings.Join(help.Keys, ", ")), tview.Escape(help.Description))
+		}
+	}
+
+	text := tview.NewTextView().SetDynamicColors(true).SetText(sb.String())
+	text.SetBackgroundColor(Tcell(WindowBg))
+	text.SetTextColor(Tcell(TextPrimary))
+	text.SetBorder(true)
+	text.SetTitle("Key bindings (Esc: close)")
+	text.SetTitleColor(Tcell(TextPrimary))
+	text.SetBorderPadding(0, 0, 1, 1)
+
+	text.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyEnter {
+			answerCh <- struct{}{}
+			return nil
+		}
+
+		return event
+	})
+
+	dialog := Center(text, 96, 30)
+	c.pages.AddPage(PageKeys, dialog, true, true)
+}
@@ -0,0 +1,194 @@
+package console
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/types"
+)
+
+const (
+	// MacroPageTimeout is how long a played macro waits for the page a key
+	// was recorded in to be in front (ex: a dialog opened by the previous key)
+	// and for a key to be handled; the macro is stopped once it is exceeded
+	MacroPageTimeout = 5 * time.Second
+
+	// MacroKeyDelay is how long keys of macros saved without pages wait
+	// before being played, as there is no page to wait for
+	MacroKeyDelay = 150 * time.Millisecond
+
+	// macroPollInterval is how often the front page is checked while waiting
+	macroPollInterval = 10 * time.Millisecond
+)
+
+// macroRecorder records the keys pressed while a macro is being recorded.
+// Keys are recorded by name (see KeyBinding.Name) so that macros can be saved
+// and played in later sessions, with the page that was in front.
+type macroRecorder struct {
+	mu        sync.Mutex
+	recording bool
+	keys      []*types.MacroKey
+
+	// playing is the key of a played macro that has been queued; handledCh
+	// is closed once it went through captureInput
+	playing   *tcell.EventKey
+	handledCh chan struct{}
+}
+
+// record adds the key of event to the macro being recorded (if any); keys
+// that cannot be named (ex: Alt combinations) are not recorded
+func (r *macroRecorder) record(event *tcell.EventKey, page string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event == r.playing {
+		close(r.handledCh)
+		r.playing = nil
+	}
+
+	if !r.recording || event.Modifiers()&tcell.ModAlt != 0 {
+		return
+	}
+
+	key := &KeyBinding{Key: event.Key(), Rune: event.Rune()}
+
+	if _, ok := tcell.KeyNames[key.Key]; !ok && key.Key != tcell.KeyRune {
+		return
+	}
+
+	r.keys = append(r.keys, &types.MacroKey{Key: key.Name(), Page: page})
+}
+
+// play registers event as queued; the returned channel is closed once it has
+// been handled
+func (r *macroRecorder) play(event *tcell.EventKey) <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.playing = event
+	r.handledCh = make(chan struct{})
+
+	return r.handledCh
+}
+
+// captureInput is the input capture of the application: keys are recorded
+// for macros, then go through the input capture of the current view
+func (c *Console) captureInput(event *tcell.EventKey) *tcell.EventKey {
+	page, _ := c.pages.GetFrontPage()
+	c.macro.record(event, page)
+
+	if c.inputCapture == nil {
+		return event
+	}
+
+	return c.inputCapture(event)
+}
+
+// StartRecording starts recording the keys pressed in every view and dialog
+// until StopRecording() is called
+func (c *Console) StartRecording() {
+	c.macro.mu.Lock()
+	defer c.macro.mu.Unlock()
+
+	c.macro.recording = true
+	c.macro.keys = nil
+}
+
+// StopRecording stops recording and returns the recorded keys, without the
+// key that stopped the recording
+func (c *Console) StopRecording() []*types.MacroKey {
+	c.macro.mu.Lock()
+	defer c.macro.mu.Unlock()
+
+	keys := c.macro.keys
+
+	if len(keys) > 0 {
+		keys = keys[:len(keys)-1]
+	}
+
+	c.macro.recording = false
+	c.macro.keys = nil
+
+	return keys
+}
+
+// Recording returns true while a macro is being recorded
+func (c *Console) Recording() bool {
+	c.macro.mu.Lock()
+	defer c.macro.mu.Unlock()
+
+	return c.macro.recording
+}
+
+// PlayKeys plays keys recorded with StartRecording() in the background, as if
+// they were pressed one after the other: every key is sent once the previous
+// one has been handled and the page it was recorded in is in front. The macro
+// is stopped (with a toast) if a page is not in front within MacroPageTimeout.
+func (c *Console) PlayKeys(keys []*types.MacroKey) error {
+	events := make([]*tcell.EventKey, 0, len(keys))
+
+	for _, k := range keys {
+		key, err := parseKey(k.Key)
+		if err != nil {
+			return errors.Wrap(err, "invalid macro")
+		}
+
+		events = append(events, tcell.NewEventKey(key.Key, key.Rune, tcell.ModNone))
+	}
+
+	crash.Go(func() {
+		for i, event := range events {
+			if err := c.waitForPage(keys[i].Page); err != nil {
+				c.ShowToast(fmt.Sprintf("Macro stopped at key %d of %d (%s): %s", i+1, len(events), keys[i].Key, err))
+				return
+			}
+
+			handledCh := c.macro.play(event)
+			c.app.QueueEvent(event)
+
+			select {
+			case <-handledCh:
+			case <-time.After(MacroPageTimeout):
+				c.ShowToast(fmt.Sprintf("Macro stopped at key %d of %d (%s): the key was not handled", i+1, len(events), keys[i].Key))
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// waitForPage waits for page to be the front page; keys recorded without a
+// page wait for MacroKeyDelay instead
+func (c *Console) waitForPage(page string) error {
+	if page == "" {
+		time.Sleep(MacroKeyDelay)
+		return nil
+	}
+
+	deadline := time.Now().Add(MacroPageTimeout)
+
+	for {
+		frontCh := make(chan string, 1)
+
+		c.app.QueueUpdate(func() {
+			front, _ := c.pages.GetFrontPage()
+			frontCh <- front
+		})
+
+		if <-frontCh == page {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.Errorf("'%s' is not displayed", page)
+		}
+
+		time.Sleep(macroPollInterval)
+	}
+}
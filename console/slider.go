@@ -0,0 +1,226 @@
+package console
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	sliderBarWidth = 16
+)
+
+// Slider is a form item that lets the user step through a fixed, sorted list
+// of values with the left/right arrow keys (or h/l). It implements
+// tview.FormItem so that it can be added to a tview.Form.
+type Slider struct {
+	*tview.Box
+
+	label      string
+	values     []int
+	index      int
+	disabled   bool
+	labelWidth int
+
+	labelColor     tcell.Color
+	fieldTextColor tcell.Color
+	fieldBgColor   tcell.Color
+
+	changed  func(value int)
+	finished func(key tcell.Key)
+}
+
+// NewSlider creates a slider for the given values; if value is not one of the
+// values, it is inserted so that the current setting is always selectable.
+func NewSlider(label string, values []int, value int) *Slider {
+	vals := append([]int{}, values...)
+
+	if !containsInt(vals, value) {
+		vals = append(vals, value)
+	}
+
+	sort.Ints(vals)
+
+	s := &Slider{
+		Box:            tview.NewBox(),
+		label:          label,
+		values:         vals,
+		labelColor:     tview.Styles.SecondaryTextColor,
+		fieldTextColor: tview.Styles.PrimaryTextColor,
+		fieldBgColor:   tview.Styles.ContrastBackgroundColor,
+	}
+
+	for i, v := range vals {
+		if v == value {
+			s.index = i
+		}
+	}
+
+	return s
+}
+
+// SetChangedFunc sets a handler that is called whenever the value changes
+func (s *Slider) SetChangedFunc(handler func(value int)) *Slider {
+	s.changed = handler
+	return s
+}
+
+// GetValue returns the currently selected value
+func (s *Slider) GetValue() int {
+	return s.values[s.index]
+}
+
+func (s *Slider) GetLabel() string {
+	return s.label
+}
+
+func (s *Slider) SetFormAttributes(labelWidth int, labelColor, bgColor, fieldTextColor, fieldBgColor tcell.Color) tview.FormItem {
+	s.labelWidth = labelWidth
+	s.labelColor = labelColor
+	s.SetBackgroundColor(bgColor)
+	s.fieldTextColor = fieldTextColor
+	s.fieldBgColor = fieldBgColor
+
+	return s
+}
+
+func (s *Slider) GetFieldWidth() int {
+	// "◀" + bar + "▶" + " " + value
+	return sliderBarWidth + 2 + 1 + len(s.formatValue(s.values[len(s.values)-1]))
+}
+
+func (s *Slider) GetFieldHeight() int {
+	return 1
+}
+
+func (s *Slider) SetFinishedFunc(handler func(key tcell.Key)) tview.FormItem {
+	s.finished = handler
+	return s
+}
+
+func (s *Slider) SetDisabled(disabled bool) tview.FormItem {
+	s.disabled = disabled
+
+	if s.finished != nil {
+		s.finished(-1)
+	}
+
+	return s
+}
+
+func (s *Slider) Draw(screen tcell.Screen) {
+	s.DrawForSubclass(screen, s)
+
+	x, y, width, height := s.GetInnerRect()
+	if height < 1 || width < 1 {
+		return
+	}
+
+	labelWidth := s.labelWidth
+	if labelWidth == 0 {
+		labelWidth = tview.TaggedStringWidth(s.label) + 1
+	}
+
+	if labelWidth > width {
+		labelWidth = width
+	}
+
+	tview.Print(screen, s.label, x, y, labelWidth, tview.AlignLeft, s.labelColor)
+
+	x += labelWidth
+	width -= labelWidth
+
+	// Position of the knob on the bar
+	filled := sliderBarWidth
+
+	if len(s.values) > 1 {
+		filled = s.index * sliderBarWidth / (len(s.values) - 1)
+	}
+
+	bar := util.Glyph("◀", "<", "") + strings.Repeat(util.Glyph("■", "#", ""), filled) +
+		strings.Repeat(util.Glyph("□", "-", ""), sliderBarWidth-filled) + util.Glyph("▶ ", "> ", "") + s.formatValue(s.GetValue())
+
+	color := s.fieldTextColor
+	if s.HasFocus() {
+		color = s.labelColor
+	}
+
+	tview.Print(screen, tview.Escape(bar), x, y, width, tview.AlignLeft, color)
+}
+
+func (s *Slider) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return s.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		if s.disabled {
+			return
+		}
+
+		prev := s.index
+
+		switch key := event.Key(); key {
+		case tcell.KeyLeft:
+			s.step(-1)
+		case tcell.KeyRight:
+			s.step(1)
+		case tcell.KeyHome:
+			s.index = 0
+		case tcell.KeyEnd:
+			s.index = len(s.values) - 1
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'h', '-':
+				s.step(-1)
+			case 'l', '+':
+				s.step(1)
+			}
+		case tcell.KeyEnter, tcell.KeyTab, tcell.KeyBacktab, tcell.KeyEscape, tcell.KeyUp, tcell.KeyDown:
+			if s.finished != nil {
+				if key == tcell.KeyUp {
+					key = tcell.KeyBacktab
+				} else if key == tcell.KeyDown {
+					key = tcell.KeyTab
+				}
+
+				s.finished(key)
+			}
+		}
+
+		if s.index != prev && s.changed != nil {
+			s.changed(s.GetValue())
+		}
+	})
+}
+
+func (s *Slider) step(delta int) {
+	s.index += delta
+
+	if s.index < 0 {
+		s.index = 0
+	}
+
+	if s.index >= len(s.values) {
+		s.index = len(s.values) - 1
+	}
+}
+
+func (s *Slider) formatValue(value int) string {
+	if value == 0 {
+		return "off"
+	}
+
+	return fmt.Sprintf("%d/s", value)
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
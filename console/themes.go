@@ -0,0 +1,172 @@
+package console
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pkg/errors"
+)
+
+// Theme names (see --theme)
+const (
+	ThemeDefault      = "default"
+	ThemeHighContrast = "high-contrast"
+	ThemeDeuteranopia = "deuteranopia"
+	ThemeProtanopia   = "protanopia"
+)
+
+// Theme is a palette that replaces some of the colors of ColorMap
+type Theme struct {
+	Colors map[Element]Color
+
+	// Replace ComponentColors and SearchGroupColors if set
+	ComponentColors   []tcell.Color
+	SearchGroupColors []tcell.Color
+}
+
+// Okabe-Ito colors; they can be told apart with all common forms of color
+// blindness (https://jfly.uni-koeln.de/color/)
+var (
+	okabeItoOrange    = tcell.NewRGBColor(230, 159, 0)
+	okabeItoSkyBlue   = tcell.NewRGBColor(86, 180, 233)
+	okabeItoGreen     = tcell.NewRGBColor(0, 158, 115)
+	okabeItoYellow    = tcell.NewRGBColor(240, 228, 66)
+	okabeItoBlue      = tcell.NewRGBColor(0, 114, 178)
+	okabeItoVermilion = tcell.NewRGBColor(213, 94, 0)
+	okabeItoPurple    = tcell.NewRGBColor(204, 121, 167)
+)
+
+var (
+	// Themes are the palettes selectable with --theme; the default theme is
+	// ColorMap as is
+	Themes = map[string]*Theme{
+		ThemeDefault: {},
+
+		// Low-vision friendly: pure black backgrounds, white text and bright
+		// highlight backgrounds with black text
+		ThemeHighContrast: {
+			Colors: map[Element]Color{
+				TextSecondary:     themeColor("light gray", tcell.NewRGBColor(230, 230, 230)),
+				TextAccent1:       themeColor("yellow", tcell.ColorYellow),
+				TextAccent2:       themeColor("cyan", tcell.ColorAqua),
+				TextAccent3:       themeColor("light red", tcell.NewRGBColor(255, 102, 102)),
+				ActiveButtonBg:    themeColor("yellow", tcell.ColorYellow),
+				ActiveButtonFg:    themeColor("black", tcell.ColorBlack),
+				InactiveButtonBg:  themeColor("white", tcell.ColorWhite),
+				InactiveButtonFg:  themeColor("black", tcell.ColorBlack),
+				MenuActiveBg:      themeColor("yellow", tcell.ColorYellow),
+				MenuInactiveFg:    themeColor("light gray", tcell.NewRGBColor(230, 230, 230)),
+				InputFieldFg:      themeColor("black", tcell.ColorBlack),
+				InputFieldBg:      themeColor("white", tcell.ColorWhite),
+				WindowBg:          themeColor("black", tcell.ColorBlack),
+				CLIBg:             themeColor("black", tcell.ColorBlack),
+				StatusOK:          themeColor("cyan", tcell.ColorAqua),
+				StatusWarning:     themeColor("yellow", tcell.ColorYellow),
+				StatusError:       themeColor("light red", tcell.NewRGBColor(255, 102, 102)),
+				FilterHighlightFg: themeColor("black", tcell.ColorBlack),
+				FilterHighlightBg: themeColor("cyan", tcell.ColorAqua),
+				SearchHighlightFg: themeColor("black", tcell.ColorBlack),
+				SearchHighlightBg: themeColor("yellow", tcell.ColorYellow),
+				JSONKey:           themeColor("cyan", tcell.ColorAqua),
+				JSONString:        themeColor("white", tcell.ColorWhite),
+				JSONNumber:        themeColor("yellow", tcell.ColorYellow),
+				JSONBool:          themeColor("white", tcell.ColorWhite),
+				JSONNull:          themeColor("light red", tcell.NewRGBColor(255, 102, 102)),
+			},
+		},
+
+		// Red and green are told apart by brightness only; blue and orange
+		// are used instead
+		ThemeDeuteranopia: {
+			Colors: map[Element]Color{
+				TextAccent3:       themeColor("vermilion", okabeItoVermilion),
+				ActiveButtonBg:    themeColor("blue", okabeItoBlue),
+				StatusOK:          themeColor("sky blue", okabeItoSkyBlue),
+				StatusWarning:     themeColor("yellow", okabeItoYellow),
+				StatusError:       themeColor("orange", okabeItoOrange),
+				FilterHighlightFg: themeColor("black", tcell.ColorBlack),
+				FilterHighlightBg: themeColor("sky blue", okabeItoSkyBlue),
+				SearchHighlightFg: themeColor("black", tcell.ColorBlack),
+				SearchHighlightBg: themeColor("orange", okabeItoOrange),
+				JSONKey:           themeColor("sky blue", okabeItoSkyBlue),
+				JSONString:        themeColor("yellow", okabeItoYellow),
+				JSONNumber:        themeColor("orange", okabeItoOrange),
+				JSONNull:          themeColor("vermilion", okabeItoVermilion),
+			},
+			ComponentColors:   okabeIto(),
+			SearchGroupColors: okabeIto(),
+		},
+
+		// Like deuteranopia, but reds also look dark: errors use the reddish
+		// purple, which stays bright
+		ThemeProtanopia: {
+			Colors: map[Element]Color{
+				TextAccent3:       themeColor("reddish purple", okabeItoPurple),
+				ActiveButtonBg:    themeColor("blue", okabeItoBlue),
+				StatusOK:          themeColor("sky blue", okabeItoSkyBlue),
+				StatusWarning:     themeColor("yellow", okabeItoYellow),
+				StatusError:       themeColor("reddish purple", okabeItoPurple),
+				FilterHighlightFg: themeColor("black", tcell.ColorBlack),
+				FilterHighlightBg: themeColor("sky blue", okabeItoSkyBlue),
+				SearchHighlightFg: themeColor("black", tcell.ColorBlack),
+				SearchHighlightBg: themeColor("yellow", okabeItoYellow),
+				JSONKey:           themeColor("sky blue", okabeItoSkyBlue),
+				JSONString:        themeColor("yellow", okabeItoYellow),
+				JSONNumber:        themeColor("orange", okabeItoOrange),
+				JSONNull:          themeColor("reddish purple", okabeItoPurple),
+			},
+			ComponentColors:   okabeIto(),
+			SearchGroupColors: okabeIto(),
+		},
+	}
+
+	// ThemeNames are the names of Themes, in the order they are documented
+	ThemeNames = []string{ThemeDefault, ThemeHighContrast, ThemeDeuteranopia, ThemeProtanopia}
+)
+
+// SetTheme replaces the colors of ColorMap (and the component and search group
+// colors) with those of the named theme. Must be called before New().
+func SetTheme(name string) error {
+	theme, ok := Themes[name]
+	if !ok {
+		return errors.Errorf("unknown theme '%s' (expected one of: %s)", name, strings.Join(ThemeNames, ", "))
+	}
+
+	// MenuString is colored with the menu color of the default theme
+	menuFg := Hex(MenuInactiveFg)
+
+	for element, color := range theme.Colors {
+		ColorMap[element] = color
+	}
+
+	if Hex(MenuInactiveFg) != menuFg {
+		MenuString = strings.ReplaceAll(MenuString, "["+menuFg, "["+Hex(MenuInactiveFg))
+	}
+
+	if len(theme.ComponentColors) > 0 {
+		ComponentColors = theme.ComponentColors
+	}
+
+	if len(theme.SearchGroupColors) > 0 {
+		SearchGroupColors = theme.SearchGroupColors
+	}
+
+	return nil
+}
+
+// themeColor is a color that is the same in 256 and 24 bit color modes
+func themeColor(name string, c tcell.Color) Color {
+	return Color{
+		Name:       name,
+		Hex256:     fmt.Sprintf("#%06X", c.Hex()),
+		Tcell256:   c,
+		Hex24Bit:   fmt.Sprintf("#%06X", c.Hex()),
+		Tcell24Bit: c,
+	}
+}
+
+// okabeIto returns the Okabe-Ito colors that are legible on a dark background
+func okabeIto() []tcell.Color {
+	return []tcell.Color{okabeItoOrange, okabeItoSkyBlue, okabeItoGreen, okabeItoYellow, okabeItoVermilion, okabeItoPurple}
+}
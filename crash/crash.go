@@ -0,0 +1,345 @@
+// Package crash restores the terminal and writes a crash report when the CLI
+// panics, so that a bug does not leave the terminal unusable and users have
+// something to attach to an issue. The tail view session that was
+// interrupted is saved so that it can be resumed on the next start.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/config"
+	"github.com/streamdal/cli/types"
+)
+
+const (
+	// MaxActions is the number of recent actions included in a report
+	MaxActions = 50
+
+	// IssuesURL is where users are asked to report crashes
+	IssuesURL = "https://github.com/streamdal/cli/issues"
+
+	// RestoreTimeout is how long restoring the terminal may take; the UI
+	// goroutine may hold a lock that is never released after a panic
+	RestoreTimeout = time.Second
+)
+
+type Options struct {
+	// Dir is where reports are written (ex: ~/.streamdal)
+	Dir string
+
+	Config *config.Config
+	Logger *log.Logger
+}
+
+type Reporter struct {
+	options *Options
+	restore func()
+	actions []string
+	session *types.Session
+	mu      sync.Mutex
+	crashed bool
+	log     *log.Logger
+}
+
+func New(opts *Options) (*Reporter, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate crash options")
+	}
+
+	return &Reporter{
+		options: opts,
+		log:     opts.Logger.WithPrefix("crash"),
+	}, nil
+}
+
+// SetRestore sets the function that returns the terminal to its original
+// state; it is called before the report is written
+func (r *Reporter) SetRestore(restore func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.restore = restore
+}
+
+// Record adds an action (ex: "filter_set") to the recent actions included in
+// a report; details are optional key/value pairs
+func (r *Reporter) Record(action string, details map[string]string) {
+	keys := make([]string, 0, len(details))
+
+	for key := range details {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	line := time.Now().UTC().Format(time.RFC3339) + " " + action
+
+	for _, key := range keys {
+		line += fmt.Sprintf(" %s=%q", key, details[key])
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.actions = append(r.actions, line)
+
+	if len(r.actions) > MaxActions {
+		r.actions = r.actions[len(r.actions)-MaxActions:]
+	}
+}
+
+// SetSession sets the tail view session that is saved if the CLI crashes
+func (r *Reporter) SetSession(session *types.Session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.session = session
+}
+
+// current is the reporter that handles panics of goroutines started with Go()
+var current atomic.Pointer[Reporter]
+
+// SetDefault makes r handle the panics of goroutines started with Go()
+func SetDefault(r *Reporter) {
+	current.Store(r)
+}
+
+// Go runs fn in a new goroutine; a panic in fn is handled like in Recover()
+// by the reporter set with SetDefault() (if none, the panic is not recovered)
+func Go(fn func()) {
+	go func() {
+		defer recoverDefault()
+		fn()
+	}()
+}
+
+func recoverDefault() {
+	r := current.Load()
+	if r == nil {
+		return
+	}
+
+	r.handle(recover())
+}
+
+// Recover must be deferred at the top of a goroutine (ex: the UI goroutine):
+// on panic, the terminal is restored, a report is written and the CLI exits.
+// Goroutines started with Go() recover the same way.
+func (r *Reporter) Recover() {
+	r.handle(recover())
+}
+
+// handle restores the terminal, writes a report for panic p and exits; does
+// nothing if p is nil (no panic)
+func (r *Reporter) handle(p interface{}) {
+	if p == nil {
+		return
+	}
+
+	r.mu.Lock()
+
+	// Another goroutine is already handling a crash; it exits the CLI
+	if r.crashed {
+		r.mu.Unlock()
+		select {}
+	}
+
+	r.crashed = true
+	restore, session := r.restore, r.session
+	r.mu.Unlock()
+
+	if restore != nil {
+		r.restoreTerminal(restore)
+	}
+
+	stack := debug.Stack()
+
+	fmt.Fprintf(os.Stderr, "streamdal crashed: %v\n\n", p)
+
+	file, err := r.write(p, stack)
+	if err != nil {
+		// Better than nothing: the stack is always printed
+		fmt.Fprintf(os.Stderr, "unable to write crash report: %s\n\n%s\n", err, stack)
+		os.Exit(2)
+	}
+
+	fmt.Fprintf(os.Stderr, "A crash report was written to %s\nPlease attach it when reporting the issue at %s\n", file, IssuesURL)
+
+	if session != nil {
+		session.CrashReport = file
+
+		if err := config.SaveSession(session); err != nil {
+			r.log.Debugf("unable to save session: %s", err)
+		} else {
+			fmt.Fprintln(os.Stderr, "The session will be offered for resuming on the next start.")
+		}
+	}
+
+	os.Exit(2)
+}
+
+// restoreTerminal calls restore for up to RestoreTimeout; a panic in restore
+// (ex: the terminal is already gone) is ignored so that the report is still
+// written
+func (r *Reporter) restoreTerminal(restore func()) {
+	doneCh := make(chan struct{})
+
+	go func() {
+		defer close(doneCh)
+
+		defer func() {
+			if p := recover(); p != nil {
+				r.log.Debugf("panic while restoring the terminal: %v", p)
+			}
+		}()
+
+		restore()
+	}()
+
+	select {
+	case <-doneCh:
+	case <-time.After(RestoreTimeout):
+		r.log.Debug("timed out restoring the terminal")
+	}
+}
+
+// write writes a report to Dir and returns its path
+func (r *Reporter) write(p interface{}, stack []byte) (string, error) {
+	if err := os.MkdirAll(r.options.Dir, 0755); err != nil {
+		return "", errors.Wrap(err, "unable to create crash report directory")
+	}
+
+	now := time.Now().UTC()
+	file := path.Join(r.options.Dir, "crash-"+now.Format("20060102-150405")+".txt")
+
+	if err := os.WriteFile(file, []byte(r.report(now, p, stack)), 0600); err != nil {
+		return "", errors.Wrap(err, "unable to write crash report")
+	}
+
+	return file, nil
+}
+
+// report returns the contents of a report: the panic, a summary of the config,
+// the recent actions and the stacks
+func (r *Reporter) report(now time.Time, p interface{}, stack []byte) string {
+	cfg := r.options.Config
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "streamdal CLI crash report\n\n")
+	fmt.Fprintf(&b, "time:    %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "version: %s\n", cfg.GetVersion())
+	fmt.Fprintf(&b, "go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "command: %s\n", cfg.KongContext.Command())
+	fmt.Fprintf(&b, "panic:   %v\n", p)
+
+	fmt.Fprintf(&b, "\nflags that differ from their defaults (sensitive values are redacted):\n")
+
+	for _, flag := range summary(cfg) {
+		fmt.Fprintf(&b, "  %s\n", flag)
+	}
+
+	r.mu.Lock()
+	actions := append([]string(nil), r.actions...)
+	r.mu.Unlock()
+
+	fmt.Fprintf(&b, "\nlast actions (oldest first):\n")
+
+	if len(actions) == 0 {
+		fmt.Fprintf(&b, "  none\n")
+	}
+
+	for _, action := range actions {
+		fmt.Fprintf(&b, "  %s\n", action)
+	}
+
+	fmt.Fprintf(&b, "\nstack:\n%s\n", stack)
+
+	// The goroutine that panicked is not necessarily the one at fault (ex: a
+	// closed channel)
+	all := make([]byte, 1<<20)
+	all = all[:runtime.Stack(all, true)]
+
+	fmt.Fprintf(&b, "\nall goroutines:\n%s\n", all)
+
+	return b.String()
+}
+
+// summary returns the flags that differ from their defaults (set on the
+// command line, via env vars or the config file) as "--name=value"
+func summary(cfg *config.Config) []string {
+	flags := make([]string, 0)
+
+	for _, flag := range cfg.KongContext.Flags() {
+		if flag.Name == "help" {
+			continue
+		}
+
+		value := formatValue(flag.Target)
+
+		if value == flag.Default || (flag.Default == "" && flag.Target.IsZero()) {
+			continue
+		}
+
+		if config.SensitiveFlags[flag.Name] {
+			value = "<redacted>"
+		}
+
+		flags = append(flags, fmt.Sprintf("--%s=%s", flag.Name, value))
+	}
+
+	if len(flags) == 0 {
+		flags = append(flags, "none")
+	}
+
+	return flags
+}
+
+// formatValue formats a flag value the way defaults are written (ex: "a,b"
+// for slices)
+func formatValue(v reflect.Value) string {
+	if v.Kind() != reflect.Slice {
+		return fmt.Sprint(v.Interface())
+	}
+
+	values := make([]string, 0, v.Len())
+
+	for i := 0; i < v.Len(); i++ {
+		values = append(values, fmt.Sprint(v.Index(i).Interface()))
+	}
+
+	return strings.Join(values, ",")
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.Dir == "" {
+		return errors.New(".Dir cannot be empty")
+	}
+
+	if opts.Config == nil {
+		return errors.New(".Config cannot be nil")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
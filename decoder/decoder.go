@@ -0,0 +1,179 @@
+// Package decoder contains payload decoders that convert raw message bytes
+// into something that can be displayed in the tail view. Besides the built-in
+// decoders, external decoders can be loaded from Go plugins or WASM modules so
+// that proprietary wire formats can be displayed as well.
+package decoder
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultDecoder passes the payload through as-is
+	DefaultDecoder = "none"
+)
+
+// Decoder converts a raw payload into its display representation (ideally
+// JSON so that the tail view can pretty-print it).
+type Decoder interface {
+	Name() string
+	Decode(data []byte) ([]byte, error)
+}
+
+type Options struct {
+	// Plugins is a list of paths to Go plugins that export a "Decoder"
+	// symbol implementing the Decoder interface
+	Plugins []string
+
+	// WASMModules is a list of paths to WASM modules implementing the ABI
+	// described in wasm.go
+	WASMModules []string
+
+	Logger *log.Logger
+}
+
+type Registry struct {
+	decoders map[string]Decoder
+	wasm     []*WASMDecoder
+	log      *log.Logger
+}
+
+// New creates a registry containing the built-in decoders and all external
+// decoders specified in options.
+func New(opts *Options) (*Registry, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate decoder options")
+	}
+
+	r := &Registry{
+		decoders: make(map[string]Decoder),
+		log:      opts.Logger.WithPrefix("decoder"),
+	}
+
+	for _, d := range []Decoder{&noneDecoder{}, &base64Decoder{}, &hexDecoder{}} {
+		r.decoders[d.Name()] = d
+	}
+
+	for _, path := range opts.Plugins {
+		d, err := LoadPlugin(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to load decoder plugin '%s'", path)
+		}
+
+		if err := r.register(d); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range opts.WASMModules {
+		d, err := LoadWASM(context.Background(), path)
+		if err != nil {
+			r.Close()
+			return nil, errors.Wrapf(err, "unable to load decoder WASM module '%s'", path)
+		}
+
+		r.wasm = append(r.wasm, d)
+
+		if err := r.register(d); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Get returns the decoder registered under name
+func (r *Registry) Get(name string) (Decoder, bool) {
+	d, ok := r.decoders[name]
+	return d, ok
+}
+
+// Names returns the names of all registered decoders (sorted)
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.decoders))
+
+	for name := range r.decoders {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Close releases resources held by external decoders
+func (r *Registry) Close() {
+	for _, d := range r.wasm {
+		if err := d.Close(); err != nil {
+			r.log.Errorf("unable to close WASM decoder '%s': %s", d.Name(), err)
+		}
+	}
+}
+
+func (r *Registry) register(d Decoder) error {
+	if _, ok := r.decoders[d.Name()]; ok {
+		return errors.Errorf("decoder '%s' is already registered", d.Name())
+	}
+
+	r.decoders[d.Name()] = d
+
+	return nil
+}
+
+// nameFromPath derives a decoder name from a file path (ex: "/tmp/foo.wasm" -> "foo")
+func nameFromPath(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+type noneDecoder struct{}
+
+func (d *noneDecoder) Name() string { return DefaultDecoder }
+
+func (d *noneDecoder) Decode(data []byte) ([]byte, error) { return data, nil }
+
+type base64Decoder struct{}
+
+func (d *base64Decoder) Name() string { return "base64" }
+
+func (d *base64Decoder) Decode(data []byte) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode base64 payload")
+	}
+
+	return decoded, nil
+}
+
+type hexDecoder struct{}
+
+func (d *hexDecoder) Name() string { return "hex" }
+
+func (d *hexDecoder) Decode(data []byte) ([]byte, error) {
+	decoded, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode hex payload")
+	}
+
+	return decoded, nil
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
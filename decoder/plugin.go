@@ -0,0 +1,39 @@
+package decoder
+
+import (
+	"plugin"
+
+	"github.com/pkg/errors"
+)
+
+// PluginSymbol is the symbol a Go plugin must export; it must be a variable
+// whose value implements Decoder, for example:
+//
+//	var Decoder myDecoder
+//
+// NOTE: Go plugins are only supported on linux/darwin and require the CLI to
+// be built with CGO_ENABLED=1 using the same Go version as the plugin.
+const PluginSymbol = "Decoder"
+
+// LoadPlugin opens a Go plugin and returns the decoder it exports
+func LoadPlugin(path string) (Decoder, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open plugin")
+	}
+
+	sym, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, errors.Wrapf(err, "plugin does not export '%s'", PluginSymbol)
+	}
+
+	// Exported variables are looked up as pointers
+	switch d := sym.(type) {
+	case Decoder:
+		return d, nil
+	case *Decoder:
+		return *d, nil
+	default:
+		return nil, errors.Errorf("'%s' symbol of type '%T' does not implement Decoder", PluginSymbol, sym)
+	}
+}
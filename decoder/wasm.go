@@ -0,0 +1,56 @@
+package decoder
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/wasm"
+)
+
+// WASMDecodeFunc is the function WASM decoder modules must export (in addition
+// to the alloc function described in the wasm package):
+//
+//	decode(ptr: i32, len: i32) -> i64  decode input, return (out_ptr << 32 | out_len)
+//
+// The decoder name is derived from the module filename.
+const WASMDecodeFunc = "decode"
+
+type WASMDecoder struct {
+	module *wasm.Module
+}
+
+// LoadWASM compiles and instantiates a WASM decoder module
+func LoadWASM(ctx context.Context, path string) (*WASMDecoder, error) {
+	wasmBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read WASM module")
+	}
+
+	mod, err := wasm.Load(ctx, nameFromPath(path), wasmBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mod.HasFunction(WASMDecodeFunc) {
+		_ = mod.Close(ctx)
+		return nil, errors.Errorf("module must export '%s' function", WASMDecodeFunc)
+	}
+
+	return &WASMDecoder{
+		module: mod,
+	}, nil
+}
+
+func (d *WASMDecoder) Name() string {
+	return d.module.Name()
+}
+
+func (d *WASMDecoder) Decode(data []byte) ([]byte, error) {
+	return d.module.Call(context.Background(), WASMDecodeFunc, data)
+}
+
+func (d *WASMDecoder) Close() error {
+	return d.module.Close(context.Background())
+}
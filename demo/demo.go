@@ -0,0 +1,299 @@
+// Package demo contains a synthetic data source that implements api.IAPI. It
+// is used for running demos without a streamdal server and for benchmarking
+// the tail view with a reproducible message rate, payload size and JSON shape.
+package demo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	ShapeFlat   = "flat"
+	ShapeNested = "nested"
+	ShapeArray  = "array"
+
+	// tickInterval is how often the generator wakes up to emit messages; the
+	// number of messages emitted per tick is derived from the rate.
+	tickInterval = 10 * time.Millisecond
+
+	// ratesInterval is how often audience rates are reported (see
+	// WatchAudienceRates)
+	ratesInterval = time.Second
+)
+
+var (
+	Audiences = []*protos.Audience{
+		{
+			ServiceName:   "demo-service",
+			ComponentName: "kafka",
+			OperationType: protos.OperationType_OPERATION_TYPE_PRODUCER,
+			OperationName: "orders",
+		},
+		{
+			ServiceName:   "demo-service",
+			ComponentName: "kafka",
+			OperationType: protos.OperationType_OPERATION_TYPE_CONSUMER,
+			OperationName: "payments",
+		},
+		{
+			ServiceName:   "demo-billing",
+			ComponentName: "postgres",
+			OperationType: protos.OperationType_OPERATION_TYPE_PRODUCER,
+			OperationName: "invoices",
+		},
+	}
+
+	statuses = []string{"ok", "ok", "ok", "pending", "error"}
+)
+
+type Options struct {
+	// Rate is the number of messages generated per second (per tail)
+	Rate int
+
+	// PayloadSize is the approximate size of each generated payload in bytes
+	PayloadSize int
+
+	// Shape is the JSON shape of generated payloads (flat, nested or array)
+	Shape string
+
+	Logger *log.Logger
+}
+
+type Demo struct {
+	options   *Options
+	generated uint64
+	log       *log.Logger
+}
+
+func New(opts *Options) (*Demo, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate demo options")
+	}
+
+	return &Demo{
+		options: opts,
+		log:     opts.Logger.WithPrefix("demo"),
+	}, nil
+}
+
+// Test always succeeds as there is no server to talk to
+func (d *Demo) Test(_ context.Context) error {
+	return nil
+}
+
+// GetAllLiveAudiences returns a static list of demo audiences
+func (d *Demo) GetAllLiveAudiences(_ context.Context) ([]*protos.Audience, error) {
+	return Audiences, nil
+}
+
+// Tail generates messages for the given audience until ctx is canceled
+func (d *Demo) Tail(ctx context.Context, audience *protos.Audience) (chan *protos.TailResponse, error) {
+	if audience == nil {
+		return nil, errors.New("audience cannot be nil")
+	}
+
+	tailRespCh := make(chan *protos.TailResponse, 1)
+
+	crash.Go(func() {
+		defer d.log.Debug("demo.Tail() goroutine exiting")
+
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		var (
+			owed  float64
+			seq   int64
+			last  = time.Now()
+			perNs = float64(d.options.Rate) / float64(time.Second)
+		)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				owed += perNs * float64(now.Sub(last))
+				last = now
+
+				for ; owed >= 1; owed-- {
+					seq++
+
+					resp := &protos.TailResponse{
+						Type:         protos.TailResponseType_TAIL_RESPONSE_TYPE_PAYLOAD,
+						Audience:     audience,
+						TimestampNs:  time.Now().UnixNano(),
+						OriginalData: d.generate(seq),
+					}
+
+					select {
+					case tailRespCh <- resp:
+						atomic.AddUint64(&d.generated, 1)
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	})
+
+	return tailRespCh, nil
+}
+
+// WatchAudienceRates reports a synthetic rate for every demo audience. Rates
+// slowly rise and fall (out of phase) so that the audiences can be told apart;
+// they are not the rate of the messages generated by Tail().
+func (d *Demo) WatchAudienceRates(ctx context.Context) (chan *protos.GetAudienceRatesResponse, error) {
+	respCh := make(chan *protos.GetAudienceRatesResponse, 1)
+
+	crash.Go(func() {
+		defer close(respCh)
+
+		ticker := time.NewTicker(ratesInterval)
+		defer ticker.Stop()
+
+		start := time.Now()
+
+		for {
+			resp := &protos.GetAudienceRatesResponse{
+				Rates: make(map[string]*protos.AudienceRate),
+			}
+
+			elapsed := time.Since(start).Seconds()
+
+			for i, aud := range Audiences {
+				// Later audiences are busier
+				base := float64(d.options.Rate) * float64(i+1) / float64(len(Audiences))
+				rate := base * (1 + 0.5*math.Sin(elapsed/4+float64(i)*2))
+
+				resp.Rates[util.AudienceToStr(aud)] = &protos.AudienceRate{
+					Processed: int64(rate),
+					Bytes:     int64(rate) * int64(d.options.PayloadSize),
+				}
+			}
+
+			select {
+			case respCh <- resp:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return respCh, nil
+}
+
+// Generated returns the total number of messages generated so far
+func (d *Demo) Generated() uint64 {
+	return atomic.LoadUint64(&d.generated)
+}
+
+// generate creates a JSON payload in the configured shape, padded to roughly
+// the configured payload size.
+func (d *Demo) generate(seq int64) []byte {
+	now := time.Now()
+	status := statuses[seq%int64(len(statuses))]
+
+	var payload map[string]interface{}
+
+	switch d.options.Shape {
+	case ShapeNested:
+		payload = map[string]interface{}{
+			"id": seq,
+			"meta": map[string]interface{}{
+				"created_at":  now.UnixMilli(),
+				"traceparent": traceParent(),
+			},
+			"user": map[string]interface{}{
+				"id":    fmt.Sprintf("user-%d", seq%100),
+				"email": fmt.Sprintf("user%d@example.com", seq%100),
+			},
+			"status": status,
+		}
+	case ShapeArray:
+		items := make([]map[string]interface{}, 0)
+
+		for i := int64(0); i < 1+seq%3; i++ {
+			items = append(items, map[string]interface{}{
+				"sku":      fmt.Sprintf("sku-%d", (seq+i)%50),
+				"quantity": 1 + i,
+			})
+		}
+
+		payload = map[string]interface{}{
+			"id":         seq,
+			"created_at": now.UnixMilli(),
+			"items":      items,
+			"status":     status,
+		}
+	default:
+		payload = map[string]interface{}{
+			"id":         seq,
+			"created_at": now.UnixMilli(),
+			"status":     status,
+		}
+	}
+
+	data, _ := json.Marshal(payload)
+
+	// Pad payload up to requested size
+	if padding := d.options.PayloadSize - len(data) - len(`,"padding":""`); padding > 0 {
+		payload["padding"] = strings.Repeat("x", padding)
+		data, _ = json.Marshal(payload)
+	}
+
+	return data
+}
+
+// traceParent generates a random W3C traceparent value
+func traceParent() string {
+	traceID := make([]byte, 16)
+	parentID := make([]byte, 8)
+
+	_, _ = rand.Read(traceID)
+	_, _ = rand.Read(parentID)
+
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(traceID), hex.EncodeToString(parentID))
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.Rate < 1 {
+		return errors.New("rate must be at least 1")
+	}
+
+	switch opts.Shape {
+	case ShapeFlat, ShapeNested, ShapeArray:
+	default:
+		return errors.Errorf("unknown shape '%s'", opts.Shape)
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
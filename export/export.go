@@ -0,0 +1,100 @@
+// Package export renders buffered tail records into standalone documents, such
+// as an HTML report that can be attached to an incident review, a Markdown
+// snippet that can be pasted into an issue or a Parquet file for analysts.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/types"
+)
+
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "md"
+	FormatParquet  = "parquet"
+)
+
+// Formats are the supported export formats
+var Formats = []string{FormatHTML, FormatMarkdown, FormatParquet}
+
+var filenameRegex = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// Options control what is rendered in addition to the records
+type Options struct {
+	// Title is displayed at the top of the document (ex: the component name)
+	Title string
+
+	// Filter and Search are highlighted in payloads
+	Filter string
+	Search string
+
+	// Created is the time displayed as the export time
+	Created time.Time
+}
+
+// Write renders records in the given format
+func Write(w io.Writer, format string, records []*types.TailRecord, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	if opts.Created.IsZero() {
+		opts.Created = time.Now()
+	}
+
+	switch format {
+	case FormatHTML:
+		return HTML(w, records, opts)
+	case FormatMarkdown:
+		return Markdown(w, records, opts)
+	case FormatParquet:
+		return Parquet(w, records, opts)
+	default:
+		return errors.Errorf("unknown export format '%s'", format)
+	}
+}
+
+// Filename returns a default filename for an export of the given component
+func Filename(name, format string, now time.Time) string {
+	name = filenameRegex.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-")
+
+	if name == "" {
+		name = "tail"
+	}
+
+	return fmt.Sprintf("streamdal-%s-%s.%s", name, now.Format("20060102-150405"), format)
+}
+
+// CountLines returns the number of records that are not banners
+func CountLines(records []*types.TailRecord) int {
+	var count int
+
+	for _, r := range records {
+		if r.Banner == "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+// prettyJSON indents JSON payloads; other payloads are returned as-is
+func prettyJSON(data []byte) string {
+	var buf bytes.Buffer
+
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return string(data)
+	}
+
+	return buf.String()
+}
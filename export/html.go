@@ -0,0 +1,160 @@
+package export
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { background: #1e1b2e; color: #e6e6e6; font-family: sans-serif; margin: 2em; }
+h1 { color: #9d87d7; font-size: 1.4em; }
+.meta { color: #999; margin-bottom: 1.5em; }
+.record { border-left: 3px solid transparent; margin: 0 0 0.5em 0; padding: 0.2em 0.6em; }
+.record.bookmarked { border-left-color: #ffcc55; background: #2a2540; }
+.header { color: #999; font-family: monospace; }
+.component { color: #21c4c7; }
+.star { color: #ffcc55; }
+.note { color: #ffcc55; margin-top: 0.3em; }
+.banner { color: #888; font-family: monospace; margin: 0.5em 0; }
+pre { margin: 0.2em 0; white-space: pre-wrap; word-break: break-all; }
+mark.filter { background: #3a6b3a; color: inherit; }
+mark.search { background: #2f4e8a; color: inherit; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<div class="meta">Exported {{ .Created }} &middot; {{ .Lines }} lines{{ if .Bookmarks }} &middot; {{ .Bookmarks }} bookmarks{{ end }}{{ if .Filter }} &middot; filter: <mark class="filter">{{ .Filter }}</mark>{{ end }}{{ if .Search }} &middot; search: <mark class="search">{{ .Search }}</mark>{{ end }}</div>
+{{ range .Records }}{{ if .Banner }}<div class="banner">{{ .Banner }}</div>
+{{ else }}<div class="record{{ if .Bookmarked }} bookmarked{{ end }}" id="line-{{ .LineNum }}">
+<div class="header">{{ if .Bookmarked }}<span class="star">&#9733;</span> {{ end }}[{{ .LineNum }}] {{ .Received }}{{ if .Component }} <span class="component">[{{ .Component }}]</span>{{ end }}{{ if .TraceID }} trace: {{ .TraceID }}{{ end }}</div>
+<pre>{{ .Data }}</pre>
+{{ if .Note }}<div class="note">&#9998; {{ .Note }}</div>
+{{ end }}</div>
+{{ end }}{{ end }}</body>
+</html>
+`))
+
+type htmlReport struct {
+	Title     string
+	Created   string
+	Lines     int
+	Bookmarks int
+	Filter    string
+	Search    string
+	Records   []*htmlRecord
+}
+
+type htmlRecord struct {
+	LineNum    int
+	Received   string
+	Component  string
+	TraceID    string
+	Bookmarked bool
+	Note       string
+	Banner     string
+	Data       template.HTML
+}
+
+// HTML renders records as a standalone HTML report; filter and search matches
+// are highlighted and bookmarked lines (and their notes) are marked.
+func HTML(w io.Writer, records []*types.TailRecord, opts *Options) error {
+	report := &htmlReport{
+		Title:   opts.Title,
+		Created: opts.Created.In(util.DisplayLocation()).Format("2006-01-02 15:04:05 MST"),
+		Filter:  opts.Filter,
+		Search:  opts.Search,
+		Records: make([]*htmlRecord, 0, len(records)),
+	}
+
+	if report.Title == "" {
+		report.Title = "Streamdal tail export"
+	}
+
+	for _, r := range records {
+		if r.Banner != "" {
+			report.Records = append(report.Records, &htmlRecord{Banner: strings.TrimSpace(r.Banner)})
+			continue
+		}
+
+		record := &htmlRecord{
+			LineNum:    r.LineNum,
+			Received:   r.Received.In(util.DisplayLocation()).Format("15:04:05.000"),
+			TraceID:    r.TraceID,
+			Bookmarked: r.Bookmarked,
+			Note:       r.Note,
+			Data:       highlightHTML(prettyJSON(r.Data), opts),
+		}
+
+		if r.Component != nil {
+			record.Component = r.Component.Name
+		}
+
+		report.Lines++
+
+		if r.Bookmarked {
+			report.Bookmarks++
+		}
+
+		report.Records = append(report.Records, record)
+	}
+
+	if err := htmlTemplate.Execute(w, report); err != nil {
+		return errors.Wrap(err, "unable to render HTML report")
+	}
+
+	return nil
+}
+
+// highlightHTML escapes data and wraps filter/search matches in <mark> tags.
+// Matches are found in the unescaped data so that terms never match inside
+// escaped entities or previously inserted tags.
+func highlightHTML(data string, opts *Options) template.HTML {
+	var sb strings.Builder
+
+	for len(data) > 0 {
+		idx, term, class := nextMatch(data, opts)
+		if idx < 0 {
+			sb.WriteString(template.HTMLEscapeString(data))
+			break
+		}
+
+		sb.WriteString(template.HTMLEscapeString(data[:idx]))
+		sb.WriteString(`<mark class="` + class + `">` + template.HTMLEscapeString(term) + `</mark>`)
+
+		data = data[idx+len(term):]
+	}
+
+	return template.HTML(sb.String())
+}
+
+// nextMatch returns the position of the first filter or search match in data
+// (-1 if there is none); the filter wins if both match at the same position.
+func nextMatch(data string, opts *Options) (int, string, string) {
+	idx, term, class := -1, "", ""
+
+	for _, h := range []struct{ term, class string }{
+		{opts.Filter, "filter"},
+		{opts.Search, "search"},
+	} {
+		if h.term == "" {
+			continue
+		}
+
+		if i := strings.Index(data, h.term); i >= 0 && (idx < 0 || i < idx) {
+			idx, term, class = i, h.term, h.class
+		}
+	}
+
+	return idx, term, class
+}
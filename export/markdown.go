@@ -0,0 +1,113 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// Markdown renders records as a fenced code block (one line per record) that
+// can be pasted into GitHub issues or Slack. Bookmarked lines are marked with
+// a star and notes are added below the line they belong to.
+func Markdown(w io.Writer, records []*types.TailRecord, opts *Options) error {
+	title := opts.Title
+	if title == "" {
+		title = "Streamdal tail export"
+	}
+
+	header := fmt.Sprintf("**%s** · %d lines · exported %s", title, CountLines(records), opts.Created.In(util.DisplayLocation()).Format("2006-01-02 15:04:05 MST"))
+
+	if opts.Filter != "" {
+		header += " · filter: " + inlineCode(opts.Filter)
+	}
+
+	if _, err := fmt.Fprintf(w, "%s\n\n%s\n", header, CodeBlock(records)); err != nil {
+		return errors.Wrap(err, "unable to write markdown")
+	}
+
+	return nil
+}
+
+// CodeBlock renders records as a fenced code block (one line per record); the
+// format is understood by both GitHub Markdown and Slack mrkdwn.
+func CodeBlock(records []*types.TailRecord) string {
+	lines := make([]string, 0, len(records))
+
+	for _, r := range records {
+		if r.Banner != "" {
+			lines = append(lines, "--- "+strings.TrimSpace(r.Banner)+" ---")
+			continue
+		}
+
+		line := fmt.Sprintf("[%d] %s", r.LineNum, r.Received.In(util.DisplayLocation()).Format("15:04:05.000"))
+
+		if r.Bookmarked {
+			line = "★ " + line
+		}
+
+		if r.Component != nil {
+			line += " [" + r.Component.Name + "]"
+		}
+
+		// Payloads are kept on a single line so that lines can be told apart
+		line += " " + strings.ReplaceAll(string(r.Data), "\n", " ")
+
+		lines = append(lines, line)
+
+		if r.Note != "" {
+			lines = append(lines, "    ✎ "+r.Note)
+		}
+	}
+
+	body := strings.Join(lines, "\n")
+	fence := codeFence(body)
+
+	return fence + "\n" + body + "\n" + fence
+}
+
+// codeFence returns a fence that is longer than any run of backticks in s so
+// that payloads containing backticks do not end the code block early
+func codeFence(s string) string {
+	n := maxBackticks(s) + 1
+
+	if n < 3 {
+		n = 3
+	}
+
+	return strings.Repeat("`", n)
+}
+
+// inlineCode wraps s in enough backticks to contain the backticks in s
+func inlineCode(s string) string {
+	fence := strings.Repeat("`", maxBackticks(s)+1)
+
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+
+	return fence + s + fence
+}
+
+func maxBackticks(s string) int {
+	var longest, current int
+
+	for _, r := range s {
+		if r != '`' {
+			current = 0
+			continue
+		}
+
+		current++
+
+		if current > longest {
+			longest = current
+		}
+	}
+
+	return longest
+}
@@ -0,0 +1,285 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/parquet"
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/util"
+)
+
+// Columns of every Parquet export; payload fields with the same name are
+// prefixed with "payload_"
+const (
+	parquetLineColumn     = "line"
+	parquetReceivedColumn = "received"
+	parquetAudienceColumn = "audience"
+	parquetTraceIDColumn  = "trace_id"
+
+	// Bookmarks and notes are only written if a line is bookmarked
+	parquetBookmarkedColumn = "bookmarked"
+	parquetNoteColumn       = "note"
+
+	// parquetPayloadColumn holds payloads that are not JSON objects
+	parquetPayloadColumn = "payload"
+
+	// parquetFieldSeparator joins the keys of nested objects (ex:
+	// "user_email"); Athena only allows letters, numbers and underscores
+	parquetFieldSeparator = "_"
+)
+
+// The kinds of JSON values seen in a field, used for inferring its type
+const (
+	kindBool = 1 << iota
+	kindInt
+	kindFloat
+	kindOther
+)
+
+// parquetField is a column inferred from the JSON fields of payloads
+type parquetField struct {
+	kinds  int
+	values []interface{} // one per row; nil if the field is missing
+}
+
+// Parquet writes the messages of records (banners are skipped) as a Parquet
+// file for analysis with DuckDB, Athena, etc. Every field of JSON object
+// payloads becomes a column (nested objects are flattened and arrays are
+// stored as JSON); the type of a column is inferred from its values and is
+// string if they are of mixed types.
+func Parquet(w io.Writer, records []*types.TailRecord, _ *Options) error {
+	lines := make([]interface{}, 0, len(records))
+	received := make([]interface{}, 0, len(records))
+	audiences := make([]interface{}, 0, len(records))
+	traceIDs := make([]interface{}, 0, len(records))
+	payloads := make([]interface{}, 0, len(records))
+	bookmarked := make([]interface{}, 0, len(records))
+	notes := make([]interface{}, 0, len(records))
+
+	var hasTraceIDs, hasPayloads, hasBookmarks bool
+
+	fields := make(map[string]*parquetField)
+	order := make([]string, 0)
+
+	for _, r := range records {
+		if r.Banner != "" {
+			continue
+		}
+
+		row := len(lines)
+
+		lines = append(lines, int64(r.LineNum))
+		received = append(received, r.Received)
+		audiences = append(audiences, nil)
+		traceIDs = append(traceIDs, nil)
+		payloads = append(payloads, nil)
+		bookmarked = append(bookmarked, r.Bookmarked)
+		notes = append(notes, nil)
+
+		if r.Bookmarked {
+			hasBookmarks = true
+		}
+
+		if r.Note != "" {
+			notes[row] = r.Note
+		}
+
+		if r.Component != nil {
+			audiences[row] = util.FormatAudience(r.Component.Audience)
+		}
+
+		if r.TraceID != "" {
+			traceIDs[row] = r.TraceID
+			hasTraceIDs = true
+		}
+
+		values, ok := flattenJSON(r.Data)
+		if !ok {
+			payloads[row] = string(r.Data)
+			hasPayloads = true
+
+			continue
+		}
+
+		// Sorted so that the column order does not depend on map iteration
+		names := make([]string, 0, len(values))
+
+		for name := range values {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			v := values[name]
+
+			field, ok := fields[name]
+			if !ok {
+				field = &parquetField{}
+				fields[name] = field
+				order = append(order, name)
+			}
+
+			// Fields that first appear in a later row are null until then
+			for len(field.values) < row {
+				field.values = append(field.values, nil)
+			}
+
+			field.kinds |= jsonKind(v)
+			field.values = append(field.values, v)
+		}
+	}
+
+	columns := []*parquet.Column{
+		{Name: parquetLineColumn, Type: parquet.Int64, Values: lines},
+		{Name: parquetReceivedColumn, Type: parquet.Timestamp, Values: received},
+		{Name: parquetAudienceColumn, Type: parquet.String, Values: audiences},
+	}
+
+	if hasTraceIDs {
+		columns = append(columns, &parquet.Column{Name: parquetTraceIDColumn, Type: parquet.String, Values: traceIDs})
+	}
+
+	if hasPayloads {
+		columns = append(columns, &parquet.Column{Name: parquetPayloadColumn, Type: parquet.String, Values: payloads})
+	}
+
+	if hasBookmarks {
+		columns = append(columns,
+			&parquet.Column{Name: parquetBookmarkedColumn, Type: parquet.Boolean, Values: bookmarked},
+			&parquet.Column{Name: parquetNoteColumn, Type: parquet.String, Values: notes},
+		)
+	}
+
+	reserved := make(map[string]bool, len(columns))
+
+	for _, column := range columns {
+		reserved[column.Name] = true
+	}
+
+	for _, name := range order {
+		field := fields[name]
+
+		for len(field.values) < len(lines) {
+			field.values = append(field.values, nil)
+		}
+
+		for reserved[name] {
+			name = parquetPayloadColumn + parquetFieldSeparator + name
+		}
+
+		reserved[name] = true
+
+		columns = append(columns, field.column(name))
+	}
+
+	if err := parquet.Write(w, columns); err != nil {
+		return errors.Wrap(err, "unable to write parquet")
+	}
+
+	return nil
+}
+
+// column converts the decoded JSON values of the field to the inferred type
+func (f *parquetField) column(name string) *parquet.Column {
+	column := &parquet.Column{Name: name, Values: make([]interface{}, len(f.values))}
+
+	switch f.kinds {
+	case kindBool:
+		column.Type = parquet.Boolean
+	case kindInt:
+		column.Type = parquet.Int64
+	case kindFloat, kindInt | kindFloat:
+		column.Type = parquet.Double
+	default:
+		column.Type = parquet.String
+	}
+
+	for i, v := range f.values {
+		if v == nil {
+			continue
+		}
+
+		switch column.Type {
+		case parquet.Boolean:
+			column.Values[i] = v
+		case parquet.Int64:
+			column.Values[i], _ = v.(json.Number).Int64()
+		case parquet.Double:
+			column.Values[i], _ = v.(json.Number).Float64()
+		default:
+			if s, ok := v.(string); ok {
+				column.Values[i] = s
+			} else {
+				// Numbers, booleans and arrays are stored as JSON
+				data, _ := json.Marshal(v)
+				column.Values[i] = string(data)
+			}
+		}
+	}
+
+	return column
+}
+
+// flattenJSON returns the fields of a JSON object payload, with the keys of
+// nested objects joined by parquetFieldSeparator; false if data is not a JSON
+// object. Null values are omitted.
+func flattenJSON(data []byte) (map[string]interface{}, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var obj map[string]interface{}
+
+	if err := dec.Decode(&obj); err != nil || obj == nil {
+		return nil, false
+	}
+
+	fields := make(map[string]interface{})
+	flatten(fields, "", obj)
+
+	return fields, true
+}
+
+func flatten(fields map[string]interface{}, prefix string, obj map[string]interface{}) {
+	for key, v := range obj {
+		name := key
+		if prefix != "" {
+			name = prefix + parquetFieldSeparator + key
+		}
+
+		switch val := v.(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			flatten(fields, name, val)
+		default:
+			fields[name] = val
+		}
+	}
+}
+
+// jsonKind returns the kind of a decoded JSON value (decoded with UseNumber)
+func jsonKind(v interface{}) int {
+	switch val := v.(type) {
+	case bool:
+		return kindBool
+	case json.Number:
+		if strings.ContainsAny(val.String(), ".eE") {
+			return kindFloat
+		}
+
+		if _, err := val.Int64(); err != nil {
+			return kindFloat
+		}
+
+		return kindInt
+	default:
+		return kindOther
+	}
+}
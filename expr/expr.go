@@ -0,0 +1,150 @@
+// Package expr evaluates CEL (https://github.com/google/cel-spec) expressions
+// against tailed messages; filters and break expressions are either a CEL
+// expression or a plain substring.
+//
+// The following variables are available in expressions:
+//
+//	payload   - the decoded payload (JSON) or the raw payload as a string
+//	raw       - the raw payload as a string
+//	metadata  - map of message metadata (string => string)
+//	timestamp - when the message was produced (or received)
+//
+// Ex: payload.status == 500 && metadata["region"] == "us-east-1"
+package expr
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/pkg/errors"
+)
+
+// Variables that can be referenced in expressions
+const (
+	VarPayload   = "payload"
+	VarRaw       = "raw"
+	VarMetadata  = "metadata"
+	VarTimestamp = "timestamp"
+)
+
+// Message is what expressions are evaluated against
+type Message struct {
+	Payload   []byte
+	Metadata  map[string]string
+	Timestamp time.Time
+}
+
+// Expr is a compiled expression; it is safe for concurrent use
+type Expr struct {
+	source  string
+	program cel.Program
+}
+
+var env *cel.Env
+
+func init() {
+	var err error
+
+	env, err = cel.NewEnv(
+		cel.Variable(VarPayload, cel.DynType),
+		cel.Variable(VarRaw, cel.StringType),
+		cel.Variable(VarMetadata, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(VarTimestamp, cel.TimestampType),
+	)
+	if err != nil {
+		panic("unable to create CEL environment: " + err.Error())
+	}
+}
+
+// Compile compiles a boolean expression
+func Compile(source string) (*Expr, error) {
+	ast, err := compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create CEL program")
+	}
+
+	return &Expr{source: source, program: program}, nil
+}
+
+// IsExpression returns true if source is a CEL expression that references at
+// least one variable and is known to return a bool; anything else (ex:
+// "error", "true", or "payload" whose type is only known at runtime) is meant
+// to be a substring.
+func IsExpression(source string) bool {
+	ast, err := compile(source)
+	if err != nil || !ast.OutputType().IsExactType(cel.BoolType) {
+		return false
+	}
+
+	checked, err := cel.AstToCheckedExpr(ast)
+	if err != nil {
+		return false
+	}
+
+	for _, ref := range checked.GetReferenceMap() {
+		switch ref.GetName() {
+		case VarPayload, VarRaw, VarMetadata, VarTimestamp:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Match evaluates the expression against msg. Expressions that fail to
+// evaluate (ex: a field is missing from the payload) return an error.
+func (e *Expr) Match(msg *Message) (bool, error) {
+	metadata := msg.Metadata
+
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	var payload interface{}
+
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		payload = string(msg.Payload)
+	}
+
+	out, _, err := e.program.Eval(map[string]interface{}{
+		VarPayload:   payload,
+		VarRaw:       string(msg.Payload),
+		VarMetadata:  metadata,
+		VarTimestamp: msg.Timestamp,
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to evaluate expression")
+	}
+
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, errors.Errorf("expression returned '%v' instead of a bool", out.Value())
+	}
+
+	return matched, nil
+}
+
+func (e *Expr) String() string {
+	return e.source
+}
+
+// compile parses and type-checks source; the result must be a bool (or dyn,
+// ex: payload.enabled)
+func compile(source string) (*cel.Ast, error) {
+	ast, issues := env.Compile(source)
+	if issues != nil && issues.Err() != nil {
+		return nil, errors.Wrap(issues.Err(), "invalid expression")
+	}
+
+	if t := ast.OutputType(); !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+		return nil, errors.Errorf("expression must return a bool, not %s", t)
+	}
+
+	return ast, nil
+}
@@ -0,0 +1,455 @@
+// Package grpcweb is a minimal gRPC-web client (unary and server-streaming
+// calls) implementing grpc.ClientConnInterface, so that generated gRPC clients
+// can talk to a server through a gRPC-web proxy (ex: Envoy) when raw gRPC
+// (HTTP/2) is blocked by a corporate proxy.
+package grpcweb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamdal/cli/crash"
+)
+
+const (
+	ContentType = "application/grpc-web+proto"
+
+	// Frame flags; every message and the trailers are prefixed with a flag
+	// byte and a 4-byte (big endian) length
+	flagData     = 0x00
+	flagTrailers = 0x80
+
+	// MaxMessageSize is the largest message that is accepted from the server
+	MaxMessageSize = 64 * 1024 * 1024
+)
+
+type Options struct {
+	// URL of the gRPC-web endpoint (ex: https://streamdal.example.com)
+	URL string
+
+	// ConnectTimeout is the timeout for establishing the TCP/TLS connection
+	ConnectTimeout time.Duration
+
+	// Dialer is used instead of a direct TCP connection (ex: to go through an
+	// SSH tunnel); optional
+	Dialer func(ctx context.Context, addr string) (net.Conn, error)
+}
+
+// Conn is a gRPC-web "connection"; every call is a separate HTTP request
+type Conn struct {
+	options *Options
+	baseURL string
+	client  *http.Client
+
+	// state mimics grpc.ClientConn.GetState(): READY after a request (or a
+	// read of a stream) succeeded, TRANSIENT_FAILURE after a request could not
+	// be sent or a stream broke
+	state atomic.Int32
+}
+
+func New(opts *Options) (*Conn, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate grpcweb options")
+	}
+
+	proxy, dialContext := http.ProxyFromEnvironment, (&net.Dialer{Timeout: opts.ConnectTimeout}).DialContext
+
+	// HTTP proxies are not reachable from the other end of the dialer
+	if opts.Dialer != nil {
+		proxy = nil
+		dialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return opts.Dialer(ctx, addr)
+		}
+	}
+
+	c := &Conn{
+		options: opts,
+		baseURL: strings.TrimSuffix(opts.URL, "/"),
+
+		// No client timeout: streams are long-lived
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:               proxy,
+				DialContext:         dialContext,
+				TLSHandshakeTimeout: opts.ConnectTimeout,
+			},
+		},
+	}
+
+	c.state.Store(int32(connectivity.Idle))
+
+	return c, nil
+}
+
+// GetState returns the state of the last request or stream read
+func (c *Conn) GetState() connectivity.State {
+	return connectivity.State(c.state.Load())
+}
+
+// Invoke performs a unary call; grpc.Header() and grpc.Trailer() are the
+// only call options that are supported
+func (c *Conn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	resp, err := c.post(ctx, method, args)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	s := &stream{ctx: ctx, resp: resp, reader: bufio.NewReader(resp.Body)}
+
+	if err := s.RecvMsg(reply); err != nil {
+		if err == io.EOF {
+			return status.Error(codes.Internal, "server did not send a response message")
+		}
+
+		return err
+	}
+
+	// Read the trailers (and the status of the call)
+	if err := s.RecvMsg(nil); err != io.EOF {
+		return err
+	}
+
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = headerToMD(resp.Header)
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = headerToMD(http.Header(s.trailer))
+		}
+	}
+
+	return nil
+}
+
+// NewStream starts a server-streaming call; the request is sent on
+// CloseSend(). Client and bidirectional streaming are not supported by
+// gRPC-web.
+func (c *Conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	if desc.ClientStreams {
+		return nil, status.Errorf(codes.Unimplemented, "grpcweb: client streaming is not supported (%s)", method)
+	}
+
+	return &stream{ctx: ctx, conn: c, method: method}, nil
+}
+
+// post sends a single (framed) message to method
+func (c *Conn) post(ctx context.Context, method string, msg interface{}) (*http.Response, error) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "grpcweb: message is not a proto.Message: %T", msg)
+	}
+
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "grpcweb: unable to marshal request: %s", err)
+	}
+
+	body := make([]byte, 5+len(data))
+	body[0] = flagData
+	binary.BigEndian.PutUint32(body[1:5], uint32(len(data)))
+	copy(body[5:], data)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "grpcweb: unable to create request: %s", err)
+	}
+
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", ContentType)
+	req.Header.Set("X-Grpc-Web", "1")
+
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		for k, values := range md {
+			for _, v := range values {
+				req.Header.Add(k, v)
+			}
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.state.Store(int32(connectivity.TransientFailure))
+		}
+
+		return nil, status.Errorf(codes.Unavailable, "grpcweb: unable to send request: %s", err)
+	}
+
+	c.state.Store(int32(connectivity.Ready))
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, status.Errorf(httpStatusToCode(resp.StatusCode), "grpcweb: unexpected HTTP status %s", resp.Status)
+	}
+
+	// Trailers-only response (ex: an error before any message was sent)
+	if err := statusError(resp.Header); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// stream is a server-streaming call
+type stream struct {
+	ctx     context.Context
+	conn    *Conn
+	method  string
+	request interface{}
+	resp    *http.Response
+	reader  *bufio.Reader
+	trailer metadata.MD
+	err     error
+	done    chan struct{} // closed once the stream ended
+}
+
+func (s *stream) Header() (metadata.MD, error) {
+	if s.resp == nil {
+		return nil, errors.New("grpcweb: request has not been sent")
+	}
+
+	return headerToMD(s.resp.Header), nil
+}
+
+func (s *stream) Trailer() metadata.MD {
+	return s.trailer
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg stores the request; it is sent on CloseSend()
+func (s *stream) SendMsg(m interface{}) error {
+	if s.request != nil || s.resp != nil {
+		return status.Error(codes.Unimplemented, "grpcweb: only a single request message is supported")
+	}
+
+	s.request = m
+
+	return nil
+}
+
+func (s *stream) CloseSend() error {
+	if s.resp != nil {
+		return nil
+	}
+
+	resp, err := s.conn.post(s.ctx, s.method, s.request)
+	if err != nil {
+		return err
+	}
+
+	s.resp = resp
+	s.reader = bufio.NewReader(resp.Body)
+	s.done = make(chan struct{})
+
+	// Release the response once the call is canceled (unblocks RecvMsg) or
+	// the stream ended
+	crash.Go(func() {
+		select {
+		case <-s.ctx.Done():
+		case <-s.done:
+		}
+
+		resp.Body.Close()
+	})
+
+	return nil
+}
+
+// RecvMsg reads the next message into m; io.EOF is returned once the server
+// finished the call successfully
+func (s *stream) RecvMsg(m interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.reader == nil {
+		return status.Error(codes.Internal, "grpcweb: request has not been sent")
+	}
+
+	// Like grpc-go, any error ends the stream
+	s.err = s.recv(m)
+
+	if s.err != nil && s.done != nil {
+		close(s.done)
+	}
+
+	return s.err
+}
+
+func (s *stream) recv(m interface{}) error {
+	flag, data, err := readFrame(s.reader)
+	if err != nil {
+		if s.ctx.Err() != nil {
+			return status.FromContextError(s.ctx.Err()).Err()
+		}
+
+		// The response broke (ex: the proxy went away) after the request
+		// succeeded
+		s.conn.state.Store(int32(connectivity.TransientFailure))
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return status.Error(codes.Unavailable, "grpcweb: stream ended without trailers")
+		}
+
+		return status.Errorf(codes.Unavailable, "grpcweb: unable to read response: %s", err)
+	}
+
+	s.conn.state.Store(int32(connectivity.Ready))
+
+	if flag&flagTrailers != 0 {
+		s.trailer = parseTrailers(data)
+
+		if err := statusError(http.Header(s.trailer)); err != nil {
+			return err
+		}
+
+		return io.EOF
+	}
+
+	if m == nil {
+		return status.Error(codes.Internal, "grpcweb: unexpected response message")
+	}
+
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "grpcweb: message is not a proto.Message: %T", m)
+	}
+
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return status.Errorf(codes.Internal, "grpcweb: unable to unmarshal response: %s", err)
+	}
+
+	return nil
+}
+
+// readFrame reads a single length-prefixed frame
+func readFrame(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > MaxMessageSize {
+		return 0, nil, fmt.Errorf("message too large (%d bytes)", length)
+	}
+
+	data := make([]byte, length)
+
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+
+	return header[0], data, nil
+}
+
+// parseTrailers parses a trailers frame ("key: value\r\n" lines); keys are
+// canonicalized so that the result can be used as an http.Header
+func parseTrailers(data []byte) metadata.MD {
+	md := metadata.MD{}
+
+	for _, line := range strings.Split(string(data), "\r\n") {
+		k, v, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		k = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(k))
+		md[k] = append(md[k], strings.TrimSpace(v))
+	}
+
+	return md
+}
+
+// statusError returns the error described by the grpc-status and
+// grpc-message headers/trailers; nil if the status is OK or missing
+func statusError(h http.Header) error {
+	code := h.Get("Grpc-Status")
+	if code == "" || code == "0" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return status.Errorf(codes.Unknown, "grpcweb: invalid grpc-status '%s'", code)
+	}
+
+	msg, err := url.PathUnescape(h.Get("Grpc-Message"))
+	if err != nil {
+		msg = h.Get("Grpc-Message")
+	}
+
+	return status.Error(codes.Code(n), msg)
+}
+
+func headerToMD(h http.Header) metadata.MD {
+	md := metadata.MD{}
+
+	for k, v := range h {
+		md[strings.ToLower(k)] = v
+	}
+
+	return md
+}
+
+// httpStatusToCode maps HTTP errors (ex: returned by a proxy) to gRPC codes
+// the same way grpc-go does
+func httpStatusToCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.URL == "" {
+		return errors.New(".URL cannot be empty")
+	}
+
+	if !strings.HasPrefix(opts.URL, "http://") && !strings.HasPrefix(opts.URL, "https://") {
+		return errors.New(".URL must start with http:// or https://")
+	}
+
+	return nil
+}
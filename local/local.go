@@ -0,0 +1,317 @@
+// Package local contains a data source that implements api.IAPI by reading
+// payloads from local files or stdin (one payload per line, ex: ndjson or
+// plain logs) so that the tail UI can be used on data that never touched a
+// streamdal server.
+package local
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	// ServiceName and ComponentFileName are used for the audiences of local
+	// files; the operation name is the path of the file.
+	ServiceName       = "local"
+	ComponentFileName = "file"
+
+	// ComponentStdin is used as both the operation and component name of the
+	// stdin audience
+	ComponentStdin = "stdin"
+
+	// stdinBufferSize is the number of lines read from stdin ahead of the
+	// tail; once full, the producer is blocked until the lines are consumed
+	stdinBufferSize = 1000
+
+	// pollInterval is how often a followed file is checked for new data
+	pollInterval = 250 * time.Millisecond
+)
+
+type Options struct {
+	// Paths are the files to read; every file is a separate component
+	Paths []string
+
+	// Follow keeps reading files as they grow (like tail -f); otherwise the
+	// tail ends once the end of the file is reached
+	Follow bool
+
+	// Stdin (optional) is read line by line as an additional component
+	Stdin io.Reader
+
+	Logger *log.Logger
+}
+
+type Local struct {
+	options   *Options
+	audiences []*protos.Audience
+	paths     map[string]string
+	stdinCh   chan []byte
+	log       *log.Logger
+}
+
+func New(opts *Options) (*Local, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate local source options")
+	}
+
+	l := &Local{
+		options:   opts,
+		audiences: make([]*protos.Audience, 0),
+		paths:     make(map[string]string),
+		log:       opts.Logger.WithPrefix("local"),
+	}
+
+	for _, path := range opts.Paths {
+		aud := &protos.Audience{
+			ServiceName:   ServiceName,
+			ComponentName: ComponentFileName,
+			OperationType: protos.OperationType_OPERATION_TYPE_CONSUMER,
+			OperationName: filepath.Clean(path),
+		}
+
+		l.audiences = append(l.audiences, aud)
+		l.paths[util.AudienceToStr(aud)] = path
+	}
+
+	if opts.Stdin != nil {
+		l.audiences = append(l.audiences, StdinAudience())
+		l.stdinCh = make(chan []byte, stdinBufferSize)
+
+		// Stdin can only be read once so it is read for the lifetime of the
+		// source (instead of per Tail() call)
+		crash.Go(l.readStdin)
+	}
+
+	return l, nil
+}
+
+// Test always succeeds as there is no server to talk to
+func (l *Local) Test(_ context.Context) error {
+	return nil
+}
+
+// GetAllLiveAudiences returns an audience for every file
+func (l *Local) GetAllLiveAudiences(_ context.Context) ([]*protos.Audience, error) {
+	return l.audiences, nil
+}
+
+// Tail reads the file for the given audience line by line until ctx is
+// canceled; if the file is not followed, the channel is closed once the end
+// of the file has been reached.
+func (l *Local) Tail(ctx context.Context, audience *protos.Audience) (chan *protos.TailResponse, error) {
+	if audience == nil {
+		return nil, errors.New("audience cannot be nil")
+	}
+
+	if l.stdinCh != nil && util.AudienceEquals(audience, StdinAudience()) {
+		return l.tailStdin(ctx, audience), nil
+	}
+
+	path, ok := l.paths[util.AudienceToStr(audience)]
+	if !ok {
+		return nil, errors.Errorf("unknown audience '%s'", util.FormatAudience(audience))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open file")
+	}
+
+	tailRespCh := make(chan *protos.TailResponse, 1)
+
+	crash.Go(func() {
+		defer l.log.Debugf("local.Tail() goroutine for '%s' exiting", path)
+		defer f.Close()
+
+		if err := l.read(ctx, f, audience, tailRespCh); err != nil {
+			l.log.Errorf("unable to read '%s': %s", path, err)
+		}
+	})
+
+	return tailRespCh, nil
+}
+
+// read sends every line of f to tailRespCh; tailRespCh is closed when the
+// end of the file is reached (unless following) or on error.
+func (l *Local) read(ctx context.Context, f *os.File, audience *protos.Audience, tailRespCh chan *protos.TailResponse) error {
+	var (
+		reader  = bufio.NewReader(f)
+		partial []byte
+		offset  int64
+	)
+
+	send := func(line []byte) bool {
+		line = bytes.TrimRight(line, "\r\n")
+
+		if len(line) == 0 {
+			return true
+		}
+
+		select {
+		case tailRespCh <- newResponse(audience, line):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		data, err := reader.ReadBytes('\n')
+		offset += int64(len(data))
+		partial = append(partial, data...)
+
+		switch {
+		case err == nil:
+			if !send(partial) {
+				return nil
+			}
+
+			partial = nil
+
+			continue
+		case !errors.Is(err, io.EOF):
+			close(tailRespCh)
+			return err
+		case !l.options.Follow:
+			// The last line does not have to end with a newline
+			send(partial)
+			close(tailRespCh)
+
+			return nil
+		}
+
+		// Following: start over if the file was truncated (ex: by logrotate's
+		// copytruncate); otherwise wait for more data
+		if info, err := f.Stat(); err == nil && info.Size() < offset {
+			l.log.Debugf("'%s' was truncated, reading from the start", f.Name())
+
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				close(tailRespCh)
+				return errors.Wrap(err, "unable to seek to start of truncated file")
+			}
+
+			reader.Reset(f)
+			partial = nil
+			offset = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// StdinAudience returns the audience used for lines read from stdin
+func StdinAudience() *protos.Audience {
+	return &protos.Audience{
+		ServiceName:   ServiceName,
+		ComponentName: ComponentStdin,
+		OperationType: protos.OperationType_OPERATION_TYPE_CONSUMER,
+		OperationName: ComponentStdin,
+	}
+}
+
+// readStdin sends every non-empty line read from stdin to stdinCh; stdinCh is
+// closed once stdin is closed (ex: the producer piping into the CLI exited).
+func (l *Local) readStdin() {
+	defer close(l.stdinCh)
+
+	reader := bufio.NewReader(l.options.Stdin)
+
+	for {
+		data, err := reader.ReadBytes('\n')
+
+		if line := bytes.TrimRight(data, "\r\n"); len(line) > 0 {
+			l.stdinCh <- line
+		}
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				l.log.Errorf("unable to read stdin: %s", err)
+			}
+
+			return
+		}
+	}
+}
+
+// tailStdin forwards lines read from stdin until ctx is canceled or stdin is
+// closed; lines read while stdin is not being tailed are buffered (up to
+// stdinBufferSize).
+func (l *Local) tailStdin(ctx context.Context, audience *protos.Audience) chan *protos.TailResponse {
+	tailRespCh := make(chan *protos.TailResponse, 1)
+
+	crash.Go(func() {
+		defer l.log.Debug("local.tailStdin() goroutine exiting")
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case line, ok := <-l.stdinCh:
+				if !ok {
+					close(tailRespCh)
+					return
+				}
+
+				select {
+				case tailRespCh <- newResponse(audience, line):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+
+	return tailRespCh
+}
+
+func newResponse(audience *protos.Audience, line []byte) *protos.TailResponse {
+	return &protos.TailResponse{
+		Type:         protos.TailResponseType_TAIL_RESPONSE_TYPE_PAYLOAD,
+		Audience:     audience,
+		TimestampNs:  time.Now().UnixNano(),
+		OriginalData: line,
+	}
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if len(opts.Paths) == 0 && opts.Stdin == nil {
+		return errors.New("at least one path or stdin is required")
+	}
+
+	for _, path := range opts.Paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.Wrapf(err, "unable to stat '%s'", path)
+		}
+
+		if info.IsDir() {
+			return errors.Errorf("'%s' is a directory", path)
+		}
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
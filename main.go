@@ -11,6 +11,8 @@ import (
 	"github.com/streamdal/cli/cmd"
 	"github.com/streamdal/cli/config"
 	"github.com/streamdal/cli/console"
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/profiling"
 	"github.com/streamdal/cli/telemetry"
 	"github.com/streamdal/cli/types"
 	"github.com/streamdal/cli/util"
@@ -35,16 +37,34 @@ func main() {
 		util.RedirectStdErr(f)
 
 		logger.SetOutput(f)
-		logger.SetFormatter(log.JSONFormatter)
 	} else {
 		logger = log.Default()
 	}
 
-	if cfg.Debug {
-		logger.SetLevel(log.DebugLevel)
+	logger.SetLevel(cfg.GetLogLevel())
+	logger.SetFormatter(cfg.GetLogFormatter())
+
+	// Log tooling expects machine-parseable timestamps
+	if cfg.GetLogFormatter() != log.TextFormatter {
+		logger.SetTimeFormat(time.RFC3339Nano)
+	}
+
+	if cfg.GetLogLevel() == log.DebugLevel {
 		logger.SetReportCaller(true)
 	}
 
+	profiler, err := profiling.Start(&profiling.Options{
+		PprofAddress: cfg.Pprof,
+		CPUProfile:   cfg.CPUProfile,
+		MemProfile:   cfg.MemProfile,
+		Logger:       logger,
+	})
+	if err != nil {
+		log.Fatalf("unable to start profiling: %s", err)
+	}
+
+	defer profiler.Stop()
+
 	var t statsd.Statter
 	if !cfg.TelemetryDisable {
 		statsdClient, err := statsd.NewClientWithConfig(&statsd.ClientConfig{
@@ -68,28 +88,69 @@ func main() {
 	_ = t.Gauge(types.GaugeArgsNum, int64(len(cfg.KongContext.Args)), 1.0, cfg.GetStatsdTags()...)
 	_ = t.Inc(types.CounterExecTotal, 1, 1.0, cfg.GetStatsdTags()...)
 
+	// ReportErrorAndExit() exits right away; deferred calls would not run
+	fatal := func(err error) {
+		profiler.Stop()
+		util.ReportErrorAndExit(t, cfg, err)
+	}
+
+	// Applies to every displayed timestamp and stat (TUI and headless commands)
+	util.SetUTC(cfg.UTC)
+	util.SetRawNumbers(cfg.RawNumbers)
+	util.SetAccessible(cfg.Accessible)
+	util.SetASCII(cfg.ASCII)
+
+	crashDir, err := config.Dir()
+	if err != nil {
+		crashDir = os.TempDir()
+	}
+
+	reporter, err := crash.New(&crash.Options{
+		Dir:    crashDir,
+		Config: cfg,
+		Logger: logger,
+	})
+	if err != nil {
+		fatal(errors.Wrap(err, "unable to initialize crash reporter"))
+	}
+
+	// Steps run in this goroutine; the UI goroutine recovers on its own (see
+	// console.Start) and background goroutines are started with crash.Go()
+	defer reporter.Recover()
+	crash.SetDefault(reporter)
+
 	// Initialize console components
 	ui, err := console.New(&console.Options{
 		Config: cfg,
+		Crash:  reporter,
 		Logger: logger,
 	})
 	if err != nil {
-		util.ReportErrorAndExit(t, cfg, errors.Wrap(err, "unable to initialize console"))
+		fatal(errors.Wrap(err, "unable to initialize console"))
 	}
 
+	reporter.SetRestore(ui.Stop)
+
 	// Initialize cmd which houses business logic
 	c, err := cmd.New(&cmd.Options{
 		Config:    cfg,
 		Console:   ui,
+		Crash:     reporter,
 		Logger:    logger,
 		Telemetry: t,
 	})
 	if err != nil {
-		util.ReportErrorAndExit(t, cfg, errors.Wrap(err, "unable to initialize cmd"))
+		fatal(errors.Wrap(err, "unable to initialize cmd"))
 	}
 
 	// Do the dance
 	if err := c.Run(); err != nil {
-		util.ReportErrorAndExit(t, cfg, errors.Wrap(err, "error during cmd run"))
+		// Not an error; only signals that headless tail did not see a match
+		if errors.Is(err, cmd.ErrNoMatches) {
+			profiler.Stop()
+			os.Exit(1)
+		}
+
+		fatal(errors.Wrap(err, "error during cmd run"))
 	}
 }
@@ -0,0 +1,401 @@
+// Package parquet writes flat Parquet files that can be queried with DuckDB,
+// Athena, Spark and the like. Only what is needed for exporting tailed
+// messages is supported: a single row group, optional (nullable) columns of a
+// few primitive types and one PLAIN encoded, Snappy compressed data page per
+// column.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/pkg/errors"
+)
+
+const (
+	magic     = "PAR1"
+	createdBy = "streamdal-cli"
+)
+
+// Type is the type of the values of a column
+type Type int
+
+const (
+	Boolean   Type = iota // bool
+	Int64                 // int64
+	Double                // float64
+	String                // string
+	Timestamp             // time.Time, stored with millisecond precision (UTC)
+)
+
+func (t Type) String() string {
+	switch t {
+	case Boolean:
+		return "boolean"
+	case Int64:
+		return "int64"
+	case Double:
+		return "double"
+	case String:
+		return "string"
+	case Timestamp:
+		return "timestamp"
+	default:
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+}
+
+// Parquet physical types, converted types, encodings and codecs (see
+// parquet.thrift in apache/parquet-format)
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionOptional = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecSnappy = 1
+
+	pageTypeData = 0
+)
+
+// Column is a named column with one value per row; nil values are nulls
+type Column struct {
+	Name   string
+	Type   Type
+	Values []interface{}
+}
+
+// columnChunk is what is needed for describing a written column in the
+// file metadata
+type columnChunk struct {
+	column           *Column
+	offset           int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+// countingWriter keeps track of the file offset
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// Write writes columns as a Parquet file; all columns must have the same
+// number of values (rows)
+func Write(w io.Writer, columns []*Column) error {
+	if err := validateColumns(columns); err != nil {
+		return errors.Wrap(err, "unable to validate columns")
+	}
+
+	var rows int
+
+	if len(columns) > 0 {
+		rows = len(columns[0].Values)
+	}
+
+	cw := &countingWriter{w: w}
+
+	if _, err := io.WriteString(cw, magic); err != nil {
+		return errors.Wrap(err, "unable to write parquet header")
+	}
+
+	chunks := make([]*columnChunk, 0, len(columns))
+
+	for _, column := range columns {
+		page, err := encodePage(column)
+		if err != nil {
+			return errors.Wrapf(err, "unable to encode column '%s'", column.Name)
+		}
+
+		compressed := snappy.Encode(nil, page)
+		header := pageHeader(rows, len(page), len(compressed))
+
+		chunk := &columnChunk{
+			column:           column,
+			offset:           cw.n,
+			uncompressedSize: int64(len(header) + len(page)),
+			compressedSize:   int64(len(header) + len(compressed)),
+		}
+
+		if _, err := cw.Write(header); err != nil {
+			return errors.Wrap(err, "unable to write page header")
+		}
+
+		if _, err := cw.Write(compressed); err != nil {
+			return errors.Wrap(err, "unable to write page")
+		}
+
+		chunks = append(chunks, chunk)
+	}
+
+	footer := fileMetadata(rows, chunks)
+
+	var trailer [4]byte
+
+	binary.LittleEndian.PutUint32(trailer[:], uint32(len(footer)))
+
+	for _, b := range [][]byte{footer, trailer[:], []byte(magic)} {
+		if _, err := cw.Write(b); err != nil {
+			return errors.Wrap(err, "unable to write parquet footer")
+		}
+	}
+
+	return nil
+}
+
+// encodePage returns the (uncompressed) data page of a column: the RLE
+// encoded definition levels (1 = set, 0 = null) followed by the PLAIN
+// encoded values that are set
+func encodePage(column *Column) ([]byte, error) {
+	levels := encodeLevels(column.Values)
+
+	var page bytes.Buffer
+
+	_ = binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+	page.Write(levels)
+
+	var bits []bool
+
+	for row, v := range column.Values {
+		if v == nil {
+			continue
+		}
+
+		var ok bool
+
+		switch column.Type {
+		case Boolean:
+			var b bool
+			if b, ok = v.(bool); ok {
+				bits = append(bits, b)
+			}
+		case Int64:
+			var i int64
+			if i, ok = v.(int64); ok {
+				_ = binary.Write(&page, binary.LittleEndian, i)
+			}
+		case Double:
+			var f float64
+			if f, ok = v.(float64); ok {
+				_ = binary.Write(&page, binary.LittleEndian, math.Float64bits(f))
+			}
+		case String:
+			var s string
+			if s, ok = v.(string); ok {
+				_ = binary.Write(&page, binary.LittleEndian, uint32(len(s)))
+				page.WriteString(s)
+			}
+		case Timestamp:
+			var t time.Time
+			if t, ok = v.(time.Time); ok {
+				_ = binary.Write(&page, binary.LittleEndian, t.UnixMilli())
+			}
+		}
+
+		if !ok {
+			return nil, errors.Errorf("row %d: expected a %s value, got %T", row, column.Type, v)
+		}
+	}
+
+	// Booleans are bit-packed, least significant bit first
+	if column.Type == Boolean {
+		packed := make([]byte, (len(bits)+7)/8)
+
+		for i, b := range bits {
+			if b {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+
+		page.Write(packed)
+	}
+
+	return page.Bytes(), nil
+}
+
+// encodeLevels encodes the definition levels of values as runs of the
+// RLE/bit-packing hybrid encoding (bit width 1)
+func encodeLevels(values []interface{}) []byte {
+	var buf bytes.Buffer
+
+	var tmp [binary.MaxVarintLen64]byte
+
+	for i := 0; i < len(values); {
+		set := values[i] != nil
+
+		run := 1
+		for i+run < len(values) && (values[i+run] != nil) == set {
+			run++
+		}
+
+		// The header of an RLE run is the run length shifted left by one,
+		// followed by the repeated value
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(run)<<1)])
+
+		if set {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+
+		i += run
+	}
+
+	return buf.Bytes()
+}
+
+func pageHeader(rows, uncompressedSize, compressedSize int) []byte {
+	w := &compactWriter{}
+
+	w.i32(1, pageTypeData)
+	w.i32(2, int32(uncompressedSize))
+	w.i32(3, int32(compressedSize))
+
+	w.beginStruct(5) // DataPageHeader
+	w.i32(1, int32(rows))
+	w.i32(2, encodingPlain)
+	w.i32(3, encodingRLE)
+	w.i32(4, encodingRLE)
+	w.endStruct()
+
+	return w.end()
+}
+
+func fileMetadata(rows int, chunks []*columnChunk) []byte {
+	w := &compactWriter{}
+
+	w.i32(1, 1) // version
+
+	// The schema is flattened depth-first; the root element is followed by
+	// the columns
+	w.listHeader(2, thriftStruct, len(chunks)+1)
+
+	w.push()
+	w.string(4, "schema")
+	w.i32(5, int32(len(chunks)))
+	w.endStruct()
+
+	for _, chunk := range chunks {
+		physical, converted := physicalType(chunk.column.Type)
+
+		w.push()
+		w.i32(1, physical)
+		w.i32(3, repetitionOptional)
+		w.string(4, chunk.column.Name)
+
+		if converted >= 0 {
+			w.i32(6, converted)
+		}
+
+		w.endStruct()
+	}
+
+	w.i64(3, int64(rows))
+
+	var totalSize int64
+
+	for _, chunk := range chunks {
+		totalSize += chunk.uncompressedSize
+	}
+
+	w.listHeader(4, thriftStruct, 1)
+	w.push() // RowGroup
+
+	w.listHeader(1, thriftStruct, len(chunks))
+
+	for _, chunk := range chunks {
+		physical, _ := physicalType(chunk.column.Type)
+
+		w.push() // ColumnChunk
+		w.i64(2, chunk.offset)
+
+		w.beginStruct(3) // ColumnMetaData
+		w.i32(1, physical)
+
+		w.listHeader(2, thriftI32, 2)
+		w.varint(encodingPlain)
+		w.varint(encodingRLE)
+
+		w.listHeader(3, thriftBinary, 1)
+		w.rawString(chunk.column.Name)
+
+		w.i32(4, codecSnappy)
+		w.i64(5, int64(rows))
+		w.i64(6, chunk.uncompressedSize)
+		w.i64(7, chunk.compressedSize)
+		w.i64(9, chunk.offset)
+		w.endStruct()
+
+		w.endStruct()
+	}
+
+	w.i64(2, totalSize)
+	w.i64(3, int64(rows))
+	w.endStruct()
+
+	w.string(6, createdBy)
+
+	return w.end()
+}
+
+// physicalType returns the physical and converted type (-1 if none) of t
+func physicalType(t Type) (int32, int32) {
+	switch t {
+	case Boolean:
+		return physicalBoolean, -1
+	case Int64:
+		return physicalInt64, -1
+	case Double:
+		return physicalDouble, -1
+	case Timestamp:
+		return physicalInt64, convertedTimestampMillis
+	default:
+		return physicalByteArray, convertedUTF8
+	}
+}
+
+func validateColumns(columns []*Column) error {
+	names := make(map[string]bool, len(columns))
+
+	for _, column := range columns {
+		if column.Name == "" {
+			return errors.New("column name cannot be empty")
+		}
+
+		if names[column.Name] {
+			return errors.Errorf("duplicate column '%s'", column.Name)
+		}
+
+		names[column.Name] = true
+
+		if column.Type < Boolean || column.Type > Timestamp {
+			return errors.Errorf("column '%s' has an unknown type", column.Name)
+		}
+
+		if len(column.Values) != len(columns[0].Values) {
+			return errors.Errorf("column '%s' has %d values, expected %d", column.Name, len(column.Values), len(columns[0].Values))
+		}
+	}
+
+	return nil
+}
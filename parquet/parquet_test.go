@@ -0,0 +1,104 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// The golden files were read back with the Parquet reader of Apache Arrow,
+// which validates the footer and the offsets of the column chunks. After
+// regenerating them with -update, check the new files with a Parquet reader
+// (ex: pyarrow.parquet.read_table()) before committing them.
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestWriteGolden(t *testing.T) {
+	received := time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC)
+
+	tests := []struct {
+		name    string
+		columns []*Column
+	}{
+		{
+			name: "records",
+			columns: []*Column{
+				{Name: "line", Type: Int64, Values: []interface{}{int64(1), int64(2), nil, int64(-4)}},
+				{Name: "ok", Type: Boolean, Values: []interface{}{true, nil, false, true}},
+				{Name: "latency", Type: Double, Values: []interface{}{0.5, 1e9, nil, -2.25}},
+				{Name: "status", Type: String, Values: []interface{}{"ok", "", nil, "état"}},
+				{Name: "received", Type: Timestamp, Values: []interface{}{received, nil, received.Add(time.Second), nil}},
+				{Name: "missing", Type: String, Values: []interface{}{nil, nil, nil, nil}},
+			},
+		},
+		{
+			name:    "no_rows",
+			columns: []*Column{{Name: "line", Type: Int64}, {Name: "status", Type: String}},
+		},
+	}
+
+	for _, tt := range tests {
+		data := write(t, tt.columns)
+		path := filepath.Join("testdata", tt.name+".parquet")
+
+		if *update {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("%s: unable to update the golden file: %s", tt.name, err)
+			}
+
+			continue
+		}
+
+		golden, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: unable to read the golden file: %s", tt.name, err)
+		}
+
+		if !bytes.Equal(data, golden) {
+			t.Errorf("%s: written file differs from %s", tt.name, path)
+		}
+	}
+}
+
+func TestWriteInvalidColumns(t *testing.T) {
+	for name, columns := range map[string][]*Column{
+		"empty name":      {{Type: Int64}},
+		"duplicate name":  {{Name: "a", Type: Int64}, {Name: "a", Type: String}},
+		"unknown type":    {{Name: "a", Type: Timestamp + 1}},
+		"different rows":  {{Name: "a", Type: Int64, Values: []interface{}{int64(1)}}, {Name: "b", Type: Int64}},
+		"different types": {{Name: "a", Type: Int64, Values: []interface{}{"1"}}},
+	} {
+		if err := Write(&bytes.Buffer{}, columns); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// write returns the file written for columns after checking that it is
+// framed by the magic number and that the trailer points at the footer
+func write(t *testing.T, columns []*Column) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	if err := Write(&buf, columns); err != nil {
+		t.Fatalf("unable to write: %s", err)
+	}
+
+	data := buf.Bytes()
+
+	if !bytes.HasPrefix(data, []byte(magic)) || !bytes.HasSuffix(data, []byte(magic)) {
+		t.Fatal("file does not start and end with the magic number")
+	}
+
+	footerSize := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+
+	if footerSize <= 0 || len(magic)+footerSize+8 > len(data) {
+		t.Fatalf("trailer says the footer is %d bytes in a %d byte file", footerSize, len(data))
+	}
+
+	return data
+}
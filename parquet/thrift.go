@@ -0,0 +1,119 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol types used by the Parquet metadata
+const (
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftStruct    = 12
+)
+
+// compactWriter encodes the Thrift structs of the Parquet format with the
+// compact protocol. Fields must be written in increasing field id order, as
+// required by the field header deltas.
+type compactWriter struct {
+	buf    bytes.Buffer
+	lastID int16
+
+	// stack holds the last field id of the enclosing structs
+	stack []int16
+}
+
+func (w *compactWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - w.lastID; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(int64(id))
+	}
+
+	w.lastID = id
+}
+
+// varint writes a zigzag encoded varint
+func (w *compactWriter) varint(v int64) {
+	w.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *compactWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+
+	w.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *compactWriter) bool(id int16, v bool) {
+	if v {
+		w.fieldHeader(id, thriftBoolTrue)
+	} else {
+		w.fieldHeader(id, thriftBoolFalse)
+	}
+}
+
+func (w *compactWriter) string(id int16, v string) {
+	w.fieldHeader(id, thriftBinary)
+	w.rawString(v)
+}
+
+func (w *compactWriter) rawString(v string) {
+	w.uvarint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+// beginStruct starts a struct field; it must be ended with endStruct
+func (w *compactWriter) beginStruct(id int16) {
+	w.fieldHeader(id, thriftStruct)
+	w.push()
+}
+
+func (w *compactWriter) endStruct() {
+	w.buf.WriteByte(0) // stop field
+	w.pop()
+}
+
+// listHeader starts a list field of size elements of type elemType. Struct
+// elements are written between push and endStruct.
+func (w *compactWriter) listHeader(id int16, elemType byte, size int) {
+	w.fieldHeader(id, thriftList)
+
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xf0 | elemType)
+		w.uvarint(uint64(size))
+	}
+}
+
+// push starts a struct (ex: a list element) without a field header
+func (w *compactWriter) push() {
+	w.stack = append(w.stack, w.lastID)
+	w.lastID = 0
+}
+
+func (w *compactWriter) pop() {
+	w.lastID = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+// end writes the stop field of the top-level struct and returns its bytes
+func (w *compactWriter) end() []byte {
+	w.buf.WriteByte(0)
+	return w.buf.Bytes()
+}
@@ -0,0 +1,226 @@
+// Package pipeline handles declarative (YAML/JSON) pipeline definitions. The
+// file format mirrors protos.Pipeline using the proto field names, for example:
+//
+//	name: reject-emails
+//	steps:
+//	  - name: detect email
+//	    on_success: [PIPELINE_STEP_CONDITION_ABORT]
+//	    detective:
+//	      path: user.email
+//	      type: DETECTIVE_TYPE_PII_EMAIL
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Load reads a pipeline definition from a YAML or JSON file
+func Load(path string) (*protos.Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read pipeline file")
+	}
+
+	// YAML is a superset of JSON but we want protojson error messages for
+	// JSON files, so only convert YAML files.
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse pipeline YAML")
+		}
+	}
+
+	p := &protos.Pipeline{}
+
+	if err := protojson.Unmarshal(data, p); err != nil {
+		return nil, errors.Wrap(err, "unable to parse pipeline definition")
+	}
+
+	return p, nil
+}
+
+// Validate checks a pipeline against the same constraints the server enforces
+// so that problems are reported before anything is sent to the server.
+func Validate(p *protos.Pipeline) error {
+	if p == nil {
+		return errors.New("pipeline cannot be nil")
+	}
+
+	problems := make([]string, 0)
+
+	if p.Name == "" {
+		problems = append(problems, "pipeline name cannot be empty")
+	}
+
+	if len(p.Steps) == 0 {
+		problems = append(problems, "pipeline must have at least one step")
+	}
+
+	for i, step := range p.Steps {
+		for _, problem := range validateStep(step) {
+			problems = append(problems, fmt.Sprintf("step %d (%s): %s", i+1, step.Name, problem))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func validateStep(step *protos.PipelineStep) []string {
+	problems := make([]string, 0)
+
+	if step.Name == "" {
+		problems = append(problems, "name cannot be empty")
+	}
+
+	for _, cond := range append(step.OnSuccess, step.OnFailure...) {
+		if cond == protos.PipelineStepCondition_PIPELINE_STEP_CONDITION_UNSET {
+			problems = append(problems, "on_success/on_failure conditions cannot be unset")
+		}
+	}
+
+	switch s := step.Step.(type) {
+	case nil:
+		problems = append(problems, "step type (detective, transform, ...) must be set")
+	case *protos.PipelineStep_Detective:
+		if s.Detective.GetType() == 0 {
+			problems = append(problems, "detective type must be set")
+		}
+	case *protos.PipelineStep_Transform:
+		if s.Transform.GetType() == 0 {
+			problems = append(problems, "transform type must be set")
+		}
+
+		if s.Transform.GetPath() == "" {
+			problems = append(problems, "transform path cannot be empty")
+		}
+	case *protos.PipelineStep_Encode:
+		if s.Encode.GetId() == "" {
+			problems = append(problems, "encode id cannot be empty")
+		}
+	case *protos.PipelineStep_Decode:
+		if s.Decode.GetId() == "" {
+			problems = append(problems, "decode id cannot be empty")
+		}
+	case *protos.PipelineStep_HttpRequest:
+		if s.HttpRequest.GetRequest().GetUrl() == "" {
+			problems = append(problems, "http request url cannot be empty")
+		}
+	}
+
+	return problems
+}
+
+// Marshal encodes a pipeline in the declarative file format (format is either
+// "yaml" or "json"). Fields that are populated by the server (WASM module
+// bytes, IDs and function names) are omitted.
+func Marshal(p *protos.Pipeline, format string) ([]byte, error) {
+	p = proto.Clone(p).(*protos.Pipeline)
+
+	for _, step := range p.Steps {
+		step.XWasmId = nil
+		step.XWasmBytes = nil
+		step.XWasmFunction = nil
+	}
+
+	data, err := protojson.MarshalOptions{
+		Multiline:     true,
+		Indent:        "  ",
+		UseProtoNames: true,
+	}.Marshal(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal pipeline")
+	}
+
+	switch format {
+	case FormatJSON:
+		return append(data, '\n'), nil
+	case FormatYAML:
+		return jsonToYAML(data)
+	default:
+		return nil, errors.Errorf("unknown format '%s'", format)
+	}
+}
+
+// Filename returns a filesystem-friendly filename for a pipeline
+func Filename(p *protos.Pipeline, format string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return unicode.ToLower(r)
+		}
+
+		return '-'
+	}, p.Name)
+
+	if name == "" {
+		name = p.Id
+	}
+
+	return name + "." + format
+}
+
+// jsonToYAML converts JSON to block-style YAML while preserving key order
+func jsonToYAML(data []byte) ([]byte, error) {
+	node := &yaml.Node{}
+
+	// JSON is valid YAML (flow style)
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return nil, errors.Wrap(err, "unable to convert JSON to YAML")
+	}
+
+	resetStyle(node)
+
+	buf := &bytes.Buffer{}
+
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(node); err != nil {
+		return nil, errors.Wrap(err, "unable to marshal YAML")
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, errors.Wrap(err, "unable to marshal YAML")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// yamlToJSON converts a YAML document to JSON so that it can be parsed by protojson
+func yamlToJSON(data []byte) ([]byte, error) {
+	var obj interface{}
+
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(obj)
+}
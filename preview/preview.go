@@ -0,0 +1,160 @@
+// Package preview runs a streamdal pipeline step (WASM module) client-side
+// against tailed payloads, so that users can see what a step would detect or
+// transform before the pipeline is deployed to the server.
+package preview
+
+import (
+	"context"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamdal/cli/types"
+	"github.com/streamdal/cli/wasm"
+)
+
+const (
+	// DefaultFunction is the function exported by streamdal WASM modules
+	DefaultFunction = "f"
+)
+
+type Options struct {
+	// WASMFile is the path to the step's WASM module
+	WASMFile string
+
+	// StepFile is the path to a JSON encoded protos.PipelineStep containing
+	// the step configuration (ex: detective type, path and args)
+	StepFile string
+
+	// Function is the WASM function to execute (default: "f")
+	Function string
+
+	Logger *log.Logger
+}
+
+type Preview struct {
+	step    *protos.PipelineStep
+	module  *wasm.Module
+	options *Options
+	log     *log.Logger
+}
+
+func New(opts *Options) (*Preview, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate preview options")
+	}
+
+	stepData, err := os.ReadFile(opts.StepFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read step file")
+	}
+
+	step := &protos.PipelineStep{}
+
+	if err := protojson.Unmarshal(stepData, step); err != nil {
+		return nil, errors.Wrap(err, "unable to parse step file")
+	}
+
+	wasmBytes, err := os.ReadFile(opts.WASMFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read WASM module")
+	}
+
+	if opts.Function == "" {
+		opts.Function = DefaultFunction
+	}
+
+	name := step.Name
+	if name == "" {
+		name = "preview"
+	}
+
+	mod, err := wasm.Load(context.Background(), name, wasmBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load step WASM module")
+	}
+
+	if !mod.HasFunction(opts.Function) {
+		_ = mod.Close(context.Background())
+		return nil, errors.Errorf("WASM module does not export function '%s'", opts.Function)
+	}
+
+	return &Preview{
+		step:    step,
+		module:  mod,
+		options: opts,
+		log:     opts.Logger.WithPrefix("preview"),
+	}, nil
+}
+
+// Name returns the name of the step being previewed
+func (p *Preview) Name() string {
+	return p.module.Name()
+}
+
+// Run executes the step against a payload and returns the result
+func (p *Preview) Run(data []byte) *types.StepPreview {
+	result := &types.StepPreview{
+		Step: p.Name(),
+	}
+
+	req, err := proto.Marshal(&protos.WASMRequest{
+		Step:         p.step,
+		InputPayload: data,
+	})
+	if err != nil {
+		result.Error = "unable to marshal WASM request: " + err.Error()
+		return result
+	}
+
+	out, err := p.module.Call(context.Background(), p.options.Function, req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	resp := &protos.WASMResponse{}
+
+	if err := proto.Unmarshal(out, resp); err != nil {
+		result.Error = "unable to unmarshal WASM response: " + err.Error()
+		return result
+	}
+
+	result.Success = resp.ExitCode == protos.WASMExitCode_WASM_EXIT_CODE_SUCCESS
+	result.Message = resp.ExitMsg
+	result.Output = resp.OutputPayload
+
+	if resp.ExitCode == protos.WASMExitCode_WASM_EXIT_CODE_INTERNAL_ERROR {
+		result.Error = resp.ExitMsg
+	}
+
+	return result
+}
+
+func (p *Preview) Close() error {
+	return p.module.Close(context.Background())
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.WASMFile == "" {
+		return errors.New("WASM file must be specified")
+	}
+
+	if opts.StepFile == "" {
+		return errors.New("step file must be specified")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
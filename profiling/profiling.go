@@ -0,0 +1,130 @@
+// Package profiling exposes the Go runtime profilers so that the CLI's own
+// resource usage can be diagnosed (ex: during very high-throughput tails).
+package profiling
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	rpprof "runtime/pprof"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/crash"
+)
+
+type Options struct {
+	// PprofAddress is the address the net/http/pprof endpoints will listen on
+	PprofAddress string
+
+	// CPUProfile is the file the CPU profile will be written to
+	CPUProfile string
+
+	// MemProfile is the file the heap profile will be written to on Stop()
+	MemProfile string
+
+	Logger *log.Logger
+}
+
+type Profiler struct {
+	options *Options
+	cpuFile *os.File
+	server  *http.Server
+	log     *log.Logger
+}
+
+// Start launches all profilers that have been enabled in options. Stop() must
+// be called before exit for the CPU and memory profiles to be written.
+func Start(opts *Options) (*Profiler, error) {
+	if opts == nil {
+		return nil, errors.New("options cannot be nil")
+	}
+
+	if opts.Logger == nil {
+		return nil, errors.New(".Logger cannot be nil")
+	}
+
+	p := &Profiler{
+		options: opts,
+		log:     opts.Logger.WithPrefix("profiling"),
+	}
+
+	if opts.CPUProfile != "" {
+		f, err := os.Create(opts.CPUProfile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create cpu profile file")
+		}
+
+		if err := rpprof.StartCPUProfile(f); err != nil {
+			_ = f.Close()
+			return nil, errors.Wrap(err, "unable to start cpu profile")
+		}
+
+		p.cpuFile = f
+	}
+
+	if opts.PprofAddress != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+		p.server = &http.Server{
+			Addr:    opts.PprofAddress,
+			Handler: mux,
+		}
+
+		crash.Go(func() {
+			p.log.Debugf("starting pprof server on '%s'", opts.PprofAddress)
+
+			if err := p.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				p.log.Errorf("pprof server error: %s", err)
+			}
+		})
+	}
+
+	return p, nil
+}
+
+// Stop flushes the CPU profile, writes the heap profile and shuts down the
+// pprof server.
+func (p *Profiler) Stop() {
+	if p.cpuFile != nil {
+		rpprof.StopCPUProfile()
+
+		if err := p.cpuFile.Close(); err != nil {
+			p.log.Errorf("unable to close cpu profile: %s", err)
+		}
+	}
+
+	if p.options.MemProfile != "" {
+		if err := p.writeMemProfile(); err != nil {
+			p.log.Errorf("unable to write memory profile: %s", err)
+		}
+	}
+
+	if p.server != nil {
+		_ = p.server.Close()
+	}
+}
+
+func (p *Profiler) writeMemProfile() error {
+	f, err := os.Create(p.options.MemProfile)
+	if err != nil {
+		return errors.Wrap(err, "unable to create memory profile file")
+	}
+	defer f.Close()
+
+	// Get up-to-date statistics
+	runtime.GC()
+
+	if err := rpprof.WriteHeapProfile(f); err != nil {
+		return errors.Wrap(err, "unable to write heap profile")
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package query
+
+import (
+	"regexp"
+	"strings"
+)
+
+// condition is a WHERE clause (or part of it)
+type condition interface {
+	eval(r *row) bool
+}
+
+// operand is a field or a literal
+type operand interface {
+	value(r *row) interface{}
+}
+
+type literal struct {
+	v interface{}
+}
+
+func (l *literal) value(_ *row) interface{} {
+	return l.v
+}
+
+type and struct {
+	left, right condition
+}
+
+func (c *and) eval(r *row) bool {
+	return c.left.eval(r) && c.right.eval(r)
+}
+
+type or struct {
+	left, right condition
+}
+
+func (c *or) eval(r *row) bool {
+	return c.left.eval(r) || c.right.eval(r)
+}
+
+type not struct {
+	cond condition
+}
+
+func (c *not) eval(r *row) bool {
+	return !c.cond.eval(r)
+}
+
+type comparison struct {
+	left  operand
+	op    string
+	right operand
+}
+
+// eval compares numerically if both sides are numbers (or one side is a
+// number and the other a numeric string, ex: "500" = 500), otherwise as
+// strings. Like SQL, comparisons with null are false.
+func (c *comparison) eval(r *row) bool {
+	a, b := c.left.value(r), c.right.value(r)
+
+	if a == nil || b == nil {
+		return false
+	}
+
+	var cmp int
+
+	_, aNum := a.(float64)
+	_, bNum := b.(float64)
+
+	if an, ok := toNumber(a); ok && (aNum || bNum) {
+		bn, ok := toNumber(b)
+		if !ok {
+			return c.op == "!=" || c.op == "<>"
+		}
+
+		switch {
+		case an < bn:
+			cmp = -1
+		case an > bn:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(format(a), format(b))
+	}
+
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=", "<>":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+type like struct {
+	operand operand
+	pattern *regexp.Regexp
+	negate  bool
+}
+
+func (c *like) eval(r *row) bool {
+	v := c.operand.value(r)
+	if v == nil {
+		return false
+	}
+
+	return c.pattern.MatchString(format(v)) != c.negate
+}
+
+type in struct {
+	operand operand
+	values  []operand
+	negate  bool
+}
+
+func (c *in) eval(r *row) bool {
+	v := c.operand.value(r)
+	if v == nil {
+		return false
+	}
+
+	for _, value := range c.values {
+		eq := &comparison{left: &literal{v: v}, op: "=", right: value}
+
+		if eq.eval(r) {
+			return !c.negate
+		}
+	}
+
+	return c.negate
+}
+
+type isNull struct {
+	operand operand
+	negate  bool
+}
+
+func (c *isNull) eval(r *row) bool {
+	return (c.operand.value(r) == nil) != c.negate
+}
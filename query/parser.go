@@ -0,0 +1,634 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/util"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	pos    int  // 1-based position in the query
+	quoted bool // identifier was quoted with backticks (never a keyword)
+}
+
+func (t *token) String() string {
+	if t.kind == tokenEOF {
+		return "end of query"
+	}
+
+	return "'" + t.text + "'"
+}
+
+var symbols = []string{"<=", ">=", "!=", "<>", "(", ")", ",", "*", "=", "<", ">", ";"}
+
+// aggregates are the supported aggregate functions
+var aggregates = map[string]bool{
+	"count": true,
+	"sum":   true,
+	"avg":   true,
+	"min":   true,
+	"max":   true,
+}
+
+// tokenize splits a query into identifiers, numbers, strings and symbols
+func tokenize(text string) ([]*token, error) {
+	tokens := make([]*token, 0)
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			// The quote is escaped by doubling it (ex: 'it''s')
+			var value strings.Builder
+
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, errors.Errorf("unterminated %c at position %d", r, start+1)
+				}
+
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						value.WriteRune(r)
+						i++
+
+						continue
+					}
+
+					i++
+
+					break
+				}
+
+				value.WriteRune(runes[i])
+			}
+
+			if r == '`' {
+				tokens = append(tokens, &token{kind: tokenIdent, text: value.String(), pos: start + 1, quoted: true})
+			} else {
+				tokens = append(tokens, &token{kind: tokenString, text: value.String(), pos: start + 1})
+			}
+
+			continue
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			for i++; i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.'); i++ {
+			}
+
+			tokens = append(tokens, &token{kind: tokenNumber, text: string(runes[start:i]), pos: start + 1})
+
+			continue
+		case isIdentRune(r, true):
+			for i++; i < len(runes) && isIdentRune(runes[i], false); i++ {
+			}
+
+			tokens = append(tokens, &token{kind: tokenIdent, text: string(runes[start:i]), pos: start + 1})
+
+			continue
+		}
+
+		symbol := ""
+
+		for _, s := range symbols {
+			if strings.HasPrefix(string(runes[i:]), s) {
+				symbol = s
+				break
+			}
+		}
+
+		if symbol == "" {
+			return nil, errors.Errorf("unexpected '%c' at position %d", r, start+1)
+		}
+
+		tokens = append(tokens, &token{kind: tokenSymbol, text: symbol, pos: start + 1})
+		i += len(symbol)
+	}
+
+	return append(tokens, &token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+// isIdentRune returns true for runes that can be part of a field or source
+// name; fields are JSONPaths (ex: $.items[0].sku) and sources can be named
+// snapshots (ex: before-deploy)
+func isIdentRune(r rune, first bool) bool {
+	if unicode.IsLetter(r) || r == '_' || r == '$' {
+		return true
+	}
+
+	return !first && (unicode.IsDigit(r) || r == '.' || r == '[' || r == ']' || r == '-')
+}
+
+type parser struct {
+	tokens []*token
+	pos    int
+}
+
+// Parse parses a query; see the package documentation for the syntax
+func Parse(text string) (*Query, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+
+	q := &Query{}
+
+	if p.symbol("*") {
+		// Without GROUP BY, this is the most useful view of the records
+		q.star = true
+		q.items = []*item{
+			{label: FieldLine, field: newField(FieldLine)},
+			{label: FieldReceived, field: newField(FieldReceived)},
+			{label: FieldPayload, field: newField(FieldPayload)},
+		}
+	} else {
+		for {
+			item, err := p.parseItem()
+			if err != nil {
+				return nil, err
+			}
+
+			if p.keyword("AS") {
+				alias, err := p.expectIdent("alias")
+				if err != nil {
+					return nil, err
+				}
+
+				item.label = alias
+			}
+
+			q.items = append(q.items, item)
+
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+
+	source, err := p.expectIdent("source")
+	if err != nil {
+		return nil, err
+	}
+
+	q.source = source
+
+	if p.keyword("WHERE") {
+		if q.where, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.keyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+
+		for {
+			name, err := p.expectIdent("field")
+			if err != nil {
+				return nil, err
+			}
+
+			q.groupBy = append(q.groupBy, newField(name))
+
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+
+		for {
+			o, err := p.parseOrder(q)
+			if err != nil {
+				return nil, err
+			}
+
+			q.orderBy = append(q.orderBy, o)
+
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("LIMIT") {
+		tok := p.next()
+
+		limit, err := strconv.Atoi(tok.text)
+		if tok.kind != tokenNumber || err != nil || limit < 1 {
+			return nil, p.errorf(tok, "expected a positive LIMIT")
+		}
+
+		q.limit = limit
+	}
+
+	p.symbol(";")
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+
+	return q, nil
+}
+
+// parseItem parses a field or an aggregate (ex: count(*), avg(latency))
+func (p *parser) parseItem() (*item, error) {
+	tok := p.next()
+
+	if tok.kind != tokenIdent {
+		return nil, p.errorf(tok, "expected a field or an aggregate, found %s", tok)
+	}
+
+	fn := strings.ToLower(tok.text)
+
+	if tok.quoted || !aggregates[fn] || !p.symbol("(") {
+		return &item{label: tok.text, field: newField(tok.text)}, nil
+	}
+
+	it := &item{fn: fn}
+
+	if p.symbol("*") {
+		if fn != "count" {
+			return nil, p.errorf(tok, "%s(*) is not supported; pass a field", fn)
+		}
+
+		it.label = "count(*)"
+	} else {
+		name, err := p.expectIdent("field")
+		if err != nil {
+			return nil, err
+		}
+
+		it.field = newField(name)
+		it.label = fn + "(" + name + ")"
+	}
+
+	if !p.symbol(")") {
+		return nil, p.errorf(p.peek(), "expected ')', found %s", p.peek())
+	}
+
+	return it, nil
+}
+
+// parseOrder parses a column of ORDER BY: the label (or alias) of a selected
+// item or its (1-based) position
+func (p *parser) parseOrder(q *Query) (*order, error) {
+	tok := p.peek()
+	o := &order{column: -1}
+
+	if tok.kind == tokenNumber {
+		p.next()
+
+		n, err := strconv.Atoi(tok.text)
+		if err != nil || n < 1 || n > len(q.items) {
+			return nil, p.errorf(tok, "ORDER BY position must be between 1 and %d", len(q.items))
+		}
+
+		o.column = n - 1
+	} else {
+		it, err := p.parseItem()
+		if err != nil {
+			return nil, err
+		}
+
+		for i, selected := range q.items {
+			if strings.EqualFold(selected.label, it.label) || (it.field != nil && selected.fn == it.fn && selected.field != nil && selected.field.key == it.field.key) {
+				o.column = i
+				break
+			}
+		}
+
+		if o.column < 0 {
+			return nil, p.errorf(tok, "ORDER BY %s is not a selected column", tok)
+		}
+	}
+
+	if p.keyword("DESC") {
+		o.desc = true
+	} else {
+		p.keyword("ASC")
+	}
+
+	return o, nil
+}
+
+func (p *parser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &or{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &and{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if p.keyword("NOT") {
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &not{cond: cond}, nil
+	}
+
+	if p.symbol("(") {
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.symbol(")") {
+			return nil, p.errorf(p.peek(), "expected ')', found %s", p.peek())
+		}
+
+		return cond, nil
+	}
+
+	return p.parsePredicate()
+}
+
+// parsePredicate parses a comparison, [NOT] LIKE, [NOT] IN or IS [NOT] NULL
+func (p *parser) parsePredicate() (condition, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.keyword("IS") {
+		negate := p.keyword("NOT")
+
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+
+		return &isNull{operand: left, negate: negate}, nil
+	}
+
+	negate := p.keyword("NOT")
+
+	if p.keyword("LIKE") {
+		tok := p.next()
+		if tok.kind != tokenString {
+			return nil, p.errorf(tok, "expected a string after LIKE, found %s", tok)
+		}
+
+		return &like{operand: left, pattern: likePattern(tok.text), negate: negate}, nil
+	}
+
+	if p.keyword("IN") {
+		if !p.symbol("(") {
+			return nil, p.errorf(p.peek(), "expected '(' after IN, found %s", p.peek())
+		}
+
+		in := &in{operand: left, negate: negate}
+
+		for {
+			value, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+
+			in.values = append(in.values, value)
+
+			if !p.symbol(",") {
+				break
+			}
+		}
+
+		if !p.symbol(")") {
+			return nil, p.errorf(p.peek(), "expected ')', found %s", p.peek())
+		}
+
+		return in, nil
+	}
+
+	if negate {
+		return nil, p.errorf(p.peek(), "expected LIKE or IN after NOT, found %s", p.peek())
+	}
+
+	tok := p.next()
+
+	if tok.kind != tokenSymbol || !isComparison(tok.text) {
+		return nil, p.errorf(tok, "expected a comparison (=, !=, <, <=, >, >=), found %s", tok)
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return &comparison{left: left, op: tok.text, right: right}, nil
+}
+
+// parseOperand parses a field or a literal
+func (p *parser) parseOperand() (operand, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %s", tok)
+		}
+
+		return &literal{v: n}, nil
+	case tokenString:
+		return &literal{v: tok.text}, nil
+	case tokenIdent:
+		if !tok.quoted {
+			switch strings.ToUpper(tok.text) {
+			case "TRUE":
+				return &literal{v: true}, nil
+			case "FALSE":
+				return &literal{v: false}, nil
+			case "NULL":
+				return &literal{v: nil}, nil
+			}
+		}
+
+		return newField(tok.text), nil
+	default:
+		return nil, p.errorf(tok, "expected a field or a value, found %s", tok)
+	}
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+		return true
+	default:
+		return false
+	}
+}
+
+// likePattern converts a LIKE pattern ('%' matches any number of characters,
+// '_' a single character) to a regular expression
+func likePattern(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+
+	expr.WriteString("(?s)^")
+
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	expr.WriteString("$")
+
+	return regexp.MustCompile(expr.String())
+}
+
+func (p *parser) peek() *token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() *token {
+	tok := p.tokens[p.pos]
+
+	// The last token is always EOF
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+
+	return tok
+}
+
+// keyword consumes the next token if it is the given keyword
+func (p *parser) keyword(keyword string) bool {
+	tok := p.peek()
+
+	if tok.kind == tokenIdent && !tok.quoted && strings.EqualFold(tok.text, keyword) {
+		p.next()
+		return true
+	}
+
+	return false
+}
+
+// symbol consumes the next token if it is the given symbol
+func (p *parser) symbol(symbol string) bool {
+	tok := p.peek()
+
+	if tok.kind == tokenSymbol && tok.text == symbol {
+		p.next()
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.keyword(keyword) {
+		return p.errorf(p.peek(), "expected %s, found %s", keyword, p.peek())
+	}
+
+	return nil
+}
+
+func (p *parser) expectIdent(what string) (string, error) {
+	tok := p.next()
+
+	if tok.kind != tokenIdent {
+		return "", p.errorf(tok, "expected %s, found %s", what, tok)
+	}
+
+	return tok.text, nil
+}
+
+func (p *parser) errorf(tok *token, format string, args ...interface{}) error {
+	return errors.Errorf("syntax error at position %d: %s", tok.pos, fmt.Sprintf(format, args...))
+}
+
+// newField returns a column of the record (ex: _line) or a payload field
+func newField(name string) *field {
+	switch name {
+	case FieldLine, FieldReceived, FieldComponent, FieldSize, FieldPayload:
+		return &field{name: name, key: name}
+	}
+
+	path := util.ParseJSONPath(name)
+
+	return &field{name: name, path: path, key: "$." + strings.Join(path, ".")}
+}
@@ -0,0 +1,498 @@
+// Package query runs SQL-like queries against the records captured in the
+// tail view, ex:
+//
+//	SELECT status, count(*) FROM buffer WHERE code >= 500 GROUP BY status
+//
+// Only a subset of SQL is supported:
+//
+//	SELECT * | item [AS alias], ...
+//	FROM source
+//	[WHERE condition]
+//	[GROUP BY field, ...]
+//	[ORDER BY column [ASC|DESC], ...]
+//	[LIMIT n]
+//
+// Items are payload fields (JSONPath, ex: user.id or $.items[0].sku) or the
+// aggregates count(*), count(field), sum(field), avg(field), min(field) and
+// max(field). Conditions compare fields and literals with =, !=, <>, <, <=, >,
+// >=, [NOT] LIKE ('%' and '_' wildcards), [NOT] IN (...) and IS [NOT] NULL,
+// combined with AND, OR, NOT and parentheses. Field names that clash with
+// keywords can be quoted with backticks.
+//
+// The following fields describe the record instead of the payload:
+//
+//	_line      - line number in the tail view
+//	_received  - when the message was received (hh:mm:ss)
+//	_component - component the message was received from
+//	_size      - payload size in bytes
+//	_payload   - the payload
+//
+// Sources are resolved by the caller (see Source); the CLI supports "buffer"
+// (the lines of the tail view) and named snapshots.
+package query
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/types"
+)
+
+const (
+	// DefaultSource is the records currently held in the tail view
+	DefaultSource = "buffer"
+
+	FieldLine      = "_line"
+	FieldReceived  = "_received"
+	FieldComponent = "_component"
+	FieldSize      = "_size"
+	FieldPayload   = "_payload"
+)
+
+// Source returns the records of the named source (ex: DefaultSource)
+type Source func(name string) ([]*types.TailRecord, error)
+
+// Result is a table; values are formatted for display
+type Result struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// Query is a parsed query; see Parse()
+type Query struct {
+	source  string
+	star    bool
+	items   []*item
+	where   condition
+	groupBy []*field
+	orderBy []*order
+	limit   int // 0 == no limit
+}
+
+// item is a selected column
+type item struct {
+	label string
+	fn    string // aggregate function; empty for plain fields
+	field *field // nil for count(*)
+}
+
+type order struct {
+	column int // index of the selected item
+	desc   bool
+}
+
+// Run runs the query against the records of its source; banners are ignored
+func (q *Query) Run(source Source) (*Result, error) {
+	records, err := source(q.source)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]*row, 0, len(records))
+
+	for _, record := range records {
+		if record.Banner != "" {
+			continue
+		}
+
+		r := newRow(record)
+
+		if q.where != nil && !q.where.eval(r) {
+			continue
+		}
+
+		rows = append(rows, r)
+	}
+
+	var values [][]interface{}
+
+	if q.aggregated() {
+		values = q.aggregate(rows)
+	} else {
+		values = make([][]interface{}, 0, len(rows))
+
+		for _, r := range rows {
+			v := make([]interface{}, len(q.items))
+
+			for i, it := range q.items {
+				v[i] = it.field.value(r)
+			}
+
+			values = append(values, v)
+		}
+	}
+
+	if len(q.orderBy) > 0 {
+		sort.SliceStable(values, func(i, j int) bool {
+			for _, o := range q.orderBy {
+				c := compareValues(values[i][o.column], values[j][o.column])
+				if c == 0 {
+					continue
+				}
+
+				if o.desc {
+					return c > 0
+				}
+
+				return c < 0
+			}
+
+			return false
+		})
+	}
+
+	if q.limit > 0 && len(values) > q.limit {
+		values = values[:q.limit]
+	}
+
+	result := &Result{
+		Columns: make([]string, len(q.items)),
+		Rows:    make([][]string, len(values)),
+	}
+
+	for i, it := range q.items {
+		result.Columns[i] = it.label
+	}
+
+	for i, v := range values {
+		result.Rows[i] = make([]string, len(v))
+
+		for j := range v {
+			result.Rows[i][j] = format(v[j])
+		}
+	}
+
+	return result, nil
+}
+
+// aggregated returns true if the query returns one row per group instead of
+// one row per record
+func (q *Query) aggregated() bool {
+	if len(q.groupBy) > 0 {
+		return true
+	}
+
+	for _, it := range q.items {
+		if it.fn != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// group is a GROUP BY bucket
+type group struct {
+	values       []interface{} // values of the GROUP BY fields
+	accumulators []*accumulator
+}
+
+// aggregate returns one row per group, in order of first appearance; without
+// GROUP BY, all rows are a single group
+func (q *Query) aggregate(rows []*row) [][]interface{} {
+	groups := make([]*group, 0)
+	index := make(map[string]*group)
+
+	if len(q.groupBy) == 0 {
+		groups = append(groups, q.newGroup(nil))
+	}
+
+	for _, r := range rows {
+		var g *group
+
+		if len(q.groupBy) == 0 {
+			g = groups[0]
+		} else {
+			values := make([]interface{}, len(q.groupBy))
+			keys := make([]string, len(q.groupBy))
+
+			for i, f := range q.groupBy {
+				values[i] = f.value(r)
+				keys[i] = format(values[i])
+			}
+
+			key := strings.Join(keys, "\x00")
+
+			var ok bool
+
+			if g, ok = index[key]; !ok {
+				g = q.newGroup(values)
+				index[key] = g
+				groups = append(groups, g)
+			}
+		}
+
+		for i, it := range q.items {
+			if it.fn == "" {
+				continue
+			}
+
+			var v interface{} = true // count(*) counts every row
+
+			if it.field != nil {
+				v = it.field.value(r)
+			}
+
+			g.accumulators[i].add(v)
+		}
+	}
+
+	values := make([][]interface{}, 0, len(groups))
+
+	for _, g := range groups {
+		v := make([]interface{}, len(q.items))
+
+		for i, it := range q.items {
+			if it.fn != "" {
+				v[i] = g.accumulators[i].result()
+				continue
+			}
+
+			// validate() ensured that the field is in GROUP BY
+			for j, f := range q.groupBy {
+				if f.key == it.field.key {
+					v[i] = g.values[j]
+					break
+				}
+			}
+		}
+
+		values = append(values, v)
+	}
+
+	return values
+}
+
+func (q *Query) newGroup(values []interface{}) *group {
+	g := &group{
+		values:       values,
+		accumulators: make([]*accumulator, len(q.items)),
+	}
+
+	for i, it := range q.items {
+		if it.fn != "" {
+			g.accumulators[i] = &accumulator{fn: it.fn}
+		}
+	}
+
+	return g
+}
+
+// validate checks that the selected fields can be displayed for every row;
+// aggregated queries can only select aggregates and GROUP BY fields
+func (q *Query) validate() error {
+	if !q.aggregated() {
+		return nil
+	}
+
+	if q.star {
+		return errors.New("SELECT * cannot be combined with GROUP BY")
+	}
+
+	for _, it := range q.items {
+		if it.fn != "" {
+			continue
+		}
+
+		grouped := false
+
+		for _, f := range q.groupBy {
+			if f.key == it.field.key {
+				grouped = true
+				break
+			}
+		}
+
+		if !grouped {
+			return errors.Errorf("'%s' must be aggregated or listed in GROUP BY", it.field.name)
+		}
+	}
+
+	return nil
+}
+
+// accumulator computes an aggregate over the rows of a group
+type accumulator struct {
+	fn    string
+	count int
+	sum   float64
+	value interface{} // min/max
+}
+
+func (a *accumulator) add(v interface{}) {
+	if v == nil {
+		return
+	}
+
+	switch a.fn {
+	case "count":
+		a.count++
+	case "sum", "avg":
+		if n, ok := toNumber(v); ok {
+			a.sum += n
+			a.count++
+		}
+	case "min":
+		if a.value == nil || compareValues(v, a.value) < 0 {
+			a.value = v
+		}
+	case "max":
+		if a.value == nil || compareValues(v, a.value) > 0 {
+			a.value = v
+		}
+	}
+}
+
+func (a *accumulator) result() interface{} {
+	switch a.fn {
+	case "count":
+		return float64(a.count)
+	case "sum":
+		if a.count == 0 {
+			return nil
+		}
+
+		return a.sum
+	case "avg":
+		if a.count == 0 {
+			return nil
+		}
+
+		return a.sum / float64(a.count)
+	default:
+		return a.value
+	}
+}
+
+// row is a record with its payload decoded (if it is JSON)
+type row struct {
+	record  *types.TailRecord
+	payload interface{}
+}
+
+func newRow(record *types.TailRecord) *row {
+	r := &row{record: record}
+
+	// Not JSON: payload fields are null
+	_ = json.Unmarshal(record.Data, &r.payload)
+
+	return r
+}
+
+// field is a payload field or one of the Field* columns
+type field struct {
+	name string
+	path []string // nil for Field* columns
+	key  string   // identifies the field regardless of how it was written (ex: status, $.status)
+}
+
+func (f *field) value(r *row) interface{} {
+	switch f.name {
+	case FieldLine:
+		return float64(r.record.LineNum)
+	case FieldReceived:
+		return r.record.Received.Format("15:04:05")
+	case FieldComponent:
+		if r.record.Component == nil {
+			return nil
+		}
+
+		return r.record.Component.Name
+	case FieldSize:
+		return float64(len(r.record.Data))
+	case FieldPayload:
+		return string(r.record.Data)
+	}
+
+	current := r.payload
+
+	for _, elem := range f.path {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			current = v[elem]
+		case []interface{}:
+			idx, err := strconv.Atoi(elem)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil
+			}
+
+			current = v[idx]
+		default:
+			return nil
+		}
+	}
+
+	return current
+}
+
+// format formats a value for display; strings are not quoted
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+
+		return string(data)
+	}
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// compareValues orders values for ORDER BY, min() and max(): nulls first,
+// then numbers (numerically) and everything else by its formatted value
+func compareValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	an, aOK := a.(float64)
+	bn, bOK := b.(float64)
+
+	switch {
+	case aOK && bOK:
+		if an < bn {
+			return -1
+		}
+
+		if an > bn {
+			return 1
+		}
+
+		return 0
+	case aOK:
+		return -1
+	case bOK:
+		return 1
+	}
+
+	return strings.Compare(format(a), format(b))
+}
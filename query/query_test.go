@@ -0,0 +1,278 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/types"
+)
+
+// testRecords are the records of the "buffer" source in the tests below
+func testRecords() []*types.TailRecord {
+	received := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	orders := &types.TailComponent{Name: "orders"}
+	payments := &types.TailComponent{Name: "payments"}
+
+	payloads := []struct {
+		component *types.TailComponent
+		data      string
+	}{
+		{orders, `{"status": "ok", "code": 200, "latency": 10, "user": {"name": "ann"}, "tags": ["a", "b"]}`},
+		{orders, `{"status": "error", "code": 500, "latency": 250, "user": {"name": "bob"}}`},
+		{payments, `{"status": "ok", "code": "201", "latency": 30, "user": {"name": "it's"}}`},
+		{payments, `{"status": "error", "code": 503, "latency": 120}`},
+		{orders, `not json`},
+	}
+
+	records := make([]*types.TailRecord, 0, len(payloads)+1)
+
+	for i, p := range payloads {
+		records = append(records, &types.TailRecord{
+			LineNum:   i + 1,
+			Received:  received.Add(time.Duration(i) * time.Second),
+			Component: p.component,
+			Data:      []byte(p.data),
+		})
+	}
+
+	// Banners are not records and never show up in results
+	return append(records, &types.TailRecord{Banner: " Segment 1"})
+}
+
+func testSource(name string) ([]*types.TailRecord, error) {
+	if name != DefaultSource {
+		return nil, errors.Errorf("unknown source '%s'", name)
+	}
+
+	return testRecords(), nil
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		columns []string
+		rows    [][]string
+	}{
+		{
+			name:    "star",
+			query:   "SELECT * FROM buffer LIMIT 1",
+			columns: []string{FieldLine, FieldReceived, FieldPayload},
+			rows:    [][]string{{"1", "03:04:05", `{"status": "ok", "code": 200, "latency": 10, "user": {"name": "ann"}, "tags": ["a", "b"]}`}},
+		},
+		{
+			name:    "fields and aliases",
+			query:   "select _line, user.name AS who, $.tags[1], _component, _size from buffer where _line <= 2",
+			columns: []string{FieldLine, "who", "$.tags[1]", FieldComponent, FieldSize},
+			rows:    [][]string{{"1", "ann", "b", "orders", "89"}, {"2", "bob", "null", "orders", "73"}},
+		},
+		{
+			name:    "comparison with a numeric string",
+			query:   "SELECT _line FROM buffer WHERE code > 200",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"2"}, {"3"}, {"4"}},
+		},
+		{
+			name:    "AND binds tighter than OR",
+			query:   "SELECT _line FROM buffer WHERE status = 'ok' OR code = 500 AND latency > 500",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"1"}, {"3"}},
+		},
+		{
+			name:    "parentheses",
+			query:   "SELECT _line FROM buffer WHERE (status = 'ok' OR code = 500) AND latency > 20",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"2"}, {"3"}},
+		},
+		{
+			name:    "NOT binds tighter than AND",
+			query:   "SELECT _line FROM buffer WHERE NOT status = 'ok' AND _component = 'orders'",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"2"}, {"5"}},
+		},
+		{
+			name:    "comparisons with null are false",
+			query:   "SELECT _line FROM buffer WHERE status != 'ok'",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"2"}, {"4"}},
+		},
+		{
+			name:    "LIKE",
+			query:   "SELECT _line FROM buffer WHERE user.name LIKE '_o%' OR _payload LIKE 'not%'",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"2"}, {"5"}},
+		},
+		{
+			name:    "NOT LIKE",
+			query:   "SELECT _line FROM buffer WHERE status NOT LIKE 'err%'",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"1"}, {"3"}},
+		},
+		{
+			name:    "IN",
+			query:   "SELECT _line FROM buffer WHERE code IN (201, 503)",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"3"}, {"4"}},
+		},
+		{
+			name:    "NOT IN",
+			query:   "SELECT _line FROM buffer WHERE code NOT IN (201, 503)",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"1"}, {"2"}},
+		},
+		{
+			name:    "IS NULL",
+			query:   "SELECT _line FROM buffer WHERE user IS NULL",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"4"}, {"5"}},
+		},
+		{
+			name:    "IS NOT NULL",
+			query:   "SELECT _line FROM buffer WHERE tags IS NOT NULL",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"1"}},
+		},
+		{
+			name:    "escaped quote",
+			query:   "SELECT _line FROM buffer WHERE user.name = 'it''s'",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"3"}},
+		},
+		{
+			name:    "quoted keyword field",
+			query:   "SELECT `status` FROM buffer WHERE `status` = \"error\" LIMIT 1;",
+			columns: []string{"status"},
+			rows:    [][]string{{"error"}},
+		},
+		{
+			name:    "ORDER BY DESC",
+			query:   "SELECT _line, latency FROM buffer WHERE latency IS NOT NULL ORDER BY latency DESC",
+			columns: []string{FieldLine, "latency"},
+			rows:    [][]string{{"2", "250"}, {"4", "120"}, {"3", "30"}, {"1", "10"}},
+		},
+		{
+			name:    "ORDER BY position, nulls first",
+			query:   "SELECT user.name, _line FROM buffer ORDER BY 1, 2 DESC LIMIT 3",
+			columns: []string{"user.name", FieldLine},
+			rows:    [][]string{{"null", "5"}, {"null", "4"}, {"ann", "1"}},
+		},
+		{
+			name:    "aggregates",
+			query:   "SELECT count(*), count(user), sum(latency), avg(latency), min(latency), max(user.name) FROM buffer",
+			columns: []string{"count(*)", "count(user)", "sum(latency)", "avg(latency)", "min(latency)", "max(user.name)"},
+			rows:    [][]string{{"5", "3", "410", "102.5", "10", "it's"}},
+		},
+		{
+			name:    "aggregates of no rows",
+			query:   "SELECT count(*), sum(latency), max(latency) FROM buffer WHERE code = 404",
+			columns: []string{"count(*)", "sum(latency)", "max(latency)"},
+			rows:    [][]string{{"0", "null", "null"}},
+		},
+		{
+			name:    "GROUP BY",
+			query:   "SELECT status, count(*) AS n, avg(latency) FROM buffer GROUP BY status ORDER BY n DESC, status",
+			columns: []string{"status", "n", "avg(latency)"},
+			rows:    [][]string{{"error", "2", "185"}, {"ok", "2", "20"}, {"null", "1", "null"}},
+		},
+		{
+			name:    "GROUP BY several fields",
+			query:   "SELECT _component, status, count(*) FROM buffer WHERE status IS NOT NULL GROUP BY _component, $.status",
+			columns: []string{FieldComponent, "status", "count(*)"},
+			rows:    [][]string{{"orders", "ok", "1"}, {"orders", "error", "1"}, {"payments", "ok", "1"}, {"payments", "error", "1"}},
+		},
+		{
+			name:    "ORDER BY an aggregate that is not aliased",
+			query:   "SELECT _component, max(latency) AS slowest FROM buffer GROUP BY _component ORDER BY max(latency)",
+			columns: []string{FieldComponent, "slowest"},
+			rows:    [][]string{{"payments", "120"}, {"orders", "250"}},
+		},
+		{
+			name:    "LIMIT",
+			query:   "SELECT _line FROM buffer ORDER BY _line DESC LIMIT 2",
+			columns: []string{FieldLine},
+			rows:    [][]string{{"5"}, {"4"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("unable to parse '%s': %s", tt.query, err)
+			}
+
+			result, err := q.Run(testSource)
+			if err != nil {
+				t.Fatalf("unable to run '%s': %s", tt.query, err)
+			}
+
+			if !reflect.DeepEqual(result.Columns, tt.columns) {
+				t.Fatalf("columns = %q, want %q", result.Columns, tt.columns)
+			}
+
+			if !reflect.DeepEqual(result.Rows, tt.rows) {
+				t.Fatalf("rows = %q, want %q", result.Rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestRunUnknownSource(t *testing.T) {
+	q, err := Parse("SELECT * FROM before-deploy")
+	if err != nil {
+		t.Fatalf("unable to parse: %s", err)
+	}
+
+	if _, err := q.Run(testSource); err == nil || !strings.Contains(err.Error(), "before-deploy") {
+		t.Fatalf("Run() error = %v, want an unknown source error", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{name: "empty", query: "", err: "expected SELECT, found end of query"},
+		{name: "missing FROM", query: "SELECT status", err: "expected FROM, found end of query"},
+		{name: "missing source", query: "SELECT status FROM", err: "expected source, found end of query"},
+		{name: "trailing tokens", query: "SELECT status FROM buffer extra", err: "position 27: unexpected 'extra'"},
+		{name: "unterminated string", query: "SELECT status FROM buffer WHERE status = 'ok", err: "unterminated ' at position 42"},
+		{name: "unexpected character", query: "SELECT status FROM buffer WHERE status # 1", err: "unexpected '#' at position 40"},
+		{name: "missing comparison", query: "SELECT status FROM buffer WHERE status 'ok'", err: "expected a comparison"},
+		{name: "missing operand", query: "SELECT status FROM buffer WHERE status =", err: "expected a field or a value, found end of query"},
+		{name: "unbalanced parentheses", query: "SELECT status FROM buffer WHERE (status = 'ok'", err: "expected ')', found end of query"},
+		{name: "NOT without LIKE or IN", query: "SELECT status FROM buffer WHERE status NOT = 'ok'", err: "expected LIKE or IN after NOT"},
+		{name: "LIKE without a string", query: "SELECT status FROM buffer WHERE status LIKE 1", err: "expected a string after LIKE"},
+		{name: "IN without parentheses", query: "SELECT status FROM buffer WHERE code IN 1", err: "expected '(' after IN"},
+		{name: "IS without NULL", query: "SELECT status FROM buffer WHERE code IS 1", err: "expected NULL"},
+		{name: "sum(*)", query: "SELECT sum(*) FROM buffer", err: "sum(*) is not supported"},
+		{name: "unclosed aggregate", query: "SELECT count(status FROM buffer", err: "expected ')'"},
+		{name: "zero LIMIT", query: "SELECT status FROM buffer LIMIT 0", err: "expected a positive LIMIT"},
+		{name: "LIMIT without a number", query: "SELECT status FROM buffer LIMIT all", err: "expected a positive LIMIT"},
+		{name: "ORDER BY position out of range", query: "SELECT status FROM buffer ORDER BY 2", err: "ORDER BY position must be between 1 and 1"},
+		{name: "ORDER BY unselected column", query: "SELECT status FROM buffer ORDER BY code", err: "ORDER BY 'code' is not a selected column"},
+		{name: "GROUP BY without BY", query: "SELECT status FROM buffer GROUP status", err: "expected BY"},
+		{name: "star with GROUP BY", query: "SELECT * FROM buffer GROUP BY status", err: "SELECT * cannot be combined with GROUP BY"},
+		{name: "ungrouped field", query: "SELECT code, count(*) FROM buffer GROUP BY status", err: "'code' must be aggregated or listed in GROUP BY"},
+		{name: "field with aggregate", query: "SELECT code, count(*) FROM buffer", err: "'code' must be aggregated or listed in GROUP BY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			if err == nil {
+				t.Fatalf("expected an error parsing '%s'", tt.query)
+			}
+
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error = '%s', want it to contain '%s'", err, tt.err)
+			}
+		})
+	}
+}
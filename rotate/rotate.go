@@ -0,0 +1,273 @@
+// Package rotate writes long-running captures to a series of files instead of
+// a single, ever growing one. The file being written to is always at the
+// configured path; once it grows past a size or has been written to for long
+// enough, it is renamed (and optionally gzipped) and a new file is started.
+package rotate
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/crash"
+)
+
+// bufferSize is the size of the write buffer of the current file
+const bufferSize = 64 * 1024
+
+type Options struct {
+	// Path is the file that is written to; rotated files are named after it
+	// (ex: orders.ndjson -> orders-20240101-120000.ndjson)
+	Path string
+
+	// MaxSize rotates the file once it is larger than this many bytes
+	// (0 = no size limit)
+	MaxSize int64
+
+	// MaxAge rotates the file once it has been written to for this long
+	// (0 = no time limit)
+	MaxAge time.Duration
+
+	// Gzip compresses rotated files in the background
+	Gzip bool
+
+	Logger *log.Logger
+}
+
+// Writer is an io.WriteCloser that rotates the underlying file. Rotation only
+// happens between calls to Write() so that a line is never split across
+// files. It is NOT safe for concurrent use.
+type Writer struct {
+	options *Options
+	file    *os.File
+	buf     *bufio.Writer
+	size    int64
+	opened  time.Time
+	wg      sync.WaitGroup
+	errMu   sync.Mutex
+	err     error // first error encountered while compressing
+	log     *log.Logger
+}
+
+func New(opts *Options) (*Writer, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate rotate options")
+	}
+
+	w := &Writer{
+		options: opts,
+		log:     opts.Logger.WithPrefix("rotate"),
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.due(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.buf.Write(p)
+	w.size += int64(n)
+
+	if err != nil {
+		return n, errors.Wrapf(err, "unable to write to '%s'", w.options.Path)
+	}
+
+	return n, nil
+}
+
+// Close closes the current file and waits for rotated files to be compressed
+func (w *Writer) Close() error {
+	err := w.closeFile()
+
+	w.wg.Wait()
+
+	if err != nil {
+		return err
+	}
+
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+
+	return w.err
+}
+
+// due returns true if the current file must be rotated before writing n more
+// bytes; an empty file is never rotated
+func (w *Writer) due(n int) bool {
+	if w.size == 0 {
+		return false
+	}
+
+	if w.options.MaxSize > 0 && w.size+int64(n) > w.options.MaxSize {
+		return true
+	}
+
+	return w.options.MaxAge > 0 && time.Since(w.opened) >= w.options.MaxAge
+}
+
+func (w *Writer) open() error {
+	f, err := os.Create(w.options.Path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create '%s'", w.options.Path)
+	}
+
+	w.file = f
+	w.buf = bufio.NewWriterSize(f, bufferSize)
+	w.size = 0
+	w.opened = time.Now()
+
+	return nil
+}
+
+func (w *Writer) closeFile() error {
+	if err := w.buf.Flush(); err != nil {
+		_ = w.file.Close()
+		return errors.Wrapf(err, "unable to write to '%s'", w.options.Path)
+	}
+
+	if err := w.file.Close(); err != nil {
+		return errors.Wrapf(err, "unable to close '%s'", w.options.Path)
+	}
+
+	return nil
+}
+
+// rotate renames the current file after the time it was opened and starts a
+// new one
+func (w *Writer) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	rotated := rotatedPath(w.options.Path, w.opened)
+
+	if err := os.Rename(w.options.Path, rotated); err != nil {
+		return errors.Wrapf(err, "unable to rotate '%s'", w.options.Path)
+	}
+
+	w.log.Debugf("rotated '%s' to '%s'", w.options.Path, rotated)
+
+	if w.options.Gzip {
+		w.wg.Add(1)
+
+		crash.Go(func() {
+			defer w.wg.Done()
+
+			if err := compress(rotated); err != nil {
+				w.errMu.Lock()
+				defer w.errMu.Unlock()
+
+				if w.err == nil {
+					w.err = err
+				}
+
+				w.log.Error(err)
+			}
+		})
+	}
+
+	return w.open()
+}
+
+// rotatedPath returns the name of a rotated file (ex: orders.ndjson ->
+// orders-20240101-120000.ndjson); a counter is added if several files were
+// opened within the same second
+func rotatedPath(path string, opened time.Time) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext) + "-" + opened.Format("20060102-150405")
+
+	rotated := base + ext
+
+	for i := 1; exists(rotated) || exists(rotated+".gz"); i++ {
+		rotated = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+
+	return rotated
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// compress replaces path with path.gz
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open '%s' for compression", path)
+	}
+
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		_ = src.Close()
+		return errors.Wrapf(err, "unable to create '%s.gz'", path)
+	}
+
+	gz := gzip.NewWriter(dst)
+
+	_, err = io.Copy(gz, src)
+
+	// Closed before removing path (cannot remove open files on Windows)
+	_ = src.Close()
+
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(path + ".gz")
+		return errors.Wrapf(err, "unable to compress '%s'", path)
+	}
+
+	// Only remove the original once it has been compressed successfully
+	if err := os.Remove(path); err != nil {
+		return errors.Wrapf(err, "unable to remove '%s' after compression", path)
+	}
+
+	return nil
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.Path == "" {
+		return errors.New(".Path cannot be empty")
+	}
+
+	if opts.MaxSize < 0 {
+		return errors.New(".MaxSize cannot be negative")
+	}
+
+	if opts.MaxAge < 0 {
+		return errors.New(".MaxAge cannot be negative")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
@@ -0,0 +1,298 @@
+// Package script runs a user script (Starlark) with hooks that are called as
+// components are tailed, so that advanced users can tag, annotate or drop
+// messages without forking the CLI.
+//
+// Hooks are optional top-level functions of the script:
+//
+//	on_connect(component)  called when tailing a component starts; a returned
+//	                       string is displayed as a banner
+//	on_message(record)     called for every message before it is filtered;
+//	                       returning False drops the message
+//
+// Records have the attributes payload (decoded JSON, or the payload as a
+// string), raw, metadata (dict), timestamp (unix milliseconds) and
+// component, and the methods tag(label), note(text) and drop().
+package script
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+
+	"github.com/streamdal/cli/expr"
+)
+
+const (
+	// HookConnect is called when tailing a component starts
+	HookConnect = "on_connect"
+
+	// HookMessage is called for every received message
+	HookMessage = "on_message"
+
+	// MaxSteps is the number of execution steps a hook may take per call; a
+	// runaway script fails instead of freezing the tail
+	MaxSteps = 1_000_000
+)
+
+type Options struct {
+	// File is the path to the script
+	File string
+
+	Logger *log.Logger
+}
+
+type Script struct {
+	onConnect starlark.Callable
+	onMessage starlark.Callable
+
+	// Hooks are called from the tail and compare views only, but a thread
+	// is not safe for concurrent use
+	mu      sync.Mutex
+	options *Options
+	log     *log.Logger
+}
+
+// Result is what the on_message hook did with a message
+type Result struct {
+	Drop bool
+	Tags []string
+	Note string
+
+	// Error is set if the hook failed; the message is kept as is
+	Error string
+}
+
+func New(opts *Options) (*Script, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate script options")
+	}
+
+	s := &Script{
+		options: opts,
+		log:     opts.Logger.WithPrefix("script"),
+	}
+
+	predeclared := starlark.StringDict{
+		"json": starlarkjson.Module,
+	}
+
+	globals, err := starlark.ExecFile(s.thread(), opts.File, nil, predeclared)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to execute script")
+	}
+
+	for name, hook := range map[string]*starlark.Callable{HookConnect: &s.onConnect, HookMessage: &s.onMessage} {
+		value, ok := globals[name]
+		if !ok {
+			continue
+		}
+
+		fn, ok := value.(starlark.Callable)
+		if !ok {
+			return nil, errors.Errorf("%s must be a function, not %s", name, value.Type())
+		}
+
+		*hook = fn
+	}
+
+	if s.onConnect == nil && s.onMessage == nil {
+		return nil, errors.Errorf("script does not define any hooks (%s, %s)", HookConnect, HookMessage)
+	}
+
+	return s, nil
+}
+
+// thread returns a thread for running the script; print() goes to the log
+func (s *Script) thread() *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: s.options.File,
+		Print: func(_ *starlark.Thread, msg string) {
+			s.log.Info(msg)
+		},
+	}
+
+	thread.SetMaxExecutionSteps(MaxSteps)
+
+	return thread
+}
+
+// OnConnect calls the on_connect hook (if any) for a component; returns the
+// banner to display (empty if none)
+func (s *Script) OnConnect(component string) (string, error) {
+	if s.onConnect == nil {
+		return "", nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	args := starlark.Tuple{starlark.String(component)}
+
+	// The component is optional
+	if fn, ok := s.onConnect.(*starlark.Function); ok && fn.NumParams() == 0 {
+		args = nil
+	}
+
+	value, err := starlark.Call(s.thread(), s.onConnect, args, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "%s failed", HookConnect)
+	}
+
+	if banner, ok := starlark.AsString(value); ok {
+		return banner, nil
+	}
+
+	return "", nil
+}
+
+// OnMessage calls the on_message hook (if any) for a message; nil is returned
+// if there is no hook
+func (s *Script) OnMessage(msg *expr.Message, component string) *Result {
+	if s.onMessage == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	thread := s.thread()
+	r := &record{thread: thread, msg: msg, component: component, result: &Result{}}
+
+	value, err := starlark.Call(thread, s.onMessage, starlark.Tuple{r}, nil)
+	if err != nil {
+		return &Result{Error: fmt.Sprintf("%s failed: %s", HookMessage, err)}
+	}
+
+	if value == starlark.False {
+		r.result.Drop = true
+	}
+
+	return r.result
+}
+
+// record is the message passed to on_message
+type record struct {
+	thread    *starlark.Thread
+	msg       *expr.Message
+	component string
+	payload   starlark.Value // decoded on first access
+	result    *Result
+}
+
+var _ starlark.HasAttrs = (*record)(nil)
+
+func (r *record) String() string        { return fmt.Sprintf("record(%q)", string(r.msg.Payload)) }
+func (r *record) Type() string          { return "record" }
+func (r *record) Freeze()               {}
+func (r *record) Truth() starlark.Bool  { return starlark.True }
+func (r *record) Hash() (uint32, error) { return 0, errors.New("unhashable type: record") }
+
+func (r *record) AttrNames() []string {
+	return []string{"component", "drop", "metadata", "note", "payload", "raw", "tag", "timestamp"}
+}
+
+func (r *record) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "payload":
+		return r.decodedPayload(), nil
+	case "raw":
+		return starlark.String(r.msg.Payload), nil
+	case "metadata":
+		return metadataDict(r.msg.Metadata), nil
+	case "timestamp":
+		return starlark.MakeInt64(r.msg.Timestamp.UnixMilli()), nil
+	case "component":
+		return starlark.String(r.component), nil
+	case "tag":
+		return starlark.NewBuiltin("tag", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var label string
+
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &label); err != nil {
+				return nil, err
+			}
+
+			r.result.Tags = append(r.result.Tags, label)
+
+			return starlark.None, nil
+		}), nil
+	case "note":
+		return starlark.NewBuiltin("note", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &r.result.Note); err != nil {
+				return nil, err
+			}
+
+			return starlark.None, nil
+		}), nil
+	case "drop":
+		return starlark.NewBuiltin("drop", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+				return nil, err
+			}
+
+			r.result.Drop = true
+
+			return starlark.None, nil
+		}), nil
+	}
+
+	// Not an attribute
+	return nil, nil
+}
+
+// decodedPayload returns the payload decoded as JSON; payloads that are not
+// JSON are strings
+func (r *record) decodedPayload() starlark.Value {
+	if r.payload != nil {
+		return r.payload
+	}
+
+	r.payload = starlark.String(r.msg.Payload)
+
+	decode := starlarkjson.Module.Members["decode"]
+
+	if value, err := starlark.Call(r.thread, decode, starlark.Tuple{starlark.String(r.msg.Payload)}, nil); err == nil {
+		r.payload = value
+	}
+
+	return r.payload
+}
+
+// metadataDict converts message metadata to a dict (sorted by key)
+func metadataDict(metadata map[string]string) *starlark.Dict {
+	keys := make([]string, 0, len(metadata))
+
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	dict := starlark.NewDict(len(keys))
+
+	for _, key := range keys {
+		_ = dict.SetKey(starlark.String(key), starlark.String(metadata[key]))
+	}
+
+	return dict
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.File == "" {
+		return errors.New("script file must be specified")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package sink
+
+import (
+	"crypto/tls"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/crash"
+)
+
+const (
+	// connQueueSize is the number of messages buffered by the syslog and
+	// GELF sinks before new messages are dropped (ex: when the endpoint is
+	// unreachable)
+	connQueueSize = 10_000
+
+	// connDialTimeout and connWriteTimeout keep an unreachable endpoint from
+	// stalling the queue
+	connDialTimeout  = 5 * time.Second
+	connWriteTimeout = 5 * time.Second
+)
+
+// endpoint is a udp://, tcp:// or tls:// address that messages are written to
+type endpoint struct {
+	scheme  string
+	address string
+}
+
+// parseEndpoint parses an endpoint URL; defaultPort is used if the URL does
+// not include a port
+func parseEndpoint(endpointURL, defaultPort string) (*endpoint, error) {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse address")
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, errors.Errorf("unsupported address scheme '%s' (expected udp://, tcp:// or tls://)", u.Scheme)
+	}
+
+	if u.Hostname() == "" {
+		return nil, errors.New("address must include a host (ex: udp://localhost)")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+
+	return &endpoint{
+		scheme:  u.Scheme,
+		address: net.JoinHostPort(u.Hostname(), port),
+	}, nil
+}
+
+func (e *endpoint) String() string {
+	return e.scheme + "://" + e.address
+}
+
+// connSink queues framed messages and writes them to an endpoint from a
+// single goroutine; the connection is (re)established on demand so that an
+// endpoint that is restarted does not require restarting the CLI.
+type connSink struct {
+	endpoint *endpoint
+	conn     net.Conn
+	queue    chan []byte
+	done     chan struct{}
+	onError  func(err error)
+	errors   uint64
+	dropped  uint64
+	log      *log.Logger
+}
+
+func newConnSink(e *endpoint, onError func(err error), logger *log.Logger) *connSink {
+	s := &connSink{
+		endpoint: e,
+		queue:    make(chan []byte, connQueueSize),
+		done:     make(chan struct{}),
+		onError:  onError,
+		log:      logger,
+	}
+
+	crash.Go(s.run)
+
+	return s
+}
+
+// enqueue queues a framed message; if the queue is full, the message is
+// dropped
+func (s *connSink) enqueue(frame []byte) {
+	select {
+	case s.queue <- frame:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+// close writes queued messages and closes the connection
+func (s *connSink) close() error {
+	close(s.queue)
+	<-s.done
+
+	if failed := atomic.LoadUint64(&s.errors); failed > 0 {
+		s.log.Warnf("unable to forward %d message(s)", failed)
+	}
+
+	if dropped := atomic.LoadUint64(&s.dropped); dropped > 0 {
+		s.log.Warnf("dropped %d message(s) because the queue was full", dropped)
+	}
+
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+
+	return nil
+}
+
+// run writes queued messages until the queue is closed
+func (s *connSink) run() {
+	defer close(s.done)
+
+	reporter := &errorReporter{onError: s.onError, log: s.log}
+
+	for frame := range s.queue {
+		if err := s.write(frame); err != nil {
+			atomic.AddUint64(&s.errors, 1)
+
+			reporter.report(errors.Wrapf(err, "unable to forward message to '%s'", s.endpoint))
+		}
+	}
+}
+
+// write writes a frame, connecting first if needed; the connection is dropped
+// on error so that the next write reconnects
+func (s *connSink) write(frame []byte) error {
+	if s.conn == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return err
+		}
+
+		s.conn = conn
+	}
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(connWriteTimeout))
+
+	if _, err := s.conn.Write(frame); err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *connSink) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: connDialTimeout}
+
+	if s.endpoint.scheme == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", s.endpoint.address, &tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	return dialer.Dial(s.endpoint.scheme, s.endpoint.address)
+}
@@ -0,0 +1,376 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	// esQueueSize is the number of messages buffered before new messages are
+	// dropped (ex: when the cluster is unreachable)
+	esQueueSize = 10_000
+
+	// esBatchSize, esBatchBytes and esFlushInterval control how messages are
+	// batched into bulk requests: a batch is sent once it is full or when
+	// the oldest message in it has waited for esFlushInterval
+	esBatchSize     = 500
+	esBatchBytes    = 5 << 20
+	esFlushInterval = time.Second
+
+	// esMaxAttempts limits how many times a bulk request is retried when the
+	// cluster is overloaded (429) or unavailable (5xx)
+	esMaxAttempts = 3
+	esRetryDelay  = time.Second
+
+	// esRequestTimeout is how long a single bulk request may take
+	esRequestTimeout = 30 * time.Second
+)
+
+// ElasticsearchOptions also work for OpenSearch, which implements the same
+// bulk API
+type ElasticsearchOptions struct {
+	// URL of the cluster (ex: https://localhost:9200)
+	URL string
+
+	// Index (or data stream) that messages are written to; it is created by
+	// the cluster on first write unless auto-creation is disabled
+	Index string
+
+	// Authentication (optional): basic auth or an API key (base64 encoded
+	// "id:key", as returned by the create API key API)
+	Username string
+	Password string
+	APIKey   string
+
+	// CACert is a PEM file used for verifying the cluster's certificate (ex:
+	// the self-signed CA generated by Elasticsearch on first start)
+	CACert string
+
+	// OnError is called (at most once per errorReportInterval) when
+	// messages could not be forwarded; errors are logged if not set
+	OnError func(err error)
+
+	Logger *log.Logger
+}
+
+type Elasticsearch struct {
+	options *ElasticsearchOptions
+	client  *http.Client
+	bulkURL string
+	queue   chan []byte
+	done    chan struct{}
+	errors  uint64
+	dropped uint64
+	log     *log.Logger
+}
+
+// esDocument is the document indexed for every message. Payloads that are
+// JSON objects are indexed as-is so that their fields can be searched and
+// visualized in Kibana/OpenSearch Dashboards; other payloads are indexed as
+// a string.
+type esDocument struct {
+	Timestamp     string          `json:"@timestamp"`
+	Audience      string          `json:"audience"`
+	Service       string          `json:"service"`
+	OperationType string          `json:"operation_type"`
+	OperationName string          `json:"operation_name"`
+	Component     string          `json:"component"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	Message       string          `json:"message,omitempty"`
+}
+
+// esBulkResponse contains the parts of a bulk response needed to tell which
+// documents were rejected
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+func NewElasticsearch(opts *ElasticsearchOptions) (*Elasticsearch, error) {
+	if err := validateElasticsearchOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate elasticsearch sink options")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.CACert != "" {
+		pem, err := os.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read CA certificate")
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in '%s'", opts.CACert)
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	e := &Elasticsearch{
+		options: opts,
+		client:  &http.Client{Transport: transport, Timeout: esRequestTimeout},
+		bulkURL: strings.TrimSuffix(opts.URL, "/") + "/_bulk",
+		queue:   make(chan []byte, esQueueSize),
+		done:    make(chan struct{}),
+		log:     opts.Logger.WithPrefix("sink-elasticsearch"),
+	}
+
+	crash.Go(e.run)
+
+	return e, nil
+}
+
+func (e *Elasticsearch) Name() string {
+	return "elasticsearch:" + e.options.Index
+}
+
+// Write queues a message; messages are sent in bulk requests by run() so that
+// a slow or unreachable cluster never blocks the caller. If the queue is
+// full, the message is dropped.
+func (e *Elasticsearch) Write(_ context.Context, msg *Message) error {
+	doc, err := json.Marshal(newESDocument(msg))
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal document")
+	}
+
+	select {
+	case e.queue <- doc:
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+	}
+
+	return nil
+}
+
+// Close sends queued messages
+func (e *Elasticsearch) Close() error {
+	close(e.queue)
+	<-e.done
+
+	if failed := atomic.LoadUint64(&e.errors); failed > 0 {
+		e.log.Warnf("unable to forward %d message(s)", failed)
+	}
+
+	if dropped := atomic.LoadUint64(&e.dropped); dropped > 0 {
+		e.log.Warnf("dropped %d message(s) because the queue was full", dropped)
+	}
+
+	e.client.CloseIdleConnections()
+
+	return nil
+}
+
+// run sends queued messages in bulk requests until the queue is closed
+func (e *Elasticsearch) run() {
+	defer close(e.done)
+
+	reporter := &errorReporter{onError: e.options.OnError, log: e.log}
+
+	// The action line is the same for every document; "create" is used as
+	// it is the only action accepted by data streams
+	action, _ := json.Marshal(map[string]map[string]string{"create": {"_index": e.options.Index}})
+	action = append(action, '\n')
+
+	var body bytes.Buffer
+
+	for doc := range e.queue {
+		body.Reset()
+		count := 0
+
+		add := func(doc []byte) {
+			body.Write(action)
+			body.Write(doc)
+			body.WriteByte('\n')
+			count++
+		}
+
+		add(doc)
+
+		// Wait for more messages until the batch is full or was started
+		// esFlushInterval ago
+		timer := time.NewTimer(esFlushInterval)
+
+	collect:
+		for count < esBatchSize && body.Len() < esBatchBytes {
+			select {
+			case next, ok := <-e.queue:
+				if !ok {
+					break collect
+				}
+
+				add(next)
+			case <-timer.C:
+				break collect
+			}
+		}
+
+		timer.Stop()
+
+		failed, err := e.send(body.Bytes(), count)
+		if err != nil {
+			atomic.AddUint64(&e.errors, uint64(failed))
+
+			reporter.report(err)
+		}
+	}
+}
+
+// send sends a bulk request, retrying if the cluster is overloaded or
+// unavailable; it returns how many of the count documents were not indexed
+func (e *Elasticsearch) send(body []byte, count int) (int, error) {
+	var err error
+
+	for attempt := 1; attempt <= esMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(esRetryDelay * time.Duration(attempt-1))
+		}
+
+		failed, retry, bulkErr := e.bulk(body, count)
+		if bulkErr == nil || !retry {
+			return failed, bulkErr
+		}
+
+		err = bulkErr
+	}
+
+	return count, errors.Wrapf(err, "giving up after %d attempts", esMaxAttempts)
+}
+
+// bulk sends a single bulk request; retry is set if the request failed as a
+// whole and can be retried
+func (e *Elasticsearch) bulk(body []byte, count int) (failed int, retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, e.bulkURL, bytes.NewReader(body))
+	if err != nil {
+		return count, false, errors.Wrap(err, "unable to create bulk request")
+	}
+
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	if e.options.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+e.options.APIKey)
+	} else if e.options.Username != "" {
+		req.SetBasicAuth(e.options.Username, e.options.Password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return count, true, errors.Wrapf(err, "unable to forward %d message(s)", count)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		reason, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+
+		return count, retry, errors.Errorf("unable to forward %d message(s): elasticsearch returned %d: %s",
+			count, resp.StatusCode, strings.TrimSpace(string(reason)))
+	}
+
+	bulkResp := &esBulkResponse{}
+
+	if err := json.NewDecoder(resp.Body).Decode(bulkResp); err != nil {
+		return 0, false, errors.Wrap(err, "unable to decode bulk response")
+	}
+
+	if !bulkResp.Errors {
+		return 0, false, nil
+	}
+
+	// Some documents were rejected (ex: a mapping conflict); report the
+	// first reason as the others are usually the same
+	var reason string
+
+	for _, item := range bulkResp.Items {
+		for _, result := range item {
+			if result.Error == nil {
+				continue
+			}
+
+			failed++
+
+			if reason == "" {
+				reason = result.Error.Type + ": " + result.Error.Reason
+			}
+		}
+	}
+
+	return failed, false, errors.Errorf("unable to index %d of %d message(s): %s", failed, count, reason)
+}
+
+func newESDocument(msg *Message) *esDocument {
+	doc := &esDocument{
+		Timestamp:     msg.Timestamp.UTC().Format(time.RFC3339Nano),
+		Audience:      util.FormatAudience(msg.Audience),
+		Service:       msg.Audience.GetServiceName(),
+		OperationType: util.ProtosOperationTypeToStr(msg.Audience.GetOperationType()),
+		OperationName: msg.Audience.GetOperationName(),
+		Component:     msg.Audience.GetComponentName(),
+	}
+
+	if msg.Timestamp.IsZero() {
+		doc.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+
+	// Only objects are indexed as fields; a payload that is a JSON string or
+	// array would make the "payload" mapping conflict between messages
+	if trimmed := bytes.TrimSpace(msg.Data); len(trimmed) > 0 && trimmed[0] == '{' && json.Valid(trimmed) {
+		doc.Payload = trimmed
+	} else {
+		doc.Message = string(msg.Data)
+	}
+
+	return doc
+}
+
+func validateElasticsearchOptions(opts *ElasticsearchOptions) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if !strings.HasPrefix(opts.URL, "https://") && !strings.HasPrefix(opts.URL, "http://") {
+		return errors.New(".URL must be an http(s) URL")
+	}
+
+	if opts.Index == "" {
+		return errors.New(".Index cannot be empty")
+	}
+
+	if opts.Index != strings.ToLower(opts.Index) {
+		return errors.New(".Index must be lowercase")
+	}
+
+	if opts.APIKey != "" && opts.Username != "" {
+		return errors.New(".APIKey and .Username cannot both be set")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
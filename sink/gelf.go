@@ -0,0 +1,230 @@
+package sink
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	// gelfDefaultPort is used if the address does not include a port
+	gelfDefaultPort = "12201"
+
+	// gelfLevel is the (syslog) level of every message (informational)
+	gelfLevel = 6
+
+	// gelfShortMessageLength is the length short_message is truncated to; the
+	// complete payload is sent as full_message
+	gelfShortMessageLength = 250
+
+	// gelfChunkSize is the largest UDP datagram sent; larger messages are
+	// split into chunks of this size, up to gelfMaxChunks (larger messages
+	// are dropped)
+	gelfChunkSize = 8192
+	gelfMaxChunks = 128
+
+	// gelfChunkHeaderSize is the size of the magic bytes, message ID, and
+	// sequence number and count of every chunk
+	gelfChunkHeaderSize = 12
+)
+
+type GELFOptions struct {
+	// Address is udp://host[:port], tcp://host[:port] or tls://host[:port]
+	Address string
+
+	// OnError is called (at most once per errorReportInterval) when
+	// messages could not be forwarded; errors are logged if not set
+	OnError func(err error)
+
+	Logger *log.Logger
+}
+
+// GELF forwards messages as GELF 1.1 messages (ex: to Graylog); the audience
+// is sent as additional fields. Messages sent over UDP are chunked if needed;
+// messages sent over TCP/TLS are null byte delimited.
+type GELF struct {
+	options  *GELFOptions
+	endpoint *endpoint
+	hostname string
+	conn     *connSink
+}
+
+// gelfMessage is a GELF 1.1 message
+type gelfMessage struct {
+	Version       string  `json:"version"`
+	Host          string  `json:"host"`
+	ShortMessage  string  `json:"short_message"`
+	FullMessage   string  `json:"full_message,omitempty"`
+	Timestamp     float64 `json:"timestamp"`
+	Level         int     `json:"level"`
+	Audience      string  `json:"_audience"`
+	Service       string  `json:"_service"`
+	OperationType string  `json:"_operation_type"`
+	OperationName string  `json:"_operation_name"`
+	Component     string  `json:"_component"`
+}
+
+func NewGELF(opts *GELFOptions) (*GELF, error) {
+	if err := validateGELFOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate gelf sink options")
+	}
+
+	e, err := parseEndpoint(opts.Address, gelfDefaultPort)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid gelf address")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "streamdal-cli"
+	}
+
+	return &GELF{
+		options:  opts,
+		endpoint: e,
+		hostname: hostname,
+		conn:     newConnSink(e, opts.OnError, opts.Logger.WithPrefix("sink-gelf")),
+	}, nil
+}
+
+func (g *GELF) Name() string {
+	return "gelf:" + g.endpoint.String()
+}
+
+// Write queues a message; messages are sent by a background goroutine so that
+// a slow or unreachable endpoint never blocks the caller. If the queue is
+// full, the message is dropped.
+func (g *GELF) Write(_ context.Context, msg *Message) error {
+	data, err := json.Marshal(g.message(msg))
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal gelf message")
+	}
+
+	if g.endpoint.scheme != "udp" {
+		g.conn.enqueue(append(data, 0))
+		return nil
+	}
+
+	chunks, err := gelfChunks(data)
+	if err != nil {
+		return err
+	}
+
+	for _, chunk := range chunks {
+		g.conn.enqueue(chunk)
+	}
+
+	return nil
+}
+
+// Close writes queued messages and closes the connection
+func (g *GELF) Close() error {
+	return g.conn.close()
+}
+
+func (g *GELF) message(msg *Message) *gelfMessage {
+	timestamp := msg.Timestamp
+
+	if timestamp.IsZero() || timestamp.Unix() <= 0 {
+		timestamp = time.Now()
+	}
+
+	payload := string(msg.Data)
+
+	m := &gelfMessage{
+		Version:      "1.1",
+		Host:         g.hostname,
+		ShortMessage: payload,
+		Timestamp:    float64(timestamp.UnixNano()) / float64(time.Second),
+		Level:        gelfLevel,
+		Audience:     util.FormatAudience(msg.Audience),
+	}
+
+	if msg.Audience != nil {
+		m.Service = msg.Audience.ServiceName
+		m.OperationType = util.ProtosOperationTypeToStr(msg.Audience.OperationType)
+		m.OperationName = msg.Audience.OperationName
+		m.Component = msg.Audience.ComponentName
+	}
+
+	// Multi-line and long payloads are sent in full as full_message
+	if short := strings.SplitN(payload, "\n", 2)[0]; short != payload || len([]rune(short)) > gelfShortMessageLength {
+		if runes := []rune(short); len(runes) > gelfShortMessageLength {
+			short = string(runes[:gelfShortMessageLength])
+		}
+
+		m.ShortMessage = short
+		m.FullMessage = payload
+	}
+
+	// short_message is required to be non-empty
+	if strings.TrimSpace(m.ShortMessage) == "" {
+		m.ShortMessage = "(empty payload)"
+	}
+
+	return m
+}
+
+// gelfChunks splits a UDP message into GELF chunks; messages that fit in a
+// single datagram are not chunked
+func gelfChunks(data []byte) ([][]byte, error) {
+	if len(data) <= gelfChunkSize {
+		return [][]byte{data}, nil
+	}
+
+	payloadSize := gelfChunkSize - gelfChunkHeaderSize
+	count := (len(data) + payloadSize - 1) / payloadSize
+
+	if count > gelfMaxChunks {
+		return nil, errors.Errorf("message is too large for gelf over udp (%d bytes)", len(data))
+	}
+
+	id := make([]byte, 8)
+
+	if _, err := rand.Read(id); err != nil {
+		return nil, errors.Wrap(err, "unable to generate gelf message id")
+	}
+
+	chunks := make([][]byte, 0, count)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * payloadSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		chunk := make([]byte, 0, gelfChunkHeaderSize+end-i*payloadSize)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, data[i*payloadSize:end]...)
+
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, nil
+}
+
+func validateGELFOptions(opts *GELFOptions) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.Address == "" {
+		return errors.New(".Address cannot be empty")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
@@ -0,0 +1,369 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	// httpQueueSize is the number of messages buffered before new messages
+	// are dropped (ex: when the endpoint is unreachable)
+	httpQueueSize = 10_000
+
+	// httpFlushInterval is how long a partial batch waits for more messages
+	httpFlushInterval = time.Second
+
+	// httpMaxAttempts limits how many times a request is retried when the
+	// endpoint is overloaded (429) or unavailable (5xx)
+	httpMaxAttempts = 3
+	httpRetryDelay  = time.Second
+
+	// DefaultHTTPTimeout is how long a single request may take
+	DefaultHTTPTimeout = 10 * time.Second
+
+	// DefaultHTTPBody sends the payload as-is; DefaultHTTPBatchBody sends
+	// the payloads of a batch as ndjson
+	DefaultHTTPBody      = "{{.Payload}}"
+	DefaultHTTPBatchBody = "{{range .Messages}}{{.Payload}}\n{{end}}"
+)
+
+type HTTPOptions struct {
+	URL string
+
+	// Method is POST if not set
+	Method string
+
+	// Headers are "Name: value" pairs; values are templates (see Body)
+	Headers []string
+
+	// Body is a text/template rendered for every request (DefaultHTTPBody or
+	// DefaultHTTPBatchBody if not set). The fields of the (first) message
+	// are available as {{.Payload}}, {{.Audience}}, {{.Service}},
+	// {{.OperationType}}, {{.OperationName}}, {{.Component}} and
+	// {{.Timestamp}}; all messages of a batch as {{.Messages}}. {{json .X}}
+	// encodes a value as JSON and {{env "NAME"}} returns an environment
+	// variable (ex: for keeping tokens off the command line).
+	Body string
+
+	// BatchSize is the maximum number of messages sent per request; 1 (or
+	// 0) sends a request per message
+	BatchSize int
+
+	// Timeout for a single request (DefaultHTTPTimeout if not set)
+	Timeout time.Duration
+
+	// OnError is called (at most once per errorReportInterval) when
+	// messages could not be forwarded; errors are logged if not set
+	OnError func(err error)
+
+	Logger *log.Logger
+}
+
+type HTTP struct {
+	options *HTTPOptions
+	client  *http.Client
+	body    *template.Template
+	headers []httpHeader
+
+	// contentType is sent unless set by a header
+	contentType string
+
+	queue   chan *httpMessage
+	done    chan struct{}
+	errors  uint64
+	dropped uint64
+	log     *log.Logger
+}
+
+type httpHeader struct {
+	name  string
+	value *template.Template
+}
+
+// httpMessage is the template data of a message
+type httpMessage struct {
+	Payload       string
+	Audience      string
+	Service       string
+	OperationType string
+	OperationName string
+	Component     string
+	Timestamp     time.Time
+}
+
+// httpRequest is the template data of a request; the fields of the first
+// message are promoted so that templates of unbatched requests stay short
+type httpRequest struct {
+	*httpMessage
+	Messages []*httpMessage
+}
+
+var httpTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"env": os.Getenv,
+}
+
+func NewHTTP(opts *HTTPOptions) (*HTTP, error) {
+	if err := validateHTTPOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate http sink options")
+	}
+
+	if opts.Method == "" {
+		opts.Method = http.MethodPost
+	}
+
+	if opts.BatchSize == 0 {
+		opts.BatchSize = 1
+	}
+
+	if opts.Body == "" {
+		opts.Body = DefaultHTTPBody
+
+		if opts.BatchSize > 1 {
+			opts.Body = DefaultHTTPBatchBody
+		}
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultHTTPTimeout
+	}
+
+	h := &HTTP{
+		options:     opts,
+		client:      &http.Client{Timeout: opts.Timeout},
+		contentType: "application/json",
+		log:         opts.Logger.WithPrefix("sink-http"),
+	}
+
+	if opts.Body == DefaultHTTPBatchBody {
+		h.contentType = "application/x-ndjson"
+	}
+
+	body, err := template.New("body").Funcs(httpTemplateFuncs).Parse(opts.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse body template")
+	}
+
+	h.body = body
+
+	for _, header := range opts.Headers {
+		name, value, _ := strings.Cut(header, ":")
+		name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+
+		t, err := template.New(name).Funcs(httpTemplateFuncs).Parse(strings.TrimSpace(value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse template of header '%s'", name)
+		}
+
+		h.headers = append(h.headers, httpHeader{name: name, value: t})
+	}
+
+	h.queue = make(chan *httpMessage, httpQueueSize)
+	h.done = make(chan struct{})
+
+	crash.Go(h.run)
+
+	return h, nil
+}
+
+func (h *HTTP) Name() string {
+	return "http:" + h.options.URL
+}
+
+// Write queues a message; requests are sent by run() so that a slow or
+// unreachable endpoint never blocks the caller. If the queue is full, the
+// message is dropped.
+func (h *HTTP) Write(_ context.Context, msg *Message) error {
+	m := &httpMessage{
+		Payload:       string(msg.Data),
+		Audience:      util.FormatAudience(msg.Audience),
+		Service:       msg.Audience.GetServiceName(),
+		OperationType: util.ProtosOperationTypeToStr(msg.Audience.GetOperationType()),
+		OperationName: msg.Audience.GetOperationName(),
+		Component:     msg.Audience.GetComponentName(),
+		Timestamp:     msg.Timestamp,
+	}
+
+	select {
+	case h.queue <- m:
+	default:
+		atomic.AddUint64(&h.dropped, 1)
+	}
+
+	return nil
+}
+
+// Close sends queued messages
+func (h *HTTP) Close() error {
+	close(h.queue)
+	<-h.done
+
+	if failed := atomic.LoadUint64(&h.errors); failed > 0 {
+		h.log.Warnf("unable to forward %d message(s)", failed)
+	}
+
+	if dropped := atomic.LoadUint64(&h.dropped); dropped > 0 {
+		h.log.Warnf("dropped %d message(s) because the queue was full", dropped)
+	}
+
+	h.client.CloseIdleConnections()
+
+	return nil
+}
+
+// run sends queued messages (in batches if enabled) until the queue is closed
+func (h *HTTP) run() {
+	defer close(h.done)
+
+	reporter := &errorReporter{onError: h.options.OnError, log: h.log}
+
+	batch := make([]*httpMessage, 0, h.options.BatchSize)
+
+	for msg := range h.queue {
+		batch = append(batch[:0], msg)
+
+		if h.options.BatchSize > 1 {
+			// Wait for more messages until the batch is full or was started
+			// httpFlushInterval ago
+			timer := time.NewTimer(httpFlushInterval)
+
+		collect:
+			for len(batch) < h.options.BatchSize {
+				select {
+				case next, ok := <-h.queue:
+					if !ok {
+						break collect
+					}
+
+					batch = append(batch, next)
+				case <-timer.C:
+					break collect
+				}
+			}
+
+			timer.Stop()
+		}
+
+		if err := h.send(batch); err != nil {
+			atomic.AddUint64(&h.errors, uint64(len(batch)))
+
+			reporter.report(errors.Wrapf(err, "unable to forward %d message(s)", len(batch)))
+		}
+	}
+}
+
+// send renders and sends a request, retrying if the endpoint is overloaded or
+// unavailable
+func (h *HTTP) send(batch []*httpMessage) error {
+	data := &httpRequest{httpMessage: batch[0], Messages: batch}
+
+	var body bytes.Buffer
+
+	if err := h.body.Execute(&body, data); err != nil {
+		return errors.Wrap(err, "unable to render body template")
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", h.contentType)
+
+	for _, hdr := range h.headers {
+		var value strings.Builder
+
+		if err := hdr.value.Execute(&value, data); err != nil {
+			return errors.Wrapf(err, "unable to render template of header '%s'", hdr.name)
+		}
+
+		header.Set(hdr.name, value.String())
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= httpMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(httpRetryDelay * time.Duration(attempt-1))
+		}
+
+		retry, reqErr := h.do(header, body.Bytes())
+		if reqErr == nil || !retry {
+			return reqErr
+		}
+
+		err = reqErr
+	}
+
+	return errors.Wrapf(err, "giving up after %d attempts", httpMaxAttempts)
+}
+
+// do sends a single request; retry is set if it can be retried
+func (h *HTTP) do(header http.Header, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(h.options.Method, h.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.Wrap(err, "unable to create request")
+	}
+
+	req.Header = header.Clone()
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return true, errors.Wrap(err, "unable to send request")
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reason, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+
+		return retry, errors.Errorf("endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(reason)))
+	}
+
+	// Drain the body so that the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return false, nil
+}
+
+func validateHTTPOptions(opts *HTTPOptions) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if !strings.HasPrefix(opts.URL, "https://") && !strings.HasPrefix(opts.URL, "http://") {
+		return errors.New(".URL must be an http(s) URL")
+	}
+
+	if opts.BatchSize < 0 {
+		return errors.New(".BatchSize cannot be negative")
+	}
+
+	for _, header := range opts.Headers {
+		if name, _, ok := strings.Cut(header, ":"); !ok || strings.TrimSpace(name) == "" {
+			return errors.Errorf("header '%s' must be in the format 'Name: value'", header)
+		}
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"github.com/streamdal/cli/crash"
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+
+	// kafkaQueueSize is the number of messages buffered before new messages
+	// are dropped (ex: when the brokers are unreachable)
+	kafkaQueueSize = 10_000
+
+	// kafkaBatchSize and kafkaBatchTimeout control how messages are batched
+	kafkaBatchSize    = 100
+	kafkaBatchTimeout = 10 * time.Millisecond
+
+	// kafkaMaxAttempts limits how many times a batch is retried so that an
+	// unreachable broker is reported quickly
+	kafkaMaxAttempts = 3
+)
+
+type KafkaOptions struct {
+	Brokers []string
+	Topic   string
+
+	// SASL authentication (optional)
+	Username      string
+	Password      string
+	SASLMechanism string
+
+	TLS bool
+
+	// OnError is called (at most once per errorReportInterval) when
+	// messages could not be forwarded; errors are logged if not set
+	OnError func(err error)
+
+	Logger *log.Logger
+}
+
+type Kafka struct {
+	options *KafkaOptions
+	writer  *kafka.Writer
+	queue   chan kafka.Message
+	done    chan struct{}
+	errors  uint64
+	dropped uint64
+	log     *log.Logger
+}
+
+func NewKafka(opts *KafkaOptions) (*Kafka, error) {
+	if err := validateKafkaOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate kafka sink options")
+	}
+
+	k := &Kafka{
+		options: opts,
+		log:     opts.Logger.WithPrefix("sink-kafka"),
+	}
+
+	transport := &kafka.Transport{}
+
+	if opts.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if opts.Username != "" {
+		mechanism, err := saslMechanism(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.SASL = mechanism
+	}
+
+	k.writer = &kafka.Writer{
+		Addr:         kafka.TCP(opts.Brokers...),
+		Topic:        opts.Topic,
+		Balancer:     &kafka.Hash{},
+		BatchSize:    kafkaBatchSize,
+		BatchTimeout: kafkaBatchTimeout,
+		MaxAttempts:  kafkaMaxAttempts,
+		Transport:    transport,
+	}
+
+	k.queue = make(chan kafka.Message, kafkaQueueSize)
+	k.done = make(chan struct{})
+
+	crash.Go(k.run)
+
+	return k, nil
+}
+
+func (k *Kafka) Name() string {
+	return "kafka:" + k.writer.Topic
+}
+
+// Write queues a message; messages are sent in batches by run() so that slow
+// or unreachable brokers never block the caller. If the queue is full, the
+// message is dropped.
+func (k *Kafka) Write(_ context.Context, msg *Message) error {
+	audience := util.FormatAudience(msg.Audience)
+
+	select {
+	case k.queue <- kafka.Message{
+		Key:     []byte(audience),
+		Value:   msg.Data,
+		Time:    msg.Timestamp,
+		Headers: []kafka.Header{{Key: AudienceHeader, Value: []byte(audience)}},
+	}:
+	default:
+		atomic.AddUint64(&k.dropped, 1)
+	}
+
+	return nil
+}
+
+// Close flushes queued messages
+func (k *Kafka) Close() error {
+	close(k.queue)
+	<-k.done
+
+	if failed := atomic.LoadUint64(&k.errors); failed > 0 {
+		k.log.Warnf("unable to forward %d message(s)", failed)
+	}
+
+	if dropped := atomic.LoadUint64(&k.dropped); dropped > 0 {
+		k.log.Warnf("dropped %d message(s) because the queue was full", dropped)
+	}
+
+	return k.writer.Close()
+}
+
+// run sends queued messages in batches until the queue is closed
+func (k *Kafka) run() {
+	defer close(k.done)
+
+	reporter := &errorReporter{onError: k.options.OnError, log: k.log}
+
+	batch := make([]kafka.Message, 0, kafkaBatchSize)
+
+	for msg := range k.queue {
+		batch = append(batch[:0], msg)
+
+		// Collect whatever else is queued (up to the batch size)
+	collect:
+		for len(batch) < kafkaBatchSize {
+			select {
+			case next, ok := <-k.queue:
+				if !ok {
+					break collect
+				}
+
+				batch = append(batch, next)
+			default:
+				break collect
+			}
+		}
+
+		if err := k.writer.WriteMessages(context.Background(), batch...); err != nil {
+			atomic.AddUint64(&k.errors, uint64(len(batch)))
+
+			reporter.report(errors.Wrapf(err, "unable to forward %d message(s)", len(batch)))
+		}
+	}
+}
+
+func saslMechanism(opts *KafkaOptions) (sasl.Mechanism, error) {
+	switch strings.ToLower(opts.SASLMechanism) {
+	case SASLPlain:
+		return plain.Mechanism{Username: opts.Username, Password: opts.Password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, opts.Username, opts.Password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, opts.Username, opts.Password)
+	default:
+		return nil, errors.Errorf("unknown SASL mechanism '%s'", opts.SASLMechanism)
+	}
+}
+
+func validateKafkaOptions(opts *KafkaOptions) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if len(opts.Brokers) == 0 {
+		return errors.New("at least one broker is required")
+	}
+
+	if opts.Topic == "" {
+		return errors.New(".Topic cannot be empty")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
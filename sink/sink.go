@@ -0,0 +1,59 @@
+// Package sink contains destinations that tailed messages can be forwarded
+// to (ex: a Kafka topic, a NATS subject or an Elasticsearch index),
+// effectively turning the CLI into an ad-hoc tap that republishes the
+// messages passing the filter.
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/streamdal/snitch-protos/build/go/protos"
+)
+
+const (
+	// AudienceHeader is set on every forwarded message
+	AudienceHeader = "streamdal-audience"
+
+	// errorReportInterval limits how often send errors are reported
+	errorReportInterval = 10 * time.Second
+)
+
+// Message is a tailed message that is forwarded to a sink
+type Message struct {
+	Audience  *protos.Audience
+	Data      []byte
+	Timestamp time.Time
+}
+
+// Sink is implemented by every forwarding destination. Write must not block
+// for long as it is called from the tail loop; implementations are expected
+// to buffer and send messages asynchronously.
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+// errorReporter rate limits send errors; they are passed to onError if set and
+// logged otherwise. It is NOT safe for concurrent use.
+type errorReporter struct {
+	onError func(err error)
+	last    time.Time
+	log     *log.Logger
+}
+
+func (r *errorReporter) report(err error) {
+	if time.Since(r.last) < errorReportInterval {
+		return
+	}
+
+	r.last = time.Now()
+
+	if r.onError != nil {
+		r.onError(err)
+	} else {
+		r.log.Error(err)
+	}
+}
@@ -0,0 +1,175 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+
+	"github.com/streamdal/cli/util"
+)
+
+const (
+	// syslogDefaultPort is used if the address does not include a port
+	syslogDefaultPort = "514"
+
+	// syslogSeverity is the severity of every message (informational)
+	syslogSeverity = 6
+
+	// syslogSDID is the structured data ID that the audience is sent under;
+	// 32473 is reserved for documentation/examples by RFC 5612
+	syslogSDID = "streamdal@32473"
+
+	// syslogMaxUDPSize is the largest message sent over UDP; larger messages
+	// are truncated
+	syslogMaxUDPSize = 65_000
+)
+
+// SyslogFacilities are the supported facilities (and their codes)
+var SyslogFacilities = map[string]int{
+	"user":   1,
+	"daemon": 3,
+	"local0": 16,
+	"local1": 17,
+	"local2": 18,
+	"local3": 19,
+	"local4": 20,
+	"local5": 21,
+	"local6": 22,
+	"local7": 23,
+}
+
+type SyslogOptions struct {
+	// Address is udp://host[:port], tcp://host[:port] or tls://host[:port]
+	Address string
+
+	// Tag is the APP-NAME of every message
+	Tag string
+
+	// Facility is one of SyslogFacilities
+	Facility string
+
+	// OnError is called (at most once per errorReportInterval) when
+	// messages could not be forwarded; errors are logged if not set
+	OnError func(err error)
+
+	Logger *log.Logger
+}
+
+// Syslog forwards messages as RFC 5424 syslog messages; the audience is sent
+// as structured data. Messages sent over TCP/TLS are framed with octet
+// counting (RFC 6587) so that multi-line payloads are not split.
+type Syslog struct {
+	options  *SyslogOptions
+	endpoint *endpoint
+	priority int
+	hostname string
+	conn     *connSink
+}
+
+func NewSyslog(opts *SyslogOptions) (*Syslog, error) {
+	if err := validateSyslogOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate syslog sink options")
+	}
+
+	e, err := parseEndpoint(opts.Address, syslogDefaultPort)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid syslog address")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	return &Syslog{
+		options:  opts,
+		endpoint: e,
+		priority: SyslogFacilities[opts.Facility]*8 + syslogSeverity,
+		hostname: hostname,
+		conn:     newConnSink(e, opts.OnError, opts.Logger.WithPrefix("sink-syslog")),
+	}, nil
+}
+
+func (s *Syslog) Name() string {
+	return "syslog:" + s.endpoint.String()
+}
+
+// Write queues a message; messages are sent by a background goroutine so that
+// a slow or unreachable endpoint never blocks the caller. If the queue is
+// full, the message is dropped.
+func (s *Syslog) Write(_ context.Context, msg *Message) error {
+	line := s.format(msg)
+
+	if s.endpoint.scheme == "udp" {
+		if len(line) > syslogMaxUDPSize {
+			line = line[:syslogMaxUDPSize]
+		}
+	} else {
+		line = strconv.Itoa(len(line)) + " " + line
+	}
+
+	s.conn.enqueue([]byte(line))
+
+	return nil
+}
+
+// Close writes queued messages and closes the connection
+func (s *Syslog) Close() error {
+	return s.conn.close()
+}
+
+// format returns the RFC 5424 representation of msg
+func (s *Syslog) format(msg *Message) string {
+	timestamp := msg.Timestamp
+
+	if timestamp.IsZero() || timestamp.Unix() <= 0 {
+		timestamp = time.Now()
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d - [%s audience=\"%s\"] %s",
+		s.priority,
+		timestamp.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		s.options.Tag,
+		os.Getpid(),
+		syslogSDID,
+		escapeSDValue(util.FormatAudience(msg.Audience)),
+		msg.Data,
+	)
+}
+
+// escapeSDValue escapes the characters that must be escaped in structured
+// data parameter values
+func escapeSDValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+func validateSyslogOptions(opts *SyslogOptions) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.Address == "" {
+		return errors.New(".Address cannot be empty")
+	}
+
+	if opts.Tag == "" || strings.ContainsAny(opts.Tag, " \t\n") {
+		return errors.New(".Tag must be a non-empty string without spaces")
+	}
+
+	if _, ok := SyslogFacilities[opts.Facility]; !ok {
+		return errors.Errorf("unknown facility '%s'", opts.Facility)
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
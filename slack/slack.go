@@ -0,0 +1,108 @@
+// Package slack posts messages to a Slack channel via an incoming webhook
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultTimeout = 10 * time.Second
+
+	// MaxTextLength is the maximum length of the text posted to Slack; Slack
+	// truncates messages that are longer than 40,000 characters.
+	MaxTextLength = 39_000
+)
+
+type Options struct {
+	// WebhookURL is the URL of a Slack incoming webhook
+	WebhookURL string
+
+	// Timeout for posting a message (default: DefaultTimeout)
+	Timeout time.Duration
+
+	Logger *log.Logger
+}
+
+type Slack struct {
+	options *Options
+	client  *http.Client
+	log     *log.Logger
+}
+
+func New(opts *Options) (*Slack, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate slack options")
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Slack{
+		options: opts,
+		client:  &http.Client{Timeout: opts.Timeout},
+		log:     opts.Logger.WithPrefix("slack"),
+	}, nil
+}
+
+// Post sends text (Slack mrkdwn) to the webhook's channel
+func (s *Slack) Post(ctx context.Context, text string) error {
+	if len(text) > MaxTextLength {
+		return errors.Errorf("message is too long (%d > %d characters)", len(text), MaxTextLength)
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal slack message")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.options.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "unable to create slack request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to post to slack")
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Slack returns a short plain text reason (ex: "invalid_payload")
+		reason, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+
+		return errors.Errorf("slack returned %d: %s", resp.StatusCode, strings.TrimSpace(string(reason)))
+	}
+
+	s.log.Debugf("posted %d bytes to slack", len(text))
+
+	return nil
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if !strings.HasPrefix(opts.WebhookURL, "https://") && !strings.HasPrefix(opts.WebhookURL, "http://") {
+		return errors.New("webhook URL must be an http(s) URL")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
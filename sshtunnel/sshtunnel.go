@@ -0,0 +1,363 @@
+// Package sshtunnel dials connections through an SSH server (ex: a bastion
+// host) so that the CLI can reach servers in private networks without a
+// separate `ssh -L` process.
+package sshtunnel
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/streamdal/cli/crash"
+)
+
+const DefaultPort = "22"
+
+// DefaultKeys are the private keys (in ~/.ssh) that are tried when no key
+// file is given, in the same order as OpenSSH
+var DefaultKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+type Options struct {
+	// Destination is the SSH server, in the same format as ssh(1):
+	// [user@]host[:port]; the current user and port 22 are used by default
+	Destination string
+
+	// KeyFile is the private key used for authenticating; if empty, ssh-agent
+	// (SSH_AUTH_SOCK) and the DefaultKeys are used
+	KeyFile string
+
+	// KnownHostsFile is used for verifying the host key of the SSH server
+	// (default: ~/.ssh/known_hosts)
+	KnownHostsFile string
+
+	ConnectTimeout time.Duration
+
+	Logger *log.Logger
+}
+
+// Tunnel is a connection to an SSH server; connections are dialed through it
+// with DialContext. The SSH connection is re-established if it breaks.
+type Tunnel struct {
+	options *Options
+	address string
+	config  *ssh.ClientConfig
+	client  *ssh.Client
+	mu      sync.Mutex
+	log     *log.Logger
+}
+
+func New(opts *Options) (*Tunnel, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate sshtunnel options")
+	}
+
+	username, address, err := parseDestination(opts.Destination)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse SSH destination")
+	}
+
+	knownHostsFile := opts.KnownHostsFile
+
+	if knownHostsFile == "" {
+		knownHostsFile, err = sshPath("known_hosts")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read known hosts file '%s'", knownHostsFile)
+	}
+
+	t := &Tunnel{
+		options: opts,
+		address: address,
+		log:     opts.Logger.WithPrefix("sshtunnel"),
+	}
+
+	authMethods, err := t.authMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	t.config = &ssh.ClientConfig{
+		User:            username,
+		Auth:            authMethods,
+		HostKeyCallback: verifyHostKey(hostKeyCallback),
+		Timeout:         opts.ConnectTimeout,
+	}
+
+	// Connect right away so that a bad destination or key fails early
+	if _, err := t.getClient(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// DialContext dials addr (host:port) from the SSH server; host names are
+// resolved by the SSH server
+func (t *Tunnel) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	conn, err := t.dial(ctx, addr)
+	if err == nil || ctx.Err() != nil {
+		return conn, err
+	}
+
+	// The SSH connection may have broken without us noticing yet (ex: the
+	// bastion was restarted); retry once with a new connection
+	t.reset()
+
+	return t.dial(ctx, addr)
+}
+
+// Close closes the SSH connection and every connection dialed through it
+func (t *Tunnel) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client == nil {
+		return nil
+	}
+
+	err := t.client.Close()
+	t.client = nil
+
+	return err
+}
+
+func (t *Tunnel) dial(ctx context.Context, addr string) (net.Conn, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+
+	// ssh.Client.Dial() cannot be canceled
+	resultCh := make(chan result, 1)
+
+	crash.Go(func() {
+		conn, err := client.Dial("tcp", addr)
+		resultCh <- result{conn: conn, err: err}
+	})
+
+	select {
+	case r := <-resultCh:
+		if r.err != nil {
+			return nil, errors.Wrapf(r.err, "unable to dial '%s' through SSH tunnel", addr)
+		}
+
+		return r.conn, nil
+	case <-ctx.Done():
+		crash.Go(func() {
+			if r := <-resultCh; r.conn != nil {
+				_ = r.conn.Close()
+			}
+		})
+
+		return nil, ctx.Err()
+	}
+}
+
+// getClient returns the current SSH connection, connecting if necessary
+func (t *Tunnel) getClient() (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	client, err := ssh.Dial("tcp", t.address, t.config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to connect to SSH server '%s'", t.address)
+	}
+
+	t.log.Debugf("connected to SSH server '%s'", t.address)
+
+	t.client = client
+
+	// Forget the connection once it breaks so that the next dial reconnects
+	crash.Go(func() {
+		err := client.Wait()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if t.client == client {
+			t.log.Debugf("SSH connection to '%s' closed: %v", t.address, err)
+			t.client = nil
+		}
+	})
+
+	return client, nil
+}
+
+// verifyHostKey explains how to fix unknown host keys; ssh.Dial() does not
+// wrap the errors returned by the callback
+func verifyHostKey(callback ssh.HostKeyCallback) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+
+		var keyErr *knownhosts.KeyError
+
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return errors.Errorf("host key of '%s' is not in known_hosts; connect to it with ssh once to add it", hostname)
+		}
+
+		return err
+	}
+}
+
+// reset closes the current SSH connection (if any)
+func (t *Tunnel) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		_ = t.client.Close()
+		t.client = nil
+	}
+}
+
+// authMethods returns the key file if one was given; otherwise ssh-agent and
+// the default keys that exist
+func (t *Tunnel) authMethods() ([]ssh.AuthMethod, error) {
+	if t.options.KeyFile != "" {
+		signer, err := readKey(t.options.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+
+	methods := make([]ssh.AuthMethod, 0)
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		// Connections to the agent are never closed; there's only ever one
+		if conn, err := net.Dial("unix", sock); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		} else {
+			t.log.Debugf("unable to connect to ssh-agent: %s", err)
+		}
+	}
+
+	signers := make([]ssh.Signer, 0)
+
+	for _, name := range DefaultKeys {
+		path, err := sshPath(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		// Passphrase-protected keys can only be used via ssh-agent
+		signer, err := readKey(path)
+		if err != nil {
+			t.log.Debugf("skipping SSH key: %s", err)
+			continue
+		}
+
+		signers = append(signers, signer)
+	}
+
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("no SSH keys found; start ssh-agent or pass a key file")
+	}
+
+	return methods, nil
+}
+
+func readKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read SSH key")
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		var passphraseErr *ssh.PassphraseMissingError
+
+		if errors.As(err, &passphraseErr) {
+			return nil, errors.Errorf("SSH key '%s' is protected by a passphrase; add it to ssh-agent instead", path)
+		}
+
+		return nil, errors.Wrapf(err, "unable to parse SSH key '%s'", path)
+	}
+
+	return signer, nil
+}
+
+// parseDestination splits [user@]host[:port] into the user name and the
+// address of the SSH server
+func parseDestination(destination string) (string, string, error) {
+	username, host, found := strings.Cut(destination, "@")
+	if !found {
+		host = destination
+
+		u, err := user.Current()
+		if err != nil {
+			return "", "", errors.Wrap(err, "unable to determine current user")
+		}
+
+		username = u.Username
+	}
+
+	if username == "" || host == "" {
+		return "", "", errors.Errorf("invalid destination '%s' (expected [user@]host[:port])", destination)
+	}
+
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, DefaultPort)
+	}
+
+	return username, host, nil
+}
+
+func sshPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to determine home directory")
+	}
+
+	return filepath.Join(homeDir, ".ssh", name), nil
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.Destination == "" {
+		return errors.New(".Destination cannot be empty")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package types
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/streamdal/snitch-protos/build/go/protos"
 )
 
@@ -14,6 +17,37 @@ const (
 	StepPause
 	StepRate
 	StepViewOptions
+	StepSelectLine
+	StepTraceFilter
+	StepMaxLines
+	StepBack
+	StepComponentSettings
+	StepTimeWindow
+	StepBreak
+	StepBookmark
+	StepBookmarkJump
+	StepBookmarks
+	StepNote
+	StepExport
+	StepShare
+	StepCompare
+	StepCorrelationKey
+	StepSetup
+	StepNotifications
+	StepLineDetail
+	StepQuery
+	StepSnapshots
+	StepOperationTab
+	StepOperationCycle
+	StepFinder
+	StepRecentSwitch
+	StepClear
+	StepTimeZone
+	StepChipSelect
+	StepChipRemove
+	StepKeys
+	StepMacroRecord
+	StepMacroPlay
 
 	// GaugeUptimeSeconds is the number of seconds the CLI has been running
 	GaugeUptimeSeconds = "cli_uptime_seconds"
@@ -39,6 +73,58 @@ const (
 	// CounterFeatureSampleTotal is the number of times sample feature was used
 	CounterFeatureSampleTotal = "cli_feature_sample_total"
 
+	// CounterFeatureMaxLinesTotal is the number of times max output lines was changed
+	CounterFeatureMaxLinesTotal = "cli_feature_max_lines_total"
+
+	// CounterFeatureComponentSettingsTotal is the number of times per-component settings were changed
+	CounterFeatureComponentSettingsTotal = "cli_feature_component_settings_total"
+
+	// CounterFeatureTimeWindowTotal is the number of times the time window filter was used
+	CounterFeatureTimeWindowTotal = "cli_feature_time_window_total"
+
+	// CounterFeatureBreakTotal is the number of times a break expression was set
+	CounterFeatureBreakTotal = "cli_feature_break_total"
+
+	// CounterFeatureBookmarkTotal is the number of times a line was bookmarked
+	CounterFeatureBookmarkTotal = "cli_feature_bookmark_total"
+
+	// CounterFeatureExportTotal is the number of times the tail view was exported
+	CounterFeatureExportTotal = "cli_feature_export_total"
+
+	// CounterFeatureShareTotal is the number of times lines were shared to Slack
+	CounterFeatureShareTotal = "cli_feature_share_total"
+
+	// CounterFeatureCompareTotal is the number of times the comparison view was used
+	CounterFeatureCompareTotal = "cli_feature_compare_total"
+
+	// CounterFeatureSetupTotal is the number of times the setup wizard was completed
+	CounterFeatureSetupTotal = "cli_feature_setup_total"
+
+	// CounterFeatureNotificationsTotal is the number of times the notifications list was opened
+	CounterFeatureNotificationsTotal = "cli_feature_notifications_total"
+
+	// CounterFeatureLineDetailTotal is the number of times the line detail view was opened
+	CounterFeatureLineDetailTotal = "cli_feature_line_detail_total"
+
+	// CounterFeatureQueryTotal is the number of times the query page was opened
+	CounterFeatureQueryTotal = "cli_feature_query_total"
+
+	// CounterFeatureSnapshotTotal is the number of times a buffer snapshot was taken
+	CounterFeatureSnapshotTotal = "cli_feature_snapshot_total"
+
+	// CounterFeatureFinderTotal is the number of times the component finder was used
+	CounterFeatureFinderTotal = "cli_feature_finder_total"
+
+	// CounterFeatureClearTotal is the number of times the tail view was cleared
+	CounterFeatureClearTotal = "cli_feature_clear_total"
+
+	// CounterFeatureTimeZoneTotal is the number of times the displayed time
+	// zone was switched between local time and UTC
+	CounterFeatureTimeZoneTotal = "cli_feature_time_zone_total"
+
+	// CounterFeatureMacroTotal is the number of times a macro was played
+	CounterFeatureMacroTotal = "cli_feature_macro_total"
+
 	// CounterFeatureSelectTotal is the number of times an audience was selected
 	CounterFeatureSelectTotal = "cli_feature_select_total"
 
@@ -55,12 +141,78 @@ type Action struct {
 
 	// Args specifically used by tail()
 	TailComponent   *TailComponent
+	TailComponents  []*TailComponent // set when several components are tailed (interleaved)
 	TailFilter      string
 	TailSearch      string
-	TailSearchPrev  string
 	TailRate        int
 	TailViewOptions *ViewOptions
-	TailLineNum     int // line num we are at in tail view
+	TailLineNum     int    // line num we are at in tail view
+	TailTraceID     string // only display records with this trace ID
+	TailTimeWindow  *TimeWindow
+	TailBreak       string // pause the tail when a payload contains this string
+	TailWhere       *FieldFilter
+	TailOperation   protos.OperationType // only display messages of this operation type; unset displays all
+
+	// Only match whole words (ex: "err" does not match "error"); substring
+	// filters and searches only
+	TailFilterWholeWord bool
+	TailSearchWholeWord bool
+
+	// Args used by compare()
+	CompareKey string // JSONPath used for aligning lines of the compared components
+}
+
+// FieldFilter only lets through payloads where the field at Path equals Value;
+// set from the line detail view
+type FieldFilter struct {
+	Path  string // JSONPath (ex: $.user.id)
+	Value string // JSON encoded (ex: "abc" with quotes, 42, true)
+}
+
+// Session is the state of the tail view that is persisted in the config file
+// whenever it changes so that it can be restored on the next start (ex: after
+// an accidental exit or a crash)
+type Session struct {
+	// Components are formatted with util.FormatAudience(); they are matched
+	// against the live components on start
+	Components      []string     `json:"components"`
+	Filter          string       `json:"filter,omitempty"`
+	FilterWholeWord bool         `json:"filter_whole_word,omitempty"`
+	Search          string       `json:"search,omitempty"`
+	SearchWholeWord bool         `json:"search_whole_word,omitempty"`
+	Rate            int          `json:"rate,omitempty"`
+	ViewOptions     *ViewOptions `json:"view_options,omitempty"`
+	Paused          bool         `json:"paused,omitempty"`
+
+	// CrashReport is the report written when the session was interrupted by
+	// a crash
+	CrashReport string `json:"crash_report,omitempty"`
+}
+
+// Snapshot is a named copy of the buffer (ex: "before-deploy") that can be
+// viewed, queried and compared with the live buffer or other snapshots
+type Snapshot struct {
+	Name    string
+	Taken   time.Time
+	Records []*TailRecord
+}
+
+// Snapshot actions; see SnapshotRequest
+const (
+	SnapshotTake   = "take"
+	SnapshotView   = "view"
+	SnapshotDiff   = "diff"
+	SnapshotDelete = "delete"
+)
+
+// SnapshotRequest is returned by the snapshots dialog
+type SnapshotRequest struct {
+	Action string // one of the Snapshot* actions
+	Name   string
+
+	// Other is the snapshot Name is compared with (SnapshotDiff); the live
+	// buffer if empty
+	Other string
 }
 
 // TailComponent is used to display audiences in the "select component" view
@@ -68,6 +220,106 @@ type TailComponent struct {
 	Name        string
 	Description string
 	Audience    *protos.Audience
+
+	// Color is the (hex) color used for the line prefix of this component's
+	// messages when several components are tailed at once
+	Color string
+
+	// Filter only applies to messages from this component (in addition to the
+	// global TailFilter)
+	Filter string
+}
+
+// TailRecord is a single entry in the tail view; it is either a message
+// received from the server or a banner line (ex: "PAUSED @ 12:00:00").
+type TailRecord struct {
+	LineNum    int
+	Received   time.Time
+	Data       []byte
+	TraceID    string
+	Latency    time.Duration
+	HasLatency bool
+
+	// Component the message was received from
+	Component *TailComponent
+
+	// Preview is the result of running a previewed pipeline step (if any)
+	Preview *StepPreview
+
+	// Banner is set when the record is an informational line and not a message
+	Banner string
+
+	// Bookmarked is set when the user bookmarked the line
+	Bookmarked bool
+
+	// Note is a short annotation attached to a bookmarked line
+	Note string
+
+	// Seen is the number of times the message ID (--id-field) was seen; above
+	// 1 for duplicates
+	Seen int
+
+	// Tags are labels attached by the on_message hook of --script
+	Tags []string
+}
+
+// ExportRequest is returned by the export dialog
+type ExportRequest struct {
+	Format string
+	Path   string
+	Lines  string // range of line numbers (ex: 10-20); empty for all lines
+}
+
+// TermRequest is returned by the filter and search dialogs
+type TermRequest struct {
+	Term      string
+	WholeWord bool
+}
+
+// SetupRequest is returned by the setup wizard
+type SetupRequest struct {
+	Server     string
+	Auth       string
+	DisableTLS bool
+}
+
+// Notification is a control-plane event (ex: a client registered) that is
+// displayed as a toast and kept for review in the notifications list
+type Notification struct {
+	Time time.Time
+	Text string
+}
+
+// ShareRequest is returned by the share dialog
+type ShareRequest struct {
+	Lines   string // range of line numbers (ex: 10-20)
+	Message string // optional message posted above the lines
+}
+
+// StepPreview is the result of running a pipeline step client-side
+type StepPreview struct {
+	Step    string
+	Success bool
+	Message string
+	Output  []byte
+	Error   string
+}
+
+// TimeWindow limits the tail view to records received between From and To; a
+// zero To means that the window is open-ended.
+type TimeWindow struct {
+	Input string // as entered by the user (ex: "30s" or "14:02-14:05")
+	From  time.Time
+	To    time.Time
+}
+
+// Contains returns true if t falls within the window
+func (w *TimeWindow) Contains(t time.Time) bool {
+	if t.Before(w.From) {
+		return false
+	}
+
+	return w.To.IsZero() || !t.After(w.To)
 }
 
 type ViewOptions struct {
@@ -75,4 +327,40 @@ type ViewOptions struct {
 	EnableColors       bool
 	DisplayTimestamp   bool
 	DisplayLineNumbers bool
+
+	// Decimate only displays 1 in N messages (0 or 1 == display all)
+	Decimate int
+
+	// MaskSecrets replaces common secrets in payloads with util.SecretMask
+	MaskSecrets bool
+
+	// DetectPII marks lines containing probable PII in the gutter
+	DetectPII bool
+
+	// RawNumbers displays counters, rates and sizes in stats as plain
+	// numbers (see util.SetRawNumbers)
+	RawNumbers bool
+}
+
+// MacroKey is a key of a macro (see console.KeyBinding.Name) and the page
+// that was in front when it was pressed; when the macro is played, every key
+// waits for its page to be in front (ex: a dialog opened by the previous key)
+type MacroKey struct {
+	Key  string `json:"key"`
+	Page string `json:"page,omitempty"`
+}
+
+// UnmarshalJSON also accepts a key name alone, as macros were saved before
+// pages were recorded
+func (k *MacroKey) UnmarshalJSON(data []byte) error {
+	var name string
+
+	if err := json.Unmarshal(data, &name); err == nil {
+		*k = MacroKey{Key: name}
+		return nil
+	}
+
+	type macroKey MacroKey
+
+	return json.Unmarshal(data, (*macroKey)(k))
 }
@@ -0,0 +1,426 @@
+// Package update checks GitHub for newer releases of the CLI and replaces the
+// running binary with the release binary for the platform. The binary is
+// verified against the checksums published with the release and, when the
+// CLI was built with the release public key (see PublicKey), the checksums
+// are verified against their signature.
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LatestURL is the GitHub API endpoint returning the latest release
+	LatestURL = "https://api.github.com/repos/streamdal/cli/releases/latest"
+
+	// DefaultTimeout applies to every request, including binary downloads
+	DefaultTimeout = 2 * time.Minute
+
+	// ChecksumsAsset lists the SHA-256 of every release binary (sha256sum
+	// format); SignatureAsset is its ed25519 signature
+	ChecksumsAsset = "streamdal-checksums.txt"
+	SignatureAsset = "streamdal-checksums.txt.sig"
+
+	// MaxBinarySize is the largest binary that is downloaded
+	MaxBinarySize = 512 << 20
+
+	// maxMetadataSize is the largest release, checksums or signature
+	// response that is read
+	maxMetadataSize = 1 << 20
+)
+
+// PublicKey is the (base64) ed25519 public key release checksums are signed
+// with; it is set at build time (see RELEASE_PUBLIC_KEY in the Makefile).
+// Development builds do not have it and only verify checksums.
+var PublicKey string
+
+type Options struct {
+	// Version of the running CLI (ex: v0.1.2-abc1234)
+	Version string
+
+	// LatestURL is the API endpoint returning the latest release (default:
+	// LatestURL)
+	LatestURL string
+
+	// Timeout for every request (default: DefaultTimeout)
+	Timeout time.Duration
+
+	Logger *log.Logger
+}
+
+type Updater struct {
+	options *Options
+	client  *http.Client
+	log     *log.Logger
+}
+
+// Release is a GitHub release of the CLI
+type Release struct {
+	Version string    // tag (ex: v0.2.0)
+	URL     string    // release page
+	Date    time.Time // publication date
+	assets  map[string]string
+}
+
+func New(opts *Options) (*Updater, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "unable to validate update options")
+	}
+
+	if opts.LatestURL == "" {
+		opts.LatestURL = LatestURL
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Updater{
+		options: opts,
+		client:  &http.Client{Timeout: opts.Timeout},
+		log:     opts.Logger.WithPrefix("update"),
+	}, nil
+}
+
+// Latest returns the latest release
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	data, err := u.get(ctx, u.options.LatestURL, maxMetadataSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch latest release")
+	}
+
+	var resp struct {
+		TagName     string    `json:"tag_name"`
+		HTMLURL     string    `json:"html_url"`
+		PublishedAt time.Time `json:"published_at"`
+		Assets      []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, errors.Wrap(err, "unable to parse latest release")
+	}
+
+	if resp.TagName == "" {
+		return nil, errors.New("latest release has no tag")
+	}
+
+	release := &Release{
+		Version: resp.TagName,
+		URL:     resp.HTMLURL,
+		Date:    resp.PublishedAt,
+		assets:  make(map[string]string),
+	}
+
+	for _, asset := range resp.Assets {
+		release.assets[asset.Name] = asset.URL
+	}
+
+	return release, nil
+}
+
+// Apply replaces the binary at exe (the running binary; symlinks are
+// followed) with the release binary for the platform. Nothing is replaced
+// unless the binary matches its checksum and, if PublicKey is set, the
+// checksums match their signature.
+func (u *Updater) Apply(ctx context.Context, release *Release, exe string) error {
+	name, err := AssetName(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+
+	binaryURL, ok := release.assets[name]
+	if !ok {
+		return errors.Errorf("release %s has no '%s' binary", release.Version, name)
+	}
+
+	checksums, err := u.asset(ctx, release, ChecksumsAsset)
+	if err != nil {
+		return err
+	}
+
+	if err := u.verifySignature(ctx, release, checksums); err != nil {
+		return err
+	}
+
+	expected, err := checksum(checksums, name)
+	if err != nil {
+		return err
+	}
+
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return errors.Wrap(err, "unable to resolve binary path")
+	}
+
+	info, err := os.Stat(exe)
+	if err != nil {
+		return errors.Wrap(err, "unable to stat binary")
+	}
+
+	// Written next to the binary so that the final rename does not cross
+	// file systems
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".streamdal-update-*")
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary file (is the binary's directory writable?)")
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if err := u.download(ctx, binaryURL, tmp, expected); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "unable to write binary")
+	}
+
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return errors.Wrap(err, "unable to make binary executable")
+	}
+
+	// A running binary cannot be replaced on Windows, but it can be renamed
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+
+		if err := os.Rename(exe, old); err != nil {
+			return errors.Wrap(err, "unable to move the current binary")
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return errors.Wrap(err, "unable to replace binary")
+	}
+
+	u.log.Debugf("replaced '%s' with %s", exe, release.Version)
+
+	return nil
+}
+
+// verifySignature verifies the checksums against their signature; skipped
+// (with a warning) if the CLI was built without PublicKey
+func (u *Updater) verifySignature(ctx context.Context, release *Release, checksums []byte) error {
+	if PublicKey == "" {
+		u.log.Warn("this build does not include the release public key; only the checksum of the binary is verified")
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid release public key")
+	}
+
+	signature, err := u.asset(ctx, release, SignatureAsset)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(key, checksums, signature) {
+		return errors.Errorf("signature of the %s checksums does not match the release public key", release.Version)
+	}
+
+	return nil
+}
+
+// asset returns the contents of a small release asset (ex: checksums)
+func (u *Updater) asset(ctx context.Context, release *Release, name string) ([]byte, error) {
+	url, ok := release.assets[name]
+	if !ok {
+		return nil, errors.Errorf("release %s has no '%s'; download it from %s", release.Version, name, release.URL)
+	}
+
+	data, err := u.get(ctx, url, maxMetadataSize)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to download '%s'", name)
+	}
+
+	return data, nil
+}
+
+// download writes the binary at url to w; an error is returned if its
+// SHA-256 is not expected
+func (u *Updater) download(ctx context.Context, url string, w io.Writer, expected []byte) error {
+	resp, err := u.request(ctx, url)
+	if err != nil {
+		return errors.Wrap(err, "unable to download binary")
+	}
+
+	defer resp.Body.Close()
+
+	hash := sha256.New()
+
+	n, err := io.Copy(io.MultiWriter(w, hash), io.LimitReader(resp.Body, MaxBinarySize+1))
+	if err != nil {
+		return errors.Wrap(err, "unable to download binary")
+	}
+
+	if n > MaxBinarySize {
+		return errors.Errorf("binary is larger than %d bytes", MaxBinarySize)
+	}
+
+	if !bytes.Equal(hash.Sum(nil), expected) {
+		return errors.New("checksum of the downloaded binary does not match the release checksums")
+	}
+
+	return nil
+}
+
+func (u *Updater) get(ctx context.Context, url string, max int64) ([]byte, error) {
+	resp, err := u.request(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	return io.ReadAll(io.LimitReader(resp.Body, max))
+}
+
+func (u *Updater) request(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create request")
+	}
+
+	req.Header.Set("User-Agent", "streamdal-cli/"+u.options.Version)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// checksum returns the SHA-256 of name in checksums (sha256sum format)
+func checksum(checksums []byte, name string) ([]byte, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		// The name is prefixed with '*' in binary mode
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+
+		sum, err := hex.DecodeString(fields[0])
+		if err != nil || len(sum) != sha256.Size {
+			return nil, errors.Errorf("invalid checksum for '%s'", name)
+		}
+
+		return sum, nil
+	}
+
+	return nil, errors.Errorf("checksums do not include '%s'", name)
+}
+
+// AssetName returns the name of the release binary for a platform (see the
+// build targets in the Makefile)
+func AssetName(goos, goarch string) (string, error) {
+	switch {
+	case goos == "linux" && goarch == "amd64":
+		return "streamdal-linux", nil
+	case goos == "darwin" && goarch == "amd64":
+		return "streamdal-darwin", nil
+	case goos == "darwin" && goarch == "arm64":
+		return "streamdal-darwin-arm64", nil
+	case goos == "windows" && goarch == "amd64":
+		return "streamdal-windows.exe", nil
+	}
+
+	return "", errors.Errorf("there is no release binary for %s/%s", goos, goarch)
+}
+
+// Newer returns true if latest is a newer version than current; ok is false
+// if either version cannot be parsed (ex: development builds)
+func Newer(current, latest string) (newer, ok bool) {
+	c, ok := parseVersion(current)
+	if !ok {
+		return false, false
+	}
+
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false, false
+	}
+
+	for i := range c {
+		if l[i] != c[i] {
+			return l[i] > c[i], true
+		}
+	}
+
+	return false, true
+}
+
+// parseVersion parses vX.Y.Z, ignoring any suffix (ex: the commit in
+// v0.1.2-abc1234)
+func parseVersion(version string) ([3]int, bool) {
+	var parsed [3]int
+
+	version = strings.TrimPrefix(version, "v")
+
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return parsed, false
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, false
+		}
+
+		parsed[i] = n
+	}
+
+	return parsed, true
+}
+
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return errors.New("options cannot be nil")
+	}
+
+	if opts.Version == "" {
+		return errors.New(".Version cannot be empty")
+	}
+
+	if opts.Logger == nil {
+		return errors.New(".Logger cannot be nil")
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package util
+
+import "sync/atomic"
+
+var (
+	// accessible is set in screen reader friendly mode (--accessible):
+	// decorative characters and animations are left out, states are spelled
+	// out instead of being signalled by color only and the screen is redrawn
+	// less often
+	accessible atomic.Bool
+
+	// ascii is set in ASCII-only mode (--ascii) for terminals and fonts that
+	// render box drawing, block and braille characters poorly
+	ascii atomic.Bool
+)
+
+// SetAccessible sets whether the screen reader friendly mode is enabled
+func SetAccessible(enabled bool) {
+	accessible.Store(enabled)
+}
+
+// Accessible returns true if the screen reader friendly mode is enabled
+func Accessible() bool {
+	return accessible.Load()
+}
+
+// SetASCII sets whether the ASCII-only mode is enabled
+func SetASCII(enabled bool) {
+	ascii.Store(enabled)
+}
+
+// ASCII returns true if the ASCII-only mode is enabled
+func ASCII() bool {
+	return ascii.Load()
+}
+
+// Glyph returns symbol, its ASCII replacement in ASCII-only mode or text in
+// screen reader friendly mode (ex: "★", "*" or "bookmark")
+func Glyph(symbol, ascii, text string) string {
+	switch {
+	case Accessible():
+		return text
+	case ASCII():
+		return ascii
+	default:
+		return symbol
+	}
+}
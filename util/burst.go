@@ -0,0 +1,64 @@
+package util
+
+import (
+	"time"
+)
+
+// BurstDetector detects when the number of events seen in the current second
+// exceeds a multiple of the average rate reported by a Throughput. It is NOT
+// safe for concurrent use.
+type BurstDetector struct {
+	throughput *Throughput
+	multiplier float64
+	minRate    int
+	active     bool
+	lastSec    int64
+}
+
+// NewBurstDetector creates a detector that reports a burst once the current
+// second has seen more than multiplier times the average rate (and at least
+// minRate events). A multiplier of 0 (or less) disables detection.
+func NewBurstDetector(throughput *Throughput, multiplier float64, minRate int) *BurstDetector {
+	return &BurstDetector{
+		throughput: throughput,
+		multiplier: multiplier,
+		minRate:    minRate,
+	}
+}
+
+// Check should be called after every Throughput.Add(); it returns true only
+// for the event that started a burst, along with the current and average rate.
+// A burst ends once a whole second passes without exceeding the threshold.
+func (b *BurstDetector) Check(now time.Time) (bool, int, float64) {
+	if b.multiplier <= 0 || !b.throughput.Warm(now) {
+		return false, 0, 0
+	}
+
+	sec := now.Unix()
+	current := b.throughput.Count(now)
+	average := b.throughput.Rate(now)
+
+	if current < b.minRate || float64(current) <= average*b.multiplier {
+		if b.active && sec > b.lastSec+1 {
+			b.active = false
+		}
+
+		return false, current, average
+	}
+
+	b.lastSec = sec
+
+	if b.active {
+		return false, current, average
+	}
+
+	b.active = true
+
+	return true, current, average
+}
+
+// Reset clears the burst state
+func (b *BurstDetector) Reset() {
+	b.active = false
+	b.lastSec = 0
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// displayUTC is set when timestamps are displayed in UTC instead of local time
+// (--utc or toggled in the tail view); it applies to every displayed time
+var displayUTC atomic.Bool
+
+// SetUTC sets whether timestamps are displayed in UTC or local time
+func SetUTC(utc bool) {
+	displayUTC.Store(utc)
+}
+
+// UTC returns true if timestamps are displayed in UTC
+func UTC() bool {
+	return displayUTC.Load()
+}
+
+// DisplayLocation returns the location timestamps are displayed in
+func DisplayLocation() *time.Location {
+	if UTC() {
+		return time.UTC
+	}
+
+	return time.Local
+}
+
+// Clock formats t as a time of day (15:04:05) in the display location
+func Clock(t time.Time) string {
+	return t.In(DisplayLocation()).Format("15:04:05")
+}
+
+// TimeZone returns the abbreviation of the display time zone (ex: "UTC" or
+// "CEST"); "local" if the local zone has no abbreviation
+func TimeZone() string {
+	name, _ := time.Now().In(DisplayLocation()).Zone()
+
+	if name == "" {
+		return "local"
+	}
+
+	return name
+}
@@ -0,0 +1,49 @@
+package util
+
+// DuplicateTracker counts how many times recently seen IDs were seen; only
+// the most recent size IDs are remembered. It is NOT safe for concurrent use.
+type DuplicateTracker struct {
+	counts map[string]int
+	order  []string // remembered IDs; once full, order[next] is the oldest
+	next   int
+}
+
+func NewDuplicateTracker(size int) *DuplicateTracker {
+	if size < 1 {
+		size = 1
+	}
+
+	return &DuplicateTracker{
+		counts: make(map[string]int),
+		order:  make([]string, 0, size),
+	}
+}
+
+// Seen records id and returns how many times it has been seen, including this
+// time; anything above 1 is a duplicate.
+func (d *DuplicateTracker) Seen(id string) int {
+	if count, ok := d.counts[id]; ok {
+		d.counts[id] = count + 1
+		return count + 1
+	}
+
+	// Forget the oldest ID once full
+	if len(d.order) < cap(d.order) {
+		d.order = append(d.order, id)
+	} else {
+		delete(d.counts, d.order[d.next])
+		d.order[d.next] = id
+		d.next = (d.next + 1) % len(d.order)
+	}
+
+	d.counts[id] = 1
+
+	return 1
+}
+
+// Reset forgets all seen IDs
+func (d *DuplicateTracker) Reset() {
+	d.counts = make(map[string]int)
+	d.order = d.order[:0]
+	d.next = 0
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	fuzzyMatchScore       = 1
+	fuzzyConsecutiveBonus = 5
+	fuzzyWordStartBonus   = 8
+	fuzzyMaxGapPenalty    = 3
+)
+
+// FuzzyMatch matches pattern against text the way most fuzzy finders do:
+// every character of pattern must appear in text in the same order (case
+// insensitive) but not necessarily next to each other. The score rewards
+// consecutive characters and characters at the start of words (ex: "bo"
+// scores higher for "billing:orders" than for "robot"); higher is better.
+// Positions are the indexes of the matched runes in text.
+func FuzzyMatch(pattern, text string) (int, []int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(text)
+
+	positions := make([]int, 0, len(p))
+	score := 0
+
+	for i, j := 0, 0; i < len(p); i++ {
+		for j < len(t) && unicode.ToLower(t[j]) != p[i] {
+			j++
+		}
+
+		if j == len(t) {
+			return 0, nil, false
+		}
+
+		score += fuzzyMatchScore
+
+		if j == 0 || isWordSeparator(t[j-1]) {
+			score += fuzzyWordStartBonus
+		}
+
+		if n := len(positions); n > 0 {
+			if gap := j - positions[n-1] - 1; gap == 0 {
+				score += fuzzyConsecutiveBonus
+			} else if gap > fuzzyMaxGapPenalty {
+				score -= fuzzyMaxGapPenalty
+			} else {
+				score -= gap
+			}
+		}
+
+		positions = append(positions, j)
+		j++
+	}
+
+	return score, positions, true
+}
+
+func isWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(":/-_.", r)
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+)
+
+// rawNumbers is set when counters, rates and sizes in stats are displayed as
+// plain numbers (--raw-numbers or the view options), ex: for copy/paste
+var rawNumbers atomic.Bool
+
+// SetRawNumbers sets whether stats are displayed as plain numbers
+func SetRawNumbers(raw bool) {
+	rawNumbers.Store(raw)
+}
+
+// RawNumbers returns true if stats are displayed as plain numbers
+func RawNumbers() bool {
+	return rawNumbers.Load()
+}
+
+// FormatCount formats a counter with thousands separators (ex: "1,234,567")
+func FormatCount(n int64) string {
+	str := strconv.FormatInt(n, 10)
+
+	if RawNumbers() {
+		return str
+	}
+
+	sign := ""
+
+	if n < 0 {
+		sign, str = "-", str[1:]
+	}
+
+	for i := len(str) - 3; i > 0; i -= 3 {
+		str = str[:i] + "," + str[i:]
+	}
+
+	return sign + str
+}
+
+// FormatRate formats a rate (ex: msgs/sec) compactly with a unit suffix (ex:
+// "0.5", "12", "1.2k", "3.4M"); as a number with at most 2 decimals in raw
+// mode
+func FormatRate(rate float64) string {
+	if RawNumbers() {
+		return strconv.FormatFloat(math.Round(rate*100)/100, 'f', -1, 64)
+	}
+
+	switch abs := math.Abs(rate); {
+	case abs >= 1e9:
+		return fmt.Sprintf("%.1fG", rate/1e9)
+	case abs >= 1e6:
+		return fmt.Sprintf("%.1fM", rate/1e6)
+	case abs >= 1e3:
+		return fmt.Sprintf("%.1fk", rate/1e3)
+	case abs >= 10 || abs == 0:
+		return fmt.Sprintf("%.0f", rate)
+	default:
+		return fmt.Sprintf("%.1f", rate)
+	}
+}
+
+// FormatSize formats a number of bytes with a binary unit (ex: "3.4 MiB");
+// in bytes in raw mode
+func FormatSize(n int64) string {
+	if RawNumbers() {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+
+	return HumanizeBytes(n)
+}
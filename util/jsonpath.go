@@ -0,0 +1,155 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GetJSONPath returns the value found at the given path in a JSON payload.
+// Only a small subset of JSONPath is supported: dot-separated object keys and
+// numeric array indexes, with an optional leading "$." (ex: "$.meta.ts" or
+// "$.events[0].created_at").
+func GetJSONPath(data []byte, path string) (interface{}, error) {
+	var obj interface{}
+
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal payload")
+	}
+
+	current := obj
+
+	for _, elem := range ParseJSONPath(path) {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			val, ok := v[elem]
+			if !ok {
+				return nil, errors.Errorf("key '%s' not found", elem)
+			}
+
+			current = val
+		case []interface{}:
+			idx, err := strconv.Atoi(elem)
+			if err != nil {
+				return nil, errors.Errorf("'%s' is not a valid array index", elem)
+			}
+
+			if idx < 0 || idx >= len(v) {
+				return nil, errors.Errorf("array index '%d' out of range", idx)
+			}
+
+			current = v[idx]
+		default:
+			return nil, errors.Errorf("unable to descend into '%s'", elem)
+		}
+	}
+
+	return current, nil
+}
+
+// ParseJSONPath splits a path such as "$.events[0].id" into its elements
+// (ex: ["events", "0", "id"])
+func ParseJSONPath(path string) []string {
+	path = strings.TrimPrefix(path, "$")
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+
+	elems := make([]string, 0)
+
+	for _, elem := range strings.Split(path, ".") {
+		if elem == "" {
+			continue
+		}
+
+		elems = append(elems, elem)
+	}
+
+	return elems
+}
+
+// JSONField is a leaf (scalar) value in a JSON payload
+type JSONField struct {
+	Path  string // ex: "$.user.id", "$.items[0].sku"
+	Value string // JSON encoded (ex: `"abc"`, `42`, `true`, `null`)
+}
+
+// FlattenJSON returns every leaf value of a JSON payload; object keys are
+// sorted and array elements are in order. Keys that cannot be addressed with
+// GetJSONPath (ex: keys containing a ".") are skipped.
+func FlattenJSON(data []byte) ([]*JSONField, error) {
+	var obj interface{}
+
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal payload")
+	}
+
+	fields := make([]*JSONField, 0)
+
+	flatten(obj, "$", &fields)
+
+	return fields, nil
+}
+
+func flatten(current interface{}, path string, fields *[]*JSONField) {
+	switch v := current.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+
+		for key := range v {
+			if key != "" && !strings.ContainsAny(key, ".[]") {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			flatten(v[key], path+"."+key, fields)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			flatten(elem, path+"["+strconv.Itoa(i)+"]", fields)
+		}
+	default:
+		value, err := encodeJSONValue(v)
+		if err != nil {
+			return
+		}
+
+		*fields = append(*fields, &JSONField{Path: path, Value: value})
+	}
+}
+
+// JSONPathEquals returns true if the value found at path is equal to value
+// (JSON encoded, as in JSONField)
+func JSONPathEquals(data []byte, path, value string) bool {
+	found, err := GetJSONPath(data, path)
+	if err != nil {
+		return false
+	}
+
+	encoded, err := encodeJSONValue(found)
+	if err != nil {
+		return false
+	}
+
+	return encoded == value
+}
+
+// encodeJSONValue encodes a scalar as-is (ex: "<" is not escaped)
+func encodeJSONValue(v interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
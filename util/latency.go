@@ -0,0 +1,93 @@
+package util
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ParseTimestamp converts a timestamp value extracted from a JSON payload into
+// a time.Time. Numeric values are treated as unix epoch timestamps; the unit
+// (s, ms, µs or ns) is guessed based on the magnitude of the value. String
+// values can either be RFC3339 timestamps or numeric epoch timestamps.
+func ParseTimestamp(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case float64:
+		return epochToTime(int64(v)), nil
+	case string:
+		if ts, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return ts, nil
+		}
+
+		epoch, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return time.Time{}, errors.Errorf("unable to parse '%s' as a timestamp", v)
+		}
+
+		return epochToTime(epoch), nil
+	default:
+		return time.Time{}, errors.Errorf("unsupported timestamp type '%T'", value)
+	}
+}
+
+func epochToTime(epoch int64) time.Time {
+	switch {
+	case epoch > 1e17:
+		return time.Unix(0, epoch)
+	case epoch > 1e14:
+		return time.UnixMicro(epoch)
+	case epoch > 1e11:
+		return time.UnixMilli(epoch)
+	default:
+		return time.Unix(epoch, 0)
+	}
+}
+
+// RollingAverage keeps track of the average of the last N durations it has
+// been given. It is NOT safe for concurrent use.
+type RollingAverage struct {
+	window []time.Duration
+	next   int
+	count  int
+	sum    time.Duration
+}
+
+func NewRollingAverage(size int) *RollingAverage {
+	if size < 1 {
+		size = 1
+	}
+
+	return &RollingAverage{
+		window: make([]time.Duration, size),
+	}
+}
+
+// Add records a new duration, evicting the oldest one if the window is full
+func (r *RollingAverage) Add(d time.Duration) {
+	if r.count == len(r.window) {
+		r.sum -= r.window[r.next]
+	} else {
+		r.count++
+	}
+
+	r.window[r.next] = d
+	r.sum += d
+	r.next = (r.next + 1) % len(r.window)
+}
+
+// Average returns the average of all durations currently in the window
+func (r *RollingAverage) Average() time.Duration {
+	if r.count == 0 {
+		return 0
+	}
+
+	return r.sum / time.Duration(r.count)
+}
+
+// Reset removes all recorded durations
+func (r *RollingAverage) Reset() {
+	r.next = 0
+	r.count = 0
+	r.sum = 0
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseLineRange parses a range of line numbers such as "10-20" or a single
+// line such as "15". An empty input returns 0, 0 (all lines).
+func ParseLineRange(input string) (int, int, error) {
+	input = strings.TrimSpace(input)
+
+	if input == "" {
+		return 0, 0, nil
+	}
+
+	parts := strings.SplitN(input, "-", 2)
+
+	from, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || from < 1 {
+		return 0, 0, errors.Errorf("invalid line number '%s'", parts[0])
+	}
+
+	if len(parts) == 1 {
+		return from, from, nil
+	}
+
+	to, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || to < 1 {
+		return 0, 0, errors.Errorf("invalid line number '%s'", parts[1])
+	}
+
+	if to < from {
+		return 0, 0, errors.Errorf("line range '%s' ends before it starts", input)
+	}
+
+	return from, to, nil
+}
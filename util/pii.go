@@ -0,0 +1,71 @@
+package util
+
+import (
+	"regexp"
+)
+
+var (
+	emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// Card numbers are 13-19 digits (starting with 2-6 for the major card
+	// networks), optionally grouped with spaces or dashes; candidates are
+	// validated with the Luhn checksum to reduce false positives (ex: unix
+	// timestamps in milliseconds)
+	creditCardRegex = regexp.MustCompile(`\b[2-6](?:[ \-]?\d){12,18}\b`)
+
+	// Area 000, 666 and 900-999, group 00 and serial 0000 are never issued
+	ssnRegex = regexp.MustCompile(`\b(\d{3})-(\d{2})-(\d{4})\b`)
+)
+
+// ContainsPII returns true if data contains a probable email address, credit
+// card number or US social security number
+func ContainsPII(data []byte) bool {
+	if emailRegex.Match(data) {
+		return true
+	}
+
+	for _, match := range creditCardRegex.FindAll(data, -1) {
+		if luhn(match) {
+			return true
+		}
+	}
+
+	for _, match := range ssnRegex.FindAllSubmatch(data, -1) {
+		area, group, serial := string(match[1]), string(match[2]), string(match[3])
+
+		if area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// luhn validates the checksum of a (possibly grouped) card number
+func luhn(number []byte) bool {
+	var (
+		sum    int
+		double bool
+	)
+
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			continue
+		}
+
+		digit := int(number[i] - '0')
+
+		if double {
+			digit *= 2
+
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
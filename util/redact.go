@@ -0,0 +1,92 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// Redacted replaces the values of redacted fields
+const Redacted = "<redacted>"
+
+// RedactJSONPaths replaces the values found at the given paths with Redacted.
+// Paths use the same syntax as GetJSONPath; additionally, "*" matches every
+// key or array element (ex: "$.users[*].email"). data is returned as-is if it
+// is not JSON or none of the paths exist.
+func RedactJSONPaths(data []byte, paths []string) []byte {
+	if len(paths) == 0 {
+		return data
+	}
+
+	var obj interface{}
+
+	// Numbers are kept as-is (ex: large IDs don't lose precision)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&obj); err != nil {
+		return data
+	}
+
+	var redacted bool
+
+	for _, path := range paths {
+		if elems := ParseJSONPath(path); len(elems) > 0 && redact(obj, elems) {
+			redacted = true
+		}
+	}
+
+	if !redacted {
+		return data
+	}
+
+	buf := &bytes.Buffer{}
+
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(obj); err != nil {
+		return data
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redact replaces the value(s) at elems in current; returns true if a value
+// was replaced
+func redact(current interface{}, elems []string) bool {
+	elem, last := elems[0], len(elems) == 1
+
+	var redacted bool
+
+	switch v := current.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if elem != "*" && elem != key {
+				continue
+			}
+
+			if last {
+				v[key] = Redacted
+				redacted = true
+			} else if redact(val, elems[1:]) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			if elem != "*" && elem != strconv.Itoa(i) {
+				continue
+			}
+
+			if last {
+				v[i] = Redacted
+				redacted = true
+			} else if redact(val, elems[1:]) {
+				redacted = true
+			}
+		}
+	}
+
+	return redacted
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package util
+
+import (
+	"runtime"
+)
+
+// RSS approximates the resident set size of the current process with the
+// memory obtained from the OS by the Go runtime
+func RSS() (int64, error) {
+	var m runtime.MemStats
+
+	runtime.ReadMemStats(&m)
+
+	return int64(m.Sys), nil
+}
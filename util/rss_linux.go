@@ -0,0 +1,32 @@
+//go:build linux
+
+package util
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RSS returns the resident set size of the current process in bytes
+func RSS() (int64, error) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to read /proc/self/statm")
+	}
+
+	// size resident shared text lib data dt (in pages)
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, errors.Errorf("unexpected /proc/self/statm contents '%s'", strings.TrimSpace(string(data)))
+	}
+
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to parse resident pages")
+	}
+
+	return pages * int64(os.Getpagesize()), nil
+}
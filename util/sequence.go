@@ -0,0 +1,78 @@
+package util
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// SequenceReorderWindow is how far below the highest sequence number seen a
+// sequence number is considered late (out of order) or a duplicate; further
+// below, the sequence is considered to have started over (ex: producer
+// restarted)
+const SequenceReorderWindow = 1000
+
+// SequenceTracker detects gaps in sequence numbers (ex: offsets) seen per key
+// (ex: per component and partition). It is NOT safe for concurrent use.
+type SequenceTracker struct {
+	highest map[string]int64
+}
+
+func NewSequenceTracker() *SequenceTracker {
+	return &SequenceTracker{
+		highest: make(map[string]int64),
+	}
+}
+
+// Check records seq for key; if seq skips numbers above the highest one seen
+// so far, the first and last missing numbers are returned. Lower numbers
+// (late or redelivered messages, ex: 1, 3, 2, 4) are not gaps and do not
+// lower the highest number, unless they are more than SequenceReorderWindow
+// below it: the sequence then starts over without reporting a gap.
+func (s *SequenceTracker) Check(key string, seq int64) (int64, int64, bool) {
+	highest, ok := s.highest[key]
+
+	switch {
+	case !ok || seq < highest-SequenceReorderWindow:
+		s.highest[key] = seq
+		return 0, 0, false
+	case seq <= highest:
+		return 0, 0, false
+	}
+
+	s.highest[key] = seq
+
+	if seq == highest+1 {
+		return 0, 0, false
+	}
+
+	return highest + 1, seq - 1, true
+}
+
+// Reset forgets all recorded sequence numbers
+func (s *SequenceTracker) Reset() {
+	s.highest = make(map[string]int64)
+}
+
+// ParseSequence converts a sequence number extracted from a JSON payload into
+// an int64; both numbers and numeric strings are supported.
+func ParseSequence(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, errors.Errorf("'%v' is not an integer", v)
+		}
+
+		return int64(v), nil
+	case string:
+		seq, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, errors.Errorf("unable to parse '%s' as a sequence number", v)
+		}
+
+		return seq, nil
+	default:
+		return 0, errors.Errorf("unsupported sequence number type '%T'", value)
+	}
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestSequenceTrackerCheck(t *testing.T) {
+	type gap struct{ from, to int64 }
+
+	tests := []struct {
+		name string
+		seqs []int64
+		want []gap
+	}{
+		{name: "in order", seqs: []int64{1, 2, 3, 4}},
+		{name: "gap", seqs: []int64{1, 2, 6, 7}, want: []gap{{3, 5}}},
+		{name: "out of order", seqs: []int64{1, 3, 2, 4}, want: []gap{{2, 2}}},
+		{name: "redelivered", seqs: []int64{1, 2, 3, 2, 3, 4}},
+		{name: "gap after late message", seqs: []int64{10, 12, 11, 15}, want: []gap{{11, 11}, {13, 14}}},
+		{name: "restart", seqs: []int64{5000, 5001, 0, 1, 3}, want: []gap{{2, 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSequenceTracker()
+
+			var got []gap
+
+			for _, seq := range tt.seqs {
+				if from, to, ok := s.Check("key", seq); ok {
+					got = append(got, gap{from, to})
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("gaps = %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("gaps = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceTrackerKeys(t *testing.T) {
+	s := NewSequenceTracker()
+
+	// Offsets of different partitions do not interleave
+	for _, check := range []struct {
+		key string
+		seq int64
+	}{{"p0", 100}, {"p1", 7}, {"p0", 101}, {"p1", 8}, {"p0", 102}} {
+		if from, to, ok := s.Check(check.key, check.seq); ok {
+			t.Fatalf("unexpected gap %d-%d in %s", from, to, check.key)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"math"
+)
+
+// brailleDots are the dots of a braille cell from the bottom row to the top
+// row, for the left and right column
+var brailleDots = [2][4]rune{
+	{0x40, 0x04, 0x02, 0x01},
+	{0x80, 0x20, 0x10, 0x08},
+}
+
+// asciiLevels replace braille cells in ASCII-only mode (see ASCII), by
+// height of the highest of the two values
+var asciiLevels = [5]rune{' ', '.', ':', '|', '#'}
+
+// Sparkline renders values as braille characters, two values per character
+// and up to 4 dots high. Values are scaled to max (values above max are
+// clipped); any value above zero is at least one dot high so that a quiet
+// series can be told apart from an idle one.
+func Sparkline(values []float64, max float64) string {
+	runes := make([]rune, 0, (len(values)+1)/2)
+
+	if ASCII() {
+		for i := 0; i < len(values); i += 2 {
+			height := sparklineHeight(values[i], max)
+
+			if i+1 < len(values) && sparklineHeight(values[i+1], max) > height {
+				height = sparklineHeight(values[i+1], max)
+			}
+
+			runes = append(runes, asciiLevels[height])
+		}
+
+		return string(runes)
+	}
+
+	for i := 0; i < len(values); i += 2 {
+		cell := rune(0x2800)
+
+		for col := 0; col < 2 && i+col < len(values); col++ {
+			for row := 0; row < sparklineHeight(values[i+col], max); row++ {
+				cell |= brailleDots[col][row]
+			}
+		}
+
+		runes = append(runes, cell)
+	}
+
+	return string(runes)
+}
+
+// sparklineHeight returns the number of dots (0-4) used for displaying v
+func sparklineHeight(v, max float64) int {
+	if v <= 0 || max <= 0 {
+		return 0
+	}
+
+	height := int(math.Round(v / max * 4))
+
+	if height < 1 {
+		return 1
+	}
+
+	if height > 4 {
+		return 4
+	}
+
+	return height
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"time"
+)
+
+// Throughput keeps track of how many events per second were seen over a
+// sliding window of whole seconds. It is NOT safe for concurrent use.
+type Throughput struct {
+	counts  []int
+	seconds []int64
+	first   int64
+}
+
+func NewThroughput(window time.Duration) *Throughput {
+	size := int(window / time.Second)
+	if size < 1 {
+		size = 1
+	}
+
+	// One extra bucket for the (incomplete) current second
+	return &Throughput{
+		counts:  make([]int, size+1),
+		seconds: make([]int64, size+1),
+	}
+}
+
+// Add records a single event that happened at the given time
+func (t *Throughput) Add(now time.Time) {
+	t.AddN(now, 1)
+}
+
+// AddN records n events (ex: bytes) that happened at the given time
+func (t *Throughput) AddN(now time.Time, n int) {
+	sec := now.Unix()
+
+	if t.first == 0 {
+		t.first = sec
+	}
+
+	i := int(sec % int64(len(t.counts)))
+
+	if t.seconds[i] != sec {
+		t.seconds[i] = sec
+		t.counts[i] = 0
+	}
+
+	t.counts[i] += n
+}
+
+// Rate returns the average events per second over the complete seconds in the
+// window. If less than a full second has passed since the first event, the
+// events seen so far are returned.
+func (t *Throughput) Rate(now time.Time) float64 {
+	if t.first == 0 {
+		return 0
+	}
+
+	sec := now.Unix()
+	window := int64(len(t.counts) - 1)
+
+	elapsed := sec - t.first
+	if elapsed > window {
+		elapsed = window
+	}
+
+	var total int
+
+	for i, s := range t.seconds {
+		if elapsed == 0 && s == sec {
+			return float64(t.counts[i])
+		}
+
+		if s < sec && s >= sec-elapsed {
+			total += t.counts[i]
+		}
+	}
+
+	if elapsed == 0 {
+		return 0
+	}
+
+	return float64(total) / float64(elapsed)
+}
+
+// Count returns the number of events seen so far in the current second
+func (t *Throughput) Count(now time.Time) int {
+	sec := now.Unix()
+	i := int(sec % int64(len(t.counts)))
+
+	if t.seconds[i] != sec {
+		return 0
+	}
+
+	return t.counts[i]
+}
+
+// Warm returns true once events have been recorded for a full window
+func (t *Throughput) Warm(now time.Time) bool {
+	return t.first != 0 && now.Unix()-t.first >= int64(len(t.counts)-1)
+}
+
+// Reset removes all recorded events
+func (t *Throughput) Reset() {
+	for i := range t.counts {
+		t.counts[i] = 0
+		t.seconds[i] = 0
+	}
+
+	t.first = 0
+}
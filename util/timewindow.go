@@ -0,0 +1,69 @@
+package util
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ParseTimeWindow parses a time window relative to now. Supported formats:
+//
+//	30s, 5m, 1h       - from <duration> ago until now (open-ended)
+//	14:02             - from 14:02 today (open-ended)
+//	14:02-14:05       - between 14:02 and 14:05 today (seconds are optional)
+//
+// A zero "to" means that the window is open-ended.
+func ParseTimeWindow(input string, now time.Time) (time.Time, time.Time, error) {
+	input = strings.TrimSpace(input)
+
+	if input == "" {
+		return time.Time{}, time.Time{}, errors.New("time window cannot be empty")
+	}
+
+	if d, err := time.ParseDuration(input); err == nil {
+		if d <= 0 {
+			return time.Time{}, time.Time{}, errors.Errorf("duration '%s' must be positive", input)
+		}
+
+		return now.Add(-d), time.Time{}, nil
+	}
+
+	parts := strings.SplitN(input, "-", 2)
+
+	from, err := parseClock(parts[0], now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if len(parts) == 1 {
+		return from, time.Time{}, nil
+	}
+
+	to, err := parseClock(parts[1], now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, errors.Errorf("end of time window '%s' must be after its start", input)
+	}
+
+	return from, to, nil
+}
+
+// parseClock parses a HH:MM or HH:MM:SS time of day on the same day as now
+func parseClock(str string, now time.Time) (time.Time, error) {
+	str = strings.TrimSpace(str)
+
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		t, err := time.ParseInLocation(layout, str, now.Location())
+		if err != nil {
+			continue
+		}
+
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+	}
+
+	return time.Time{}, errors.Errorf("unable to parse '%s' as a duration or time of day (HH:MM[:SS])", str)
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// traceParentRegex matches a W3C traceparent value (version-traceid-parentid-flags)
+var traceParentRegex = regexp.MustCompile(`\b[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}\b`)
+
+// ExtractTraceID returns the trace ID found in a payload. If field is set, the
+// value at that JSONPath is used; otherwise the payload is searched for a W3C
+// traceparent value. Returns an empty string if no trace ID is found.
+func ExtractTraceID(data []byte, field string) string {
+	if field == "" {
+		if match := traceParentRegex.FindSubmatch(data); match != nil {
+			return string(match[1])
+		}
+
+		return ""
+	}
+
+	value, err := GetJSONPath(data, field)
+	if err != nil || value == nil {
+		return ""
+	}
+
+	traceID := fmt.Sprint(value)
+
+	// Field may contain a full traceparent - only use the trace ID portion
+	if match := traceParentRegex.FindStringSubmatch(traceID); match != nil {
+		return match[1]
+	}
+
+	return traceID
+}
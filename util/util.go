@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/cactus/go-statsd-client/v5/statsd"
@@ -39,6 +40,50 @@ func AudienceToStr(audience *protos.Audience) string {
 	))
 }
 
+// FormatAudience returns an audience in the format accepted by ParseAudience
+// (ex: "billing:producer:orders:kafka")
+func FormatAudience(audience *protos.Audience) string {
+	if audience == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%s:%s:%s",
+		audience.ServiceName,
+		ProtosOperationTypeToStr(audience.OperationType),
+		audience.OperationName,
+		audience.ComponentName,
+	)
+}
+
+// ParseAudience parses an audience in the format
+// "service:operation_type:operation_name:component"; operation type is either
+// "producer" or "consumer".
+func ParseAudience(str string) (*protos.Audience, error) {
+	parts := strings.Split(str, ":")
+
+	if len(parts) != 4 {
+		return nil, errors.Errorf("audience '%s' must be in the format service:operation_type:operation_name:component", str)
+	}
+
+	opType := StrOperationTypeToProtos(strings.TrimPrefix(strings.ToLower(parts[1]), "operation_type_"))
+	if opType == protos.OperationType_OPERATION_TYPE_UNSET {
+		return nil, errors.Errorf("unknown operation type '%s' (must be producer or consumer)", parts[1])
+	}
+
+	for _, part := range parts {
+		if part == "" {
+			return nil, errors.Errorf("audience '%s' contains an empty field", str)
+		}
+	}
+
+	return &protos.Audience{
+		ServiceName:   parts[0],
+		OperationType: opType,
+		OperationName: parts[2],
+		ComponentName: parts[3],
+	}, nil
+}
+
 func ContainsAudience(a *protos.Audience, b []*protos.Audience) bool {
 	for _, aud := range b {
 		if AudienceEquals(a, aud) {
@@ -81,6 +126,24 @@ func SelectedToTailComponent(name, desc string) *types.TailComponent {
 	}
 }
 
+// AudienceToTailComponent creates a tail component in the same format as the
+// one produced by the "select component" view.
+func AudienceToTailComponent(aud *protos.Audience) *types.TailComponent {
+	if aud == nil {
+		return nil
+	}
+
+	return &types.TailComponent{
+		Name: aud.OperationName,
+		Description: fmt.Sprintf("%s/%s/%s",
+			aud.ServiceName,
+			ProtosOperationTypeToStr(aud.OperationType),
+			aud.ComponentName,
+		),
+		Audience: aud,
+	}
+}
+
 // StrOperationTypeToProtos translates an operationType string to the proto enum
 func StrOperationTypeToProtos(operationType string) protos.OperationType {
 	switch operationType {
@@ -120,3 +183,59 @@ func ReportErrorAndExit(t statsd.Statter, cfg *config.Config, err error) {
 	log.Fatal(err)
 
 }
+
+// HumanizeBytes formats a byte count using binary units (ex: "1.5 MiB")
+func HumanizeBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// ParseBytes parses a human-readable size such as "512KB", "256MiB" or "1GB"
+// into a number of bytes. Both decimal (KB, MB, GB) and binary (KiB, MiB, GiB)
+// units are supported (case-insensitive); a plain number is treated as bytes.
+func ParseBytes(str string) (int64, error) {
+	str = strings.TrimSpace(str)
+
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"KiB", 1 << 10},
+		{"MiB", 1 << 20},
+		{"GiB", 1 << 30},
+		{"KB", 1000},
+		{"MB", 1000 * 1000},
+		{"GB", 1000 * 1000 * 1000},
+		{"B", 1},
+	}
+
+	multiplier := int64(1)
+
+	for _, u := range units {
+		if strings.HasSuffix(strings.ToLower(str), strings.ToLower(u.suffix)) {
+			multiplier = u.multiplier
+			str = strings.TrimSpace(str[:len(str)-len(u.suffix)])
+
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid size '%s'", str)
+	}
+
+	return int64(n * float64(multiplier)), nil
+}
@@ -0,0 +1,126 @@
+// Package wasm is a thin wrapper around wazero for calling functions in WASM
+// modules that exchange data via linear memory. Functions are expected to use
+// the following convention (same as streamdal WASM modules):
+//
+//	alloc(size: i32) -> i32            allocate size bytes, return pointer
+//	dealloc(ptr: i32, size: i32)       (optional) free memory
+//	<func>(ptr: i32, len: i32) -> i64  return (out_ptr << 32 | out_len)
+package wasm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const (
+	AllocFunc   = "alloc"
+	DeallocFunc = "dealloc"
+)
+
+type Module struct {
+	name    string
+	runtime wazero.Runtime
+	module  api.Module
+	alloc   api.Function
+	dealloc api.Function
+
+	// WASM modules are not safe for concurrent use
+	mtx *sync.Mutex
+}
+
+// Load compiles and instantiates a WASM module
+func Load(ctx context.Context, name string, wasmBytes []byte) (*Module, error) {
+	rt := wazero.NewRuntime(ctx)
+
+	// Modules built with TinyGo/Rust typically depend on WASI
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		_ = rt.Close(ctx)
+		return nil, errors.Wrap(err, "unable to instantiate WASI")
+	}
+
+	mod, err := rt.InstantiateWithConfig(ctx, wasmBytes, wazero.NewModuleConfig().WithName(name))
+	if err != nil {
+		_ = rt.Close(ctx)
+		return nil, errors.Wrap(err, "unable to instantiate WASM module")
+	}
+
+	m := &Module{
+		name:    name,
+		runtime: rt,
+		module:  mod,
+		alloc:   mod.ExportedFunction(AllocFunc),
+		dealloc: mod.ExportedFunction(DeallocFunc),
+		mtx:     &sync.Mutex{},
+	}
+
+	if m.alloc == nil {
+		_ = rt.Close(ctx)
+		return nil, errors.Errorf("module must export '%s' function", AllocFunc)
+	}
+
+	return m, nil
+}
+
+// HasFunction returns true if the module exports a function with given name
+func (m *Module) HasFunction(name string) bool {
+	return m.module.ExportedFunction(name) != nil
+}
+
+// Call writes input to the module's memory, calls the given function and
+// returns a copy of the output it produced.
+func (m *Module) Call(ctx context.Context, name string, input []byte) ([]byte, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	fn := m.module.ExportedFunction(name)
+	if fn == nil {
+		return nil, errors.Errorf("module does not export function '%s'", name)
+	}
+
+	res, err := m.alloc.Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to allocate memory in WASM module")
+	}
+
+	ptr := uint32(res[0])
+
+	if !m.module.Memory().Write(ptr, input) {
+		return nil, errors.New("unable to write input to WASM memory")
+	}
+
+	res, err = fn.Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to call '%s' in WASM module", name)
+	}
+
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+
+	out, ok := m.module.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, errors.Errorf("'%s' returned an out of range result", name)
+	}
+
+	// Memory view is only valid until the next call - copy it
+	output := make([]byte, len(out))
+	copy(output, out)
+
+	if m.dealloc != nil {
+		_, _ = m.dealloc.Call(ctx, uint64(ptr), uint64(len(input)))
+		_, _ = m.dealloc.Call(ctx, uint64(outPtr), uint64(outLen))
+	}
+
+	return output, nil
+}
+
+func (m *Module) Name() string {
+	return m.name
+}
+
+func (m *Module) Close(ctx context.Context) error {
+	return m.runtime.Close(ctx)
+}